@@ -0,0 +1,119 @@
+package ecql
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// fieldAccessor gets the field of a column from a struct. It is computed
+// when the type is registered, so Bind and Map do not need to walk the
+// embedded structs on each call. The fields of addressable structs without
+// embedded pointers in the path are accessed using its offset, and the
+// wrapper type used to marshal the values is computed once.
+type fieldAccessor struct {
+	index   []int
+	typ     reflect.Type
+	wrapper reflect.Type
+	offset  uintptr
+	direct  bool
+}
+
+// newFieldAccessor returns the accessor of the field of the struct type t
+// with the given index.
+func newFieldAccessor(t reflect.Type, index []int) *fieldAccessor {
+	a := &fieldAccessor{index: index, direct: true}
+	for i, p := range index {
+		f := t.Field(p)
+		a.offset += f.Offset
+		// Unexported fields keep the reflect access checks
+		a.direct = a.direct && f.PkgPath == ""
+		t = f.Type
+		if i < len(index)-1 && t.Kind() == reflect.Ptr {
+			a.direct = false
+			t = t.Elem()
+		}
+	}
+	a.typ = t
+	return a
+}
+
+// field returns the field of the struct v.
+func (a *fieldAccessor) field(v reflect.Value) reflect.Value {
+	if a.direct {
+		if v.CanAddr() {
+			return reflect.NewAt(a.typ, unsafe.Add(unsafe.Pointer(v.UnsafeAddr()), a.offset)).Elem()
+		}
+		return v.FieldByIndex(a.index)
+	}
+	last := len(a.index) - 1
+	for i, p := range a.index {
+		v = v.Field(p)
+		if i == last || v.Kind() != reflect.Ptr {
+			continue
+		}
+		// Embedded pointers are initialized on reads
+		if v.IsNil() {
+			if !v.CanSet() {
+				return reflect.Zero(a.typ)
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// value returns the value of the column i in the struct v used on writes.
+// The index is the position of the column in Columns.
+func (t *Table) value(i int, col Column, v reflect.Value) interface{} {
+	if i >= len(t.accessors) {
+		return col.value(v.FieldByIndex(col.Position))
+	}
+	a := t.accessors[i]
+	return col.valueOf(a.field(v), a.wrapper)
+}
+
+// ref returns the reference to the column i in the struct v used on reads.
+// The index is the position of the column in Columns, followed by
+// TTLColumns.
+func (t *Table) ref(i int, col Column, v reflect.Value) interface{} {
+	if i >= len(t.accessors) {
+		return col.ref(v.FieldByIndex(col.Position))
+	}
+	a := t.accessors[i]
+	return col.refOf(a.field(v), a.wrapper)
+}
+
+// field returns the field of the column with the given name in the struct v,
+// and false if the column does not exist. Embedded nil pointers are handled
+// like on reads and writes.
+func (t *Table) field(name string, v reflect.Value) (reflect.Value, bool) {
+	for i, c := range t.Columns {
+		if c.Name == name {
+			return t.columnField(i, v), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// columnField returns the field of the column i in the struct v. The index
+// is the position of the column in Columns.
+func (t *Table) columnField(i int, v reflect.Value) reflect.Value {
+	if i >= len(t.accessors) {
+		return v.FieldByIndex(t.Columns[i].Position)
+	}
+	return t.accessors[i].field(v)
+}
+
+// setAccessors sets the field accessors of the columns of the table of the
+// struct type typ.
+func (t *Table) setAccessors(typ reflect.Type) {
+	t.accessors = make([]*fieldAccessor, 0, len(t.Columns)+len(t.TTLColumns))
+	for _, cols := range [][]Column{t.Columns, t.TTLColumns} {
+		for _, c := range cols {
+			a := newFieldAccessor(typ, c.Position)
+			a.wrapper = c.wrapperType(a.typ)
+			t.accessors = append(t.accessors, a)
+		}
+	}
+}
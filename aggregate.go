@@ -0,0 +1,98 @@
+package ecql
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// aggregateAlias is the alias of the aggregates selected by the helpers.
+const aggregateAlias = "agg"
+
+var int64Type = reflect.TypeOf(int64(0))
+
+// Count executes the statement selecting COUNT(1) instead of the columns and
+// returns the number of rows:
+//
+//	n, err := sess.Select(Event{}).Where(Eq("stream", id)).Count()
+func (s *StatementImpl) Count() (int64, error) {
+	v, err := s.aggregate("COUNT", "1")
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case nil:
+		return 0, nil
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.Type().ConvertibleTo(int64Type) {
+			return rv.Convert(int64Type).Int(), nil
+		}
+		return 0, fmt.Errorf("ecql: unexpected count of type %T", v)
+	}
+}
+
+// MinOf executes the statement selecting the minimum value of the column. The
+// value has the type of the field of the column if the statement was created
+// with a registered type, and nil if there are no rows.
+func (s *StatementImpl) MinOf(col string) (interface{}, error) {
+	return s.aggregateOf("min", col)
+}
+
+// MaxOf executes the statement selecting the maximum value of the column,
+// see MinOf.
+func (s *StatementImpl) MaxOf(col string) (interface{}, error) {
+	return s.aggregateOf("max", col)
+}
+
+// SumOf executes the statement selecting the sum of the values of the
+// column, see MinOf.
+func (s *StatementImpl) SumOf(col string) (interface{}, error) {
+	return s.aggregateOf("sum", col)
+}
+
+// AvgOf executes the statement selecting the average of the values of the
+// column, see MinOf. Like in CQL, the average of an integer column is an
+// integer.
+func (s *StatementImpl) AvgOf(col string) (interface{}, error) {
+	return s.aggregateOf("avg", col)
+}
+
+// aggregateOf returns the aggregate of the column converted to the type of
+// its field.
+func (s *StatementImpl) aggregateOf(fn, col string) (interface{}, error) {
+	v, err := s.aggregate(fn, col)
+	if err != nil || v == nil || !s.dest.IsValid() {
+		return v, err
+	}
+	c, ok := s.Table.column(col)
+	if !ok {
+		return v, nil
+	}
+	t := s.dest.Type().FieldByIndex(c.Position).Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if rv := reflect.ValueOf(v); rv.Type() != t && rv.Type().ConvertibleTo(t) {
+		return rv.Convert(t).Interface(), nil
+	}
+	return v, nil
+}
+
+// aggregate executes a copy of the statement selecting the aggregate
+// function of the column, and returns its value.
+func (s *StatementImpl) aggregate(fn, col string) (interface{}, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	stmt := s.Clone().(*StatementImpl)
+	stmt.Command = SelectCmd
+	stmt.ColumnNames = []string{As(Fn(fn, s.Table.quote(col)), aggregateAlias)}
+	stmt.Orders, stmt.AnnColumn, stmt.LimitValue = nil, "", 0
+	rows, err := stmt.MapRows()
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return rows[0][aggregateAlias], nil
+}
@@ -0,0 +1,63 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementCount(t *testing.T) {
+	sess, d := newTestSession()
+	d.result([]string{"agg"}, []interface{}{int64(3)})
+
+	n, err := sess.Select(testStruct{}).Where(Eq("f1", "a")).OrderBy(Asc("f22")).Limit(10).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, "SELECT COUNT(1) AS agg FROM mytable WHERE f1 = ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"a"}, d.last().Values)
+
+	n, err = sess.Count(testStruct{}).AllowFiltering().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, "SELECT COUNT(1) AS agg FROM mytable ALLOW FILTERING", d.last().Statement)
+
+	d.result([]string{"agg"})
+	n, err = sess.Select(testStruct{}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	d.err = errors.New("count error")
+	_, err = sess.Select(testStruct{}).Count()
+	assert.True(t, errors.Is(err, d.err))
+}
+
+func TestAggregates(t *testing.T) {
+	sess, d := newTestSession()
+	stmt := sess.Select(testStruct{}).Where(Eq("f1", "a"))
+
+	// Values are converted to the type of the field
+	d.result([]string{"agg"}, []interface{}{int32(5)})
+	for fn, agg := range map[string]func(string) (interface{}, error){
+		"min": stmt.MinOf, "max": stmt.MaxOf, "sum": stmt.SumOf, "avg": stmt.AvgOf,
+	} {
+		v, err := agg("f22")
+		assert.NoError(t, err)
+		assert.Equal(t, 5, v)
+		assert.Equal(t, "SELECT "+fn+"(f22) AS agg FROM mytable WHERE f1 = ?", d.last().Statement)
+	}
+
+	// Without a registered type
+	v, err := NewStatement(sess).Do(SelectCmd).From("mytable").MaxOf("f22")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), v)
+
+	d.result([]string{"agg"})
+	v, err = stmt.MinOf("f22")
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	// The statement is not modified
+	query, _ := stmt.BuildQuery()
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ?", query)
+}
@@ -0,0 +1,128 @@
+package ecql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type annotationsKey struct{}
+
+// Annotate returns a copy of ctx with an annotation, a key and value that
+// identify the operation, like a request id. The annotations of the context
+// of a statement or batch are set in the Annotations of its request, so the
+// middlewares can log them, and they are included in the QueryErrors:
+//
+//	ctx = ecql.Annotate(ctx, "request_id", requestID)
+//	err := sess.WithContext(ctx).Set(order)
+func Annotate(ctx context.Context, key, value string) context.Context {
+	annotations := map[string]string{key: value}
+	for k, v := range AnnotationsOf(ctx) {
+		if k != key {
+			annotations[k] = v
+		}
+	}
+	return context.WithValue(contextOf(ctx), annotationsKey{}, annotations)
+}
+
+// AnnotationsOf returns the annotations set in the context with Annotate.
+// The returned map must not be modified.
+func AnnotationsOf(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	annotations, _ := ctx.Value(annotationsKey{}).(map[string]string)
+	return annotations
+}
+
+// ContextValues are the custom payload entries and annotations derived from
+// the context of a request by a ContextHook.
+type ContextValues struct {
+	Payload     map[string][]byte
+	Annotations map[string]string
+}
+
+// ContextHook is called with the context of each statement and batch before
+// it is executed, and the values returned are added to the request. The
+// payload entries set with WithPayload or Payload take precedence over the
+// ones returned by the hooks.
+type ContextHook func(ctx context.Context) ContextValues
+
+// WithContextHook adds hooks that derive custom payload entries and
+// annotations from the context of the requests, for example to propagate
+// the request id and tenant to a proxy:
+//
+//	sess, err := ecql.NewSession(cfg, ecql.WithContextHook(func(ctx context.Context) ecql.ContextValues {
+//		id, _ := ecql.TenantOf(ctx)
+//		return ecql.ContextValues{Payload: map[string][]byte{"tenant": []byte(fmt.Sprint(id))}}
+//	}))
+func WithContextHook(h ...ContextHook) Option {
+	return func(s *SessionImpl) {
+		s.ctxHooks = append(s.ctxHooks, h...)
+	}
+}
+
+// contextValues returns the payload and annotations of a request with the
+// given context and payload.
+func contextValues(ctx context.Context, hooks []ContextHook, payload map[string][]byte) (map[string][]byte, map[string]string) {
+	annotations := AnnotationsOf(ctx)
+	if len(hooks) == 0 {
+		return payload, annotations
+	}
+
+	ctx = contextOf(ctx)
+	payloads := make([]map[string][]byte, 0, len(hooks)+1)
+	merged := mergeAnnotations(nil, annotations)
+	for _, h := range hooks {
+		v := h(ctx)
+		payloads = append(payloads, v.Payload)
+		merged = mergeAnnotations(merged, v.Annotations)
+	}
+	payloads = append(payloads, payload)
+	return mergePayload(payloads...), merged
+}
+
+// mergeAnnotations returns a with the values of b, a is created if
+// necessary.
+func mergeAnnotations(a, b map[string]string) map[string]string {
+	for k, v := range b {
+		if a == nil {
+			a = make(map[string]string)
+		}
+		a[k] = v
+	}
+	return a
+}
+
+// formatAnnotations returns the annotations as "key=value" sorted by key.
+func formatAnnotations(annotations map[string]string) string {
+	parts := make([]string, 0, len(annotations))
+	for k, v := range annotations {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// contextDriver sets the payload and annotations derived from the context of
+// the requests.
+type contextDriver struct {
+	Driver
+	hooks []ContextHook
+}
+
+func (d contextDriver) Iter(req *Request) Rows {
+	req.Payload, req.Annotations = contextValues(req.Context, d.hooks, req.Payload)
+	return d.Driver.Iter(req)
+}
+
+func (d contextDriver) ExecBatch(b *BatchRequest) error {
+	b.Payload, b.Annotations = contextValues(b.Context, d.hooks, b.Payload)
+	return d.Driver.ExecBatch(b)
+}
+
+func (d contextDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	b.Payload, b.Annotations = contextValues(b.Context, d.hooks, b.Payload)
+	return d.Driver.ExecBatchCAS(b, dest)
+}
@@ -0,0 +1,130 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotate(t *testing.T) {
+	assert.Nil(t, AnnotationsOf(nil))
+	assert.Nil(t, AnnotationsOf(context.Background()))
+
+	ctx := Annotate(nil, "request_id", "r1")
+	ctx2 := Annotate(ctx, "user", "alice")
+	ctx3 := Annotate(ctx2, "request_id", "r2")
+	assert.Equal(t, map[string]string{"request_id": "r1"}, AnnotationsOf(ctx))
+	assert.Equal(t, map[string]string{"request_id": "r1", "user": "alice"}, AnnotationsOf(ctx2))
+	assert.Equal(t, map[string]string{"request_id": "r2", "user": "alice"}, AnnotationsOf(ctx3))
+	assert.Equal(t, "request_id=r2 user=alice", formatAnnotations(AnnotationsOf(ctx3)))
+}
+
+func TestContextHook(t *testing.T) {
+	DeleteRegistry()
+	var middlewareCtx context.Context
+	middleware := func(next Driver) Driver {
+		return &middlewareDriver{Driver: next, fn: func(req *Request) {
+			middlewareCtx = req.Context
+		}}
+	}
+	hook := func(ctx context.Context) ContextValues {
+		id, ok := TenantOf(ctx)
+		if !ok {
+			return ContextValues{}
+		}
+		return ContextValues{
+			Payload:     map[string][]byte{"tenant": []byte(fmt.Sprint(id)), "source": []byte("hook")},
+			Annotations: map[string]string{"tenant": fmt.Sprint(id)},
+		}
+	}
+	sess, d := newTestSession(WithPayload("source", []byte("session")), WithContextHook(hook), WithMiddleware(middleware))
+
+	// Without annotations nor values from the hooks
+	assert.NoError(t, sess.Insert(&testStruct{F1: "a"}).Exec())
+	assert.Equal(t, map[string][]byte{"source": []byte("session")}, d.last().Payload)
+	assert.Nil(t, d.last().Annotations)
+
+	ctx := Annotate(context.Background(), "request_id", "r1")
+	tenant := sess.WithTenant(ctx, "acme")
+	assert.NoError(t, tenant.Select(&pageStruct{}).Exec())
+	assert.Equal(t, map[string][]byte{"source": []byte("session"), "tenant": []byte("acme")}, d.last().Payload)
+	assert.Equal(t, map[string]string{"request_id": "r1", "tenant": "acme"}, d.last().Annotations)
+	id, _ := TenantOf(middlewareCtx)
+	assert.Equal(t, "acme", id)
+
+	// Statement context
+	ctx = Annotate(ctx, "request_id", "r2")
+	assert.NoError(t, sess.Select(&pageStruct{}).WithContext(ctx).Payload("source", []byte("stmt")).Exec())
+	assert.Equal(t, map[string][]byte{"source": []byte("stmt")}, d.last().Payload)
+	assert.Equal(t, map[string]string{"request_id": "r2"}, d.last().Annotations)
+	assert.Equal(t, ctx, middlewareCtx)
+
+	// Errors
+	d.err = errors.New("unavailable")
+	err := sess.Select(&pageStruct{}).WithContext(ctx).Exec()
+	var qe *QueryError
+	if assert.True(t, errors.As(err, &qe)) {
+		assert.Equal(t, map[string]string{"request_id": "r2"}, qe.Annotations)
+	}
+	assert.Contains(t, err.Error(), " [request_id=r2]")
+
+	err = tenant.Batch().Add(tenant.Insert(&testStruct{F1: "a"})).Apply()
+	assert.Contains(t, err.Error(), " [request_id=r1 tenant=acme]")
+}
+
+func TestBatchContext(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithContextHook(func(ctx context.Context) ContextValues {
+		return ContextValues{Payload: map[string][]byte{"actor": []byte(ActorOf(ctx))}}
+	}))
+
+	var before, after context.Context
+	h := &hookCtxStruct{ID: "a", before: &before, after: &after}
+	ctx := Annotate(WithActor(context.Background(), "alice"), "request_id", "r1")
+	assert.NoError(t, sess.Batch().WithContext(ctx).Add(sess.Insert(h), sess.Delete(&testStruct{F1: "a"})).Apply())
+
+	b := d.batches[len(d.batches)-1]
+	assert.Equal(t, ctx, b.Context)
+	assert.Equal(t, ctx, b.Entries[0].Context)
+	assert.Equal(t, ctx, b.Entries[1].Context)
+	assert.Equal(t, map[string][]byte{"actor": []byte("alice")}, b.Payload)
+	assert.Equal(t, map[string]string{"request_id": "r1"}, b.Annotations)
+	assert.Equal(t, ctx, before)
+	assert.Equal(t, ctx, after)
+
+	// The context of the statements is kept
+	stmtCtx := WithActor(context.Background(), "bob")
+	assert.NoError(t, sess.Batch().WithContext(ctx).Add(sess.Insert(h).WithContext(stmtCtx)).Apply())
+	b = d.batches[len(d.batches)-1]
+	assert.Equal(t, ctx, b.Context)
+	assert.Equal(t, stmtCtx, b.Entries[0].Context)
+	assert.Equal(t, stmtCtx, before)
+	assert.Equal(t, ctx, after)
+
+	// The context of the batch is used when it is set after the statements
+	before, after = nil, nil
+	assert.NoError(t, sess.Batch().Add(sess.Insert(h)).WithContext(ctx).Apply())
+	b = d.batches[len(d.batches)-1]
+	assert.Equal(t, ctx, b.Entries[0].Context)
+	assert.Equal(t, ctx, before)
+	assert.Equal(t, ctx, after)
+}
+
+type hookCtxStruct struct {
+	ID     string           `cql:"id" cqltable:"hooks" cqlkey:"id"`
+	before *context.Context `cql:"-"`
+	after  *context.Context `cql:"-"`
+}
+
+func (h *hookCtxStruct) BeforeInsert(ctx context.Context) error {
+	*h.before = ctx
+	return nil
+}
+
+func (h *hookCtxStruct) AfterInsert(ctx context.Context) error {
+	*h.after = ctx
+	return nil
+}
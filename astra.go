@@ -0,0 +1,205 @@
+package ecql
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ErrInvalidBundle is returned when a secure connect bundle cannot be used.
+var ErrInvalidBundle = errors.New("ecql: invalid secure connect bundle")
+
+// AstraBundle contains the information in a DataStax Astra secure connect
+// bundle.
+type AstraBundle struct {
+	Host      string `json:"host"`
+	Port      int    `json:"port"`
+	CQLPort   int    `json:"cql_port"`
+	Keyspace  string `json:"keyspace"`
+	LocalDC   string `json:"localDC"`
+	TLSConfig *tls.Config
+}
+
+type astraMetadata struct {
+	ContactInfo struct {
+		LocalDC         string   `json:"local_dc"`
+		ContactPoints   []string `json:"contact_points"`
+		SNIProxyAddress string   `json:"sni_proxy_address"`
+	} `json:"contact_info"`
+}
+
+// NewAstraSession creates a Session connected to a DataStax Astra database
+// using the secure connect bundle in the given path. The username and password
+// are the client id and secret of an application token, or "token" and the
+// token itself.
+func NewAstraSession(bundlePath, username, password string, opts ...Option) (Session, error) {
+	b, err := ioutil.ReadFile(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewAstraSessionFromBytes(b, username, password, opts...)
+}
+
+// NewAstraSessionFromBytes creates a Session connected to a DataStax Astra
+// database using the given secure connect bundle.
+func NewAstraSessionFromBytes(bundle []byte, username, password string, opts ...Option) (Session, error) {
+	b, err := ParseAstraBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := b.ClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]Option{WithPasswordAuth(username, password)}, opts...)
+	return NewSession(*cfg, opts...)
+}
+
+// ParseAstraBundle parses the zip file of a secure connect bundle.
+func ParseAstraBundle(bundle []byte) (*AstraBundle, error) {
+	r, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name], err = ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range []string{"config.json", "ca.crt", "cert", "key"} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrInvalidBundle, name)
+		}
+	}
+
+	var b AstraBundle
+	if err := json.Unmarshal(files["config.json"], &b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(files["ca.crt"]) {
+		return nil, fmt.Errorf("%w: invalid ca.crt", ErrInvalidBundle)
+	}
+	cert, err := tls.X509KeyPair(files["cert"], files["key"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	b.TLSConfig = &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+		ServerName:   b.Host,
+	}
+
+	return &b, nil
+}
+
+// ClusterConfig fetches the metadata of the Astra database and returns the
+// gocql configuration required to connect to it. Astra databases are
+// accessed through an SNI proxy, the connections to each host use the host id
+// as the server name.
+func (b *AstraBundle) ClusterConfig() (*gocql.ClusterConfig, error) {
+	md, err := b.metadata()
+	if err != nil {
+		return nil, err
+	}
+	if len(md.ContactInfo.ContactPoints) == 0 || md.ContactInfo.SNIProxyAddress == "" {
+		return nil, fmt.Errorf("%w: invalid metadata", ErrInvalidBundle)
+	}
+
+	host, port, err := net.SplitHostPort(md.ContactInfo.SNIProxyAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := gocql.NewCluster(host)
+	cfg.Port, _ = strconv.Atoi(port)
+	cfg.Keyspace = b.Keyspace
+	cfg.Consistency = gocql.LocalQuorum
+	cfg.PoolConfig.HostSelectionPolicy = newTokenAwarePolicy(gocql.DCAwareRoundRobinPolicy(md.ContactInfo.LocalDC))
+	cfg.HostDialer = &astraDialer{
+		proxy:         md.ContactInfo.SNIProxyAddress,
+		contactPoints: md.ContactInfo.ContactPoints,
+		tlsConfig:     b.TLSConfig,
+	}
+	return cfg, nil
+}
+
+func (b *AstraBundle) metadata() (*astraMetadata, error) {
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: b.TLSConfig},
+	}
+	resp, err := client.Get(fmt.Sprintf("https://%s/metadata", net.JoinHostPort(b.Host, strconv.Itoa(b.Port))))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ecql: error fetching astra metadata: %s", resp.Status)
+	}
+
+	var md astraMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&md); err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
+
+// astraDialer connects to the Astra SNI proxy using the host id as the TLS
+// server name. The initial connections use the contact points of the
+// metadata.
+type astraDialer struct {
+	proxy         string
+	contactPoints []string
+	tlsConfig     *tls.Config
+	next          uint32
+}
+
+func (d *astraDialer) DialHost(ctx context.Context, host *gocql.HostInfo) (*gocql.DialedHost, error) {
+	serverName := host.HostID()
+	if serverName == "" {
+		i := atomic.AddUint32(&d.next, 1)
+		serverName = d.contactPoints[int(i)%len(d.contactPoints)]
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", d.proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	config := d.tlsConfig.Clone()
+	config.ServerName = serverName
+	tconn := tls.Client(conn, config)
+	if err := tconn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &gocql.DialedHost{
+		Conn:            tconn,
+		DisableCoalesce: true,
+	}, nil
+}
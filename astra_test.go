@@ -0,0 +1,84 @@
+package ecql
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBundle(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		assert.NoError(t, err)
+		f.Write([]byte(content))
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func testCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ecql"},
+		DNSNames:              []string{"ecql"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
+
+func TestParseAstraBundle(t *testing.T) {
+	cert, key := testCertificate(t)
+	config := `{"host":"db.astra.datastax.com","port":29080,"cql_port":29042,"keyspace":"ks","localDC":"dc-1"}`
+
+	b, err := ParseAstraBundle(testBundle(t, map[string]string{
+		"config.json": config,
+		"ca.crt":      cert,
+		"cert":        cert,
+		"key":         key,
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "db.astra.datastax.com", b.Host)
+	assert.Equal(t, 29080, b.Port)
+	assert.Equal(t, 29042, b.CQLPort)
+	assert.Equal(t, "ks", b.Keyspace)
+	assert.Equal(t, "dc-1", b.LocalDC)
+	assert.Equal(t, "db.astra.datastax.com", b.TLSConfig.ServerName)
+	assert.Len(t, b.TLSConfig.Certificates, 1)
+
+	_, err = ParseAstraBundle(testBundle(t, map[string]string{"config.json": config}))
+	assert.True(t, errors.Is(err, ErrInvalidBundle))
+
+	_, err = ParseAstraBundle(testBundle(t, map[string]string{
+		"config.json": config,
+		"ca.crt":      "foo",
+		"cert":        cert,
+		"key":         key,
+	}))
+	assert.True(t, errors.Is(err, ErrInvalidBundle))
+
+	_, err = ParseAstraBundle([]byte("not a zip"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,397 @@
+package ecql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// AuditRecord is a change record written by an Audit. Key contains the JSON
+// encoded values of the key columns of the modified row, and Changes the old
+// and new values of the modified columns. The table can be created with:
+//
+//	err := sess.AutoMigrate(ecql.AuditRecord{})
+type AuditRecord struct {
+	Table   string                 `cql:"table_name" cqltable:"audit_log" cqlkey:"(table_name,row_key),id"`
+	Key     string                 `cql:"row_key"`
+	ID      gocql.UUID             `cql:"id,type=timeuuid"`
+	Time    time.Time              `cql:"time"`
+	Actor   string                 `cql:"actor"`
+	Command string                 `cql:"command"`
+	Changes map[string]AuditChange `cql:"changes,json,type=text"`
+}
+
+// AuditChange contains the value of a column before and after a write.
+type AuditChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx with the actor recorded in the audit log.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorOf returns the actor set in the context with WithActor.
+func ActorOf(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// AuditConfig contains the configuration of an Audit.
+type AuditConfig struct {
+	// Types are the structs whose writes are recorded.
+	Types []interface{}
+	// Table is the name of the audit table, it defaults to the table of
+	// AuditRecord, audit_log.
+	Table string
+	// Registry is the registry of the types, it defaults to DefaultRegistry.
+	Registry *Registry
+	// Actor returns who executes a write, it defaults to ActorOf.
+	Actor func(ctx context.Context) string
+	// Clock sets the time of the records, it defaults to SystemClock.
+	Clock Clock
+	// UUIDs generates the ids of the records, it defaults to SystemUUIDs.
+	UUIDs UUIDSource
+}
+
+// Audit is a middleware that records the inserts, updates and deletes of
+// the opted-in types in an audit table using the same session:
+//
+//	audit := ecql.NewAudit(ecql.AuditConfig{Types: []interface{}{User{}}})
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(audit.Middleware()))
+//	// ...
+//	ctx = ecql.WithActor(ctx, "alice")
+//	err = sess.WithContext(ctx).Set(user)
+//
+// The current values of a row are read before it is written to record the
+// changes, so only the writes with all the key columns are diffed. The values
+// of the encrypted columns are never recorded, and conditional writes are
+// only recorded if they are applied.
+type Audit struct {
+	tables   map[string]Table
+	registry *Registry
+	actor    func(ctx context.Context) string
+	clock    Clock
+	uuids    UUIDSource
+	table    string
+	insert   string
+}
+
+// NewAudit creates an Audit with the given configuration.
+func NewAudit(config AuditConfig) *Audit {
+	a := &Audit{
+		tables:   make(map[string]Table),
+		registry: config.Registry,
+		actor:    config.Actor,
+		clock:    config.Clock,
+		uuids:    config.UUIDs,
+	}
+	if a.registry == nil {
+		a.registry = DefaultRegistry
+	}
+	if a.actor == nil {
+		a.actor = ActorOf
+	}
+	if a.clock == nil {
+		a.clock = SystemClock
+	}
+	if a.uuids == nil {
+		a.uuids = SystemUUIDs
+	}
+	for _, t := range config.Types {
+		table := a.registry.GetTable(t)
+		a.tables[table.Name] = table
+	}
+	table := a.registry.GetTable(AuditRecord{})
+	if config.Table != "" {
+		table.Name = config.Table
+	}
+	a.table = table.Name
+	a.insert, _ = table.BuildQuery(insertQuery)
+	return a
+}
+
+// Middleware returns the middleware that records the writes.
+func (a *Audit) Middleware() Middleware {
+	return func(next Driver) Driver {
+		return &auditDriver{Driver: next, audit: a}
+	}
+}
+
+// auditEntry is a write that must be recorded.
+type auditEntry struct {
+	req   *Request
+	table Table
+	keys  []interface{}
+	old   map[string]interface{}
+}
+
+// auditDriver is the Driver used by the Audit middleware.
+type auditDriver struct {
+	Driver
+	audit *Audit
+}
+
+// entry returns the entry of the request if it must be recorded, reading
+// the current values of the row.
+func (d *auditDriver) entry(req *Request) (*auditEntry, error) {
+	switch req.Command {
+	case InsertCmd, UpdateCmd, DeleteCmd:
+	default:
+		return nil, nil
+	}
+	table, ok := d.audit.tables[req.Table]
+	if !ok || req.Row == nil {
+		return nil, nil
+	}
+
+	e := &auditEntry{req: req, table: table, keys: make([]interface{}, len(table.KeyColumns))}
+	full := true
+	for i, col := range table.KeyColumns {
+		v, ok := req.Row[col]
+		full = full && ok
+		e.keys[i] = v
+	}
+	if !full {
+		return e, nil
+	}
+
+	rows := d.Driver.Iter(&Request{
+		Context:      req.Context,
+		Command:      SelectCmd,
+		Table:        table.Name,
+		Statement:    fmt.Sprintf("SELECT * FROM %s WHERE %s", table.quotedName(), appendCols(table.quoteAll(table.KeyColumns))),
+		Values:       e.keys,
+		Consistency:  req.Consistency,
+		PartitionKey: req.PartitionKey,
+		DC:           req.DC,
+		Idempotent:   true,
+		Priority:     req.Priority,
+		Cluster:      req.Cluster,
+	})
+	old := make(map[string]interface{})
+	if rows.MapScan(old) {
+		e.old = old
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// record writes the audit record of the entry.
+func (d *auditDriver) record(e *auditEntry) error {
+	keys := make([]interface{}, len(e.keys))
+	for i, v := range e.keys {
+		keys[i], _ = plainValue(v)
+	}
+	key, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	now := d.audit.clock.Now()
+	rec := AuditRecord{
+		Table:   e.table.Name,
+		Key:     string(key),
+		ID:      d.audit.uuids.TimeUUID(now),
+		Time:    now,
+		Actor:   d.audit.actor(contextOf(e.req.Context)),
+		Command: strings.ToLower(e.req.Command.String()),
+		Changes: e.changes(),
+	}
+	return d.Driver.Iter(&Request{
+		Context:     e.req.Context,
+		Command:     InsertCmd,
+		Table:       d.audit.table,
+		Statement:   d.audit.insert,
+		Values:      d.audit.registry.Bind(&rec),
+		Consistency: e.req.Consistency,
+		Idempotent:  true,
+		Priority:    e.req.Priority,
+		Cluster:     e.req.Cluster,
+	}).Close()
+}
+
+// changes returns the columns modified by the entry.
+func (e *auditEntry) changes() map[string]AuditChange {
+	skip := make(map[string]bool)
+	for _, col := range e.table.KeyColumns {
+		skip[col] = true
+	}
+	for _, col := range e.table.Columns {
+		if col.Encrypted {
+			skip[col.Name] = true
+		}
+	}
+
+	changes := make(map[string]AuditChange)
+	if e.req.Command == DeleteCmd {
+		for col, v := range e.old {
+			if !skip[col] && v != nil {
+				changes[col] = AuditChange{Old: v}
+			}
+		}
+		return changes
+	}
+	for col, v := range e.req.Row {
+		if skip[col] {
+			continue
+		}
+		nv, ok := plainValue(v)
+		if !ok {
+			continue
+		}
+		if ov := e.old[col]; !sameValue(ov, nv) {
+			changes[col] = AuditChange{Old: ov, New: nv}
+		}
+	}
+	return changes
+}
+
+// plainValue returns the value of the field bound to a column, or false if
+// the column is not written.
+func plainValue(v interface{}) (interface{}, bool) {
+	if v == gocql.UnsetValue {
+		return nil, false
+	}
+	switch r := v.(type) {
+	case encryptedRef:
+		return nil, false
+	case enumRef, nullRef, serializedRef:
+		return refValue(r), true
+	default:
+		return deref(v), true
+	}
+}
+
+// sameValue returns true if the values have the same JSON representation,
+// the values read from the database may have a different type than the ones
+// bound to the statements.
+func sameValue(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+func (d *auditDriver) Iter(req *Request) Rows {
+	e, err := d.entry(req)
+	if err != nil {
+		return errorRows{err: err}
+	}
+	rows := d.Driver.Iter(req)
+	if e == nil {
+		return rows
+	}
+	return &auditRows{Rows: rows, driver: d, entry: e}
+}
+
+func (d *auditDriver) entries(b *BatchRequest) ([]*auditEntry, error) {
+	var entries []*auditEntry
+	for i := range b.Entries {
+		e, err := d.entry(&b.Entries[i])
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (d *auditDriver) recordAll(entries []*auditEntry) error {
+	for _, e := range entries {
+		if err := d.record(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *auditDriver) ExecBatch(b *BatchRequest) error {
+	entries, err := d.entries(b)
+	if err != nil {
+		return err
+	}
+	if err := d.Driver.ExecBatch(b); err != nil {
+		return err
+	}
+	return d.recordAll(entries)
+}
+
+func (d *auditDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	entries, err := d.entries(b)
+	if err != nil {
+		return false, err
+	}
+	applied, err := d.Driver.ExecBatchCAS(b, dest)
+	if err != nil || !applied {
+		return applied, err
+	}
+	return applied, d.recordAll(entries)
+}
+
+// auditRows records the write when the rows are closed, the conditional
+// writes are only recorded if the [applied] column is true.
+type auditRows struct {
+	Rows
+	driver     *auditDriver
+	entry      *auditEntry
+	scanned    bool
+	notApplied bool
+	closed     bool
+	err        error
+}
+
+func (r *auditRows) Scan(dest ...interface{}) bool {
+	if !r.Rows.Scan(dest...) {
+		return false
+	}
+	r.scanned = true
+	if cols := r.Columns(); len(cols) > 0 && len(dest) > 0 && cols[0].Name == "[applied]" {
+		if applied, ok := dest[0].(*bool); ok {
+			r.notApplied = !*applied
+		}
+	}
+	return true
+}
+
+func (r *auditRows) MapScan(m map[string]interface{}) bool {
+	if !r.Rows.MapScan(m) {
+		return false
+	}
+	r.scanned = true
+	if applied, ok := m["[applied]"].(bool); ok {
+		r.notApplied = !applied
+	}
+	return true
+}
+
+// Close closes the rows and records the write, the entry is recorded only
+// once if Close is called again.
+func (r *auditRows) Close() error {
+	if r.closed {
+		return r.err
+	}
+	r.closed = true
+	if !r.scanned && strings.Contains(r.entry.req.Statement, " IF ") {
+		r.MapScan(make(map[string]interface{}))
+	}
+	if r.err = r.Rows.Close(); r.err != nil || r.notApplied {
+		return r.err
+	}
+	r.err = r.driver.record(r.entry)
+	return r.err
+}
@@ -0,0 +1,122 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActor(t *testing.T) {
+	assert.Equal(t, "", ActorOf(nil))
+	assert.Equal(t, "", ActorOf(context.Background()))
+	assert.Equal(t, "alice", ActorOf(WithActor(context.Background(), "alice")))
+}
+
+func TestAudit(t *testing.T) {
+	DeleteRegistry()
+	audit := NewAudit(AuditConfig{Types: []interface{}{testStruct{}}})
+	sess, d := newTestSession(WithMiddleware(audit.Middleware()))
+	columns := []string{"f1", "f22", "f3", "f4"}
+	ctx := WithActor(context.Background(), "alice")
+
+	record := func() (AuditRecord, map[string]AuditChange) {
+		req := d.last()
+		assert.Equal(t, "audit_log", req.Table)
+		assert.Equal(t, InsertCmd, req.Command)
+		assert.Equal(t, "INSERT INTO audit_log (table_name,row_key,id,time,actor,command,changes) VALUES (?,?,?,?,?,?,?)", req.Statement)
+		changes := make(map[string]AuditChange)
+		data, err := req.Values[6].(gocql.Marshaler).MarshalCQL(nil)
+		assert.NoError(t, err)
+		assert.NoError(t, jsonSerializer{}.Unmarshal(data, &changes))
+		return AuditRecord{
+			Table:   req.Values[0].(string),
+			Key:     req.Values[1].(string),
+			Actor:   req.Values[4].(string),
+			Command: req.Values[5].(string),
+		}, changes
+	}
+
+	// Insert
+	d.result(columns, []interface{}{"a", 1, nil, nil})
+	assert.NoError(t, sess.WithContext(ctx).Set(testStruct{F1: "a", F2: 2}))
+	assert.Len(t, d.requests, 3)
+	assert.Equal(t, "SELECT * FROM mytable WHERE f1 = ?", d.requests[0].Statement)
+	assert.Equal(t, []interface{}{"a"}, d.requests[0].Values)
+	rec, changes := record()
+	assert.Equal(t, AuditRecord{Table: "mytable", Key: `["a"]`, Actor: "alice", Command: "insert"}, rec)
+	assert.Equal(t, map[string]AuditChange{"f22": {Old: float64(1), New: float64(2)}}, changes)
+
+	// Update
+	d.requests = nil
+	f4 := "foo"
+	assert.NoError(t, sess.Update(&testStruct{F1: "a", F2: 1, F4: &f4}).Columns("f4").Exec())
+	assert.Len(t, d.requests, 3)
+	rec, changes = record()
+	assert.Equal(t, AuditRecord{Table: "mytable", Key: `["a"]`, Command: "update"}, rec)
+	assert.Equal(t, map[string]AuditChange{"f4": {New: "foo"}}, changes)
+
+	// Delete
+	d.requests = nil
+	assert.NoError(t, sess.Del(&testStruct{F1: "a"}))
+	rec, changes = record()
+	assert.Equal(t, "delete", rec.Command)
+	assert.Equal(t, map[string]AuditChange{"f22": {Old: float64(1)}}, changes)
+
+	// Batch
+	d.requests = nil
+	assert.NoError(t, sess.Batch().Add(sess.Insert(&testStruct{F1: "b", F2: 1})).Apply())
+	assert.Len(t, d.batches, 1)
+	assert.Len(t, d.requests, 2)
+	rec, _ = record()
+	assert.Equal(t, `["b"]`, rec.Key)
+
+	// Reads
+	d.requests = nil
+	var ts testStruct
+	assert.NoError(t, sess.Get(&ts, "a"))
+	assert.Len(t, d.requests, 1)
+
+	// Errors
+	d.requests = nil
+	d.err = errors.New("failed")
+	assert.Error(t, sess.Set(testStruct{F1: "a"}))
+	assert.Len(t, d.requests, 1)
+}
+
+func TestAuditNotApplied(t *testing.T) {
+	DeleteRegistry()
+	audit := NewAudit(AuditConfig{Types: []interface{}{testStruct{}}, Table: "changes"})
+	sess, d := newTestSession(WithMiddleware(audit.Middleware()))
+
+	d.result([]string{"[applied]", "f1"}, []interface{}{false, "a"})
+	info, err := sess.Insert(&testStruct{F1: "a"}).IfNotExists().ExecInfo()
+	assert.NoError(t, err)
+	assert.False(t, info.Applied)
+	assert.Len(t, d.requests, 2)
+
+	d.result([]string{"[applied]"}, []interface{}{true})
+	info, err = sess.Insert(&testStruct{F1: "a"}).IfNotExists().ExecInfo()
+	assert.NoError(t, err)
+	assert.True(t, info.Applied)
+	assert.Len(t, d.requests, 5)
+	assert.Equal(t, "changes", d.last().Table)
+
+	d.requests = nil
+	d.result([]string{"[applied]"}, []interface{}{false})
+	assert.Equal(t, ErrNotFound, sess.Update(&testStruct{F1: "a"}).Columns("f22").IfExists().Exec())
+	d.result([]string{"[applied]"}, []interface{}{false})
+	assert.NoError(t, sess.Insert(&testStruct{F1: "a"}).IfNotExists().Exec())
+	assert.Len(t, d.requests, 4)
+	// Recorded once if the rows are closed twice
+	d.requests = nil
+	req, err := sess.Insert(&testStruct{F1: "a"}).(*StatementImpl).request()
+	assert.NoError(t, err)
+	rows := sess.getDriver().Iter(req)
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, rows.Close())
+	assert.Len(t, d.requests, 3)
+	assert.Equal(t, "changes", d.last().Table)
+}
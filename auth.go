@@ -0,0 +1,197 @@
+package ecql
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ErrInvalidNonce is returned by the SigV4Authenticator if the server
+// challenge does not contain a nonce.
+var ErrInvalidNonce = errors.New("ecql: sigv4 challenge without nonce")
+
+// WithPasswordAuth configures the session to authenticate using the
+// PasswordAuthenticator with the given username and password.
+//
+// It only has effect on sessions created with NewSession.
+func WithPasswordAuth(username, password string) Option {
+	return WithAuthenticator(gocql.PasswordAuthenticator{
+		Username: username,
+		Password: password,
+	})
+}
+
+// WithAuthenticator configures the session to use the given authenticator.
+//
+// It only has effect on sessions created with NewSession.
+func WithAuthenticator(auth gocql.Authenticator) Option {
+	return func(s *SessionImpl) {
+		if s.cluster != nil {
+			s.cluster.Authenticator = auth
+		}
+	}
+}
+
+// WithTLS configures the session to use TLS with the given configuration. A
+// nil configuration uses the system root certificates.
+//
+// It only has effect on sessions created with NewSession.
+func WithTLS(config *tls.Config) Option {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	return func(s *SessionImpl) {
+		if s.cluster != nil {
+			s.cluster.SslOpts = sslOptions(config)
+		}
+	}
+}
+
+// WithKeyspacesSigV4 configures the session to connect to Amazon Keyspaces
+// using SigV4 authentication with the given authenticator. It enables TLS
+// using the system root certificates, and sets the LOCAL_QUORUM consistency
+// required by Amazon Keyspaces on writes.
+//
+// It only has effect on sessions created with NewSession.
+func WithKeyspacesSigV4(auth SigV4Authenticator) Option {
+	return func(s *SessionImpl) {
+		if s.cluster != nil {
+			s.cluster.Authenticator = auth
+			s.cluster.SslOpts = &gocql.SslOptions{
+				Config:                 &tls.Config{},
+				EnableHostVerification: true,
+			}
+			s.cluster.Consistency = gocql.LocalQuorum
+		}
+		WithConsistency(ConsistencyPreset{gocql.LocalOne, gocql.LocalQuorum, gocql.LocalSerial})(s)
+	}
+}
+
+// SigV4Authenticator is a gocql.Authenticator that signs the authentication
+// challenge with AWS Signature Version 4. It is used to connect to Amazon
+// Keyspaces with IAM credentials.
+type SigV4Authenticator struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// now is used in tests to get the current time.
+	now func() time.Time
+}
+
+// NewSigV4AuthenticatorFromEnv creates a SigV4Authenticator using the
+// standard AWS environment variables: AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, and AWS_REGION or
+// AWS_DEFAULT_REGION.
+func NewSigV4AuthenticatorFromEnv() SigV4Authenticator {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return SigV4Authenticator{
+		Region:          region,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Challenge starts the SigV4 authentication, the server will reply with a
+// nonce that is signed by the returned authenticator.
+func (a SigV4Authenticator) Challenge(req []byte) ([]byte, gocql.Authenticator, error) {
+	return []byte("SigV4\x00\x00"), sigV4Signer{a}, nil
+}
+
+// Success is called on successful authentication.
+func (a SigV4Authenticator) Success(data []byte) error {
+	return nil
+}
+
+type sigV4Signer struct {
+	SigV4Authenticator
+}
+
+func (s sigV4Signer) Challenge(req []byte) ([]byte, gocql.Authenticator, error) {
+	nonce, err := sigV4Nonce(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	return []byte(s.sign(nonce, now().UTC())), nil, nil
+}
+
+// sigV4Nonce extracts the nonce from a challenge "nonce=<nonce>,...".
+func sigV4Nonce(req []byte) (string, error) {
+	i := bytes.Index(req, []byte("nonce="))
+	if i < 0 {
+		return "", ErrInvalidNonce
+	}
+	nonce := req[i+len("nonce="):]
+	if j := bytes.IndexByte(nonce, ','); j >= 0 {
+		nonce = nonce[:j]
+	}
+	return string(nonce), nil
+}
+
+// sign returns the signed response for the given nonce.
+func (a SigV4Authenticator) sign(nonce string, t time.Time) string {
+	amzdate := t.Format("2006-01-02T15:04:05.000Z")
+	scope := sigV4Scope(t, a.Region, "cassandra")
+
+	headers := []string{
+		"X-Amz-Algorithm=AWS4-HMAC-SHA256",
+		fmt.Sprintf("X-Amz-Credential=%s%%2F%s", a.AccessKeyID, url.QueryEscape(scope)),
+		fmt.Sprintf("X-Amz-Date=%s", url.QueryEscape(amzdate)),
+		"X-Amz-Expires=900",
+	}
+	sort.Strings(headers)
+	nonceHash := sha256.Sum256([]byte(nonce))
+	canonicalRequest := fmt.Sprintf("PUT\n/authenticate\n%s\nhost:cassandra\n\nhost\n%s",
+		strings.Join(headers, "&"), hex.EncodeToString(nonceHash[:]))
+
+	signature := sigV4Signature(a.SecretAccessKey, t, a.Region, "cassandra", amzdate, canonicalRequest)
+
+	resp := fmt.Sprintf("signature=%s,access_key=%s,amzdate=%s", hex.EncodeToString(signature), a.AccessKeyID, amzdate)
+	if a.SessionToken != "" {
+		resp += ",session_token=" + a.SessionToken
+	}
+	return resp
+}
+
+// sigV4Scope returns the credential scope of a SigV4 request.
+func sigV4Scope(t time.Time, region, service string) string {
+	return strings.Join([]string{t.Format("20060102"), region, service, "aws4_request"}, "/")
+}
+
+// sigV4Signature signs the canonical request with the key derived from the
+// secret for the date, region and service.
+func sigV4Signature(secret string, t time.Time, region, service, amzdate, canonicalRequest string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), t.Format("20060102"))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	return hmacSHA256(key, fmt.Sprintf("AWS4-HMAC-SHA256\n%s\n%s\n%s", amzdate, sigV4Scope(t, region, service), hex.EncodeToString(digest[:])))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
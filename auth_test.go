@@ -0,0 +1,61 @@
+package ecql
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthOptions(t *testing.T) {
+	cfg := gocql.NewCluster("127.0.0.1")
+	newSessionImpl(cfg, []Option{WithPasswordAuth("user", "pass"), WithTLS(&tls.Config{ServerName: "cassandra"})})
+	assert.Equal(t, gocql.PasswordAuthenticator{Username: "user", Password: "pass"}, cfg.Authenticator)
+	assert.Equal(t, "cassandra", cfg.SslOpts.Config.ServerName)
+	assert.True(t, cfg.SslOpts.EnableHostVerification)
+
+	cfg = gocql.NewCluster("127.0.0.1")
+	newSessionImpl(cfg, []Option{WithTLS(nil)})
+	assert.NotNil(t, cfg.SslOpts.Config)
+	assert.True(t, cfg.SslOpts.EnableHostVerification)
+
+	cfg = gocql.NewCluster("cassandra.us-east-1.amazonaws.com:9142")
+	s := newSessionImpl(cfg, []Option{WithKeyspacesSigV4(SigV4Authenticator{Region: "us-east-1"})})
+	assert.IsType(t, SigV4Authenticator{}, cfg.Authenticator)
+	assert.NotNil(t, cfg.SslOpts)
+	assert.Equal(t, gocql.LocalQuorum, cfg.Consistency)
+	assert.Equal(t, gocql.LocalQuorum, s.consistency.Write)
+}
+
+func TestSigV4Authenticator(t *testing.T) {
+	now := time.Date(2020, 6, 9, 22, 41, 51, 0, time.UTC)
+	auth := SigV4Authenticator{
+		Region:          "us-west-2",
+		AccessKeyID:     "UserID-1",
+		SecretAccessKey: "UserSecretKey-1",
+		now:             func() time.Time { return now },
+	}
+
+	resp, signer, err := auth.Challenge([]byte("com.amazonaws.cassandra.auth.SigV4AuthProvider"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("SigV4\x00\x00"), resp)
+
+	resp, next, err := signer.Challenge([]byte("nonce=91703fdc2ef562e19fbdab0f58e42fe5,foo=bar"))
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+	// Test vector of the AWS SigV4 authentication plugins
+	assert.Equal(t, "signature=7f3691c18a81b8ce7457699effbfae5b09b4e0714ab38c1292dbdf082c9ddd87,access_key=UserID-1,amzdate=2020-06-09T22:41:51.000Z", string(resp))
+
+	// Deterministic
+	resp2, _, _ := signer.Challenge([]byte("nonce=91703fdc2ef562e19fbdab0f58e42fe5"))
+	assert.Equal(t, resp, resp2)
+
+	auth.SessionToken = "token"
+	assert.True(t, strings.HasSuffix(auth.sign("nonce", now), ",session_token=token"))
+
+	_, _, err = signer.Challenge([]byte("foo=bar"))
+	assert.Equal(t, ErrInvalidNonce, err)
+}
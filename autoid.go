@@ -0,0 +1,86 @@
+package ecql
+
+import (
+	"reflect"
+
+	"github.com/gocql/gocql"
+)
+
+// hasAuto returns true if the table has columns with the auto option.
+func (t *Table) hasAuto() bool {
+	for _, c := range t.Columns {
+		if c.Auto {
+			return true
+		}
+	}
+	return false
+}
+
+// isAutoType returns true if a generated UUID can be set in a field of type
+// t: a gocql.UUID, a string, a [16]byte or a pointer to them.
+func isAutoType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == uuidType || t.Kind() == reflect.String ||
+		(t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8 && t.Len() == 16)
+}
+
+// generateIDs sets the empty columns with the auto option of the struct i
+// using the UUID source of the session. Columns of type timeuuid get a time
+// UUID and the rest a random UUID. It returns a pointer to a copy of i if it
+// is not a pointer, so the generated values are only written back to the
+// pointers.
+func (s *SessionImpl) generateIDs(i interface{}) interface{} {
+	table := s.getRegistry().GetTable(i)
+	if !table.hasAuto() {
+		return i
+	}
+
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		i, v = p.Interface(), p
+	}
+	sv := v.Elem()
+	for n, col := range table.Columns {
+		if !col.Auto {
+			continue
+		}
+		var field reflect.Value
+		if n < len(table.accessors) {
+			field = table.accessors[n].field(sv)
+		} else {
+			field = sv.FieldByIndex(col.Position)
+		}
+		if !field.CanSet() {
+			continue
+		}
+		if field.Kind() == reflect.Ptr {
+			if !field.IsNil() {
+				continue
+			}
+			field.Set(reflect.New(field.Type().Elem()))
+			field = field.Elem()
+		}
+		if !field.IsZero() {
+			continue
+		}
+		var id gocql.UUID
+		if col.Type == "timeuuid" {
+			id = s.timeUUID()
+		} else {
+			id = s.getUUIDs().RandomUUID()
+		}
+		switch {
+		case field.Type() == uuidType:
+			field.Set(reflect.ValueOf(id))
+		case field.Kind() == reflect.String:
+			field.SetString(id.String())
+		case field.Kind() == reflect.Array && field.Type().Elem().Kind() == reflect.Uint8 && field.Len() == 16:
+			reflect.Copy(field, reflect.ValueOf(id[:]))
+		}
+	}
+	return i
+}
@@ -0,0 +1,77 @@
+package ecql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type autoStruct struct {
+	ID    gocql.UUID `cql:"id,auto" cqltable:"autos" cqlkey:"id,event"`
+	Event gocql.UUID `cql:"event,auto,type=timeuuid"`
+	Ref   *string    `cql:"ref,auto"`
+	Name  string     `cql:"name"`
+}
+
+func TestAutoID(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	uuids := fixedUUIDs{
+		time:   MustUUID("00000001-0000-1000-8000-000000000000"),
+		random: MustUUID("00000002-0000-4000-8000-000000000000"),
+	}
+	sess, d := newTestSession(WithClock(ClockFunc(func() time.Time { return now })), WithUUIDSource(uuids))
+	table := sess.getRegistry().GetTable(autoStruct{})
+	assert.True(t, table.Columns[0].Auto)
+	assert.True(t, table.hasAuto())
+	other := sess.getRegistry().GetTable(testStruct{})
+	assert.False(t, other.hasAuto())
+
+	// Set writes back the generated ids
+	v := autoStruct{Name: "foo"}
+	assert.NoError(t, sess.Set(&v))
+	assert.Equal(t, uuids.random, v.ID)
+	assert.Equal(t, uuids.time, v.Event)
+	if assert.NotNil(t, v.Ref) {
+		assert.Equal(t, uuids.random.String(), *v.Ref)
+	}
+	assert.Equal(t, "INSERT INTO autos (id,event,ref,name) VALUES (?,?,?,?)", d.last().Statement)
+	assert.Equal(t, []interface{}{uuids.random, uuids.time, uuids.random.String(), "foo"}, derefValues(d.last()))
+
+	// Existing values are kept
+	id, ref := MustUUID("00000003-0000-4000-8000-000000000000"), "bar"
+	v = autoStruct{ID: id, Ref: &ref}
+	assert.NoError(t, sess.Insert(&v).Exec())
+	assert.Equal(t, id, v.ID)
+	assert.Equal(t, uuids.time, v.Event)
+	assert.Equal(t, "bar", *v.Ref)
+	assert.Equal(t, []interface{}{id, uuids.time, "bar", ""}, derefValues(d.last()))
+
+	// Values are generated on the copy
+	assert.NoError(t, sess.Insert(autoStruct{}).Exec())
+	assert.Equal(t, uuids.random, derefValues(d.last())[0])
+
+	// Only on inserts
+	v = autoStruct{Name: "foo"}
+	sess.Update(&v).Columns("name").Exec()
+	assert.Equal(t, gocql.UUID{}, v.ID)
+}
+
+func TestAutoIDInvalid(t *testing.T) {
+	type autoInt struct {
+		ID   int    `cql:"id,auto" cqltable:"autos"`
+		Name string `cql:"name"`
+	}
+	r := NewRegistry()
+	assert.PanicsWithValue(t, "auto column id is not a UUID", func() {
+		r.Register(autoInt{})
+	})
+
+	type autoBytes struct {
+		ID [16]byte `cql:"id,auto" cqltable:"autos"`
+	}
+	assert.NotPanics(t, func() {
+		r.Register(autoBytes{})
+	})
+}
@@ -1,42 +1,163 @@
 package ecql
 
-import "github.com/gocql/gocql"
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+)
 
 type Batch interface {
 	Add(s ...Statement) Batch
+	WithContext(ctx context.Context) Batch
 	Apply() error
 	ApplyCAS() (bool, error)
 }
 
 type BatchImpl struct {
 	session *SessionImpl
-	batch   *gocql.Batch
+	typ     gocql.BatchType
+	added   []Statement
+	entries []Request
+	stmts   []*StatementImpl
+	written []*StatementImpl
+	ctx     context.Context
 }
 
 func NewBatch(sess *SessionImpl, typ gocql.BatchType) Batch {
 	return &BatchImpl{
 		session: sess,
-		batch:   gocql.NewBatch(typ),
+		typ:     typ,
 	}
 }
 
 func (b *BatchImpl) Add(s ...Statement) Batch {
-	for i := range s {
-		stmt, args := s[i].BuildQuery()
-		b.batch.Query(stmt, args...)
+	for _, st := range s {
+		if im, ok := st.(immutableStatement); ok {
+			st = im.s.Clone()
+		}
+		b.added = append(b.added, st)
 	}
 	return b
 }
 
+// WithContext sets the context used to execute the batch and to call the
+// hooks of the structs written, it overrides the one of the session. The
+// statements without a context use the context of the batch.
+func (b *BatchImpl) WithContext(ctx context.Context) Batch {
+	b.ctx = ctx
+	return b
+}
+
+// build creates the entries of the batch with the statements added. The
+// callbacks of the structs written are called here, so they get the context
+// of the batch even if it is set after adding the statements.
+func (b *BatchImpl) build() error {
+	b.entries, b.stmts, b.written = nil, nil, nil
+	for _, st := range b.added {
+		stmt, ok := st.(*StatementImpl)
+		if !ok {
+			q, args := st.BuildQuery()
+			b.entries = append(b.entries, Request{Statement: q, Values: args})
+			continue
+		}
+		if b.ctx != nil && stmt.ctx == nil {
+			stmt = stmt.Clone().(*StatementImpl)
+			stmt.ctx = b.ctx
+		}
+		req, err := stmt.request()
+		if err != nil {
+			return err
+		}
+		b.entries = append(b.entries, *req)
+		b.stmts = append(b.stmts, stmt)
+		if stmt.bound != nil {
+			b.written = append(b.written, stmt)
+		}
+	}
+	return nil
+}
+
+// context returns the context of the batch or the session.
+func (b *BatchImpl) context() context.Context {
+	if b.ctx != nil {
+		return b.ctx
+	}
+	return b.session.ctx
+}
+
 func (b *BatchImpl) Apply() error {
-	return b.session.ExecuteBatch(b.batch)
+	if err := b.build(); err != nil {
+		return err
+	}
+	defer b.invalidateCache()
+	if err := b.session.getDriver().ExecBatch(b.request()); err != nil {
+		return err
+	}
+	return b.afterWrite()
 }
 
 func (b *BatchImpl) ApplyCAS() (bool, error) {
+	if err := b.build(); err != nil {
+		return false, err
+	}
+	defer b.invalidateCache()
 	mapping := make(map[string]interface{})
-	applied, iter, err := b.session.MapExecuteBatchCAS(b.batch, mapping)
-	if iter != nil {
-		iter.Close()
+	applied, err := b.session.getDriver().ExecBatchCAS(b.request(), mapping)
+	if err == nil && applied {
+		err = b.afterWrite()
 	}
 	return applied, err
 }
+
+// invalidateCache removes from the session cache the rows written by the
+// statements of the batch. The statements not built with ecql invalidate all
+// the cached rows of their tables.
+func (b *BatchImpl) invalidateCache() {
+	if b.session.cache == nil {
+		return
+	}
+	for _, stmt := range b.stmts {
+		stmt.invalidateCache()
+	}
+	for _, e := range b.entries {
+		if e.Table == "" {
+			if cmd, table := parseCommand(e.Statement); cmd != SelectCmd && table != "" {
+				b.session.versions.invalidate(table)
+			}
+		}
+	}
+}
+
+// afterWrite calls the callbacks of the structs written after the batch is
+// applied.
+func (b *BatchImpl) afterWrite() error {
+	for _, stmt := range b.written {
+		if err := stmt.afterWrite(contextOf(b.context())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// request returns the BatchRequest with the statements in the batch and the
+// write consistency of the session. The payload of the batch contains the
+// payloads of its statements.
+func (b *BatchImpl) request() *BatchRequest {
+	ctx := b.context()
+	payload := b.session.payload
+	for i := range b.entries {
+		payload = mergePayload(payload, b.entries[i].Payload)
+		if b.entries[i].Context == nil {
+			b.entries[i].Context = ctx
+		}
+	}
+	return &BatchRequest{
+		Context:           ctx,
+		Type:              b.typ,
+		Entries:           b.entries,
+		Consistency:       b.session.consistencyOf(InsertCmd),
+		SerialConsistency: b.session.serialConsistency(),
+		Timeout:           b.session.timeout,
+		Payload:           payload,
+	}
+}
@@ -0,0 +1,163 @@
+package benchmarks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/maraino/ecql"
+)
+
+type Meta struct {
+	Created time.Time `cql:"created"`
+	Owner   string    `cql:"owner"`
+}
+
+type event struct {
+	ID     gocql.UUID        `cql:"id" cqltable:"bench_events" cqlkey:"id"`
+	Kind   string            `cql:"kind"`
+	Count  int               `cql:"count"`
+	Score  float64           `cql:"score"`
+	Tags   []string          `cql:"tags"`
+	Labels map[string]string `cql:"labels"`
+	Meta   `cql:"-"`
+}
+
+func newEvent() *event {
+	return &event{
+		ID:     gocql.TimeUUID(),
+		Kind:   "click",
+		Count:  42,
+		Score:  0.5,
+		Tags:   []string{"a", "b"},
+		Labels: map[string]string{"k": "v"},
+		Meta:   Meta{Created: time.Now(), Owner: "bench"},
+	}
+}
+
+// nopDriver is a driver that does not execute the requests.
+type nopDriver struct{}
+
+func (nopDriver) Iter(req *ecql.Request) ecql.Rows     { return nopRows{} }
+func (nopDriver) ExecBatch(b *ecql.BatchRequest) error { return nil }
+func (nopDriver) ExecBatchCAS(*ecql.BatchRequest, map[string]interface{}) (bool, error) {
+	return true, nil
+}
+func (nopDriver) Close() {}
+
+type nopRows struct{}
+
+func (nopRows) Columns() []gocql.ColumnInfo           { return nil }
+func (nopRows) Scan(dest ...interface{}) bool         { return false }
+func (nopRows) MapScan(m map[string]interface{}) bool { return false }
+func (nopRows) NumRows() int                          { return 0 }
+func (nopRows) WillSwitchPage() bool                  { return false }
+func (nopRows) PageState() []byte                     { return nil }
+func (nopRows) Info() ecql.QueryInfo                  { return ecql.QueryInfo{} }
+func (nopRows) Close() error                          { return nil }
+
+// benchmarkRegistry runs fn with a registry where the event type is
+// registered.
+func benchmarkRegistry(b *testing.B, fn func(r *ecql.Registry, v *event)) {
+	r := ecql.NewRegistry()
+	v := newEvent()
+	r.Register(v)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fn(r, v)
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	benchmarkRegistry(b, func(r *ecql.Registry, v *event) { r.Map(v) })
+}
+
+func BenchmarkBind(b *testing.B) {
+	benchmarkRegistry(b, func(r *ecql.Registry, v *event) { r.Bind(v) })
+}
+
+func BenchmarkBindTable(b *testing.B) {
+	benchmarkRegistry(b, func(r *ecql.Registry, v *event) { r.BindTable(v) })
+}
+
+func BenchmarkRegister(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ecql.NewRegistry().Register(event{})
+	}
+}
+
+func benchmarkStatement(b *testing.B, stmt func(sess ecql.Session, v *event) ecql.Statement) {
+	sess, v := nopSession(), newEvent()
+	benchmarkSession(b, func() error {
+		stmt(sess, v).BuildQuery()
+		return nil
+	})
+}
+
+func BenchmarkSelectQuery(b *testing.B) {
+	benchmarkStatement(b, func(sess ecql.Session, v *event) ecql.Statement {
+		return sess.Select(v).Where(ecql.Eq("id", v.ID)).Limit(10)
+	})
+}
+
+func BenchmarkInsertQuery(b *testing.B) {
+	benchmarkStatement(b, func(sess ecql.Session, v *event) ecql.Statement {
+		return sess.Insert(v).TTL(60)
+	})
+}
+
+func BenchmarkUpdateQuery(b *testing.B) {
+	benchmarkStatement(b, func(sess ecql.Session, v *event) ecql.Statement {
+		return sess.Update(v).Columns("kind", "count")
+	})
+}
+
+// benchmarkSession runs fn after resetting the timer, it fails if fn returns
+// an error.
+func benchmarkSession(b *testing.B, fn func() error) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := fn(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// nopSession returns a session that does not execute the requests.
+func nopSession() ecql.Session {
+	return ecql.NewWithDriver(nopDriver{}, ecql.WithRegistry(ecql.NewRegistry()))
+}
+
+// applyBatch inserts the events in an unlogged batch, newID sets a new id
+// on each event if it is true.
+func applyBatch(sess ecql.Session, events []*event, newID bool) error {
+	batch := sess.UnloggedBatch()
+	for _, v := range events {
+		if newID {
+			v.ID = gocql.TimeUUID()
+		}
+		batch.Add(sess.Insert(v))
+	}
+	return batch.Apply()
+}
+
+func newEvents(n int) []*event {
+	events := make([]*event, n)
+	for i := range events {
+		events[i] = newEvent()
+	}
+	return events
+}
+
+func BenchmarkSet(b *testing.B) {
+	sess, v := nopSession(), newEvent()
+	benchmarkSession(b, func() error { return sess.Set(v) })
+}
+
+func BenchmarkBatch(b *testing.B) {
+	sess, events := nopSession(), newEvents(10)
+	benchmarkSession(b, func() error { return applyBatch(sess, events, false) })
+}
@@ -0,0 +1,157 @@
+// Command compare compares two outputs of go test -bench and fails if a
+// benchmark of the new output is slower than the threshold or allocates
+// more than the baseline:
+//
+//	go test -run XXX -bench . -benchmem -count 5 ./benchmarks > new.txt
+//	go run ./benchmarks/compare -threshold 0.2 benchmarks/baseline.txt new.txt
+//
+// The median of the runs of each benchmark is compared. The benchmarks that
+// are not in both files are ignored.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const usage = `Usage: compare [flags] baseline.txt new.txt
+
+Flags:
+`
+
+// result contains the runs of a benchmark.
+type result struct {
+	ns     []float64
+	allocs []float64
+}
+
+func main() {
+	threshold := flag.Float64("threshold", 0.2, "maximum ns/op increase as a fraction of the baseline")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	base, err := parseFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	current, err := parseFile(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if compare(os.Stdout, base, current, *threshold) {
+		os.Exit(1)
+	}
+}
+
+func parseFile(name string) (map[string]*result, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+// parse reads the benchmark lines of a go test -bench output:
+//
+//	BenchmarkBind-8   2000000   584 ns/op   208 B/op   8 allocs/op
+func parse(r io.Reader) (map[string]*result, error) {
+	results := make(map[string]*result)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		res, ok := results[name]
+		if !ok {
+			res = new(result)
+			results[name] = res
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				res.ns = append(res.ns, v)
+			case "allocs/op":
+				res.allocs = append(res.allocs, v)
+			}
+		}
+	}
+	return results, scanner.Err()
+}
+
+// compare writes the comparison of the benchmarks and returns true if there
+// is a regression.
+func compare(w io.Writer, base, current map[string]*result, threshold float64) bool {
+	names := make([]string, 0, len(current))
+	for name := range current {
+		if _, ok := base[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	regression := false
+	for _, name := range names {
+		b, c := base[name], current[name]
+		oldNs, newNs := median(b.ns), median(c.ns)
+		oldAllocs, newAllocs := median(b.allocs), median(c.allocs)
+
+		status := "ok"
+		delta := 0.0
+		if oldNs > 0 {
+			delta = (newNs - oldNs) / oldNs
+		}
+		switch {
+		case delta > threshold:
+			status = "SLOWER"
+			regression = true
+		case len(b.allocs) > 0 && len(c.allocs) > 0 && newAllocs > oldAllocs:
+			status = "MORE ALLOCS"
+			regression = true
+		}
+		fmt.Fprintf(w, "%-40s %12.1f %12.1f ns/op %+7.1f%% %8.0f %8.0f allocs/op  %s\n",
+			name, oldNs, newNs, delta*100, oldAllocs, newAllocs, status)
+	}
+	return regression
+}
+
+// median returns the median of the values, or 0 if there are none.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
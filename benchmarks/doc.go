@@ -0,0 +1,12 @@
+// Package benchmarks contains the benchmarks of ecql. They cover the
+// reflection layer, Map and Bind, the generation of the statements, and the
+// execution of the statements through a session, with a driver that does not
+// send them or, with the integration build tag, against a Cassandra
+// instance:
+//
+//	go test -run XXX -bench . -benchmem ./benchmarks
+//	go test -tags integration -run XXX -bench . -benchmem ./benchmarks
+//
+// The Makefile targets bench-baseline and bench-compare record the results
+// and compare them with the compare command to catch regressions.
+package benchmarks
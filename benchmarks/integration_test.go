@@ -0,0 +1,73 @@
+// +build integration
+
+package benchmarks
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/maraino/ecql"
+)
+
+var (
+	liveOnce    sync.Once
+	liveSession ecql.Session
+	liveErr     error
+)
+
+// live returns a session connected to the Cassandra instance in
+// ECQL_BENCH_HOST, or 127.0.0.1, with the bench_ecql keyspace and the tables
+// of the benchmarks.
+func live(b *testing.B) ecql.Session {
+	liveOnce.Do(func() {
+		host := os.Getenv("ECQL_BENCH_HOST")
+		if host == "" {
+			host = "127.0.0.1"
+		}
+		cluster := gocql.NewCluster(host)
+		sess, err := cluster.CreateSession()
+		if err != nil {
+			liveErr = err
+			return
+		}
+		liveErr = sess.Query("CREATE KEYSPACE IF NOT EXISTS bench_ecql WITH REPLICATION = { 'class' : 'SimpleStrategy', 'replication_factor' : 1 }").Exec()
+		sess.Close()
+		if liveErr != nil {
+			return
+		}
+
+		cluster.Keyspace = "bench_ecql"
+		liveSession, liveErr = ecql.NewSession(*cluster, ecql.WithRegistry(ecql.NewRegistry()))
+		if liveErr == nil {
+			liveErr = liveSession.AutoMigrate(event{})
+		}
+	})
+	if liveErr != nil {
+		b.Skipf("cassandra is not available: %s", liveErr)
+	}
+	return liveSession
+}
+
+func BenchmarkInsertLive(b *testing.B) {
+	sess, v := live(b), newEvent()
+	benchmarkSession(b, func() error {
+		v.ID = gocql.TimeUUID()
+		return sess.Set(v)
+	})
+}
+
+func BenchmarkGetLive(b *testing.B) {
+	sess, v := live(b), newEvent()
+	if err := sess.Set(v); err != nil {
+		b.Fatal(err)
+	}
+	var e event
+	benchmarkSession(b, func() error { return sess.Get(&e, v.ID) })
+}
+
+func BenchmarkBatchLive(b *testing.B) {
+	sess, events := live(b), newEvents(10)
+	benchmarkSession(b, func() error { return applyBatch(sess, events, true) })
+}
@@ -0,0 +1,260 @@
+package ecql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ErrNotBucketed is returned by SelectBuckets if the type does not have a
+// bucket column.
+var ErrNotBucketed = errors.New("ecql: type does not have a bucket column")
+
+// BucketSize is the time interval of the partitions of a time-series table.
+type BucketSize string
+
+const (
+	HourBucket  BucketSize = "hour"
+	DayBucket   BucketSize = "day"
+	MonthBucket BucketSize = "month"
+)
+
+// Truncate returns the start of the bucket of t, in UTC.
+func (b BucketSize) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch b {
+	case HourBucket:
+		return t.Truncate(time.Hour)
+	case MonthBucket:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// next returns the start of the bucket after the one starting at t.
+func (b BucketSize) next(t time.Time) time.Time {
+	switch b {
+	case HourBucket:
+		return t.Add(time.Hour)
+	case MonthBucket:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// layout returns the format of the buckets in string columns.
+func (b BucketSize) layout() string {
+	switch b {
+	case HourBucket:
+		return "2006-01-02T15"
+	case MonthBucket:
+		return "2006-01"
+	default:
+		return "2006-01-02"
+	}
+}
+
+// Buckets returns the start of the buckets with times in the half-open
+// interval [from, to).
+func (b BucketSize) Buckets(from, to time.Time) []time.Time {
+	var buckets []time.Time
+	if !from.Before(to) {
+		return nil
+	}
+	for t := b.Truncate(from); t.Before(to); t = b.next(t) {
+		buckets = append(buckets, t)
+	}
+	return buckets
+}
+
+// TimeBucket is the bucket column of a time-series table, defined with the
+// bucket option `cql:"day,bucket=day:created"`, where day is the size of
+// the buckets and created the time column used to compute it. The time
+// column can be a time.Time or a timeuuid.
+//
+// The empty bucket columns are set on writes, and SelectBuckets reads the
+// rows of a time range from all its buckets:
+//
+//	type Reading struct {
+//		Sensor string     `cql:"sensor" cqltable:"readings" cqlkey:"(sensor,day),id"`
+//		Day    time.Time  `cql:"day,bucket=day:id,type=date"`
+//		ID     gocql.UUID `cql:"id,type=timeuuid"`
+//		Value  float64    `cql:"value"`
+//	}
+//
+// Bucket columns can be a time.Time with the start of the bucket, a string
+// like "2024-06-01T10", "2024-06-01" or "2024-06", or an integer with the
+// Unix time of the start of the bucket.
+type TimeBucket struct {
+	Column string     `json:"column"`
+	Source string     `json:"source"`
+	Size   BucketSize `json:"size"`
+}
+
+// parseBucket parses the bucket option of the column.
+func parseBucket(column, option string) *TimeBucket {
+	parts := strings.SplitN(option, ":", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	switch size := BucketSize(parts[0]); size {
+	case HourBucket, DayBucket, MonthBucket:
+		return &TimeBucket{Column: column, Source: parts[1], Size: size}
+	default:
+		return nil
+	}
+}
+
+// bucketValue returns the value of the bucket starting at t for a field of
+// type typ.
+func bucketValue(size BucketSize, t time.Time, typ reflect.Type) (reflect.Value, bool) {
+	switch {
+	case typ == timeType:
+		return reflect.ValueOf(t), true
+	case typ.Kind() == reflect.String:
+		return reflect.ValueOf(t.Format(size.layout())).Convert(typ), true
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64:
+		return reflect.ValueOf(t.Unix()).Convert(typ), true
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// timeOf returns the time of a time or timeuuid field.
+func timeOf(field reflect.Value) (time.Time, bool) {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return time.Time{}, false
+		}
+		field = field.Elem()
+	}
+	switch v := field.Interface().(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case gocql.UUID:
+		return v.Time(), v.Version() == 1
+	default:
+		return time.Time{}, false
+	}
+}
+
+// bucketColumns returns the bucket column of the table and its time column.
+func (t *Table) bucketColumns() (col, src Column, ok bool) {
+	if t.Bucket == nil {
+		return
+	}
+	for _, c := range t.Columns {
+		switch c.Name {
+		case t.Bucket.Column:
+			col = c
+		case t.Bucket.Source:
+			src = c
+		}
+	}
+	ok = col.Position != nil && src.Position != nil
+	return
+}
+
+// setBucket sets the empty bucket column of the struct i using its time
+// column. It returns a pointer to a copy of i if it is not a pointer.
+func (s *SessionImpl) setBucket(i interface{}) interface{} {
+	table := s.getRegistry().GetTable(i)
+	col, src, ok := table.bucketColumns()
+	if !ok {
+		return i
+	}
+
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		i, v = p.Interface(), p
+	}
+	field, _ := table.field(col.Name, v.Elem())
+	if !field.CanSet() || !field.IsZero() {
+		return i
+	}
+	srcField, _ := table.field(src.Name, v.Elem())
+	t, ok := timeOf(srcField)
+	if !ok {
+		return i
+	}
+	size := table.Bucket.Size
+	if value, ok := bucketValue(size, size.Truncate(t), field.Type()); ok {
+		field.Set(value)
+	}
+	return i
+}
+
+// SelectBuckets executes a SELECT statement with the given conditions on
+// all the buckets of the type of the elements of dest with rows between from
+// and to, and appends the rows to dest. The conditions on the bucket column
+// and the time range, from inclusive and to exclusive, are added to the
+// statements. The buckets are read in parallel and the rows are appended in
+// the order of the buckets:
+//
+//	var readings []Reading
+//	err := sess.SelectBuckets(&readings, from, to, Eq("sensor", id))
+func (s *SessionImpl) SelectBuckets(dest interface{}, from, to time.Time, cond ...Condition) error {
+	slice, elemType, isPtr, err := structSlice(dest)
+	if err != nil {
+		return err
+	}
+
+	table := s.getRegistry().GetTable(reflect.New(elemType).Interface())
+	col, src, ok := table.bucketColumns()
+	if !ok {
+		return ErrNotBucketed
+	}
+	size := table.Bucket.Size
+	colType := elemType.FieldByIndex(col.Position).Type
+	if _, ok := bucketValue(size, time.Time{}, colType); !ok {
+		return ErrNotBucketed
+	}
+	var timeRange Condition
+	srcName := table.quote(src.Name)
+	if elemType.FieldByIndex(src.Position).Type == uuidType {
+		timeRange = BetweenTime(srcName, from, to)
+	} else {
+		timeRange = And(Ge(srcName, from), Lt(srcName, to))
+	}
+
+	buckets := size.Buckets(from, to)
+	results := make([][]reflect.Value, len(buckets))
+	futures := make([]*Future, len(buckets))
+	for i := range buckets {
+		i := i
+		value, _ := bucketValue(size, buckets[i], colType)
+		where := append([]Condition{Eq(table.quote(col.Name), value.Interface()), timeRange}, cond...)
+		futures[i] = s.async(func(sess *SessionImpl) error {
+			v := reflect.New(elemType)
+			iter := NewStatement(sess).Do(SelectCmd).Map(v.Interface()).Where(where...).Iter()
+			for iter.TypeScan(v.Interface()) {
+				results[i] = append(results[i], v)
+				if s.tooManyRows(len(results[i])) {
+					break
+				}
+				v = reflect.New(elemType)
+			}
+			return iter.Close()
+		})
+	}
+	if err := WaitAll(futures...); err != nil {
+		return err
+	}
+
+	rows := s.appender(slice, isPtr)
+	for _, values := range results {
+		for _, v := range values {
+			if !rows.append(v) {
+				return rows.err()
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package ecql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type bucketStruct struct {
+	Sensor string     `cql:"sensor" cqltable:"readings" cqlkey:"(sensor,day),id"`
+	Day    string     `cql:"day,bucket=day:id"`
+	ID     gocql.UUID `cql:"id,auto,type=timeuuid"`
+	Value  int        `cql:"value"`
+}
+
+type hourBucketStruct struct {
+	Sensor  string    `cql:"sensor" cqltable:"hourly" cqlkey:"(sensor,hour),created"`
+	Hour    time.Time `cql:"hour,bucket=hour:created"`
+	Created time.Time `cql:"created"`
+}
+
+func TestBucketSize(t *testing.T) {
+	tm := time.Date(2024, 6, 15, 10, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	assert.Equal(t, time.Date(2024, 6, 15, 8, 0, 0, 0, time.UTC), HourBucket.Truncate(tm))
+	assert.Equal(t, time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC), DayBucket.Truncate(tm))
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), MonthBucket.Truncate(tm))
+
+	from := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}, MonthBucket.Buckets(from, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)))
+	assert.Len(t, DayBucket.Buckets(from, from.Add(12*time.Hour)), 1)
+	assert.Len(t, DayBucket.Buckets(from, from.Add(12*time.Hour+time.Second)), 2)
+	assert.Nil(t, DayBucket.Buckets(from, from))
+
+	assert.Nil(t, parseBucket("day", "week:id"))
+	assert.Nil(t, parseBucket("day", "day"))
+	assert.Equal(t, &TimeBucket{Column: "day", Source: "id", Size: DayBucket}, parseBucket("day", "day:id"))
+}
+
+func TestBucketWrites(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	sess, d := newTestSession(WithClock(ClockFunc(func() time.Time { return now })))
+	table := sess.getRegistry().GetTable(bucketStruct{})
+	assert.Equal(t, &TimeBucket{Column: "day", Source: "id", Size: DayBucket}, table.Bucket)
+
+	// The bucket is computed from the generated id
+	v := bucketStruct{Sensor: "s1", Value: 1}
+	assert.NoError(t, sess.Set(&v))
+	assert.Equal(t, "2024-06-01", v.Day)
+	assert.Equal(t, now, v.ID.Time())
+
+	// Existing buckets are kept
+	v = bucketStruct{Sensor: "s1", Day: "2024-01-01", ID: v.ID}
+	assert.NoError(t, sess.Insert(&v).Exec())
+	assert.Equal(t, "2024-01-01", v.Day)
+
+	// Deletes and updates use the bucket
+	created := time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC)
+	assert.NoError(t, sess.Del(hourBucketStruct{Sensor: "s1", Created: created}))
+	assert.Equal(t, "DELETE FROM hourly WHERE sensor = ? AND hour = ? AND created = ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"s1", time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), created}, derefValues(d.last()))
+
+	h := hourBucketStruct{Sensor: "s1", Created: created}
+	assert.NoError(t, sess.Update(&h).Exec())
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), h.Hour)
+}
+
+type BucketEmbedded struct {
+	Created time.Time `cql:"created"`
+}
+
+type embeddedBucketStruct struct {
+	Sensor string    `cql:"sensor" cqltable:"embedded_hourly" cqlkey:"(sensor,hour),created"`
+	Hour   time.Time `cql:"hour,bucket=hour:created"`
+	*BucketEmbedded
+}
+
+func TestBucketEmbedded(t *testing.T) {
+	sess, _ := newTestSession()
+	sess.getRegistry().Register(&embeddedBucketStruct{BucketEmbedded: &BucketEmbedded{}})
+
+	created := time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC)
+	v := sess.setBucket(embeddedBucketStruct{Sensor: "s1", BucketEmbedded: &BucketEmbedded{Created: created}})
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), v.(*embeddedBucketStruct).Hour)
+
+	// Nil embedded pointers
+	assert.NotPanics(t, func() {
+		sess.setBucket(embeddedBucketStruct{Sensor: "s1"})
+	})
+}
+
+func TestSelectBuckets(t *testing.T) {
+	sess, d := newTestSession()
+	from := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+
+	d.result([]string{"sensor", "day", "id", "value"}, []interface{}{"s1", "2024-06-01", gocql.UUIDFromTime(from), 1})
+	var readings []bucketStruct
+	assert.NoError(t, sess.SelectBuckets(&readings, from, to, Eq("sensor", "s1")))
+	assert.Len(t, readings, 3)
+	assert.Len(t, d.requests, 3)
+	days := make(map[interface{}]bool)
+	for _, req := range d.requests {
+		assert.Equal(t, "SELECT sensor,day,id,value FROM readings WHERE day = ? AND id >= ? AND id < ? AND sensor = ?", req.Statement)
+		assert.Equal(t, []interface{}{gocql.MinTimeUUID(from), gocql.MinTimeUUID(to), "s1"}, req.Values[1:])
+		days[req.Values[0]] = true
+	}
+	assert.Equal(t, map[interface{}]bool{"2024-06-01": true, "2024-06-02": true, "2024-06-03": true}, days)
+
+	// Time columns
+	d.requests = nil
+	var hourly []*hourBucketStruct
+	d.result([]string{"sensor", "hour", "created"}, []interface{}{"s1", from, from})
+	assert.NoError(t, sess.SelectBuckets(&hourly, from, from.Add(90*time.Minute)))
+	assert.Len(t, hourly, 2)
+	assert.Equal(t, "SELECT sensor,hour,created FROM hourly WHERE hour = ? AND created >= ? AND created < ?", d.last().Statement)
+
+	assert.Equal(t, ErrNotBucketed, sess.SelectBuckets(&[]testStruct{}, from, to))
+}
@@ -0,0 +1,278 @@
+package ecql
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrBulkWriterClosed is returned by the writes on a closed BulkWriter.
+var ErrBulkWriterClosed = errors.New("ecql: bulk writer is closed")
+
+const (
+	// DefaultBulkBatchSize is the default maximum number of rows in a batch
+	// applied by a BulkWriter.
+	DefaultBulkBatchSize = 50
+
+	// DefaultBulkConcurrency is the default number of batches applied at the
+	// same time by a BulkWriter.
+	DefaultBulkConcurrency = 8
+
+	// DefaultBulkMaxInFlightBytes is the default maximum size of the batches
+	// being applied by a BulkWriter.
+	DefaultBulkMaxInFlightBytes = 32 << 20
+)
+
+// BulkWriterConfig contains the configuration of a BulkWriter.
+type BulkWriterConfig struct {
+	// BatchSize is the maximum number of rows on each batch.
+	BatchSize int
+	// Concurrency is the number of batches applied at the same time.
+	Concurrency int
+	// MaxInFlightBytes is the maximum estimated size of the batches being
+	// applied, Write blocks while it is reached.
+	MaxInFlightBytes int
+	// OnError is called with the error and the rows of each failed batch.
+	OnError func(err error, rows []interface{})
+}
+
+// BulkStats contains the statistics of a BulkWriter.
+type BulkStats struct {
+	// Rows is the number of rows written.
+	Rows int
+	// Batches is the number of batches applied.
+	Batches int
+	// Errors is the number of failed batches.
+	Errors int
+	// FailedRows is the number of rows in the failed batches.
+	FailedRows int
+	// Bytes is the estimated size of the rows written.
+	Bytes int
+	// Elapsed is the time since the writer was created, or until it was
+	// closed.
+	Elapsed time.Duration
+}
+
+// RowsPerSecond returns the throughput of the writer.
+func (s BulkStats) RowsPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Rows) / s.Elapsed.Seconds()
+}
+
+// BulkWriter writes a stream of structs using UNLOGGED batches grouped by
+// partition key, it is meant for migrations and import jobs:
+//
+//	w := ecql.NewBulkWriter(sess, ecql.BulkWriterConfig{})
+//	for _, e := range events {
+//		if err := w.Write(e); err != nil {
+//			return err
+//		}
+//	}
+//	if err := w.Close(); err != nil {
+//		return err
+//	}
+//	log.Printf("%.0f rows/s", w.Stats().RowsPerSecond())
+//
+// The batches are applied in the background, and Write blocks while the
+// estimated size of the batches in flight exceeds MaxInFlightBytes. The rows
+// buffered in partially filled batches are applied by Flush and Close.
+type BulkWriter struct {
+	session  Session
+	config   BulkWriterConfig
+	mu       sync.Mutex
+	cond     *sync.Cond
+	groups   map[string]*bulkBatch
+	queue    chan *bulkBatch
+	inFlight int
+	pending  sync.WaitGroup
+	sending  sync.WaitGroup
+	workers  sync.WaitGroup
+	start    time.Time
+	closing  bool
+	closed   bool
+	stats    BulkStats
+	err      error
+}
+
+type bulkBatch struct {
+	rows    []interface{}
+	size    int
+	tracked bool
+}
+
+// NewBulkWriter creates a new BulkWriter and starts its workers.
+func NewBulkWriter(s Session, config BulkWriterConfig) *BulkWriter {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBulkBatchSize
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultBulkConcurrency
+	}
+	if config.MaxInFlightBytes <= 0 {
+		config.MaxInFlightBytes = DefaultBulkMaxInFlightBytes
+	}
+
+	w := &BulkWriter{
+		session: s,
+		config:  config,
+		groups:  make(map[string]*bulkBatch),
+		queue:   make(chan *bulkBatch),
+		start:   clockOf(s).Now(),
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	w.workers.Add(config.Concurrency)
+	for n := 0; n < config.Concurrency; n++ {
+		go w.worker()
+	}
+	return w
+}
+
+// Write buffers the row i, its batch is applied when it reaches the batch
+// size. It returns the first error of the batches applied so far, so a job
+// can stop early.
+func (w *BulkWriter) Write(i interface{}) error {
+	_, mapping, table := registryOf(w.session).BindTable(i)
+	key := table.partitionKey(mapping)
+	size := sizeOf(reflect.ValueOf(i))
+
+	w.mu.Lock()
+	if w.closing {
+		defer w.mu.Unlock()
+		return ErrBulkWriterClosed
+	}
+	if w.err != nil {
+		defer w.mu.Unlock()
+		return w.err
+	}
+	b, ok := w.groups[key]
+	if !ok {
+		b = &bulkBatch{}
+		w.groups[key] = b
+	}
+	b.rows = append(b.rows, i)
+	b.size += size
+	if len(b.rows) < w.config.BatchSize {
+		w.mu.Unlock()
+		return nil
+	}
+	delete(w.groups, key)
+	w.sending.Add(1)
+	w.mu.Unlock()
+
+	w.send(b)
+	w.sending.Done()
+	return nil
+}
+
+// Flush applies all the buffered rows and waits for the batches in flight.
+// It returns the first error of the batches applied so far.
+func (w *BulkWriter) Flush() error {
+	w.mu.Lock()
+	groups := w.groups
+	w.groups = make(map[string]*bulkBatch)
+	w.mu.Unlock()
+
+	for _, b := range groups {
+		w.send(b)
+	}
+	w.pending.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close flushes the buffered rows and stops the workers. The writes after
+// closing the writer return ErrBulkWriterClosed, and closing it again returns
+// the first error of the batches applied.
+func (w *BulkWriter) Close() error {
+	w.mu.Lock()
+	if w.closing {
+		defer w.mu.Unlock()
+		return w.err
+	}
+	w.closing = true
+	w.mu.Unlock()
+
+	// Wait for the writes queueing a batch
+	w.sending.Wait()
+	err := w.Flush()
+	close(w.queue)
+	w.workers.Wait()
+
+	w.mu.Lock()
+	w.closed = true
+	w.stats.Elapsed = clockOf(w.session).Now().Sub(w.start)
+	w.mu.Unlock()
+	return err
+}
+
+// Stats returns the statistics of the writer.
+func (w *BulkWriter) Stats() BulkStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := w.stats
+	if !w.closed {
+		stats.Elapsed = clockOf(w.session).Now().Sub(w.start)
+	}
+	return stats
+}
+
+// send waits until there is room for the batch in flight and queues it. A
+// batch larger than the limit is sent when there is nothing else in flight.
+func (w *BulkWriter) send(b *bulkBatch) {
+	w.mu.Lock()
+	for w.inFlight > 0 && w.inFlight+b.size > w.config.MaxInFlightBytes {
+		w.cond.Wait()
+	}
+	w.inFlight += b.size
+	w.mu.Unlock()
+
+	// Shutdown waits for the queued batches
+	b.tracked = drainerOf(w.session).acquire()
+	w.pending.Add(1)
+	w.queue <- b
+}
+
+func (w *BulkWriter) worker() {
+	defer w.workers.Done()
+	for b := range w.queue {
+		err := w.apply(b)
+
+		w.mu.Lock()
+		w.inFlight -= b.size
+		w.stats.Batches++
+		if err != nil {
+			w.stats.Errors++
+			w.stats.FailedRows += len(b.rows)
+			if w.err == nil {
+				w.err = err
+			}
+		} else {
+			w.stats.Rows += len(b.rows)
+			w.stats.Bytes += b.size
+		}
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		if err != nil && w.config.OnError != nil {
+			w.config.OnError(err, b.rows)
+		}
+		if b.tracked {
+			drainerOf(w.session).release()
+		}
+		w.pending.Done()
+	}
+}
+
+func (w *BulkWriter) apply(b *bulkBatch) error {
+	batch := w.session.UnloggedBatch()
+	for _, row := range b.rows {
+		batch.Add(w.session.Insert(row))
+	}
+	return batch.Apply()
+}
@@ -0,0 +1,106 @@
+package ecql
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+// concurrencyDriver records the maximum number of batches executed at the
+// same time.
+type concurrencyDriver struct {
+	Driver
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (d *concurrencyDriver) ExecBatch(b *BatchRequest) error {
+	d.mu.Lock()
+	d.current++
+	if d.current > d.max {
+		d.max = d.current
+	}
+	d.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	d.mu.Lock()
+	d.current--
+	d.mu.Unlock()
+	return d.Driver.ExecBatch(b)
+}
+
+func TestBulkWriter(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	w := NewBulkWriter(sess, BulkWriterConfig{BatchSize: 2})
+	for _, key := range []string{"a", "b", "a", "b", "a"} {
+		assert.NoError(t, w.Write(testStruct{F1: key}))
+	}
+	assert.NoError(t, w.Close())
+
+	// Closed writers
+	assert.Equal(t, ErrBulkWriterClosed, w.Write(testStruct{F1: "c"}))
+	assert.NoError(t, w.Close())
+
+	// Grouped by partition
+	assert.Len(t, d.batches, 3)
+	for _, b := range d.batches {
+		assert.Equal(t, gocql.UnloggedBatch, b.Type)
+		for _, e := range b.Entries {
+			assert.Equal(t, b.Entries[0].PartitionKey, e.PartitionKey)
+		}
+	}
+
+	stats := w.Stats()
+	assert.Equal(t, 5, stats.Rows)
+	assert.Equal(t, 3, stats.Batches)
+	assert.Equal(t, 0, stats.Errors)
+	assert.True(t, stats.Bytes > 0)
+	assert.True(t, stats.RowsPerSecond() > 0)
+
+	// Errors
+	var failed []interface{}
+	d.err = errors.New("an error")
+	w = NewBulkWriter(sess, BulkWriterConfig{
+		BatchSize: 2,
+		OnError:   func(err error, rows []interface{}) { failed = append(failed, rows...) },
+	})
+	assert.NoError(t, w.Write(testStruct{F1: "a"}))
+	assert.True(t, errors.Is(w.Flush(), d.err))
+	assert.True(t, errors.Is(w.Write(testStruct{F1: "a"}), d.err))
+	assert.True(t, errors.Is(w.Close(), d.err))
+	assert.True(t, errors.Is(w.Close(), d.err))
+	assert.Equal(t, []interface{}{testStruct{F1: "a"}}, failed)
+	stats = w.Stats()
+	assert.Equal(t, 0, stats.Rows)
+	assert.Equal(t, 1, stats.Errors)
+	assert.Equal(t, 1, stats.FailedRows)
+}
+
+func TestBulkWriterBackpressure(t *testing.T) {
+	DeleteRegistry()
+	cd := &concurrencyDriver{Driver: &testDriver{}}
+	sess := NewWithDriver(cd)
+
+	// Only one batch fits in flight
+	w := NewBulkWriter(sess, BulkWriterConfig{BatchSize: 1, Concurrency: 4, MaxInFlightBytes: 1})
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, w.Write(testStruct{F1: "foo"}))
+	}
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 1, cd.max)
+	assert.Equal(t, 10, w.Stats().Batches)
+
+	cd.max = 0
+	w = NewBulkWriter(sess, BulkWriterConfig{BatchSize: 1, Concurrency: 4})
+	for i := 0; i < 40; i++ {
+		assert.NoError(t, w.Write(testStruct{F1: "foo"}))
+	}
+	assert.NoError(t, w.Close())
+	assert.True(t, cd.max > 1)
+}
@@ -0,0 +1,292 @@
+package ecql
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+// Cache is the interface used by a Session to cache rows by primary key.
+// Session.Get is served from the cache if possible, and the writes done with
+// a Session update or invalidate the cached rows. Raw writes, batches and
+// writes not built from structs invalidate all the cached rows of their
+// tables. The cached rows go through
+// the policy and middlewares of the session like the rows read from the
+// database, so they are masked, audited and counted in the statistics.
+//
+// The values stored are copies of the structs, the values of the pointer
+// fields are copied too, but maps and slices are shared with the struct used
+// in the database operation.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{})
+	Delete(key string)
+}
+
+// WithCache enables a read-through/write-through cache in the session.
+func WithCache(c Cache) Option {
+	return func(s *SessionImpl) {
+		s.cache = c
+		s.versions = &cacheVersions{m: make(map[string]uint64)}
+	}
+}
+
+// LRUCache is an in-process Cache that holds a fixed number of entries and
+// evicts the least recently used ones.
+type LRUCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+// NewLRUCache creates a new LRUCache with the given number of entries.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value of the key and if it is present.
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*lruEntry).value, true
+	}
+	return nil, false
+}
+
+// Set adds or replaces the value of a key.
+func (c *LRUCache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*lruEntry).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry{key, value})
+	for c.size > 0 && c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*lruEntry).key)
+	}
+}
+
+// Delete removes a key from the cache.
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// cacheKey returns the key used in the cache for a row with the given
+// primary key values.
+func cacheKey(table string, keys []interface{}) string {
+	parts := make([]string, len(keys)+1)
+	parts[0] = table
+	for i := range keys {
+		parts[i+1] = fmt.Sprintf("%v", deref(keys[i]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// cacheVersions holds the version of the tables in the cache. The version is
+// part of the cache keys, so incrementing it invalidates all the cached rows
+// of a table.
+type cacheVersions struct {
+	mu sync.Mutex
+	m  map[string]uint64
+}
+
+// key returns the cache key of the row with the given primary key values in
+// the current version of the table.
+func (v *cacheVersions) key(table string, keys []interface{}) string {
+	v.mu.Lock()
+	n := v.m[maskName(table)]
+	v.mu.Unlock()
+	if n == 0 {
+		return cacheKey(table, keys)
+	}
+	return cacheKey(fmt.Sprintf("%s@%d", table, n), keys)
+}
+
+// invalidate increments the version of a table.
+func (v *cacheVersions) invalidate(table string) {
+	v.mu.Lock()
+	v.m[maskName(table)]++
+	v.mu.Unlock()
+}
+
+// cacheKey returns the key used in the session cache for a row with the given
+// primary key values.
+func (s *SessionImpl) cacheKey(table string, keys []interface{}) string {
+	return s.versions.key(table, keys)
+}
+
+// keyValues returns the values of the primary key columns in mapping.
+func (t *Table) keyValues(mapping map[string]interface{}) []interface{} {
+	keys := make([]interface{}, len(t.KeyColumns))
+	for i, name := range t.KeyColumns {
+		keys[i] = deref(mapping[name])
+	}
+	return keys
+}
+
+// cacheGet sets in i the cached value for the given key if present.
+func (s *SessionImpl) cacheGet(key string, i interface{}) bool {
+	return cacheGet(s.cache, key, i)
+}
+
+// cacheGet sets in i the value cached in c for the given key if present.
+func cacheGet(c Cache, key string, i interface{}) bool {
+	if cached, ok := c.Get(key); ok {
+		v := reflect.ValueOf(i)
+		if v.Kind() == reflect.Ptr && v.Elem().Type() == reflect.TypeOf(cached) {
+			v.Elem().Set(reflect.ValueOf(cached))
+			copyPointers(v.Elem())
+			return true
+		}
+	}
+	return false
+}
+
+// cacheSet stores a copy of the struct i in the cache.
+func (s *SessionImpl) cacheSet(key string, i interface{}) {
+	s.cache.Set(key, copyRow(i))
+}
+
+// copyRow returns a copy of the struct i with new copies of the values of
+// its pointer fields, so masking the values of i or the copy does not modify
+// the other.
+func copyRow(i interface{}) interface{} {
+	v := reflect.New(structOf(i).Type()).Elem()
+	v.Set(structOf(i))
+	copyPointers(v)
+	return v.Interface()
+}
+
+// copyPointers replaces the pointer fields of the struct v with pointers to
+// copies of their values.
+func copyPointers(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch {
+		case !f.CanSet():
+		case f.Kind() == reflect.Ptr && !f.IsNil():
+			p := reflect.New(f.Type().Elem())
+			p.Elem().Set(f.Elem())
+			if p.Elem().Kind() == reflect.Struct {
+				copyPointers(p.Elem())
+			}
+			f.Set(p)
+		case f.Kind() == reflect.Struct:
+			copyPointers(f)
+		}
+	}
+}
+
+// cacheLookup is set on the requests of Session.Get that can be served from
+// the cache. Dest is the struct the row is scanned into.
+type cacheLookup struct {
+	table     string
+	keys      []interface{}
+	statement string
+	dest      interface{}
+}
+
+// cacheDriver serves the requests of Session.Get from the cache. It is the
+// innermost driver of the session, so the cached rows go through the
+// policy, middlewares and masking like the rows read from the database, and
+// the rows stored are the ones read before they are masked.
+type cacheDriver struct {
+	Driver
+	cache    Cache
+	versions *cacheVersions
+}
+
+func (d cacheDriver) Iter(req *Request) Rows {
+	c := req.cache
+	// Requests rewritten by a policy are not cached
+	if c == nil || req.Statement != c.statement || cacheKey(req.Table, req.Values) != cacheKey(c.table, c.keys) {
+		return d.Driver.Iter(req)
+	}
+	key := d.versions.key(c.table, c.keys)
+	if cacheGet(d.cache, key, c.dest) {
+		return &cachedRows{}
+	}
+	return &cacheRows{Rows: d.Driver.Iter(req), cache: d.cache, key: key, dest: c.dest}
+}
+
+// cachedRows is the result of a request served from the cache, the row is
+// already set in the destination.
+type cachedRows struct {
+	scanned bool
+}
+
+func (r *cachedRows) Columns() []gocql.ColumnInfo   { return nil }
+func (r *cachedRows) Scan(dest ...interface{}) bool { return false }
+func (r *cachedRows) NumRows() int                  { return 1 }
+func (r *cachedRows) WillSwitchPage() bool          { return false }
+func (r *cachedRows) PageState() []byte             { return nil }
+func (r *cachedRows) Info() QueryInfo               { return QueryInfo{Rows: 1} }
+func (r *cachedRows) Close() error                  { return nil }
+
+func (r *cachedRows) MapScan(m map[string]interface{}) bool {
+	if r.scanned {
+		return false
+	}
+	r.scanned = true
+	return true
+}
+
+// cacheRows stores in the cache the row scanned if the request succeeds.
+type cacheRows struct {
+	Rows
+	cache Cache
+	key   string
+	dest  interface{}
+	row   interface{}
+}
+
+func (r *cacheRows) MapScan(m map[string]interface{}) bool {
+	if !r.Rows.MapScan(m) {
+		return false
+	}
+	if r.row == nil {
+		r.row = copyRow(r.dest)
+	}
+	return true
+}
+
+func (r *cacheRows) Close() error {
+	err := r.Rows.Close()
+	if err == nil && r.row != nil {
+		r.cache.Set(r.key, r.row)
+		r.row = nil
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	// b is the least recently used
+	c.Set("c", 3)
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	c.Set("a", 10)
+	v, ok = c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 10, v)
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestCacheKey(t *testing.T) {
+	DeleteRegistry()
+	s1 := "foo"
+	ts := testStruct{F1: s1}
+	_, m, table := BindTable(&ts)
+	mp, _ := MapTable(&ts)
+
+	key := cacheKey(table.Name, []interface{}{"foo"})
+	assert.Equal(t, key, cacheKey(table.Name, table.keyValues(m)))
+	assert.Equal(t, key, cacheKey(table.Name, table.keyValues(mp)))
+	assert.Equal(t, key, cacheKey(table.Name, []interface{}{&s1}))
+	assert.NotEqual(t, key, cacheKey("other", []interface{}{"foo"}))
+}
+
+func TestSessionCache(t *testing.T) {
+	DeleteRegistry()
+	s := &SessionImpl{}
+	WithCache(NewLRUCache(10))(s)
+
+	ts := testStruct{F1: "foo", F2: 123}
+	s.cacheSet("key", &ts)
+	ts.F2 = 321
+
+	var res testStruct
+	assert.True(t, s.cacheGet("key", &res))
+	assert.Equal(t, testStruct{F1: "foo", F2: 123}, res)
+
+	var other MockModel
+	assert.False(t, s.cacheGet("key", &other))
+	assert.False(t, s.cacheGet("missing", &res))
+}
+
+func TestSessionCacheDriver(t *testing.T) {
+	DeleteRegistry()
+	masking := NewMasking(MaskingConfig{Rules: []MaskRule{
+		{Table: "mytable", Column: "f4", Permission: "pii", Mask: Hash},
+	}})
+	policy := PolicyFunc(func(ctx context.Context, req *Request) error {
+		if ActorOf(ctx) == "guest" {
+			return ErrAccessDenied
+		}
+		return nil
+	})
+	sess, d := newTestSession(WithCache(NewLRUCache(10)), WithPolicy(policy), WithMiddleware(masking.Middleware()))
+	pii := sess.WithContext(WithPermissions(context.Background(), "pii"))
+
+	// The rows are cached before they are masked
+	f4 := "secret"
+	d.result([]string{"f1", "f22", "f3", "f4"}, []interface{}{"a", 1, nil, &f4})
+	var ts testStruct
+	assert.NoError(t, sess.Get(&ts, "a"))
+	assert.Equal(t, Hash("secret"), *ts.F4)
+	assert.Len(t, d.requests, 1)
+
+	ts = testStruct{}
+	assert.NoError(t, pii.Get(&ts, "a"))
+	assert.Equal(t, "secret", *ts.F4)
+	ts = testStruct{}
+	assert.NoError(t, sess.Get(&ts, "a"))
+	assert.Equal(t, Hash("secret"), *ts.F4)
+	assert.Len(t, d.requests, 1)
+
+	// The policy is evaluated on hits
+	guest := sess.WithContext(WithActor(context.Background(), "guest"))
+	assert.Equal(t, ErrAccessDenied, guest.Get(&ts, "a"))
+}
+
+func TestSessionCacheInvalidation(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithCache(NewLRUCache(10)))
+	d.result([]string{"f1", "f22"}, []interface{}{"a", 1})
+
+	// get returns true if the row is read from the database
+	get := func() bool {
+		n := len(d.requests)
+		var ts testStruct
+		assert.NoError(t, sess.Get(&ts, "a"))
+		return len(d.requests) > n
+	}
+	assert.True(t, get())
+	assert.False(t, get())
+
+	// Raw writes
+	assert.NoError(t, sess.QueryRaw("UPDATE ks.MyTable SET f22 = 2 WHERE f1 = ?", "a").Exec())
+	assert.True(t, get())
+	assert.False(t, get())
+	assert.NoError(t, sess.QueryRaw("UPDATE other SET v = 2 WHERE id = ?", "a").Exec())
+	assert.False(t, get())
+
+	// Batches
+	assert.NoError(t, sess.Batch().Add(sess.Delete(&testStruct{F1: "a"})).Apply())
+	assert.True(t, get())
+	assert.NoError(t, sess.Batch().Add(sess.Delete(&testStruct{F1: "b"})).Apply())
+	assert.False(t, get())
+	assert.NoError(t, sess.Batch().Add(sess.Update(&testStruct{F1: "a"}).Set("f22", 3)).Apply())
+	assert.True(t, get())
+	assert.NoError(t, sess.Batch().Add(NewStatement(sess).Do(UpdateCmd).From("mytable").Set("f22", 4)).Apply())
+	assert.True(t, get())
+
+	// Failed batches
+	d.err = errors.New("timeout")
+	assert.Error(t, sess.Batch().Add(sess.Delete(&testStruct{F1: "a"})).Apply())
+	d.err = nil
+	assert.True(t, get())
+
+	// Truncate
+	assert.NoError(t, sess.Truncate(&testStruct{}))
+	assert.True(t, get())
+}
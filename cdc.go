@@ -0,0 +1,379 @@
+package ecql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ErrCDCNotEnabled is returned by a CDCReader if the cluster does not have
+// any CDC generation.
+var ErrCDCNotEnabled = errors.New("cdc not enabled, no cdc generations found")
+
+const (
+	// DefaultCDCPollInterval is the default time between the reads of the
+	// CDC log.
+	DefaultCDCPollInterval = 5 * time.Second
+
+	// DefaultCDCDelay is the default time a change must be in the CDC log
+	// before it is read, it gives time to the writes in flight to be
+	// replicated.
+	DefaultCDCDelay = 10 * time.Second
+
+	// cdcStreamsPerQuery is the maximum number of streams read by a query.
+	cdcStreamsPerQuery = 100
+)
+
+// ChangeOp is the operation of a change in the CDC log.
+type ChangeOp int8
+
+// The operations of the changes in the CDC log.
+const (
+	PreImageOp ChangeOp = iota
+	UpdateOp
+	InsertOp
+	RowDeleteOp
+	PartitionDeleteOp
+	RangeDeleteStartInclusiveOp
+	RangeDeleteStartExclusiveOp
+	RangeDeleteEndInclusiveOp
+	RangeDeleteEndExclusiveOp
+	PostImageOp
+)
+
+// Change is a change of a row read from the CDC log. Before, Delta and After
+// are pointers to structs of the type of the reader. Delta contains the
+// primary key and the columns written by the operation, the rest of the
+// fields have the zero value. Before and After are only set if the table has
+// the preimage and postimage options enabled.
+type Change struct {
+	Op         ChangeOp
+	Time       gocql.UUID
+	StreamID   []byte
+	BatchSeqNo int
+	Before     interface{}
+	Delta      interface{}
+	After      interface{}
+}
+
+// CDCCheckpoint stores the time up to which the changes have been handled,
+// so a reader can resume after a restart.
+type CDCCheckpoint interface {
+	// Load returns the last time saved with the given name, or the zero time
+	// if there is none.
+	Load(name string) (time.Time, error)
+	// Save saves the time with the given name.
+	Save(name string, t time.Time) error
+}
+
+// CDCConfig contains the configuration of a CDCReader.
+type CDCConfig struct {
+	// Name is the name of the checkpoint of the reader, it defaults to the
+	// name of the table.
+	Name string
+	// Checkpoint stores the progress of the reader, it defaults to a memory
+	// checkpoint.
+	Checkpoint CDCCheckpoint
+	// Start is the time of the first change read if there is no checkpoint.
+	// It defaults to the time the reader starts.
+	Start time.Time
+	// PollInterval is the time between the reads of the log.
+	PollInterval time.Duration
+	// Delay is the time a change must be in the log before it is read.
+	Delay time.Duration
+}
+
+// CDCReader reads the changes of the table of a registered type from the
+// Scylla CDC log, the table "<name>_scylla_cdc_log" created on the tables
+// with the cdc option enabled:
+//
+//	CREATE TABLE events (...) WITH cdc = {'enabled': true, 'preimage': true};
+//
+// The changes are delivered in time order to a handler, and the progress is
+// saved in a checkpoint after each read of the log:
+//
+//	r := ecql.NewCDCReader(sess, Event{}, ecql.CDCConfig{
+//		Checkpoint: ecql.NewTableCheckpoint(sess, "cdc_checkpoints"),
+//	})
+//	err := r.Run(ctx, func(c ecql.Change) error {
+//		e := c.Delta.(*Event)
+//		// ...
+//	})
+//
+// The changes are delivered at least once, a handler can receive again the
+// changes of an incomplete read after a restart. The Cassandra CDC, that
+// writes commit log segments to the cdc_raw directory of each node, is not
+// available through CQL and it is not supported.
+type CDCReader struct {
+	session Session
+	typ     reflect.Type
+	table   Table
+	config  CDCConfig
+}
+
+// NewCDCReader creates a CDCReader of the table of i.
+func NewCDCReader(s Session, i interface{}, config CDCConfig) *CDCReader {
+	table := registryOf(s).GetTable(i)
+	if config.Name == "" {
+		config.Name = table.Name
+	}
+	if config.Checkpoint == nil {
+		config.Checkpoint = NewMemoryCheckpoint()
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultCDCPollInterval
+	}
+	if config.Delay <= 0 {
+		config.Delay = DefaultCDCDelay
+	}
+	return &CDCReader{
+		session: s,
+		typ:     structOf(i).Type(),
+		table:   table,
+		config:  config,
+	}
+}
+
+// Run reads the log until the context is done or the handler returns an
+// error, and returns that error.
+func (r *CDCReader) Run(ctx context.Context, handler func(c Change) error) error {
+	from, err := r.config.Checkpoint.Load(r.config.Name)
+	if err != nil {
+		return err
+	}
+	if from.IsZero() {
+		from = r.config.Start
+	}
+	if from.IsZero() {
+		from = clockOf(r.session).Now()
+	}
+
+	t := time.NewTicker(r.config.PollInterval)
+	defer t.Stop()
+	for {
+		if to := clockOf(r.session).Now().Add(-r.config.Delay); to.After(from) {
+			if err := r.Read(from, to, handler); err != nil {
+				return err
+			}
+			if err := r.config.Checkpoint.Save(r.config.Name, to); err != nil {
+				return err
+			}
+			from = to
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Read reads the changes after from and up to to, and calls the handler with
+// them in time order.
+func (r *CDCReader) Read(from, to time.Time, handler func(c Change) error) error {
+	generations, err := r.generations()
+	if err != nil {
+		return err
+	}
+	if len(generations) == 0 {
+		return ErrCDCNotEnabled
+	}
+
+	for n, gen := range generations {
+		// The generation is in use until the next one starts
+		start, end := from, to
+		if gen.After(start) {
+			start = gen
+		}
+		if n+1 < len(generations) && generations[n+1].Before(end) {
+			end = generations[n+1]
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		streams, err := r.streams(gen)
+		if err != nil {
+			return err
+		}
+		changes, err := r.changes(streams, start, end)
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			if err := handler(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// generations returns the start times of the CDC generations in ascending
+// order.
+func (r *CDCReader) generations() ([]time.Time, error) {
+	var generations []time.Time
+	iter := r.session.QueryRaw("SELECT time FROM system_distributed.cdc_generation_timestamps WHERE key = 'timestamps'").Iter()
+	var t time.Time
+	for iter.Scan(&t) {
+		generations = append(generations, t)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	sort.Slice(generations, func(i, j int) bool {
+		return generations[i].Before(generations[j])
+	})
+	return generations, nil
+}
+
+// streams returns the streams of the generation.
+func (r *CDCReader) streams(gen time.Time) ([][]byte, error) {
+	var streams [][]byte
+	iter := r.session.QueryRaw("SELECT streams FROM system_distributed.cdc_streams_descriptions_v2 WHERE time = ?", gen).Iter()
+	var s [][]byte
+	for iter.Scan(&s) {
+		streams = append(streams, s...)
+		s = nil
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return streams, nil
+}
+
+// changes returns the changes in the streams after from and up to to.
+func (r *CDCReader) changes(streams [][]byte, from, to time.Time) ([]Change, error) {
+	cql := `SELECT * FROM ` + r.table.Name + `_scylla_cdc_log WHERE "cdc$stream_id" IN ? AND "cdc$time" > maxTimeuuid(?) AND "cdc$time" <= maxTimeuuid(?)`
+	registry := registryOf(r.session)
+
+	var changes []Change
+	for len(streams) > 0 {
+		n := len(streams)
+		if n > cdcStreamsPerQuery {
+			n = cdcStreamsPerQuery
+		}
+		iter := r.session.QueryRaw(cql, streams[:n], from, to).Iter()
+		streams = streams[n:]
+
+		for {
+			var c Change
+			var op int8
+			var seq int32
+			v := reflect.New(r.typ)
+			m, table := registry.MapTable(v.Interface())
+			m["cdc$stream_id"] = &c.StreamID
+			m["cdc$time"] = &c.Time
+			m["cdc$batch_seq_no"] = &seq
+			m["cdc$operation"] = &op
+			if !iter.MapScan(m) {
+				break
+			}
+			table.scanned(v.Elem(), m)
+			c.Op, c.BatchSeqNo = ChangeOp(op), int(seq)
+			c.Delta = v.Interface()
+			changes = append(changes, c)
+		}
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return mergeChanges(changes), nil
+}
+
+// mergeChanges sorts the rows of the log and sets the preimages and
+// postimages in the changes of the same batch.
+func mergeChanges(rows []Change) []Change {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if ta, tb := a.Time.Time(), b.Time.Time(); !ta.Equal(tb) {
+			return ta.Before(tb)
+		}
+		if a.Time != b.Time {
+			return bytes.Compare(a.Time[:], b.Time[:]) < 0
+		}
+		if c := bytes.Compare(a.StreamID, b.StreamID); c != 0 {
+			return c < 0
+		}
+		return a.BatchSeqNo < b.BatchSeqNo
+	})
+
+	var changes []Change
+	var before interface{}
+	var batch Change
+	first := 0
+	for _, row := range rows {
+		if row.Time != batch.Time || !bytes.Equal(row.StreamID, batch.StreamID) {
+			batch, first, before = row, len(changes), nil
+		}
+		switch row.Op {
+		case PreImageOp:
+			before = row.Delta
+		case PostImageOp:
+			for n := first; n < len(changes); n++ {
+				changes[n].After = row.Delta
+			}
+		default:
+			row.Before = before
+			changes = append(changes, row)
+		}
+	}
+	return changes
+}
+
+// memoryCheckpoint is a CDCCheckpoint that keeps the times in memory.
+type memoryCheckpoint struct {
+	mu    sync.Mutex
+	times map[string]time.Time
+}
+
+// NewMemoryCheckpoint returns a CDCCheckpoint that keeps the times in memory.
+func NewMemoryCheckpoint() CDCCheckpoint {
+	return &memoryCheckpoint{times: make(map[string]time.Time)}
+}
+
+func (c *memoryCheckpoint) Load(name string) (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.times[name], nil
+}
+
+func (c *memoryCheckpoint) Save(name string, t time.Time) error {
+	c.mu.Lock()
+	c.times[name] = t
+	c.mu.Unlock()
+	return nil
+}
+
+// tableCheckpoint is a CDCCheckpoint that stores the times in a table.
+type tableCheckpoint struct {
+	session Session
+	table   string
+}
+
+// NewTableCheckpoint returns a CDCCheckpoint that stores the times in the
+// given table, it must have the following schema:
+//
+//	CREATE TABLE cdc_checkpoints (name text PRIMARY KEY, time timestamp);
+func NewTableCheckpoint(s Session, table string) CDCCheckpoint {
+	return &tableCheckpoint{session: s, table: table}
+}
+
+func (c *tableCheckpoint) Load(name string) (time.Time, error) {
+	var t time.Time
+	err := c.session.QueryRaw("SELECT time FROM "+c.table+" WHERE name = ?", name).Scan(&t)
+	if err == ErrNotFound {
+		return time.Time{}, nil
+	}
+	return t, err
+}
+
+func (c *tableCheckpoint) Save(name string, t time.Time) error {
+	return c.session.QueryRaw("INSERT INTO "+c.table+" (name, time) VALUES (?, ?)", name, t).Idempotent().Exec()
+}
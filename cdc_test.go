@@ -0,0 +1,126 @@
+package ecql
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptDriver returns the rows of the first key contained in the statement.
+type scriptDriver struct {
+	*testDriver
+	results map[string]*testRows
+}
+
+func (d *scriptDriver) Iter(req *Request) Rows {
+	d.testDriver.Iter(req)
+	for key, rows := range d.results {
+		if strings.Contains(req.Statement, key) {
+			return &testRows{columns: rows.columns, rows: rows.rows}
+		}
+	}
+	return &testRows{}
+}
+
+func TestCDCReader(t *testing.T) {
+	DeleteRegistry()
+	now := time.Now()
+	gen0, gen1 := now.Add(-2*time.Hour), now.Add(-time.Hour)
+	t0, t1 := gocql.UUIDFromTime(now.Add(-20*time.Minute)), gocql.UUIDFromTime(now.Add(-10*time.Minute))
+	cdcColumns := []string{"cdc$stream_id", "cdc$time", "cdc$batch_seq_no", "cdc$operation", "f1", "f22"}
+
+	d := &scriptDriver{testDriver: &testDriver{}, results: map[string]*testRows{
+		"cdc_generation_timestamps": {columns: []string{"time"}, rows: [][]interface{}{{gen1}, {gen0}}},
+		"cdc_streams_descriptions_v2": {columns: []string{"streams"}, rows: [][]interface{}{
+			{[][]byte{{1}}}, {[][]byte{{2}}},
+		}},
+		"mytable_scylla_cdc_log": {columns: cdcColumns, rows: [][]interface{}{
+			{[]byte{1}, t1, int32(0), int8(PreImageOp), "a", 1},
+			{[]byte{1}, t1, int32(1), int8(UpdateOp), "a", 2},
+			{[]byte{1}, t1, int32(2), int8(PostImageOp), "a", 2},
+			{[]byte{2}, t0, int32(0), int8(InsertOp), "b", 5},
+		}},
+	}}
+	sess := NewWithDriver(d)
+
+	var changes []Change
+	handler := func(c Change) error {
+		changes = append(changes, c)
+		return nil
+	}
+
+	r := NewCDCReader(sess, testStruct{}, CDCConfig{})
+	from := now.Add(-30 * time.Minute)
+	assert.NoError(t, r.Read(from, now, handler))
+	assert.Equal(t, []Change{
+		{Op: InsertOp, Time: t0, StreamID: []byte{2}, Delta: &testStruct{F1: "b", F2: 5}},
+		{
+			Op: UpdateOp, Time: t1, StreamID: []byte{1}, BatchSeqNo: 1,
+			Before: &testStruct{F1: "a", F2: 1},
+			Delta:  &testStruct{F1: "a", F2: 2},
+			After:  &testStruct{F1: "a", F2: 2},
+		},
+	}, changes)
+
+	req := d.last()
+	assert.Equal(t, `SELECT * FROM mytable_scylla_cdc_log WHERE "cdc$stream_id" IN ? AND "cdc$time" > maxTimeuuid(?) AND "cdc$time" <= maxTimeuuid(?)`, req.Statement)
+	assert.Equal(t, []interface{}{[][]byte{{1}, {2}}, from, now}, req.Values)
+	assert.Equal(t, gen1, d.requests[1].Values[0])
+
+	// A window over two generations
+	d.requests, changes = nil, nil
+	assert.NoError(t, r.Read(now.Add(-90*time.Minute), now, handler))
+	assert.Len(t, changes, 4)
+	assert.Equal(t, []interface{}{gen0}, d.requests[1].Values)
+	assert.Equal(t, []interface{}{[][]byte{{1}, {2}}, now.Add(-90 * time.Minute), gen1}, d.requests[2].Values)
+	assert.Equal(t, []interface{}{gen1}, d.requests[3].Values)
+	assert.Equal(t, []interface{}{[][]byte{{1}, {2}}, gen1, now}, d.requests[4].Values)
+
+	// Run saves the checkpoint
+	changes = nil
+	checkpoint := NewMemoryCheckpoint()
+	ctx, cancel := context.WithCancel(context.Background())
+	r = NewCDCReader(sess, testStruct{}, CDCConfig{
+		Checkpoint:   checkpoint,
+		Start:        from,
+		PollInterval: time.Millisecond,
+		Delay:        time.Millisecond,
+	})
+	err := r.Run(ctx, func(c Change) error {
+		cancel()
+		return handler(c)
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, changes, 2)
+	saved, err := checkpoint.Load("mytable")
+	assert.NoError(t, err)
+	assert.True(t, saved.After(from))
+
+	// Without generations
+	delete(d.results, "cdc_generation_timestamps")
+	assert.Equal(t, ErrCDCNotEnabled, r.Read(from, now, handler))
+}
+
+func TestTableCheckpoint(t *testing.T) {
+	sess, d := newTestSession()
+	c := NewTableCheckpoint(sess, "cdc_checkpoints")
+
+	saved, err := c.Load("events")
+	assert.NoError(t, err)
+	assert.True(t, saved.IsZero())
+	assert.Equal(t, "SELECT time FROM cdc_checkpoints WHERE name = ?", d.last().Statement)
+
+	now := time.Now()
+	d.result([]string{"time"}, []interface{}{now})
+	saved, err = c.Load("events")
+	assert.NoError(t, err)
+	assert.Equal(t, now, saved)
+
+	assert.NoError(t, c.Save("events", now))
+	assert.Equal(t, "INSERT INTO cdc_checkpoints (name, time) VALUES (?, ?)", d.last().Statement)
+	assert.Equal(t, []interface{}{"events", now}, d.last().Values)
+}
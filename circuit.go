@@ -0,0 +1,269 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ErrCircuitOpen is returned by the requests rejected by a CircuitBreaker.
+var ErrCircuitOpen = errors.New("ecql: circuit breaker open")
+
+const (
+	// DefaultCircuitWindow is the default time window used to compute the
+	// error rate of a CircuitBreaker.
+	DefaultCircuitWindow = 10 * time.Second
+
+	// DefaultCircuitMinRequests is the default minimum number of requests in
+	// a window before a CircuitBreaker can trip.
+	DefaultCircuitMinRequests = 20
+
+	// DefaultCircuitErrorRate is the default error rate that trips a
+	// CircuitBreaker.
+	DefaultCircuitErrorRate = 0.5
+
+	// DefaultCircuitOpenTimeout is the default time a CircuitBreaker stays
+	// open before letting a request through.
+	DefaultCircuitOpenTimeout = 30 * time.Second
+)
+
+// CircuitBreakerConfig contains the configuration of a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Window is the duration of the time window used to compute the error
+	// rate.
+	Window time.Duration
+	// MinRequests is the minimum number of requests in a window before the
+	// circuit can trip.
+	MinRequests int
+	// ErrorRate is the fraction of failed requests, between 0 and 1, that
+	// trips the circuit.
+	ErrorRate float64
+	// OpenTimeout is the time the circuit stays open. After it, a single
+	// request is let through to probe the cluster, if it succeeds the
+	// circuit is closed, if not it is open again.
+	OpenTimeout time.Duration
+	// Key returns the circuit used by a request, it defaults to the table of
+	// the request.
+	Key func(req *Request) string
+	// OnStateChange is called when a circuit is open or closed.
+	OnStateChange func(key string, open bool)
+	// Clock is the source of the current time, it defaults to SystemClock.
+	Clock Clock
+}
+
+// CircuitBreaker is a middleware that keeps a circuit per table, or per the
+// key returned by the configured Key function. A circuit trips when the
+// error rate of its requests is sustained over a time window, and while it
+// is open the requests fail fast with ErrCircuitOpen instead of adding load
+// to a struggling cluster:
+//
+//	cb := ecql.NewCircuitBreaker(ecql.CircuitBreakerConfig{})
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(cb.Middleware()))
+//
+// Invalid queries, ErrNotFound and canceled requests are not considered
+// failures.
+type CircuitBreaker struct {
+	config   CircuitBreakerConfig
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	start    time.Time
+	requests int
+	failures int
+	open     bool
+	openedAt time.Time
+	probing  bool
+	probedAt time.Time
+}
+
+// NewCircuitBreaker creates a new CircuitBreaker with the given
+// configuration.
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.Window <= 0 {
+		config.Window = DefaultCircuitWindow
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = DefaultCircuitMinRequests
+	}
+	if config.ErrorRate <= 0 || config.ErrorRate > 1 {
+		config.ErrorRate = DefaultCircuitErrorRate
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = DefaultCircuitOpenTimeout
+	}
+	if config.Key == nil {
+		config.Key = func(req *Request) string {
+			return req.Table
+		}
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock
+	}
+	return &CircuitBreaker{
+		config:   config,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// Middleware returns the middleware that applies the circuit breaker.
+func (cb *CircuitBreaker) Middleware() Middleware {
+	return func(next Driver) Driver {
+		return &circuitDriver{Driver: next, cb: cb}
+	}
+}
+
+// Open returns true if the circuit with the given key is open.
+func (cb *CircuitBreaker) Open(key string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c, ok := cb.circuits[key]
+	return ok && c.open
+}
+
+// allow returns true if a request on the circuit key can be executed.
+func (cb *CircuitBreaker) allow(key string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c, ok := cb.circuits[key]
+	if !ok || !c.open {
+		return true
+	}
+	// A probe not finished after the timeout is replaced by a new one
+	now := cb.config.Clock.Now()
+	if c.probing && now.Sub(c.probedAt) < cb.config.OpenTimeout || now.Sub(c.openedAt) < cb.config.OpenTimeout {
+		return false
+	}
+	c.probing, c.probedAt = true, now
+	return true
+}
+
+// done records the result of a request on the circuit key.
+func (cb *CircuitBreaker) done(key string, err error) {
+	failed := isFailure(err)
+
+	cb.mu.Lock()
+	c, ok := cb.circuits[key]
+	if !ok {
+		c = &circuit{start: cb.config.Clock.Now()}
+		cb.circuits[key] = c
+	}
+
+	var changed bool
+	now := cb.config.Clock.Now()
+	switch {
+	case c.open && c.probing:
+		c.probing = false
+		switch {
+		case errors.Is(err, context.Canceled):
+			// The next request probes the cluster again
+		case failed:
+			c.openedAt = now
+		default:
+			*c = circuit{start: now}
+			changed = true
+		}
+	case c.open:
+	default:
+		if now.Sub(c.start) >= cb.config.Window {
+			c.start, c.requests, c.failures = now, 0, 0
+		}
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= cb.config.MinRequests && float64(c.failures) >= cb.config.ErrorRate*float64(c.requests) {
+			c.open, c.openedAt = true, now
+			changed = true
+		}
+	}
+	open := c.open
+	cb.mu.Unlock()
+
+	if changed && cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(key, open)
+	}
+}
+
+// isFailure returns true if err counts as a failure of the cluster.
+func isFailure(err error) bool {
+	if err == nil || err == ErrNotFound || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return !errors.Is(err, ErrInvalidQuery) && !errors.Is(err, ErrAlreadyExists)
+}
+
+// circuitDriver is the Driver used by the CircuitBreaker middleware.
+type circuitDriver struct {
+	Driver
+	cb *CircuitBreaker
+}
+
+func (d *circuitDriver) Iter(req *Request) Rows {
+	key := d.cb.config.Key(req)
+	if !d.cb.allow(key) {
+		return errorRows{err: newQueryError(req, ErrCircuitOpen)}
+	}
+	return &circuitRows{Rows: d.Driver.Iter(req), cb: d.cb, key: key}
+}
+
+func (d *circuitDriver) ExecBatch(b *BatchRequest) error {
+	if len(b.Entries) == 0 {
+		return d.Driver.ExecBatch(b)
+	}
+	key := d.cb.config.Key(&b.Entries[0])
+	if !d.cb.allow(key) {
+		return newBatchError(b, ErrCircuitOpen)
+	}
+	err := d.Driver.ExecBatch(b)
+	d.cb.done(key, err)
+	return err
+}
+
+func (d *circuitDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	if len(b.Entries) == 0 {
+		return d.Driver.ExecBatchCAS(b, dest)
+	}
+	key := d.cb.config.Key(&b.Entries[0])
+	if !d.cb.allow(key) {
+		return false, newBatchError(b, ErrCircuitOpen)
+	}
+	applied, err := d.Driver.ExecBatchCAS(b, dest)
+	d.cb.done(key, err)
+	return applied, err
+}
+
+// circuitRows records the result of the request when the rows are closed.
+type circuitRows struct {
+	Rows
+	cb     *CircuitBreaker
+	key    string
+	closed bool
+}
+
+func (r *circuitRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.cb.done(r.key, err)
+	}
+	return err
+}
+
+// errorRows are the Rows of a request that has not been executed.
+type errorRows struct {
+	err error
+}
+
+func (r errorRows) Columns() []gocql.ColumnInfo           { return nil }
+func (r errorRows) Scan(dest ...interface{}) bool         { return false }
+func (r errorRows) MapScan(m map[string]interface{}) bool { return false }
+func (r errorRows) NumRows() int                          { return 0 }
+func (r errorRows) WillSwitchPage() bool                  { return false }
+func (r errorRows) PageState() []byte                     { return nil }
+func (r errorRows) Info() QueryInfo                       { return QueryInfo{} }
+func (r errorRows) Close() error                          { return r.err }
@@ -0,0 +1,81 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	DeleteRegistry()
+	now := time.Now()
+
+	var changes []bool
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MinRequests: 4, Clock: ClockFunc(func() time.Time { return now }), OnStateChange: func(key string, open bool) {
+		assert.Equal(t, "mytable", key)
+		changes = append(changes, open)
+	}})
+	sess, d := newTestSession(WithMiddleware(cb.Middleware()))
+	ts := testStruct{F1: "foo"}
+
+	// Invalid queries do not trip the circuit
+	d.err = testRequestError{gocql.ErrCodeInvalid}
+	for i := 0; i < 4; i++ {
+		assert.Error(t, sess.Set(ts))
+	}
+	assert.False(t, cb.Open("mytable"))
+
+	// 2 of 4 requests failed
+	now = now.Add(DefaultCircuitWindow)
+	d.err = nil
+	assert.NoError(t, sess.Set(ts))
+	assert.NoError(t, sess.Set(ts))
+	d.err = testRequestError{gocql.ErrCodeWriteTimeout}
+	assert.Error(t, sess.Set(ts))
+	assert.False(t, cb.Open("mytable"))
+	assert.Error(t, sess.Set(ts))
+	assert.True(t, cb.Open("mytable"))
+	assert.False(t, cb.Open("other"))
+
+	// Fail fast
+	n := len(d.requests)
+	err := sess.Set(ts)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.True(t, errors.Is(sess.Batch().Add(sess.Insert(ts)).Apply(), ErrCircuitOpen))
+	_, err = sess.Batch().Add(sess.Insert(ts)).ApplyCAS()
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Len(t, d.requests, n)
+	assert.Len(t, d.batches, 0)
+
+	// Failed probe
+	now = now.Add(DefaultCircuitOpenTimeout)
+	assert.Error(t, sess.Set(ts))
+	assert.Len(t, d.requests, n+1)
+	assert.True(t, errors.Is(sess.Set(ts), ErrCircuitOpen))
+
+	// Canceled probes do not close the circuit, the next request is a probe
+	now = now.Add(DefaultCircuitOpenTimeout)
+	d.err = context.Canceled
+	assert.Error(t, sess.Set(ts))
+	d.err = testRequestError{gocql.ErrCodeWriteTimeout}
+	assert.Error(t, sess.Set(ts))
+	assert.Len(t, d.requests, n+3)
+	assert.True(t, cb.Open("mytable"))
+
+	// Probes not closed are replaced after the timeout
+	now = now.Add(DefaultCircuitOpenTimeout)
+	cb.Middleware()(d).Iter(&Request{Table: "mytable"})
+	assert.True(t, errors.Is(sess.Set(ts), ErrCircuitOpen))
+
+	// Successful probe
+	now = now.Add(DefaultCircuitOpenTimeout)
+	d.err = nil
+	assert.NoError(t, sess.Set(ts))
+	assert.False(t, cb.Open("mytable"))
+	assert.NoError(t, sess.Set(ts))
+	assert.Equal(t, []bool{true, false}, changes)
+}
@@ -0,0 +1,112 @@
+package ecql
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Clock is the source of the current time of a session, it is used to set
+// the generated timestamps, and by the bulk writer and the CDC reader of the
+// session. The middlewares and credential providers have their own Clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to use ordinary functions as a Clock.
+type ClockFunc func() time.Time
+
+// Now implements Clock.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// UUIDSource generates the UUIDs of a session, like the generated ids.
+type UUIDSource interface {
+	// TimeUUID returns a version 1 UUID with the given time.
+	TimeUUID(t time.Time) gocql.UUID
+	// RandomUUID returns a version 4 UUID.
+	RandomUUID() gocql.UUID
+}
+
+// SystemClock is the Clock that returns the current local time.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// SystemUUIDs is the UUIDSource that uses the gocql generators.
+var SystemUUIDs UUIDSource = systemUUIDs{}
+
+type systemUUIDs struct{}
+
+func (systemUUIDs) TimeUUID(t time.Time) gocql.UUID {
+	return gocql.UUIDFromTime(t)
+}
+
+func (systemUUIDs) RandomUUID() gocql.UUID {
+	u, err := gocql.RandomUUID()
+	if err != nil {
+		// The random source has failed, use a time UUID instead
+		return gocql.TimeUUID()
+	}
+	return u
+}
+
+// WithClock sets the clock used by the session. It defaults to SystemClock,
+// tests can use a clock that returns known times:
+//
+//	clock := ecql.ClockFunc(func() time.Time { return now })
+//	sess := ecql.NewWithDriver(driver, ecql.WithClock(clock))
+func WithClock(c Clock) Option {
+	return func(s *SessionImpl) {
+		s.clock = c
+	}
+}
+
+// WithUUIDSource sets the generator of the UUIDs used by the session. It
+// defaults to SystemUUIDs.
+func WithUUIDSource(u UUIDSource) Option {
+	return func(s *SessionImpl) {
+		s.uuids = u
+	}
+}
+
+func (s *SessionImpl) getClock() Clock {
+	if s.clock == nil {
+		return SystemClock
+	}
+	return s.clock
+}
+
+func (s *SessionImpl) getUUIDs() UUIDSource {
+	if s.uuids == nil {
+		return SystemUUIDs
+	}
+	return s.uuids
+}
+
+// now returns the current time of the session clock.
+func (s *SessionImpl) now() time.Time {
+	return s.getClock().Now()
+}
+
+// clockOf returns the clock of the session s, or SystemClock if it is not a
+// SessionImpl.
+func clockOf(s Session) Clock {
+	if sess, ok := s.(*SessionImpl); ok {
+		return sess.getClock()
+	}
+	return SystemClock
+}
+
+// nowOf returns the current time of the clock c, or of SystemClock if c is
+// nil.
+func nowOf(c Clock) time.Time {
+	if c == nil {
+		return SystemClock.Now()
+	}
+	return c.Now()
+}
+
+// timeUUID returns a time UUID with the current time of the session clock.
+func (s *SessionImpl) timeUUID() gocql.UUID {
+	return s.getUUIDs().TimeUUID(s.now())
+}
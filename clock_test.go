@@ -0,0 +1,52 @@
+package ecql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedUUIDs is a UUIDSource that always returns the same UUIDs.
+type fixedUUIDs struct {
+	time, random gocql.UUID
+}
+
+func (u fixedUUIDs) TimeUUID(t time.Time) gocql.UUID { return u.time }
+func (u fixedUUIDs) RandomUUID() gocql.UUID          { return u.random }
+
+func TestClock(t *testing.T) {
+	sess, _ := newTestSession()
+	assert.WithinDuration(t, time.Now(), sess.now(), time.Second)
+	assert.Equal(t, SystemUUIDs, sess.getUUIDs())
+	assert.Equal(t, 1, sess.timeUUID().Version())
+	assert.Equal(t, 4, SystemUUIDs.RandomUUID().Version())
+
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	uuids := fixedUUIDs{
+		time:   MustUUID("00000001-0000-1000-8000-000000000000"),
+		random: MustUUID("00000002-0000-4000-8000-000000000000"),
+	}
+	sess, _ = newTestSession(WithClock(ClockFunc(func() time.Time { return now })), WithUUIDSource(uuids))
+	assert.Equal(t, now, sess.now())
+	assert.Equal(t, uuids.time, sess.timeUUID())
+}
+
+func TestAuditClock(t *testing.T) {
+	DeleteRegistry()
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	id := MustUUID("00000001-0000-1000-8000-000000000000")
+	audit := NewAudit(AuditConfig{
+		Types: []interface{}{testStruct{}},
+		Clock: ClockFunc(func() time.Time { return now }),
+		UUIDs: fixedUUIDs{time: id},
+	})
+	sess, d := newTestSession(WithMiddleware(audit.Middleware()))
+
+	assert.NoError(t, sess.Set(testStruct{F1: "a", F2: 2}))
+	req := d.last()
+	assert.Equal(t, "audit_log", req.Table)
+	assert.Equal(t, id, req.Values[2])
+	assert.Equal(t, now, req.Values[3])
+}
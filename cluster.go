@@ -0,0 +1,193 @@
+package ecql
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ClusterStatus contains the state of the hosts in the cluster as seen by a
+// session.
+type ClusterStatus struct {
+	Hosts []HostStatus
+}
+
+// HostStatus contains the state and the statistics of a host.
+type HostStatus struct {
+	HostID     string
+	Address    string
+	DataCenter string
+	Rack       string
+	Up         bool
+	Tokens     []string
+	Queries    int64
+	Errors     int64
+	AvgLatency time.Duration
+	MaxLatency time.Duration
+}
+
+// Up returns the number of hosts up.
+func (c ClusterStatus) Up() int {
+	var n int
+	for i := range c.Hosts {
+		if c.Hosts[i].Up {
+			n++
+		}
+	}
+	return n
+}
+
+// Down returns the number of hosts down.
+func (c ClusterStatus) Down() int {
+	return len(c.Hosts) - c.Up()
+}
+
+// ClusterStatus returns the state of the hosts in the cluster gathered from
+// the host events and the statements executed by the session. The status is
+// only available on sessions created with NewSession.
+func (s *SessionImpl) ClusterStatus() ClusterStatus {
+	if s.monitor == nil {
+		return ClusterStatus{}
+	}
+	return s.monitor.status()
+}
+
+type hostStats struct {
+	info         *gocql.HostInfo
+	up           bool
+	queries      int64
+	errors       int64
+	totalLatency time.Duration
+	maxLatency   time.Duration
+}
+
+// hostMonitor keeps track of the hosts in the cluster and the statistics of
+// the queries executed on them. The listeners are called on the changes of
+// the hosts.
+type hostMonitor struct {
+	mu        sync.RWMutex
+	hosts     map[string]*hostStats
+	listeners []HostEventFunc
+}
+
+func newHostMonitor() *hostMonitor {
+	return &hostMonitor{
+		hosts: make(map[string]*hostStats),
+	}
+}
+
+func hostKey(host *gocql.HostInfo) string {
+	if id := host.HostID(); id != "" {
+		return id
+	}
+	return host.ConnectAddressAndPort()
+}
+
+// get returns the stats of a host, creating them if necessary. It must be
+// called with the lock held.
+func (m *hostMonitor) get(host *gocql.HostInfo) *hostStats {
+	key := hostKey(host)
+	h, ok := m.hosts[key]
+	if !ok {
+		h = &hostStats{info: host, up: host.IsUp()}
+		m.hosts[key] = h
+	}
+	h.info = host
+	return h
+}
+
+// add sets a new host of the cluster as up.
+func (m *hostMonitor) add(host *gocql.HostInfo) {
+	m.mu.Lock()
+	_, ok := m.hosts[hostKey(host)]
+	m.get(host).up = true
+	m.mu.Unlock()
+	if !ok {
+		m.notify(HostAddedEvent, host)
+	}
+}
+
+func (m *hostMonitor) setUp(host *gocql.HostInfo, up bool) {
+	m.mu.Lock()
+	h, ok := m.hosts[hostKey(host)]
+	changed := !ok || h.up != up
+	m.get(host).up = up
+	m.mu.Unlock()
+	switch {
+	case !changed:
+	case up:
+		m.notify(HostUpEvent, host)
+	default:
+		m.notify(HostDownEvent, host)
+	}
+}
+
+func (m *hostMonitor) remove(host *gocql.HostInfo) {
+	m.mu.Lock()
+	_, ok := m.hosts[hostKey(host)]
+	delete(m.hosts, hostKey(host))
+	m.mu.Unlock()
+	if ok {
+		m.notify(HostRemovedEvent, host)
+	}
+}
+
+func (m *hostMonitor) observe(host *gocql.HostInfo, latency time.Duration, err error) {
+	m.mu.Lock()
+	h := m.get(host)
+	h.queries++
+	h.totalLatency += latency
+	if latency > h.maxLatency {
+		h.maxLatency = latency
+	}
+	if err != nil {
+		h.errors++
+	}
+	m.mu.Unlock()
+}
+
+func (m *hostMonitor) status() ClusterStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var status ClusterStatus
+	for _, h := range m.hosts {
+		hs := HostStatus{
+			HostID:     h.info.HostID(),
+			Address:    h.info.ConnectAddressAndPort(),
+			DataCenter: h.info.DataCenter(),
+			Rack:       h.info.Rack(),
+			Up:         h.up,
+			Tokens:     h.info.Tokens(),
+			Queries:    h.queries,
+			Errors:     h.errors,
+			MaxLatency: h.maxLatency,
+		}
+		if h.queries > 0 {
+			hs.AvgLatency = h.totalLatency / time.Duration(h.queries)
+		}
+		status.Hosts = append(status.Hosts, hs)
+	}
+	sort.Slice(status.Hosts, func(i, j int) bool {
+		return status.Hosts[i].Address < status.Hosts[j].Address
+	})
+	return status
+}
+
+// queryObserver records the statistics of the queries in a hostMonitor.
+type queryObserver struct {
+	monitor *hostMonitor
+	next    gocql.QueryObserver
+}
+
+func (o *queryObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	if q.Host != nil {
+		o.monitor.observe(q.Host, q.End.Sub(q.Start), q.Err)
+	}
+	if o.next != nil {
+		o.next.ObserveQuery(ctx, q)
+	}
+}
@@ -0,0 +1,51 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostMonitor(t *testing.T) {
+	h1 := &gocql.HostInfo{}
+	h1.SetHostID("host-1")
+	h2 := &gocql.HostInfo{}
+	h2.SetHostID("host-2")
+
+	m := newHostMonitor()
+	m.setUp(h1, true)
+	m.setUp(h2, true)
+	m.setUp(h2, false)
+
+	o := &queryObserver{monitor: m}
+	start := time.Now()
+	o.ObserveQuery(context.Background(), gocql.ObservedQuery{Host: h1, Start: start, End: start.Add(10 * time.Millisecond)})
+	o.ObserveQuery(context.Background(), gocql.ObservedQuery{Host: h1, Start: start, End: start.Add(30 * time.Millisecond), Err: errors.New("foo")})
+
+	status := m.status()
+	assert.Len(t, status.Hosts, 2)
+	assert.Equal(t, 1, status.Up())
+	assert.Equal(t, 1, status.Down())
+
+	var hs HostStatus
+	for _, h := range status.Hosts {
+		if h.HostID == "host-1" {
+			hs = h
+		}
+	}
+	assert.True(t, hs.Up)
+	assert.Equal(t, int64(2), hs.Queries)
+	assert.Equal(t, int64(1), hs.Errors)
+	assert.Equal(t, 20*time.Millisecond, hs.AvgLatency)
+	assert.Equal(t, 30*time.Millisecond, hs.MaxLatency)
+
+	m.remove(h2)
+	assert.Len(t, m.status().Hosts, 1)
+
+	// Not available without monitor
+	assert.Empty(t, (&SessionImpl{}).ClusterStatus().Hosts)
+}
@@ -0,0 +1,341 @@
+// Command ecql creates and migrates the schema of the types registered in an
+// application, runs queries, and exports and imports the rows of tables.
+//
+// The schema commands use a registry snapshot, the JSON written by
+// ecql.ExportRegistry, so the application types are not required:
+//
+//	ecql schema -metadata registry.json
+//	ecql -hosts db1,db2 -keyspace app migrate -metadata registry.json events
+//	ecql -keyspace app generate -package models -o models/tables.go events users
+//	ecql -keyspace app query "SELECT * FROM events WHERE id = ?" 42
+//	ecql -keyspace app export -o events.jsonl events
+//	ecql -keyspace app import -i events.jsonl events
+//
+// The rows are exported and imported as JSON Lines, using the CQL JSON
+// support.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/maraino/ecql"
+)
+
+const usage = `Usage: ecql [flags] <command> [arguments]
+
+Commands:
+  schema  -metadata file [tables...]  print the CREATE TABLE statements
+  migrate -metadata file [tables...]  create the tables and add the missing columns
+  generate [-package name] [-o file] tables...
+                                      generate the Go structs of existing tables
+  query   cql [values...]             run a statement and print the rows as JSON
+  export  [-o file] table             export the rows of a table as JSON Lines
+  import  [-i file] table             import the rows of a table from JSON Lines
+
+Flags:
+`
+
+// maxLineSize is the maximum size of a row in the imported files.
+const maxLineSize = 16 << 20
+
+var (
+	hosts       = flag.String("hosts", "127.0.0.1", "comma separated list of hosts")
+	keyspace    = flag.String("keyspace", "", "keyspace")
+	username    = flag.String("username", "", "username for password authentication")
+	password    = flag.String("password", os.Getenv("ECQL_PASSWORD"), "password, defaults to $ECQL_PASSWORD")
+	consistency = flag.String("consistency", "LOCAL_QUORUM", "consistency level")
+	timeout     = flag.Duration("timeout", 10*time.Second, "timeout of the requests")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	switch cmd {
+	case "schema":
+		err = schema(args)
+	case "migrate":
+		err = migrate(args)
+	case "generate":
+		err = generate(args)
+	case "query":
+		err = query(args)
+	case "export":
+		err = export(args)
+	case "import":
+		err = importRows(args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ecql %s: %v\n", cmd, err)
+		os.Exit(1)
+	}
+}
+
+// connect creates the session using the global flags.
+func connect() (ecql.Session, error) {
+	c, err := gocql.ParseConsistencyWrapper(*consistency)
+	if err != nil {
+		return nil, err
+	}
+	cfg := gocql.NewCluster(strings.Split(*hosts, ",")...)
+	cfg.Keyspace = *keyspace
+	cfg.Consistency = c
+	cfg.Timeout = *timeout
+	var opts []ecql.Option
+	if *username != "" {
+		opts = append(opts, ecql.WithPasswordAuth(*username, *password))
+	}
+	return ecql.NewSession(*cfg, opts...)
+}
+
+// closeSession closes the gocql session of sess.
+func closeSession(sess ecql.Session) {
+	if s, ok := sess.(*ecql.SessionImpl); ok && s.Session != nil {
+		s.Session.Close()
+	}
+}
+
+// snapshotTables reads the registry snapshot in the -metadata flag and
+// returns the given tables, or all of them.
+func snapshotTables(cmd string, args []string) ([]ecql.TableSnapshot, error) {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	metadata := fs.String("metadata", "", "registry snapshot written by ecql.ExportRegistry")
+	fs.Parse(args)
+	if *metadata == "" {
+		return nil, errors.New("-metadata is required")
+	}
+
+	f, err := os.Open(*metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	snapshot, err := ecql.ImportRegistry(f)
+	if err != nil {
+		return nil, err
+	}
+	if fs.NArg() == 0 {
+		return snapshot.Tables, nil
+	}
+
+	tables := make([]ecql.TableSnapshot, fs.NArg())
+	for i, name := range fs.Args() {
+		t, ok := snapshot.Table(name)
+		if !ok {
+			return nil, fmt.Errorf("table %s not found in %s", name, *metadata)
+		}
+		tables[i] = t
+	}
+	return tables, nil
+}
+
+func schema(args []string) error {
+	tables, err := snapshotTables("schema", args)
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		cql, err := t.CreateTableCQL()
+		if err != nil {
+			return fmt.Errorf("%s: %v", t.Name, err)
+		}
+		fmt.Println(cql + ";")
+	}
+	return nil
+}
+
+func migrate(args []string) error {
+	tables, err := snapshotTables("migrate", args)
+	if err != nil {
+		return err
+	}
+	sess, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeSession(sess)
+	return sess.AutoMigrateSnapshot(tables...)
+}
+
+func generate(args []string) (err error) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	pkg := fs.String("package", "models", "package name")
+	file := fs.String("o", "-", "file name, - for the standard output")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("a table is required")
+	}
+	sess, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeSession(sess)
+
+	tables := make([]ecql.Table, fs.NArg())
+	for i, name := range fs.Args() {
+		ks := *keyspace
+		if j := strings.IndexByte(name, '.'); j >= 0 {
+			ks, name = name[:j], name[j+1:]
+		}
+		if tables[i], err = sess.DescribeTable(ks, name); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if ks != *keyspace {
+			tables[i].Name = ks + "." + name
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *file != "-" {
+		var f *os.File
+		if f, err = os.Create(*file); err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+	return ecql.GenerateStructs(w, *pkg, tables...)
+}
+
+func query(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing statement")
+	}
+	sess, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeSession(sess)
+
+	values := make([]interface{}, len(args)-1)
+	for i, v := range args[1:] {
+		values[i] = v
+	}
+	iter := sess.QueryRaw(args[0], values...).Iter()
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		row := make(map[string]interface{})
+		if !iter.MapScan(row) {
+			break
+		}
+		if err := enc.Encode(row); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
+
+// tableFile parses the arguments of export and import.
+func tableFile(cmd, flagName string, args []string) (string, string, error) {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	file := fs.String(flagName, "-", "file name, - for the standard input and output")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return "", "", errors.New("a table is required")
+	}
+	return fs.Arg(0), *file, nil
+}
+
+func export(args []string) (err error) {
+	table, file, err := tableFile("export", "o", args)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	if file != "-" {
+		var f *os.File
+		if f, err = os.Create(file); err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+	sess, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeSession(sess)
+
+	bw := bufio.NewWriter(w)
+	iter := sess.QueryRaw("SELECT JSON * FROM " + table).Iter()
+	var row string
+	for iter.Scan(&row) {
+		bw.WriteString(row)
+		bw.WriteByte('\n')
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func importRows(args []string) error {
+	table, file, err := tableFile("import", "i", args)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	sess, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeSession(sess)
+
+	cql := "INSERT INTO " + table + " JSON ?"
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	n := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := sess.QueryRaw(cql, line).Idempotent().Exec(); err != nil {
+			return fmt.Errorf("row %d: %v", n+1, err)
+		}
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d rows imported into %s\n", n, table)
+	return nil
+}
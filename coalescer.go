@@ -0,0 +1,142 @@
+package ecql
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCoalescerClosed is returned by the inserts on a closed Coalescer.
+var ErrCoalescerClosed = errors.New("ecql: coalescer is closed")
+
+const (
+	// DefaultCoalescerBatchSize is the default maximum number of statements in
+	// a batch flushed by a Coalescer.
+	DefaultCoalescerBatchSize = 50
+
+	// DefaultCoalescerWindow is the default time that a Coalescer buffers
+	// statements before flushing them.
+	DefaultCoalescerWindow = 10 * time.Millisecond
+)
+
+// CoalescerConfig contains the configuration of a Coalescer.
+type CoalescerConfig struct {
+	// MaxBatchSize is the maximum number of statements on each batch.
+	MaxBatchSize int
+	// Window is the maximum time that a statement is buffered.
+	Window time.Duration
+	// OnError is called with the errors of the batches flushed in the
+	// background.
+	OnError func(err error)
+}
+
+// Coalescer buffers INSERT statements and flushes them as UNLOGGED batches
+// grouped by partition key. Batches with statements on the same partition are
+// applied atomically in a single mutation, it makes them a good fit for high
+// throughput ingestion pipelines.
+//
+// A group is flushed when it reaches the maximum batch size, or when the time
+// window expires.
+type Coalescer struct {
+	session Session
+	config  CoalescerConfig
+	mu      sync.Mutex
+	groups  map[string][]Statement
+	done    chan struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewCoalescer creates a new Coalescer and starts the background flushing.
+func NewCoalescer(s Session, config CoalescerConfig) *Coalescer {
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = DefaultCoalescerBatchSize
+	}
+	if config.Window <= 0 {
+		config.Window = DefaultCoalescerWindow
+	}
+
+	c := &Coalescer{
+		session: s,
+		config:  config,
+		groups:  make(map[string][]Statement),
+		done:    make(chan struct{}),
+	}
+
+	c.wg.Add(1)
+	go c.loop()
+	return c
+}
+
+// Insert buffers an INSERT statement of i. If the partition group of i is full
+// the group is flushed and its error returned. It returns ErrCoalescerClosed
+// after Close.
+func (c *Coalescer) Insert(i interface{}) error {
+	_, mapping, table := registryOf(c.session).BindTable(i)
+	key := table.partitionKey(mapping)
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrCoalescerClosed
+	}
+	c.groups[key] = append(c.groups[key], c.session.Insert(i))
+	if len(c.groups[key]) < c.config.MaxBatchSize {
+		c.mu.Unlock()
+		return nil
+	}
+	stmts := c.groups[key]
+	delete(c.groups, key)
+	c.mu.Unlock()
+
+	return c.apply(stmts)
+}
+
+// Flush applies all the buffered statements.
+func (c *Coalescer) Flush() error {
+	c.mu.Lock()
+	groups := c.groups
+	c.groups = make(map[string][]Statement)
+	c.mu.Unlock()
+
+	var err error
+	for _, stmts := range groups {
+		if e := c.apply(stmts); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Close stops the background flushing and applies the buffered statements.
+// It is safe to call it more than once.
+func (c *Coalescer) Close() error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	c.mu.Unlock()
+	c.wg.Wait()
+	return c.Flush()
+}
+
+func (c *Coalescer) loop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.config.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Flush(); err != nil && c.config.OnError != nil {
+				c.config.OnError(err)
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *Coalescer) apply(stmts []Statement) error {
+	return c.session.UnloggedBatch().Add(stmts...).Apply()
+}
@@ -0,0 +1,27 @@
+package ecql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescerClose(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	c := NewCoalescer(sess, CoalescerConfig{Window: time.Hour})
+	assert.NoError(t, c.Insert(&testStruct{F1: "a"}))
+	assert.NoError(t, c.Insert(&testStruct{F1: "b"}))
+	assert.NoError(t, c.Close())
+	assert.Len(t, d.batches, 2)
+
+	// Closed twice
+	assert.NoError(t, c.Close())
+	assert.Len(t, d.batches, 2)
+
+	// Inserts after Close
+	assert.Equal(t, ErrCoalescerClosed, c.Insert(&testStruct{F1: "c"}))
+	assert.NoError(t, c.Close())
+	assert.Len(t, d.batches, 2)
+}
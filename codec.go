@@ -0,0 +1,204 @@
+package ecql
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+)
+
+var (
+	durationType   = reflect.TypeOf(time.Duration(0))
+	goDurationType = reflect.TypeOf(goDuration(0))
+	goDecimalType  = reflect.TypeOf(goDecimal(0))
+	bigIntType     = reflect.TypeOf(big.Int{})
+)
+
+// ref returns the reference to the field used to set its value on reads.
+func (c Column) ref(field reflect.Value) interface{} {
+	return c.refOf(field, c.wrapperType(field.Type()))
+}
+
+// refOf is like ref but using the given wrapper type.
+func (c Column) refOf(field reflect.Value, w reflect.Type) interface{} {
+	if !field.CanAddr() {
+		return c.valueOf(field, w)
+	}
+	if c.Encrypted {
+		return encryptedRef{column: c, v: field}
+	}
+	if c.Serializer != "" {
+		return serializedRef{column: c, v: field}
+	}
+	if c.Enum != nil {
+		return enumRef{column: c, v: field}
+	}
+	if isNullType(field.Type()) {
+		return nullRef{field}
+	}
+	ptr := field.Addr()
+	if w != nil {
+		return ptr.Convert(reflect.PtrTo(w)).Interface()
+	}
+	return ptr.Interface()
+}
+
+// value returns the value of the field used on writes.
+func (c Column) value(field reflect.Value) interface{} {
+	return c.valueOf(field, c.wrapperType(field.Type()))
+}
+
+// valueOf is like value but using the given wrapper type.
+func (c Column) valueOf(field reflect.Value, w reflect.Type) interface{} {
+	if c.OmitEmpty && field.IsZero() {
+		return gocql.UnsetValue
+	}
+	if c.Encrypted {
+		return encryptedRef{column: c, v: field}
+	}
+	if c.Serializer != "" {
+		return serializedRef{column: c, v: field}
+	}
+	if isNullType(field.Type()) {
+		return nullRef{field}
+	}
+	if c.Enum != nil {
+		return enumRef{column: c, v: field}
+	}
+	if w != nil {
+		return field.Convert(w).Interface()
+	}
+	// gocql only marshals references to big.Int
+	if field.Type() == bigIntType {
+		v := new(big.Int)
+		if field.CanAddr() {
+			v.Set(field.Addr().Interface().(*big.Int))
+		} else {
+			i := field.Interface().(big.Int)
+			v.Set(&i)
+		}
+		return v
+	}
+	return field.Interface()
+}
+
+// wrapperType returns the type used to marshal and unmarshal values of type t
+// in the column, or nil if the values are supported by gocql.
+func (c Column) wrapperType(t reflect.Type) reflect.Type {
+	cqlType := c.Type
+	if cqlType == "" {
+		cqlType, _ = cqlTypeName(t)
+	}
+	switch {
+	case strings.HasPrefix(cqlType, "vector<"):
+		if t != vectorType && t.ConvertibleTo(vectorType) {
+			return vectorType
+		}
+	case cqlType == "duration":
+		if t == durationType {
+			return goDurationType
+		}
+	case cqlType == "decimal":
+		if t.Kind() == reflect.Float64 {
+			return goDecimalType
+		}
+	}
+	return nil
+}
+
+// goDuration is used to read duration columns into time.Duration values.
+type goDuration time.Duration
+
+// MarshalCQL implements gocql.Marshaler.
+func (d goDuration) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	return gocql.Marshal(info, time.Duration(d))
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler. Durations with months cannot be
+// represented as a time.Duration, and the days are considered of 24 hours.
+func (d *goDuration) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if info == nil || info.Type() != gocql.TypeDuration {
+		return gocql.Unmarshal(info, data, (*time.Duration)(d))
+	}
+	var v gocql.Duration
+	if err := gocql.Unmarshal(info, data, &v); err != nil {
+		return err
+	}
+	if v.Months != 0 {
+		return ErrInvalidDuration
+	}
+	*d = goDuration(time.Duration(v.Days)*24*time.Hour + time.Duration(v.Nanoseconds))
+	return nil
+}
+
+// isNullType returns true if t is one of the database/sql null types, like
+// sql.NullString or sql.NullInt64.
+func isNullType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.PkgPath() == "database/sql" && t.NumField() == 2 &&
+		t.Field(1).Name == "Valid" && t.Field(1).Type.Kind() == reflect.Bool
+}
+
+// nullRef marshals and unmarshals database/sql null types, NULL values are
+// represented with Valid set to false.
+type nullRef struct {
+	v reflect.Value
+}
+
+// MarshalCQL implements gocql.Marshaler.
+func (n nullRef) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if !n.v.Field(1).Bool() {
+		return nil, nil
+	}
+	return gocql.Marshal(info, n.v.Field(0).Interface())
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler.
+func (n nullRef) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	n.v.Set(reflect.Zero(n.v.Type()))
+	if data == nil {
+		return nil
+	}
+	if err := gocql.Unmarshal(info, data, n.v.Field(0).Addr().Interface()); err != nil {
+		return err
+	}
+	n.v.Field(1).SetBool(true)
+	return nil
+}
+
+// goDecimal is used to read and write decimal columns with float64 values.
+type goDecimal float64
+
+// MarshalCQL implements gocql.Marshaler.
+func (d goDecimal) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if info == nil || info.Type() != gocql.TypeDecimal {
+		return gocql.Marshal(info, float64(d))
+	}
+	if math.IsNaN(float64(d)) || math.IsInf(float64(d), 0) {
+		return nil, ErrInvalidDecimal
+	}
+	v, _ := new(inf.Dec).SetString(strconv.FormatFloat(float64(d), 'f', -1, 64))
+	return gocql.Marshal(info, v)
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler. The decimals are rounded to the
+// nearest float64.
+func (d *goDecimal) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if info == nil || info.Type() != gocql.TypeDecimal {
+		return gocql.Unmarshal(info, data, (*float64)(d))
+	}
+	var v inf.Dec
+	if err := gocql.Unmarshal(info, data, &v); err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(v.String(), 64)
+	if err != nil {
+		return err
+	}
+	*d = goDecimal(f)
+	return nil
+}
@@ -0,0 +1,126 @@
+package ecql
+
+import (
+	"math"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/inf.v0"
+)
+
+type typesStruct struct {
+	ID       string         `cql:"id" cqltable:"types"`
+	Duration time.Duration  `cql:"duration"`
+	Time     time.Duration  `cql:"time,type=time"`
+	Date     time.Time      `cql:"date,type=date"`
+	Small    int16          `cql:"small"`
+	Tiny     int8           `cql:"tiny"`
+	Varint   big.Int        `cql:"varint"`
+	Decimal  *inf.Dec       `cql:"decimal"`
+	CQLDur   gocql.Duration `cql:"cqldur"`
+}
+
+func TestColumnTypes(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithKeyspace("ks"))
+
+	v := typesStruct{ID: "a", Duration: time.Minute, Time: time.Hour}
+	v.Varint.SetInt64(42)
+	m := Map(&v)
+	assert.IsType(t, new(goDuration), m["duration"])
+	assert.IsType(t, new(time.Duration), m["time"])
+	assert.IsType(t, new(big.Int), m["varint"])
+
+	values := Bind(v)
+	assert.Equal(t, goDuration(time.Minute), values[1])
+	assert.Equal(t, time.Hour, values[2])
+	assert.Equal(t, big.NewInt(42), values[6])
+
+	assert.NoError(t, sess.AutoMigrate(v))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS types (id text, duration duration, time time, date date, small smallint, tiny tinyint, varint varint, decimal decimal, cqldur duration, PRIMARY KEY (id))", d.last().Statement)
+}
+
+func TestGoDuration(t *testing.T) {
+	info := gocql.NewNativeType(5, gocql.TypeDuration, "")
+	data, err := goDuration(90 * time.Second).MarshalCQL(info)
+	assert.NoError(t, err)
+
+	var d goDuration
+	assert.NoError(t, d.UnmarshalCQL(info, data))
+	assert.Equal(t, goDuration(90*time.Second), d)
+
+	data, err = gocql.Marshal(info, gocql.Duration{Days: 2, Nanoseconds: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, d.UnmarshalCQL(info, data))
+	assert.Equal(t, goDuration(48*time.Hour+1), d)
+
+	data, err = gocql.Marshal(info, gocql.Duration{Months: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, ErrInvalidDuration, d.UnmarshalCQL(info, data))
+
+	// time columns
+	info = gocql.NewNativeType(5, gocql.TypeTime, "")
+	data, err = goDuration(time.Hour).MarshalCQL(info)
+	assert.NoError(t, err)
+	assert.NoError(t, d.UnmarshalCQL(info, data))
+	assert.Equal(t, goDuration(time.Hour), d)
+}
+
+type overridesStruct struct {
+	ID      int64  `cql:"id" cqltable:"overrides"`
+	IP      net.IP `cql:"ip"`
+	Addr    string `cql:"addr,type=inet"`
+	Created int64  `cql:"created,type=timestamp"`
+	Views   int64  `cql:"views,type=counter"`
+}
+
+func TestTypeOverrides(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithKeyspace("ks"))
+
+	assert.NoError(t, sess.AutoMigrate(overridesStruct{}))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS overrides (id bigint, ip inet, addr inet, created timestamp, views counter, PRIMARY KEY (id))", d.last().Statement)
+
+	// Incompatible types
+	assert.Equal(t, ErrUnsupportedType, sess.AutoMigrate(struct {
+		ID   string `cqltable:"invalid"`
+		Addr int    `cql:"addr,type=inet"`
+	}{}))
+}
+
+type decimalStruct struct {
+	ID    string  `cql:"id" cqltable:"prices"`
+	Price float64 `cql:"price,type=decimal"`
+}
+
+func TestGoDecimal(t *testing.T) {
+	DeleteRegistry()
+	v := decimalStruct{ID: "a", Price: 12.5}
+	assert.IsType(t, new(goDecimal), Map(&v)["price"])
+	assert.Equal(t, goDecimal(12.5), Bind(v)[1])
+
+	info := gocql.NewNativeType(5, gocql.TypeDecimal, "")
+	data, err := goDecimal(12.5).MarshalCQL(info)
+	assert.NoError(t, err)
+	expected, err := gocql.Marshal(info, inf.NewDec(125, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	var d goDecimal
+	assert.NoError(t, d.UnmarshalCQL(info, data))
+	assert.Equal(t, goDecimal(12.5), d)
+
+	_, err = goDecimal(math.Inf(1)).MarshalCQL(info)
+	assert.Equal(t, ErrInvalidDecimal, err)
+
+	// double columns
+	info = gocql.NewNativeType(5, gocql.TypeDouble, "")
+	data, err = goDecimal(1.5).MarshalCQL(info)
+	assert.NoError(t, err)
+	assert.NoError(t, d.UnmarshalCQL(info, data))
+	assert.Equal(t, goDecimal(1.5), d)
+}
@@ -0,0 +1,219 @@
+package ecql
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+)
+
+// initialisms are the words written in upper case in the generated names.
+var initialisms = map[string]bool{
+	"api": true, "dc": true, "id": true, "ip": true, "json": true, "ttl": true,
+	"uid": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// GenerateStructs writes the Go source of a package with a struct for each
+// table, with the cql, cqltable and cqlkey tags set, for example to create the
+// types of an existing keyspace with the tables returned by DescribeTable:
+//
+//	table, err := sess.DescribeTable("app", "user_events")
+//	err = ecql.GenerateStructs(os.Stdout, "models", table)
+//
+// The columns of user defined types are mapped to map[string]interface{}, and
+// the tuples to []interface{}. The type option is added to the tags of the
+// columns that do not use the default CQL type of the Go type, like ascii or
+// timeuuid, except on the types with commas, like maps, that are not
+// supported in the tags.
+func GenerateStructs(w io.Writer, pkg string, tables ...Table) error {
+	imports := make(map[string]bool)
+	var body bytes.Buffer
+	for _, t := range tables {
+		generateStruct(&body, t, imports)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by ecql from the database schema.\n\npackage %s\n\n", pkg)
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		src.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&src, "%q\n", path)
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(body.Bytes())
+
+	b, err := format.Source(src.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// generateStruct writes the struct of the table t.
+func generateStruct(w io.Writer, t Table, imports map[string]bool) {
+	name := t.Name
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	key := strings.Join(t.PartitionColumns, ",")
+	if len(t.PartitionColumns) > 1 {
+		key = "(" + key + ")"
+	}
+	for _, col := range t.ClusteringColumns {
+		key += "," + col
+		for _, o := range t.ClusteringOrder {
+			if o.Column == col && o.OrderType == DescOrder {
+				key += " desc"
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "type %s struct {\n", goName(name))
+	for i, c := range t.Columns {
+		goType, defaultType := goTypeOf(c.Type, imports)
+		tag := c.Name
+		switch {
+		case strings.HasPrefix(normalizeType(c.Type), "vector<"):
+			tag += ",vector=" + vectorSize(c.Type)
+		case defaultType != normalizeType(c.Type) && !strings.Contains(c.Type, ","):
+			tag += ",type=" + c.Type
+		}
+		tag = fmt.Sprintf("cql:%q", tag)
+		if i == 0 {
+			tag += fmt.Sprintf(" cqltable:%q cqlkey:%q", t.Name, key)
+		}
+		fmt.Fprintf(w, "%s %s `%s`\n", goName(c.Name), goType, tag)
+	}
+	fmt.Fprint(w, "}\n\n")
+}
+
+// goName returns the exported Go name of a table or column name.
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+		} else {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	s := b.String()
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		s = "X" + s
+	}
+	return s
+}
+
+// normalizeType returns the CQL type in lower case without spaces.
+func normalizeType(cqlType string) string {
+	return strings.ToLower(strings.Replace(cqlType, " ", "", -1))
+}
+
+// vectorSize returns the dimension of a vector type.
+func vectorSize(cqlType string) string {
+	t := normalizeType(cqlType)
+	return strings.TrimSuffix(t[strings.LastIndexByte(t, ',')+1:], ">")
+}
+
+// splitTypes splits the comma separated parameters of a CQL type.
+func splitTypes(params string) []string {
+	var types []string
+	depth, start := 0, 0
+	for i, r := range params {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				types = append(types, params[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(types, params[start:])
+}
+
+// goTypeOf returns the Go type of a CQL type, and the normalized CQL type
+// used by default with that Go type. The packages used are added to imports.
+func goTypeOf(cqlType string, imports map[string]bool) (string, string) {
+	t := normalizeType(cqlType)
+	if strings.HasPrefix(t, "frozen<") {
+		t = strings.TrimSuffix(strings.TrimPrefix(t, "frozen<"), ">")
+	}
+	if i := strings.IndexByte(t, '<'); i >= 0 && strings.HasSuffix(t, ">") {
+		params := splitTypes(t[i+1 : len(t)-1])
+		switch t[:i] {
+		case "list", "set":
+			elem, def := goTypeOf(params[0], imports)
+			return "[]" + elem, "list<" + def + ">"
+		case "map":
+			if len(params) == 2 {
+				key, kdef := goTypeOf(params[0], imports)
+				elem, edef := goTypeOf(params[1], imports)
+				return "map[" + key + "]" + elem, "map<" + kdef + "," + edef + ">"
+			}
+		case "vector":
+			return "[]float32", t
+		case "tuple":
+			return "[]interface{}", t
+		}
+	}
+
+	switch t {
+	case "text", "varchar", "ascii":
+		return "string", "text"
+	case "boolean":
+		return "bool", t
+	case "tinyint":
+		return "int8", t
+	case "smallint":
+		return "int16", t
+	case "int":
+		return "int", t
+	case "bigint", "counter":
+		return "int64", "bigint"
+	case "varint":
+		imports["math/big"] = true
+		return "*big.Int", t
+	case "float":
+		return "float32", t
+	case "double":
+		return "float64", t
+	case "decimal":
+		imports["gopkg.in/inf.v0"] = true
+		return "*inf.Dec", t
+	case "duration":
+		imports["github.com/gocql/gocql"] = true
+		return "gocql.Duration", t
+	case "time":
+		imports["time"] = true
+		return "time.Duration", "duration"
+	case "uuid", "timeuuid":
+		imports["github.com/gocql/gocql"] = true
+		return "gocql.UUID", "uuid"
+	case "timestamp", "date":
+		imports["time"] = true
+		return "time.Time", "timestamp"
+	case "inet":
+		imports["net"] = true
+		return "net.IP", t
+	case "blob":
+		return "[]byte", t
+	default:
+		// User defined types
+		return "map[string]interface{}", t
+	}
+}
@@ -0,0 +1,65 @@
+package ecql
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateStructs(t *testing.T) {
+	tables := []Table{
+		{
+			Name:              "app.user_events",
+			PartitionColumns:  []string{"user_id", "day"},
+			ClusteringColumns: []string{"event_id"},
+			ClusteringOrder:   []OrderBy{Desc("event_id")},
+			Columns: []Column{
+				{Name: "user_id", Type: "uuid"},
+				{Name: "day", Type: "date"},
+				{Name: "event_id", Type: "timeuuid"},
+				{Name: "tags", Type: "set<text>"},
+				{Name: "attrs", Type: "frozen<map<text, int>>"},
+				{Name: "ip", Type: "inet"},
+				{Name: "hits", Type: "counter"},
+				{Name: "embedding", Type: "vector<float, 3>"},
+				{Name: "address", Type: "frozen<address>"},
+			},
+		},
+		{
+			Name:             "settings",
+			PartitionColumns: []string{"key"},
+			Columns: []Column{
+				{Name: "key", Type: "ascii"},
+				{Name: "value", Type: "text"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, GenerateStructs(&buf, "models", tables...))
+	assert.Equal(t, "// Code generated by ecql from the database schema.\n\npackage models\n\n"+
+		"import (\n\t\"github.com/gocql/gocql\"\n\t\"net\"\n\t\"time\"\n)\n\n"+
+		"type UserEvents struct {\n"+
+		"\tUserID    gocql.UUID             `cql:\"user_id\" cqltable:\"app.user_events\" cqlkey:\"(user_id,day),event_id desc\"`\n"+
+		"\tDay       time.Time              `cql:\"day,type=date\"`\n"+
+		"\tEventID   gocql.UUID             `cql:\"event_id,type=timeuuid\"`\n"+
+		"\tTags      []string               `cql:\"tags,type=set<text>\"`\n"+
+		"\tAttrs     map[string]int         `cql:\"attrs\"`\n"+
+		"\tIP        net.IP                 `cql:\"ip\"`\n"+
+		"\tHits      int64                  `cql:\"hits,type=counter\"`\n"+
+		"\tEmbedding []float32              `cql:\"embedding,vector=3\"`\n"+
+		"\tAddress   map[string]interface{} `cql:\"address,type=frozen<address>\"`\n"+
+		"}\n\n"+
+		"type Settings struct {\n"+
+		"\tKey   string `cql:\"key,type=ascii\" cqltable:\"settings\" cqlkey:\"key\"`\n"+
+		"\tValue string `cql:\"value\"`\n"+
+		"}\n", buf.String())
+}
+
+func TestGoName(t *testing.T) {
+	assert.Equal(t, "UserID", goName("user_id"))
+	assert.Equal(t, "CreatedAt", goName("createdAt"))
+	assert.Equal(t, "X2fa", goName("2fa"))
+	assert.Equal(t, "URLHash", goName("url-hash"))
+}
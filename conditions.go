@@ -2,6 +2,7 @@ package ecql
 
 import (
 	"fmt"
+	"strings"
 )
 
 type OrderType string
@@ -29,56 +30,66 @@ type PredicateType int
 type Condition struct {
 	CQLFragment string
 	Values      []interface{}
+	err         error
 }
 
 func And(lhs Condition, list ...Condition) Condition {
 	cqlfragment := lhs.CQLFragment
-	values := lhs.Values
+	values := append([]interface{}(nil), lhs.Values...)
+	err := lhs.err
 	for _, rhs := range list {
 		cqlfragment += " AND " + rhs.CQLFragment
 		values = append(values, rhs.Values...)
+		if err == nil {
+			err = rhs.err
+		}
 	}
-	return Condition{CQLFragment: cqlfragment, Values: values}
+	return Condition{CQLFragment: cqlfragment, Values: values, err: err}
 }
 
 func Eq(col string, v interface{}) Condition {
-	return Condition{CQLFragment: fmt.Sprintf("%s = ?", col),
-		Values: []interface{}{v}}
+	return Condition{CQLFragment: fmt.Sprintf("%s = ?", ident(col)),
+		Values: []interface{}{v}, err: checkIdent(col)}
 }
 
 func Gt(col string, v interface{}) Condition {
-	return Condition{CQLFragment: fmt.Sprintf("%s > ?", col),
-		Values: []interface{}{v}}
+	return Condition{CQLFragment: fmt.Sprintf("%s > ?", ident(col)),
+		Values: []interface{}{v}, err: checkIdent(col)}
 }
 
 func Ge(col string, v interface{}) Condition {
-	return Condition{CQLFragment: fmt.Sprintf("%s >= ?", col),
-		Values: []interface{}{v}}
+	return Condition{CQLFragment: fmt.Sprintf("%s >= ?", ident(col)),
+		Values: []interface{}{v}, err: checkIdent(col)}
 }
 
 func Lt(col string, v interface{}) Condition {
-	return Condition{CQLFragment: fmt.Sprintf("%s < ?", col),
-		Values: []interface{}{v}}
+	return Condition{CQLFragment: fmt.Sprintf("%s < ?", ident(col)),
+		Values: []interface{}{v}, err: checkIdent(col)}
 }
 
 func Le(col string, v interface{}) Condition {
-	return Condition{CQLFragment: fmt.Sprintf("%s <= ?", col),
-		Values: []interface{}{v}}
+	return Condition{CQLFragment: fmt.Sprintf("%s <= ?", ident(col)),
+		Values: []interface{}{v}, err: checkIdent(col)}
 }
 
 func In(col string, v ...interface{}) Condition {
-	return Condition{CQLFragment: fmt.Sprintf("%s IN (%s)", col, qms(len(v))),
-		Values: v}
+	return Condition{CQLFragment: fmt.Sprintf("%s IN (%s)", ident(col), qms(len(v))),
+		Values: v, err: checkIdent(col)}
 }
 
 // EqInt takes is interested in the CQL indexes of the provided struct as a condition
 // For convenience, that struct is assumed to follow the same rules as other mappings
 func EqInt(i interface{}) Condition {
-	values, table := MapTable(i)
+	return eqKey(MapTable(i))
+}
+
+// eqKey returns the condition on the key columns of table using the values
+// in the mapping.
+func eqKey(values map[string]interface{}, table Table) Condition {
 	first := true
 	condition := True()
 	for _, column := range table.KeyColumns {
-		keyCondition := Eq(column, values[column])
+		keyCondition := Eq(table.quote(column), values[column])
 		if first {
 			condition = keyCondition
 			first = false
@@ -98,8 +109,9 @@ func True() Condition {
 // in a collection set, list, or map. Supported on CQL versions >= 3.2.0.
 func Contains(col string, v interface{}) Condition {
 	return Condition{
-		CQLFragment: fmt.Sprintf("%s CONTAINS ?", col),
+		CQLFragment: fmt.Sprintf("%s CONTAINS ?", ident(col)),
 		Values:      []interface{}{v},
+		err:         checkIdent(col),
 	}
 }
 
@@ -107,8 +119,9 @@ func Contains(col string, v interface{}) Condition {
 // by key in a map. Supported on CQL versions >= 3.2.0.
 func ContainsKey(col string, v interface{}) Condition {
 	return Condition{
-		CQLFragment: fmt.Sprintf("%s CONTAINS KEY ?", col),
+		CQLFragment: fmt.Sprintf("%s CONTAINS KEY ?", ident(col)),
 		Values:      []interface{}{v},
+		err:         checkIdent(col),
 	}
 }
 
@@ -124,3 +137,17 @@ func Raw(fragment string, v ...interface{}) Condition {
 		Values:      v,
 	}
 }
+
+// equalities returns the values of the 'col = ?' conditions in c.
+func (c Condition) equalities() map[string]interface{} {
+	eqs := make(map[string]interface{})
+	pos := 0
+	for _, fragment := range strings.Split(c.CQLFragment, " AND ") {
+		n := strings.Count(fragment, "?")
+		if col := strings.TrimSuffix(fragment, " = ?"); n == 1 && col != fragment && pos < len(c.Values) {
+			eqs[unquote(col)] = c.Values[pos]
+		}
+		pos += n
+	}
+	return eqs
+}
@@ -20,6 +20,15 @@ var mockOpData = map[string]interface{}{
 	"coins":    -90210,
 }
 
+// mockColumn returns the name of a column of mockOpData in a statement, the
+// reserved words are quoted.
+func mockColumn(col string) string {
+	if col == "index" {
+		return `"index"`
+	}
+	return col
+}
+
 type MockModel struct {
 	MockKey1 string `cql:"key1" cqlkey:"key1,key2"`
 	MockKey2 string `cql:"key2"`
@@ -52,7 +61,7 @@ func TestAndWith3Elements(t *testing.T) {
 
 func TestEq(t *testing.T) {
 	for col, val := range mockOpData {
-		expected := Condition{CQLFragment: col + " = ?", Values: []interface{}{val}}
+		expected := Condition{CQLFragment: mockColumn(col) + " = ?", Values: []interface{}{val}}
 		result := Eq(col, val)
 		assert.Equal(t, expected, result)
 	}
@@ -60,7 +69,7 @@ func TestEq(t *testing.T) {
 
 func TestGt(t *testing.T) {
 	for col, val := range mockOpData {
-		expected := Condition{CQLFragment: col + " > ?", Values: []interface{}{val}}
+		expected := Condition{CQLFragment: mockColumn(col) + " > ?", Values: []interface{}{val}}
 		result := Gt(col, val)
 		assert.Equal(t, expected, result)
 	}
@@ -68,7 +77,7 @@ func TestGt(t *testing.T) {
 
 func TestGe(t *testing.T) {
 	for col, val := range mockOpData {
-		expected := Condition{CQLFragment: col + " >= ?", Values: []interface{}{val}}
+		expected := Condition{CQLFragment: mockColumn(col) + " >= ?", Values: []interface{}{val}}
 		result := Ge(col, val)
 		assert.Equal(t, expected, result)
 	}
@@ -76,7 +85,7 @@ func TestGe(t *testing.T) {
 
 func TestLt(t *testing.T) {
 	for col, val := range mockOpData {
-		expected := Condition{CQLFragment: col + " < ?", Values: []interface{}{val}}
+		expected := Condition{CQLFragment: mockColumn(col) + " < ?", Values: []interface{}{val}}
 		result := Lt(col, val)
 		assert.Equal(t, expected, result)
 	}
@@ -84,7 +93,7 @@ func TestLt(t *testing.T) {
 
 func TestLe(t *testing.T) {
 	for col, val := range mockOpData {
-		expected := Condition{CQLFragment: col + " <= ?", Values: []interface{}{val}}
+		expected := Condition{CQLFragment: mockColumn(col) + " <= ?", Values: []interface{}{val}}
 		result := Le(col, val)
 		assert.Equal(t, expected, result)
 	}
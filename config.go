@@ -0,0 +1,220 @@
+package ecql
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidConfig is returned when a session configuration cannot be used.
+var ErrInvalidConfig = errors.New("ecql: invalid config")
+
+// Config is the configuration of a session that can be loaded from a YAML or
+// JSON file and from environment variables, see LoadConfig:
+//
+//	hosts: [cassandra-1, cassandra-2]
+//	keyspace: app
+//	consistency: LOCAL_QUORUM
+//	local_dc: dc1
+//	username: app
+//	timeout: 5s
+//	tls:
+//	  enabled: true
+//	  ca_file: /etc/ssl/cassandra/ca.pem
+//
+// The durations are strings like "500ms" or "5s". The zero values use the
+// defaults of gocql.
+type Config struct {
+	Hosts          []string  `json:"hosts" yaml:"hosts" env:"ECQL_HOSTS"`
+	Port           int       `json:"port" yaml:"port" env:"ECQL_PORT"`
+	Keyspace       string    `json:"keyspace" yaml:"keyspace" env:"ECQL_KEYSPACE"`
+	Consistency    string    `json:"consistency" yaml:"consistency" env:"ECQL_CONSISTENCY"`
+	LocalDC        string    `json:"local_dc" yaml:"local_dc" env:"ECQL_LOCAL_DC"`
+	Username       string    `json:"username" yaml:"username" env:"ECQL_USERNAME"`
+	Password       string    `json:"password" yaml:"password" env:"ECQL_PASSWORD"`
+	ProtoVersion   int       `json:"proto_version" yaml:"proto_version" env:"ECQL_PROTO_VERSION"`
+	NumConns       int       `json:"num_conns" yaml:"num_conns" env:"ECQL_NUM_CONNS"`
+	MaxConcurrency int       `json:"max_concurrency" yaml:"max_concurrency" env:"ECQL_MAX_CONCURRENCY"`
+	Timeout        Duration  `json:"timeout" yaml:"timeout" env:"ECQL_TIMEOUT"`
+	ConnectTimeout Duration  `json:"connect_timeout" yaml:"connect_timeout" env:"ECQL_CONNECT_TIMEOUT"`
+	TLS            TLSConfig `json:"tls" yaml:"tls"`
+}
+
+// Duration is a time.Duration that is read from strings like "5s" in the
+// configuration files.
+type Duration time.Duration
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// LoadConfig reads the configuration in the given YAML or JSON file, the
+// format is chosen by the extension of the file, and overrides it with the
+// environment variables. The hosts in ECQL_HOSTS are separated by commas.
+// If path is empty, the configuration is only read from the environment.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return c, err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			err = json.Unmarshal(b, &c)
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(b, &c)
+		default:
+			err = fmt.Errorf("unsupported format %s", filepath.Ext(path))
+		}
+		if err != nil {
+			return c, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+		}
+	}
+	if err := setEnv(reflect.ValueOf(&c).Elem()); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// setEnv sets the fields of v with the environment variables in their env
+// tags.
+func setEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			if f.Kind() == reflect.Struct {
+				if err := setEnv(f); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		var err error
+		if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			err = u.UnmarshalText([]byte(s))
+		} else {
+			switch f.Kind() {
+			case reflect.String:
+				f.SetString(s)
+			case reflect.Int:
+				var n int
+				n, err = strconv.Atoi(s)
+				f.SetInt(int64(n))
+			case reflect.Bool:
+				var b bool
+				b, err = strconv.ParseBool(s)
+				f.SetBool(b)
+			case reflect.Slice:
+				f.Set(reflect.ValueOf(splitList(s)))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, name, err)
+		}
+	}
+	return nil
+}
+
+// splitList returns the comma separated values in s.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// NewSessionFromConfig creates a Session with the given configuration, the
+// options are applied after the configuration:
+//
+//	c, err := ecql.LoadConfig(os.Getenv("ECQL_CONFIG"))
+//	if err != nil {
+//		return err
+//	}
+//	sess, err := ecql.NewSessionFromConfig(c, ecql.WithRetry(&ecql.RetryPolicy{MaxRetries: 3}))
+func NewSessionFromConfig(c Config, opts ...Option) (Session, error) {
+	cfg, configOpts, err := c.cluster()
+	if err != nil {
+		return nil, err
+	}
+	return NewSession(*cfg, append(configOpts, opts...)...)
+}
+
+// cluster returns the gocql configuration and the options of the session.
+func (c Config) cluster() (*gocql.ClusterConfig, []Option, error) {
+	if len(c.Hosts) == 0 {
+		return nil, nil, fmt.Errorf("%w: no hosts", ErrInvalidConfig)
+	}
+
+	cfg := gocql.NewCluster(c.Hosts...)
+	if c.Port > 0 {
+		cfg.Port = c.Port
+	}
+	cfg.Keyspace = c.Keyspace
+	if c.ProtoVersion > 0 {
+		cfg.ProtoVersion = c.ProtoVersion
+	}
+	if c.NumConns > 0 {
+		cfg.NumConns = c.NumConns
+	}
+	if c.Timeout > 0 {
+		cfg.Timeout = time.Duration(c.Timeout)
+	}
+	if c.ConnectTimeout > 0 {
+		cfg.ConnectTimeout = time.Duration(c.ConnectTimeout)
+	}
+
+	var opts []Option
+	if c.LocalDC != "" {
+		opts = append(opts, WithLocalDC(c.LocalDC))
+	}
+	if c.Consistency != "" {
+		consistency, err := gocql.ParseConsistencyWrapper(c.Consistency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+		cfg.Consistency = consistency
+	}
+	if c.Username != "" {
+		opts = append(opts, WithPasswordAuth(c.Username, c.Password))
+	}
+	if c.MaxConcurrency > 0 {
+		opts = append(opts, WithMaxConcurrency(c.MaxConcurrency))
+	}
+	if c.TLS.Enabled {
+		opts = append(opts, WithTLSFiles(c.TLS))
+	}
+	return cfg, opts, nil
+}
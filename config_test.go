@@ -0,0 +1,154 @@
+package ecql
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecql")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	yml := writeConfig(t, dir, "ecql.yml", `
+hosts: [cassandra-1, cassandra-2]
+port: 9043
+keyspace: app
+consistency: LOCAL_QUORUM
+local_dc: dc1
+username: app
+password: secret
+num_conns: 4
+max_concurrency: 100
+timeout: 5s
+connect_timeout: 500ms
+tls:
+  enabled: true
+  server_name: cassandra
+`)
+	c, err := LoadConfig(yml)
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Hosts:          []string{"cassandra-1", "cassandra-2"},
+		Port:           9043,
+		Keyspace:       "app",
+		Consistency:    "LOCAL_QUORUM",
+		LocalDC:        "dc1",
+		Username:       "app",
+		Password:       "secret",
+		NumConns:       4,
+		MaxConcurrency: 100,
+		Timeout:        Duration(5 * time.Second),
+		ConnectTimeout: Duration(500 * time.Millisecond),
+		TLS:            TLSConfig{Enabled: true, ServerName: "cassandra"},
+	}, c)
+
+	js := writeConfig(t, dir, "ecql.json", `{"hosts":["cassandra-1"],"keyspace":"app","timeout":"2s","tls":{"insecure_skip_verify":true}}`)
+	c, err = LoadConfig(js)
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Hosts:    []string{"cassandra-1"},
+		Keyspace: "app",
+		Timeout:  Duration(2 * time.Second),
+		TLS:      TLSConfig{InsecureSkipVerify: true},
+	}, c)
+
+	_, err = LoadConfig(writeConfig(t, dir, "ecql.toml", `hosts = ["cassandra-1"]`))
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	_, err = LoadConfig(writeConfig(t, dir, "bad.json", `{"timeout":"soon"}`))
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	_, err = LoadConfig(filepath.Join(dir, "missing.yml"))
+	assert.Error(t, err)
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecql")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	env := map[string]string{
+		"ECQL_HOSTS":    "10.0.0.1, 10.0.0.2",
+		"ECQL_PORT":     "9142",
+		"ECQL_PASSWORD": "from-env",
+		"ECQL_TIMEOUT":  "1s",
+		"ECQL_TLS":      "true",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	c, err := LoadConfig(writeConfig(t, dir, "ecql.yaml", "hosts: [cassandra-1]\nkeyspace: app\npassword: secret\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, c.Hosts)
+	assert.Equal(t, 9142, c.Port)
+	assert.Equal(t, "app", c.Keyspace)
+	assert.Equal(t, "from-env", c.Password)
+	assert.Equal(t, Duration(time.Second), c.Timeout)
+	assert.True(t, c.TLS.Enabled)
+
+	c, err = LoadConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, c.Hosts)
+	assert.Empty(t, c.Keyspace)
+
+	os.Setenv("ECQL_PORT", "cql")
+	_, err = LoadConfig("")
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func TestConfigCluster(t *testing.T) {
+	c := Config{
+		Hosts:          []string{"cassandra-1"},
+		Port:           9043,
+		Keyspace:       "app",
+		Consistency:    "local_quorum",
+		LocalDC:        "dc1",
+		Username:       "app",
+		Password:       "secret",
+		ProtoVersion:   4,
+		NumConns:       4,
+		MaxConcurrency: 100,
+		Timeout:        Duration(5 * time.Second),
+		ConnectTimeout: Duration(time.Second),
+		TLS:            TLSConfig{Enabled: true, ServerName: "cassandra"},
+	}
+	cfg, opts, err := c.cluster()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cassandra-1"}, cfg.Hosts)
+	assert.Equal(t, 9043, cfg.Port)
+	assert.Equal(t, "app", cfg.Keyspace)
+	assert.Equal(t, gocql.LocalQuorum, cfg.Consistency)
+	assert.Equal(t, 4, cfg.ProtoVersion)
+	assert.Equal(t, 4, cfg.NumConns)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, time.Second, cfg.ConnectTimeout)
+
+	s := &SessionImpl{cluster: cfg}
+	for _, opt := range opts {
+		opt(s)
+	}
+	assert.Equal(t, gocql.PasswordAuthenticator{Username: "app", Password: "secret"}, cfg.Authenticator)
+	assert.Equal(t, &TLSConfig{Enabled: true, ServerName: "cassandra"}, s.tlsFiles)
+	assert.Equal(t, 100, cap(s.sem))
+
+	_, _, err = Config{}.cluster()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	_, _, err = Config{Hosts: []string{"cassandra-1"}, Consistency: "most"}.cluster()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	_, err = NewSessionFromConfig(Config{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
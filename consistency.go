@@ -0,0 +1,85 @@
+package ecql
+
+import "github.com/gocql/gocql"
+
+// ConsistencyPreset defines the consistency levels used by a session on
+// reads (SELECT) and writes (INSERT, UPDATE, DELETE, and batches). Serial is
+// the serial consistency used on conditional statements, if it is not set
+// the gocql default is used.
+type ConsistencyPreset struct {
+	Read   gocql.Consistency
+	Write  gocql.Consistency
+	Serial gocql.SerialConsistency
+}
+
+var (
+	// LocalQuorumPreset reads and writes with LOCAL_QUORUM and uses
+	// LOCAL_SERIAL on conditional statements. It is the recommended preset
+	// on multi-datacenter deployments.
+	LocalQuorumPreset = ConsistencyPreset{gocql.LocalQuorum, gocql.LocalQuorum, gocql.LocalSerial}
+
+	// LocalOnePreset reads with LOCAL_ONE and writes with LOCAL_QUORUM.
+	LocalOnePreset = ConsistencyPreset{gocql.LocalOne, gocql.LocalQuorum, gocql.LocalSerial}
+
+	// EachQuorumPreset reads with LOCAL_QUORUM and writes with EACH_QUORUM.
+	EachQuorumPreset = ConsistencyPreset{gocql.LocalQuorum, gocql.EachQuorum, gocql.Serial}
+
+	// QuorumPreset reads and writes with QUORUM.
+	QuorumPreset = ConsistencyPreset{gocql.Quorum, gocql.Quorum, gocql.Serial}
+)
+
+// WithConsistency sets the consistency levels used by default on the
+// statements of the session. Statement.Consistency can be used to override
+// them on a specific statement.
+func WithConsistency(p ConsistencyPreset) Option {
+	return func(s *SessionImpl) {
+		s.consistency = &p
+	}
+}
+
+// WithLocalDC configures a session for a multi-datacenter deployment using dc
+// as the local datacenter. It sets a token aware host policy that prefers the
+// hosts in the local datacenter, and the LocalQuorumPreset as the default
+// consistency.
+//
+// The host policy is only set on sessions created with NewSession.
+func WithLocalDC(dc string) Option {
+	return func(s *SessionImpl) {
+		if s.cluster != nil {
+			s.cluster.PoolConfig.HostSelectionPolicy = newTokenAwarePolicy(gocql.DCAwareRoundRobinPolicy(dc))
+			s.cluster.Consistency = gocql.LocalQuorum
+		}
+		WithConsistency(LocalQuorumPreset)(s)
+	}
+}
+
+// isWrite returns if the command modifies data.
+func (c Command) isWrite() bool {
+	switch c {
+	case InsertCmd, UpdateCmd, DeleteCmd, TruncateCmd, DropTableCmd, SchemaCmd:
+		return true
+	default:
+		return false
+	}
+}
+
+// consistencyOf returns the consistency level of the session preset for the
+// given command, or nil if the session does not have a preset.
+func (s *SessionImpl) consistencyOf(cmd Command) *gocql.Consistency {
+	if s.consistency == nil {
+		return nil
+	}
+	c := s.consistency.Read
+	if cmd.isWrite() {
+		c = s.consistency.Write
+	}
+	return &c
+}
+
+// serialConsistency returns the serial consistency of the session preset.
+func (s *SessionImpl) serialConsistency() gocql.SerialConsistency {
+	if s.consistency == nil {
+		return 0
+	}
+	return s.consistency.Serial
+}
@@ -0,0 +1,48 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLocalDC(t *testing.T) {
+	cfg := gocql.NewCluster("127.0.0.1")
+	s := newSessionImpl(cfg, []Option{WithLocalDC("dc1")})
+	assert.NotNil(t, cfg.PoolConfig.HostSelectionPolicy)
+	assert.Equal(t, gocql.LocalQuorum, cfg.Consistency)
+	assert.Equal(t, &LocalQuorumPreset, s.consistency)
+
+	// Without cluster configuration
+	s = newSessionImpl(nil, []Option{WithLocalDC("dc1")})
+	assert.Equal(t, &LocalQuorumPreset, s.consistency)
+}
+
+func TestWithConsistency(t *testing.T) {
+	s := newSessionImpl(nil, []Option{WithConsistency(LocalOnePreset)})
+	assert.Equal(t, gocql.LocalOne, s.consistency.Read)
+	assert.Equal(t, gocql.LocalQuorum, s.consistency.Write)
+
+	stmt := NewStatement(s).Consistency(gocql.All).(*StatementImpl)
+	assert.Equal(t, gocql.All, *stmt.ConsistencyValue)
+}
+
+func TestWithScylla(t *testing.T) {
+	cfg := gocql.NewCluster("127.0.0.1")
+	newSessionImpl(cfg, []Option{WithScylla()})
+	assert.IsType(t, tokenAwarePolicy{}, cfg.PoolConfig.HostSelectionPolicy)
+
+	// Does not wrap token aware policies
+	cfg = gocql.NewCluster("127.0.0.1")
+	newSessionImpl(cfg, []Option{WithLocalDC("dc1")})
+	policy := cfg.PoolConfig.HostSelectionPolicy
+	newSessionImpl(cfg, []Option{WithScylla()})
+	assert.Equal(t, policy, cfg.PoolConfig.HostSelectionPolicy)
+
+	cfg = gocql.NewCluster("127.0.0.1")
+	policy = gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
+	cfg.PoolConfig.HostSelectionPolicy = policy
+	newSessionImpl(cfg, []Option{WithScylla()})
+	assert.Equal(t, policy, cfg.PoolConfig.HostSelectionPolicy)
+}
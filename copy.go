@@ -0,0 +1,85 @@
+package ecql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrCopyTransform is returned by CopyTable if the source and destination
+// types are different and there is no transform function.
+var ErrCopyTransform = errors.New("a transform function is required to copy between different types")
+
+const (
+	// DefaultCopySplits is the default number of token ranges scanned by
+	// CopyTable.
+	DefaultCopySplits = 64
+
+	// DefaultCopyParallelism is the default number of token ranges scanned
+	// at the same time by CopyTable.
+	DefaultCopyParallelism = 4
+)
+
+// CopyConfig contains the configuration of CopyTable.
+type CopyConfig struct {
+	// Destination is the session where the rows are written, it defaults to
+	// the session used to read them. It can be a session of another keyspace
+	// or cluster.
+	Destination Session
+	// Splits is the number of token ranges the source table is split in.
+	Splits int
+	// Parallelism is the number of token ranges scanned at the same time.
+	Parallelism int
+	// Writer is the configuration of the BulkWriter used to write the rows.
+	Writer BulkWriterConfig
+}
+
+// CopyTable copies all the rows of the table of src to the table of dst. The
+// source table is scanned in parallel by token range, and the rows are
+// written using a BulkWriter:
+//
+//	stats, err := sess.CopyTable(EventV1{}, EventV2{}, func(src interface{}) interface{} {
+//		e := src.(*EventV1)
+//		return EventV2{ID: e.ID, Name: strings.ToLower(e.Name)}
+//	}, ecql.CopyConfig{})
+//
+// The transform function receives a pointer to a struct of the type of src
+// and returns the row to write, or nil to skip it. It is called concurrently.
+// If it is nil, src and dst must be of the same type. It returns the
+// statistics of the writer.
+func (s *SessionImpl) CopyTable(src, dst interface{}, transform func(src interface{}) interface{}, config CopyConfig) (BulkStats, error) {
+	t := structOf(src).Type()
+	if transform == nil {
+		if t != structOf(dst).Type() {
+			return BulkStats{}, ErrCopyTransform
+		}
+		transform = func(src interface{}) interface{} { return src }
+	}
+	if config.Destination == nil {
+		config.Destination = s
+	}
+	if config.Splits <= 0 {
+		config.Splits = DefaultCopySplits
+	}
+	if config.Parallelism <= 0 {
+		config.Parallelism = DefaultCopyParallelism
+	}
+
+	w := NewBulkWriter(config.Destination, config.Writer)
+	err := ScanTokenRanges(s, src, config.Splits, config.Parallelism, func(split int, r TokenRange, iter Iter) error {
+		for {
+			v := reflect.New(t).Interface()
+			if !iter.TypeScan(v) {
+				return nil
+			}
+			if row := transform(v); row != nil {
+				if err := w.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+	})
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return w.Stats(), err
+}
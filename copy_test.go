@@ -0,0 +1,49 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type copyStruct struct {
+	ID    string `cql:"id" cqltable:"copytable" cqlkey:"id"`
+	Value int    `cql:"value"`
+}
+
+func TestCopyTable(t *testing.T) {
+	DeleteRegistry()
+	src, sd := newTestSession()
+	sd.result([]string{"f1", "f22"}, []interface{}{"a", 1}, []interface{}{"b", 2}, []interface{}{"c", 3})
+	dst, dd := newTestSession()
+
+	stats, err := src.CopyTable(testStruct{}, copyStruct{}, func(src interface{}) interface{} {
+		ts := src.(*testStruct)
+		if ts.F1 == "b" {
+			return nil
+		}
+		return copyStruct{ID: ts.F1, Value: ts.F2 * 10}
+	}, CopyConfig{Destination: dst, Splits: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Rows)
+
+	assert.Len(t, sd.requests, 1)
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE token(f1) >= ? AND token(f1) <= ?", sd.last().Statement)
+	var values []interface{}
+	for _, b := range dd.batches {
+		for _, e := range b.Entries {
+			assert.Equal(t, "INSERT INTO copytable (id,value) VALUES (?,?)", e.Statement)
+			values = append(values, e.Values...)
+		}
+	}
+	assert.ElementsMatch(t, []interface{}{"a", 10, "c", 30}, values)
+
+	// Same type
+	stats, err = src.CopyTable(testStruct{}, testStruct{}, nil, CopyConfig{Splits: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, stats.Rows)
+	assert.Len(t, sd.batches, 3)
+
+	_, err = src.CopyTable(testStruct{}, copyStruct{}, nil, CopyConfig{})
+	assert.Equal(t, ErrCopyTransform, err)
+}
@@ -0,0 +1,147 @@
+package ecql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// DefaultCredentialsTimeout is the default time a CredentialsAuthenticator
+// waits for the credentials of its provider.
+const DefaultCredentialsTimeout = 10 * time.Second
+
+// Credentials are the username and password used to authenticate the
+// connections of a session. Expires is the time when the credentials expire,
+// it is zero if it is not known.
+type Credentials struct {
+	Username string
+	Password string
+	Expires  time.Time
+}
+
+// CredentialsProvider returns the credentials used to authenticate the
+// connections. The session consults the provider every time it opens a
+// connection, including the reconnections, so the passwords can be rotated
+// without restarting the process.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc is an adapter to use a function as a
+// CredentialsProvider.
+type CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials implements the CredentialsProvider interface.
+func (fn CredentialsProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return fn(ctx)
+}
+
+// CredentialsAuthenticator is a gocql.Authenticator that authenticates like
+// the gocql.PasswordAuthenticator with the credentials of a provider.
+// Timeout limits the time waiting for the provider, it defaults to
+// DefaultCredentialsTimeout.
+type CredentialsAuthenticator struct {
+	Provider              CredentialsProvider
+	AllowedAuthenticators []string
+	Timeout               time.Duration
+}
+
+// Challenge gets the credentials of the provider and responds to the
+// authentication challenge.
+func (a CredentialsAuthenticator) Challenge(req []byte) ([]byte, gocql.Authenticator, error) {
+	timeout := a.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCredentialsTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := a.Provider.Credentials(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ecql: cannot get credentials: %w", err)
+	}
+	return gocql.PasswordAuthenticator{
+		Username:              c.Username,
+		Password:              c.Password,
+		AllowedAuthenticators: a.AllowedAuthenticators,
+	}.Challenge(req)
+}
+
+// Success is called on successful authentication.
+func (a CredentialsAuthenticator) Success(data []byte) error {
+	return nil
+}
+
+// WithCredentialsProvider configures the session to authenticate with the
+// credentials of the given provider. The provider is called when a
+// connection is opened:
+//
+//	sess, err := ecql.NewSession(cfg, ecql.WithCredentialsProvider(ecql.CredentialsProviderFunc(
+//		func(ctx context.Context) (ecql.Credentials, error) {
+//			return ecql.Credentials{Username: "app", Password: os.Getenv("CASSANDRA_PASSWORD")}, nil
+//		},
+//	)))
+//
+// It only has effect on sessions created with NewSession.
+func WithCredentialsProvider(p CredentialsProvider) Option {
+	return WithAuthenticator(CredentialsAuthenticator{Provider: p})
+}
+
+// CachedCredentials is a CredentialsProvider that caches the credentials of
+// another provider. The credentials are cached for TTL, or until
+// RefreshBefore their expiration if they expire before. If the provider
+// fails, the cached credentials are returned until they expire, so a short
+// outage of the secrets store does not prevent the reconnections. Clock
+// defaults to SystemClock.
+type CachedCredentials struct {
+	Provider      CredentialsProvider
+	TTL           time.Duration
+	RefreshBefore time.Duration
+	Clock         Clock
+
+	mu      sync.Mutex
+	creds   *Credentials
+	refresh time.Time
+}
+
+// NewCachedCredentials returns a CachedCredentials that caches the
+// credentials of the given provider for ttl, and refreshes the credentials
+// that expire one minute before.
+func NewCachedCredentials(p CredentialsProvider, ttl time.Duration) *CachedCredentials {
+	return &CachedCredentials{
+		Provider:      p,
+		TTL:           ttl,
+		RefreshBefore: time.Minute,
+	}
+}
+
+// Credentials implements the CredentialsProvider interface.
+func (c *CachedCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := nowOf(c.Clock)
+	if c.creds != nil && t.Before(c.refresh) {
+		return *c.creds, nil
+	}
+
+	creds, err := c.Provider.Credentials(ctx)
+	if err != nil {
+		if c.creds != nil && (c.creds.Expires.IsZero() || t.Before(c.creds.Expires)) {
+			return *c.creds, nil
+		}
+		return Credentials{}, err
+	}
+
+	c.creds = &creds
+	c.refresh = t.Add(c.TTL)
+	if !creds.Expires.IsZero() {
+		if r := creds.Expires.Add(-c.RefreshBefore); r.Before(c.refresh) {
+			c.refresh = r
+		}
+	}
+	return creds, nil
+}
@@ -0,0 +1,113 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPasswordAuthenticator = "org.apache.cassandra.auth.PasswordAuthenticator"
+
+func TestCredentialsAuthenticator(t *testing.T) {
+	var calls int
+	password := "pass1"
+	auth := CredentialsAuthenticator{Provider: CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		calls++
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(DefaultCredentialsTimeout), deadline, time.Second)
+		return Credentials{Username: "user", Password: password}, nil
+	})}
+
+	resp, next, err := auth.Challenge([]byte(testPasswordAuthenticator))
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, []byte("\x00user\x00pass1"), resp)
+
+	// Rotated password
+	password = "pass2"
+	resp, _, err = auth.Challenge([]byte(testPasswordAuthenticator))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("\x00user\x00pass2"), resp)
+	assert.Equal(t, 2, calls)
+	assert.NoError(t, auth.Success(nil))
+
+	_, _, err = auth.Challenge([]byte("com.example.Authenticator"))
+	assert.Error(t, err)
+
+	auth.AllowedAuthenticators = []string{"com.example.Authenticator"}
+	_, _, err = auth.Challenge([]byte("com.example.Authenticator"))
+	assert.NoError(t, err)
+
+	errVault := errors.New("vault sealed")
+	auth = CredentialsAuthenticator{Provider: CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		return Credentials{}, errVault
+	})}
+	_, _, err = auth.Challenge([]byte(testPasswordAuthenticator))
+	assert.True(t, errors.Is(err, errVault))
+	assert.Equal(t, "ecql: cannot get credentials: vault sealed", err.Error())
+}
+
+func TestWithCredentialsProvider(t *testing.T) {
+	p := CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		return Credentials{Username: "user", Password: "pass"}, nil
+	})
+	cfg := gocql.NewCluster("127.0.0.1")
+	newSessionImpl(cfg, []Option{WithCredentialsProvider(p)})
+	if assert.IsType(t, CredentialsAuthenticator{}, cfg.Authenticator) {
+		assert.NotNil(t, cfg.Authenticator.(CredentialsAuthenticator).Provider)
+	}
+}
+
+func TestCachedCredentials(t *testing.T) {
+	var calls int
+	var err error
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := now.Add(10 * time.Minute)
+	c := NewCachedCredentials(CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		calls++
+		if err != nil {
+			return Credentials{}, err
+		}
+		return Credentials{Username: "user", Password: fmt.Sprintf("pass%d", calls), Expires: expires}, nil
+	}), time.Hour)
+	c.Clock = ClockFunc(func() time.Time { return now })
+
+	creds, e := c.Credentials(context.Background())
+	assert.NoError(t, e)
+	assert.Equal(t, "pass1", creds.Password)
+
+	now = now.Add(8 * time.Minute)
+	creds, _ = c.Credentials(context.Background())
+	assert.Equal(t, "pass1", creds.Password)
+	assert.Equal(t, 1, calls)
+
+	// Refreshed one minute before the expiration
+	now = now.Add(time.Minute)
+	expires = now.Add(2 * time.Hour)
+	creds, _ = c.Credentials(context.Background())
+	assert.Equal(t, "pass2", creds.Password)
+
+	// Cached for TTL
+	now = now.Add(59 * time.Minute)
+	creds, _ = c.Credentials(context.Background())
+	assert.Equal(t, "pass2", creds.Password)
+	assert.Equal(t, 2, calls)
+
+	// Cached credentials are used while they are valid
+	now = now.Add(time.Minute)
+	err = errors.New("unavailable")
+	creds, e = c.Credentials(context.Background())
+	assert.NoError(t, e)
+	assert.Equal(t, "pass2", creds.Password)
+	assert.Equal(t, 3, calls)
+
+	now = now.Add(time.Hour)
+	_, e = c.Credentials(context.Background())
+	assert.Equal(t, err, e)
+}
@@ -0,0 +1,312 @@
+package ecql
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDiagnosticsWindow is the default time window of the statistics
+	// collected by Diagnostics.
+	DefaultDiagnosticsWindow = time.Minute
+
+	// DefaultDiagnosticsTopN is the default number of partitions and results
+	// reported per table.
+	DefaultDiagnosticsTopN = 10
+)
+
+// DiagnosticsConfig contains the configuration of Diagnostics.
+type DiagnosticsConfig struct {
+	// Window is the duration of the time window, the statistics are reset
+	// when the window expires.
+	Window time.Duration
+	// SampleRate is the fraction of the requests sampled, between 0 and 1.
+	// It defaults to 1, all the requests are sampled.
+	SampleRate float64
+	// TopN is the number of partitions and results reported per table.
+	TopN int
+	// Clock is the source of the current time, it defaults to SystemClock.
+	Clock Clock
+}
+
+// Diagnostics samples the requests executed by a Session and keeps track of
+// the hottest partitions and largest results of each table. It is enabled
+// using its middleware:
+//
+//	diag := ecql.NewDiagnostics(ecql.DiagnosticsConfig{SampleRate: 0.1})
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(diag.Middleware()))
+//
+// The partitions are identified by the Murmur3 token of the partition key,
+// the statements without a known partition key are only counted.
+type Diagnostics struct {
+	config DiagnosticsConfig
+	mu     sync.Mutex
+	start  time.Time
+	tables map[string]*tableStats
+}
+
+// TableDiagnostics is the report of a table in the current time window.
+type TableDiagnostics struct {
+	Table string
+	// Requests is the number of requests sampled.
+	Requests int
+	// HotPartitions are the partitions with more requests, in descending
+	// order.
+	HotPartitions []PartitionStats
+	// LargestResults are the requests with the largest results, in
+	// descending order of bytes.
+	LargestResults []ResultStats
+}
+
+// PartitionStats contains the number of requests sampled on a partition.
+type PartitionStats struct {
+	Token    int64
+	Requests int
+}
+
+// ResultStats contains the size of the result of a request. Bytes is an
+// estimation of the size of the values scanned.
+type ResultStats struct {
+	Statement string
+	Token     int64
+	Rows      int
+	Bytes     int
+}
+
+type tableStats struct {
+	requests   int
+	partitions map[int64]int
+	results    []ResultStats
+}
+
+// NewDiagnostics creates a new Diagnostics with the given configuration.
+func NewDiagnostics(config DiagnosticsConfig) *Diagnostics {
+	if config.Window <= 0 {
+		config.Window = DefaultDiagnosticsWindow
+	}
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		config.SampleRate = 1
+	}
+	if config.TopN <= 0 {
+		config.TopN = DefaultDiagnosticsTopN
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock
+	}
+	return &Diagnostics{
+		config: config,
+		start:  config.Clock.Now(),
+		tables: make(map[string]*tableStats),
+	}
+}
+
+// Middleware returns the middleware that samples the requests.
+func (d *Diagnostics) Middleware() Middleware {
+	return func(next Driver) Driver {
+		return &diagnosticsDriver{Driver: next, diag: d}
+	}
+}
+
+// Report returns the statistics of each table in the current time window
+// sorted by table name.
+func (d *Diagnostics) Report() []TableDiagnostics {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rotate()
+
+	report := make([]TableDiagnostics, 0, len(d.tables))
+	for name, ts := range d.tables {
+		td := TableDiagnostics{
+			Table:          name,
+			Requests:       ts.requests,
+			LargestResults: append([]ResultStats(nil), ts.results...),
+		}
+		for token, n := range ts.partitions {
+			td.HotPartitions = append(td.HotPartitions, PartitionStats{Token: token, Requests: n})
+		}
+		sort.Slice(td.HotPartitions, func(i, j int) bool {
+			a, b := td.HotPartitions[i], td.HotPartitions[j]
+			return a.Requests > b.Requests || (a.Requests == b.Requests && a.Token < b.Token)
+		})
+		if len(td.HotPartitions) > d.config.TopN {
+			td.HotPartitions = td.HotPartitions[:d.config.TopN]
+		}
+		report = append(report, td)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Table < report[j].Table
+	})
+	return report
+}
+
+// Reset removes the statistics and starts a new time window.
+func (d *Diagnostics) Reset() {
+	d.mu.Lock()
+	d.start = d.config.Clock.Now()
+	d.tables = make(map[string]*tableStats)
+	d.mu.Unlock()
+}
+
+// rotate resets the statistics if the time window has expired.
+func (d *Diagnostics) rotate() {
+	if now := d.config.Clock.Now(); now.Sub(d.start) >= d.config.Window {
+		d.start = now
+		d.tables = make(map[string]*tableStats)
+	}
+}
+
+// sample returns true if the request must be sampled.
+func (d *Diagnostics) sample() bool {
+	return d.config.SampleRate >= 1 || rand.Float64() < d.config.SampleRate
+}
+
+// record adds a sampled request. The token is only used if hasToken is true.
+func (d *Diagnostics) record(req *Request, token int64, hasToken bool, rows, size int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rotate()
+
+	ts, ok := d.tables[req.Table]
+	if !ok {
+		ts = &tableStats{partitions: make(map[int64]int)}
+		d.tables[req.Table] = ts
+	}
+	ts.requests++
+	if hasToken {
+		ts.partitions[token]++
+	}
+	if req.Command != SelectCmd || rows == 0 {
+		return
+	}
+
+	// Keep the top N results sorted by size
+	i := sort.Search(len(ts.results), func(i int) bool {
+		return ts.results[i].Bytes < size
+	})
+	if i >= d.config.TopN {
+		return
+	}
+	ts.results = append(ts.results, ResultStats{})
+	copy(ts.results[i+1:], ts.results[i:])
+	ts.results[i] = ResultStats{Statement: req.Statement, Token: token, Rows: rows, Bytes: size}
+	if len(ts.results) > d.config.TopN {
+		ts.results = ts.results[:d.config.TopN]
+	}
+}
+
+// requestToken returns the token of the partition of the request.
+func requestToken(req *Request) (int64, bool) {
+	key := req.RoutingKey
+	if key == nil && req.PartitionKey != nil {
+		var err error
+		if key, err = routingKey(req.PartitionKey); err != nil {
+			return 0, false
+		}
+	}
+	if key == nil {
+		return 0, false
+	}
+	return murmur3H1(key), true
+}
+
+// diagnosticsDriver is the Driver used by the Diagnostics middleware.
+type diagnosticsDriver struct {
+	Driver
+	diag *Diagnostics
+}
+
+func (d *diagnosticsDriver) Iter(req *Request) Rows {
+	rows := d.Driver.Iter(req)
+	if !d.diag.sample() {
+		return rows
+	}
+	return &diagnosticsRows{Rows: rows, req: req, diag: d.diag}
+}
+
+// diagnosticsRows counts the rows and bytes scanned, and records the request
+// when the rows are closed.
+type diagnosticsRows struct {
+	Rows
+	req    *Request
+	diag   *Diagnostics
+	rows   int
+	size   int
+	closed bool
+}
+
+func (r *diagnosticsRows) Scan(dest ...interface{}) bool {
+	if !r.Rows.Scan(dest...) {
+		return false
+	}
+	r.rows++
+	for _, v := range dest {
+		r.size += sizeOf(reflect.ValueOf(v))
+	}
+	return true
+}
+
+func (r *diagnosticsRows) MapScan(m map[string]interface{}) bool {
+	if !r.Rows.MapScan(m) {
+		return false
+	}
+	r.rows++
+	for _, v := range m {
+		r.size += sizeOf(reflect.ValueOf(v))
+	}
+	return true
+}
+
+func (r *diagnosticsRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		token, ok := requestToken(r.req)
+		r.diag.record(r.req, token, ok, r.rows, r.size)
+	}
+	return err
+}
+
+// sizeOf returns an estimation of the serialized size of v.
+func sizeOf(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return 0
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return sizeOf(v.Elem())
+	case reflect.String:
+		return v.Len()
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Len()
+		}
+		n := 0
+		for i := 0; i < v.Len(); i++ {
+			n += sizeOf(v.Index(i))
+		}
+		return n
+	case reflect.Map:
+		n := 0
+		iter := v.MapRange()
+		for iter.Next() {
+			n += sizeOf(iter.Key()) + sizeOf(iter.Value())
+		}
+		return n
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return 8
+		}
+		n := 0
+		for i := 0; i < v.NumField(); i++ {
+			n += sizeOf(v.Field(i))
+		}
+		return n
+	default:
+		return int(v.Type().Size())
+	}
+}
@@ -0,0 +1,76 @@
+package ecql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	DeleteRegistry()
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next Driver) Driver {
+			return &middlewareDriver{Driver: next, fn: func(req *Request) {
+				calls = append(calls, name+":"+req.Table)
+			}}
+		}
+	}
+
+	sess, d := newTestSession(WithMiddleware(mw("a"), mw("b")))
+	assert.NoError(t, sess.Set(testStruct{F1: "foo"}))
+	assert.Equal(t, []string{"a:mytable", "b:mytable"}, calls)
+	assert.Equal(t, []interface{}{"foo"}, d.last().PartitionKey)
+
+	d.result([]string{"f1"}, []interface{}{"foo"})
+	var ts testStruct
+	assert.NoError(t, sess.Select(&ts).Where(Gt("f22", 1), Eq("f1", "bar")).TypeScan())
+	assert.Equal(t, []interface{}{"bar"}, d.last().PartitionKey)
+	assert.NoError(t, sess.Select(&ts).Where(Gt("f22", 1)).AllowFiltering().TypeScan())
+	assert.Nil(t, d.last().PartitionKey)
+}
+
+type middlewareDriver struct {
+	Driver
+	fn func(req *Request)
+}
+
+func (d *middlewareDriver) Iter(req *Request) Rows {
+	d.fn(req)
+	return d.Driver.Iter(req)
+}
+
+func TestDiagnostics(t *testing.T) {
+	DeleteRegistry()
+	now := time.Now()
+
+	diag := NewDiagnostics(DiagnosticsConfig{TopN: 2, Clock: ClockFunc(func() time.Time { return now })})
+	sess, d := newTestSession(WithMiddleware(diag.Middleware()))
+
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 1})
+	var ts testStruct
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, sess.Get(&ts, "foo"))
+	}
+	assert.NoError(t, sess.Get(&ts, "bar"))
+	assert.NoError(t, sess.Set(testStruct{F1: "bar"}))
+	d.result([]string{"f1", "f22"}, []interface{}{"a", 1}, []interface{}{"b", 2}, []interface{}{"c", 3})
+	assert.NoError(t, sess.Select(&ts).Iter().ForEach(&ts, func() error { return nil }))
+
+	fooToken, _ := Token(testStruct{F1: "foo"})
+	barToken, _ := Token(testStruct{F1: "bar"})
+	report := diag.Report()
+	assert.Len(t, report, 1)
+	assert.Equal(t, "mytable", report[0].Table)
+	assert.Equal(t, 6, report[0].Requests)
+	assert.Equal(t, []PartitionStats{{fooToken, 3}, {barToken, 2}}, report[0].HotPartitions)
+	assert.Len(t, report[0].LargestResults, 2)
+	assert.Equal(t, 3, report[0].LargestResults[0].Rows)
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable", report[0].LargestResults[0].Statement)
+	assert.Equal(t, ResultStats{"SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ?", fooToken, 1, 11}, report[0].LargestResults[1])
+
+	// New window
+	now = now.Add(DefaultDiagnosticsWindow)
+	assert.Empty(t, diag.Report())
+}
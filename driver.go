@@ -0,0 +1,356 @@
+package ecql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Driver is the backend used by a Session to execute the statements. It
+// decouples the statements from gocql, so alternative backends, like the
+// scylladb fork of gocql, a mock, or a different native driver, can be used
+// without changes in the application code.
+//
+// NewGocqlDriver returns the default implementation.
+type Driver interface {
+	// Iter executes the request and returns an iterator over the result rows.
+	Iter(req *Request) Rows
+	// ExecBatch executes the batch.
+	ExecBatch(b *BatchRequest) error
+	// ExecBatchCAS executes a batch with conditional statements, if the batch
+	// is not applied the current values are set in dest.
+	ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error)
+	// Close closes the driver.
+	Close()
+}
+
+// Rows is the iterator over the rows returned by a Driver. Its methods have
+// the same semantics than the ones in gocql.Iter.
+type Rows interface {
+	Columns() []gocql.ColumnInfo
+	Scan(dest ...interface{}) bool
+	MapScan(m map[string]interface{}) bool
+	NumRows() int
+	WillSwitchPage() bool
+	PageState() []byte
+	// Info returns the information about the execution, it is only
+	// complete after the rows are closed.
+	Info() QueryInfo
+	Close() error
+}
+
+// Request contains a CQL statement and the options used to execute it.
+// PartitionKey contains the values of the partition key columns when they are
+// known, for example on statements built from structs or with equality
+// conditions on those columns. Idempotent is set if the request can be
+// safely retried, and Retry is the retry policy of the statement if it
+// overrides the one of the session. Priority is used by the Scheduler, and
+// Cluster is the name of the session used by a Router. Row contains the
+// values bound to the columns of the writes when they are known, and the
+// key columns of the deletes. Timeout, if set, is the deadline of the
+// request, see WithTimeout. Payload is the custom payload sent with the
+// request, and Annotations are the annotations of its context, see Annotate
+// and WithContextHook.
+type Request struct {
+	Context           context.Context
+	Command           Command
+	Table             string
+	Statement         string
+	Values            []interface{}
+	Consistency       *gocql.Consistency
+	SerialConsistency gocql.SerialConsistency
+	RoutingKey        []byte
+	PartitionKey      []interface{}
+	DC                string
+	Idempotent        bool
+	Retry             *RetryPolicy
+	Priority          Priority
+	Cluster           string
+	Row               map[string]interface{}
+	Timeout           time.Duration
+	Payload           map[string][]byte
+	Annotations       map[string]string
+	cache             *cacheLookup
+	drainHeld         bool
+}
+
+// BatchRequest contains the statements of a batch and the options used to
+// execute it.
+type BatchRequest struct {
+	Context           context.Context
+	Type              gocql.BatchType
+	Entries           []Request
+	Consistency       *gocql.Consistency
+	SerialConsistency gocql.SerialConsistency
+	Timeout           time.Duration
+	Payload           map[string][]byte
+	Annotations       map[string]string
+}
+
+// WithDriver sets the driver used by the session to execute statements.
+func WithDriver(d Driver) Option {
+	return func(s *SessionImpl) {
+		s.driver = d
+	}
+}
+
+// Middleware wraps the Driver of a Session, it allows to observe or modify
+// all the requests executed by the session, for example to collect metrics,
+// log statements, or retry failed requests.
+type Middleware func(next Driver) Driver
+
+// WithMiddleware adds middlewares to the driver of the session. The first
+// middleware is the outermost one, it receives the requests first.
+func WithMiddleware(m ...Middleware) Option {
+	return func(s *SessionImpl) {
+		s.middlewares = append(s.middlewares, m...)
+	}
+}
+
+// initDriver wraps the driver of the session to return QueryErrors, retry
+// the failed requests, and with the configured middlewares and policy. The
+// limits are checked and the timeouts set before all of them, after setting
+// the values derived from the context, and the requests after Shutdown are
+// rejected first.
+func (s *SessionImpl) initDriver() {
+	s.base = s.driver
+	if s.cache != nil {
+		s.driver = cacheDriver{Driver: s.driver, cache: s.cache, versions: s.versions}
+	}
+	s.driver = retryDriver{Driver: errorsDriver{s.driver}, policy: s.retry}
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		s.driver = s.middlewares[i](s.driver)
+	}
+	if s.policy != nil {
+		s.driver = policyDriver{Driver: s.driver, policy: s.policy}
+	}
+	if s.limits != (Limits{}) {
+		s.driver = limitsDriver{Driver: s.driver, limits: s.limits}
+	}
+	s.driver = timeoutDriver{s.driver}
+	s.driver = contextDriver{Driver: s.driver, hooks: s.ctxHooks}
+	s.driver = shutdownDriver{Driver: s.driver, drain: s.drain}
+	s.middlewares = nil
+}
+
+// getDriver returns the driver of the session. The sessions created without
+// a constructor, like &SessionImpl{Session: s}, use a gocql driver with the
+// default options.
+func (s *SessionImpl) getDriver() Driver {
+	if s.driver != nil {
+		return s.driver
+	}
+	sess := *s
+	sess.driver = NewGocqlDriver(s.Session)
+	sess.initDriver()
+	return sess.driver
+}
+
+// baseDriver returns the driver of the session without the middlewares, the
+// cache nor the retries.
+func (s *SessionImpl) baseDriver() Driver {
+	if s.base != nil {
+		return s.base
+	}
+	return NewGocqlDriver(s.Session)
+}
+
+// NewWithDriver creates a Session that executes the statements using the
+// given driver. The Query method of the session is not available on sessions
+// without a gocql.Session.
+func NewWithDriver(d Driver, opts ...Option) Session {
+	sess := newSessionImpl(nil, append([]Option{WithDriver(d)}, opts...))
+	sess.initDriver()
+	return sess
+}
+
+// errorsDriver wraps the errors returned by a Driver into QueryErrors.
+type errorsDriver struct {
+	Driver
+}
+
+func (d errorsDriver) Iter(req *Request) Rows {
+	return &errorsRows{Rows: d.Driver.Iter(req), req: req}
+}
+
+func (d errorsDriver) ExecBatch(b *BatchRequest) error {
+	return wrapBatchError(d.Driver.ExecBatch(b), b)
+}
+
+func (d errorsDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	applied, err := d.Driver.ExecBatchCAS(b, dest)
+	return applied, wrapBatchError(err, b)
+}
+
+// errorsRows wraps the error returned by Close into a QueryError.
+type errorsRows struct {
+	Rows
+	req *Request
+}
+
+func (r *errorsRows) Close() error {
+	return wrapError(r.Rows.Close(), r.req)
+}
+
+// idempotent returns true if all the statements of the batch are idempotent.
+func (b *BatchRequest) idempotent() bool {
+	if b.Type == gocql.CounterBatch {
+		return false
+	}
+	for i := range b.Entries {
+		if !b.Entries[i].Idempotent {
+			return false
+		}
+	}
+	return true
+}
+
+// cql returns the statements of the batch.
+func (b *BatchRequest) cql() string {
+	stmts := make([]string, len(b.Entries))
+	for i := range b.Entries {
+		stmts[i] = b.Entries[i].Statement
+	}
+	return "BEGIN BATCH " + strings.Join(stmts, "; ") + " APPLY BATCH"
+}
+
+// gocqlDriver is the Driver implementation using a gocql.Session.
+type gocqlDriver struct {
+	session *gocql.Session
+}
+
+// NewGocqlDriver returns a Driver that uses the given gocql.Session.
+func NewGocqlDriver(s *gocql.Session) Driver {
+	return &gocqlDriver{session: s}
+}
+
+func (d *gocqlDriver) query(req *Request) *gocql.Query {
+	query := d.session.Query(req.Statement, req.Values...)
+	if req.Context != nil {
+		query = query.WithContext(req.Context)
+	}
+	if req.Consistency != nil {
+		query.Consistency(*req.Consistency)
+	}
+	if req.SerialConsistency != 0 {
+		query.SerialConsistency(req.SerialConsistency)
+	}
+	if req.RoutingKey != nil {
+		query.RoutingKey(req.RoutingKey)
+	}
+	if req.Payload != nil {
+		query.CustomPayload(req.Payload)
+	}
+	if req.DC != "" {
+		query = query.WithContext(context.WithValue(query.Context(), dcContextKey{}, req.DC))
+	}
+	return query
+}
+
+func (d *gocqlDriver) Iter(req *Request) Rows {
+	query := d.query(req)
+	return &gocqlRows{Iter: query.Iter(), query: query}
+}
+
+func (d *gocqlDriver) batch(b *BatchRequest) *gocql.Batch {
+	batch := d.session.NewBatch(b.Type)
+	if b.Context != nil {
+		batch = batch.WithContext(b.Context)
+	}
+	if b.Consistency != nil {
+		batch.SetConsistency(*b.Consistency)
+	}
+	if b.SerialConsistency != 0 {
+		batch.SerialConsistency(b.SerialConsistency)
+	}
+	if b.Payload != nil {
+		batch.CustomPayload = b.Payload
+	}
+	for i := range b.Entries {
+		batch.Query(b.Entries[i].Statement, b.Entries[i].Values...)
+	}
+	return batch
+}
+
+func (d *gocqlDriver) ExecBatch(b *BatchRequest) error {
+	return d.session.ExecuteBatch(d.batch(b))
+}
+
+func (d *gocqlDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	applied, iter, err := d.session.MapExecuteBatchCAS(d.batch(b), dest)
+	if iter != nil {
+		iter.Close()
+	}
+	return applied, err
+}
+
+func (d *gocqlDriver) Close() {
+	d.session.Close()
+}
+
+// gocqlRows implements Rows using a gocql.Iter.
+type gocqlRows struct {
+	*gocql.Iter
+	query    *gocql.Query
+	warnings []string
+}
+
+func (r *gocqlRows) Info() QueryInfo {
+	warnings := r.warnings
+	if warnings == nil {
+		warnings = r.Iter.Warnings()
+	}
+	return QueryInfo{
+		Attempts:    r.query.Attempts(),
+		Latency:     time.Duration(r.query.Latency()),
+		Consistency: r.query.GetConsistency(),
+		Rows:        r.NumRows(),
+		Warnings:    warnings,
+	}
+}
+
+// Close keeps the warnings of the last page, they are not available in the
+// closed iterators.
+func (r *gocqlRows) Close() error {
+	if w := r.Iter.Warnings(); w != nil {
+		r.warnings = w
+	}
+	return r.Iter.Close()
+}
+
+// scanRow scans the first row into dest and closes rows. It returns
+// ErrNotFound if there are no rows.
+func scanRow(rows Rows, dest ...interface{}) error {
+	if !rows.Scan(dest...) {
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	return rows.Close()
+}
+
+// mapScanRow scans the first row into m and closes rows. It returns
+// ErrNotFound if there are no rows.
+func mapScanRow(rows Rows, m map[string]interface{}) error {
+	if !rows.MapScan(m) {
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	return rows.Close()
+}
+
+// mapScanCAS scans the result of a conditional statement into m, and returns
+// if the statement was applied.
+func mapScanCAS(rows Rows, m map[string]interface{}) (bool, error) {
+	if err := mapScanRow(rows, m); err != nil {
+		return false, err
+	}
+	applied, _ := m["[applied]"].(bool)
+	delete(m, "[applied]")
+	return applied, nil
+}
@@ -0,0 +1,203 @@
+package ecql
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDriver is a Driver that records the requests and returns the
+// configured rows.
+type testDriver struct {
+	mu       sync.Mutex
+	requests []*Request
+	batches  []*BatchRequest
+	columns  []string
+	rows     [][]interface{}
+	err      error
+	warnings []string
+}
+
+func newTestSession(opts ...Option) (*SessionImpl, *testDriver) {
+	d := &testDriver{}
+	return NewWithDriver(d, opts...).(*SessionImpl), d
+}
+
+func (d *testDriver) result(columns []string, rows ...[]interface{}) {
+	d.columns = columns
+	d.rows = rows
+}
+
+func (d *testDriver) last() *Request {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.requests) == 0 {
+		return nil
+	}
+	return d.requests[len(d.requests)-1]
+}
+
+func (d *testDriver) Iter(req *Request) Rows {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = append(d.requests, req)
+	return &testRows{columns: d.columns, rows: d.rows, err: d.err, warnings: d.warnings}
+}
+
+func (d *testDriver) ExecBatch(b *BatchRequest) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.batches = append(d.batches, b)
+	return d.err
+}
+
+func (d *testDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.batches = append(d.batches, b)
+	return d.err == nil, d.err
+}
+
+func (d *testDriver) Close() {}
+
+type testRows struct {
+	columns  []string
+	rows     [][]interface{}
+	pos      int
+	err      error
+	warnings []string
+}
+
+func (r *testRows) Columns() []gocql.ColumnInfo {
+	cols := make([]gocql.ColumnInfo, len(r.columns))
+	for i := range r.columns {
+		cols[i].Name = r.columns[i]
+	}
+	return cols
+}
+
+func (r *testRows) Scan(dest ...interface{}) bool {
+	if r.err != nil || r.pos >= len(r.rows) {
+		return false
+	}
+	row := r.rows[r.pos]
+	r.pos++
+	for i := range dest {
+		if i < len(row) && row[i] != nil {
+			reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(row[i]))
+		}
+	}
+	return true
+}
+
+func (r *testRows) MapScan(m map[string]interface{}) bool {
+	if r.err != nil || r.pos >= len(r.rows) {
+		return false
+	}
+	row := r.rows[r.pos]
+	r.pos++
+	for i, col := range r.columns {
+		if dest, ok := m[col]; ok && row[i] != nil {
+			if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && v.Elem().Type() == reflect.TypeOf(row[i]) {
+				v.Elem().Set(reflect.ValueOf(row[i]))
+			}
+		}
+		m[col] = row[i]
+	}
+	return true
+}
+
+func (r *testRows) NumRows() int         { return len(r.rows) }
+func (r *testRows) WillSwitchPage() bool { return false }
+func (r *testRows) PageState() []byte    { return nil }
+func (r *testRows) Info() QueryInfo {
+	return QueryInfo{Attempts: 1, Rows: len(r.rows), Warnings: r.warnings}
+}
+func (r *testRows) Close() error { return r.err }
+
+func TestDriverStatements(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithConsistency(LocalOnePreset))
+
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 123})
+	var ts testStruct
+	assert.NoError(t, sess.Select(&ts).Where(Eq("f1", "foo")).TypeScan())
+	assert.Equal(t, "foo", ts.F1)
+	assert.Equal(t, 123, ts.F2)
+	req := d.last()
+	assert.Equal(t, SelectCmd, req.Command)
+	assert.Equal(t, "mytable", req.Table)
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ?", req.Statement)
+	assert.Equal(t, []interface{}{"foo"}, req.Values)
+	assert.Equal(t, gocql.LocalOne, *req.Consistency)
+	assert.Equal(t, gocql.LocalSerial, req.SerialConsistency)
+
+	d.result(nil)
+	assert.Equal(t, ErrNotFound, sess.Get(&ts, "bar"))
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ?", d.last().Statement)
+
+	assert.NoError(t, sess.Insert(ts).Consistency(gocql.All).Exec())
+	req = d.last()
+	assert.Equal(t, InsertCmd, req.Command)
+	assert.Equal(t, gocql.All, *req.Consistency)
+
+	d.result([]string{"[applied]"}, []interface{}{false})
+	assert.Equal(t, ErrNotFound, sess.Update(ts).Set("f22", 1).IfExists().Exec())
+	assert.Equal(t, gocql.LocalQuorum, *d.last().Consistency)
+
+	d.result([]string{"count"}, []interface{}{1})
+	ok, err := sess.Exists(ts)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, sess.Batch().Add(sess.Insert(ts), sess.Delete(ts)).Apply())
+	assert.Len(t, d.batches, 1)
+	assert.Len(t, d.batches[0].Entries, 2)
+	assert.Equal(t, gocql.LoggedBatch, d.batches[0].Type)
+	assert.Equal(t, gocql.LocalQuorum, *d.batches[0].Consistency)
+}
+
+func TestTruncateAndDropTable(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithConsistency(LocalOnePreset))
+
+	assert.NoError(t, sess.Truncate(testStruct{}))
+	assert.Equal(t, TruncateCmd, d.last().Command)
+	assert.Equal(t, "TRUNCATE mytable", d.last().Statement)
+	assert.Equal(t, gocql.LocalQuorum, *d.last().Consistency)
+
+	assert.NoError(t, sess.DropTable(&testStruct{}))
+	assert.Equal(t, DropTableCmd, d.last().Command)
+	assert.Equal(t, "DROP TABLE IF EXISTS mytable", d.last().Statement)
+}
+
+func TestDriverIter(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 1}, []interface{}{"bar", 2})
+	var ts testStruct
+	var names []string
+	iter := sess.Select(&ts).Iter()
+	for iter.TypeScan(&ts) {
+		names = append(names, ts.F1)
+	}
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, []string{"foo", "bar"}, names)
+	assert.Nil(t, d.last().Consistency)
+}
+
+func TestSessionLiteral(t *testing.T) {
+	// Sessions created without a constructor
+	s := &SessionImpl{}
+	assert.IsType(t, shutdownDriver{}, s.getDriver())
+	assert.Nil(t, s.driver)
+	assert.NoError(t, s.async(func(*SessionImpl) error { return nil }).Wait())
+
+	d := &testDriver{}
+	s = &SessionImpl{driver: d}
+	assert.Equal(t, d, s.getDriver())
+}
@@ -0,0 +1,291 @@
+// Package dump exports and imports tables to and from CSV and JSON Lines
+// files, it is a lightweight replacement of the cqlsh COPY command. Tables
+// can also be exported to Parquet files for analytics:
+//
+//	f, err := os.Create("events.csv")
+//	// ...
+//	err = dump.Export(sess, Event{}, f, dump.CSV)
+//
+//	f, err := os.Open("events.csv")
+//	// ...
+//	stats, err := dump.Import(sess, f, Event{}, dump.CSV)
+//
+// Only the columns mapped to struct fields are exported. In CSV files the
+// first line contains the column names, strings are written as they are and
+// the other values are encoded as JSON, with the quotes of JSON strings
+// removed. Empty cells and JSON nulls are NULL values.
+package dump
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/maraino/ecql"
+)
+
+// Format is the format of a dump.
+type Format int
+
+const (
+	// CSV is the comma-separated values format with a header line.
+	CSV Format = iota
+	// JSONL is the JSON Lines format, one JSON object per row.
+	JSONL
+)
+
+// ErrInvalidFormat is returned when the format is not supported.
+var ErrInvalidFormat = errors.New("invalid dump format")
+
+// Export writes all the rows of the table of i to w in the given format.
+func Export(sess ecql.Session, i interface{}, w io.Writer, format Format) error {
+	if format != CSV && format != JSONL {
+		return ErrInvalidFormat
+	}
+
+	t := structType(i)
+	columns := ecql.TableOf(sess, i).Columns
+	names := make([]string, len(columns))
+	for n, col := range columns {
+		names[n] = col.Name
+	}
+
+	var cw *csv.Writer
+	enc := json.NewEncoder(w)
+	if format == CSV {
+		cw = csv.NewWriter(w)
+		if err := cw.Write(names); err != nil {
+			return err
+		}
+	}
+
+	iter := sess.Select(i).Iter()
+	v := reflect.New(t)
+	for iter.TypeScan(v.Interface()) {
+		var err error
+		if format == CSV {
+			record := make([]string, len(columns))
+			for n, col := range columns {
+				if record[n], err = formatCell(fieldOf(v.Elem(), col.Position, false)); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = cw.Write(record)
+			}
+		} else {
+			row := make(map[string]interface{}, len(columns))
+			for _, col := range columns {
+				if f := fieldOf(v.Elem(), col.Position, false); f.IsValid() {
+					row[col.Name] = f.Interface()
+				} else {
+					row[col.Name] = nil
+				}
+			}
+			err = enc.Encode(row)
+		}
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		v = reflect.New(t)
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	if cw != nil {
+		cw.Flush()
+		return cw.Error()
+	}
+	return nil
+}
+
+// Import reads the rows in r in the given format and writes them in the
+// table of i using an ecql.BulkWriter with the default configuration. It
+// returns the statistics of the writer.
+func Import(sess ecql.Session, r io.Reader, i interface{}, format Format) (ecql.BulkStats, error) {
+	return ImportWith(sess, r, i, format, ecql.BulkWriterConfig{})
+}
+
+// ImportWith is like Import but it uses the given configuration on the
+// ecql.BulkWriter.
+func ImportWith(sess ecql.Session, r io.Reader, i interface{}, format Format, config ecql.BulkWriterConfig) (ecql.BulkStats, error) {
+	var next func() (interface{}, error)
+	switch format {
+	case CSV:
+		next = csvReader(r, i, columnsOf(sess, i))
+	case JSONL:
+		next = jsonlReader(r, i, columnsOf(sess, i))
+	default:
+		return ecql.BulkStats{}, ErrInvalidFormat
+	}
+
+	w := ecql.NewBulkWriter(sess, config)
+	for {
+		row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = w.Write(row)
+		}
+		if err != nil {
+			w.Close()
+			return w.Stats(), err
+		}
+	}
+	err := w.Close()
+	return w.Stats(), err
+}
+
+// csvReader returns a function that reads the next row in a CSV file.
+func csvReader(r io.Reader, i interface{}, columns map[string]ecql.Column) func() (interface{}, error) {
+	t := structType(i)
+	cr := csv.NewReader(r)
+	var header []ecql.Column
+	return func() (interface{}, error) {
+		if header == nil {
+			names, err := cr.Read()
+			if err != nil {
+				return nil, err
+			}
+			header = make([]ecql.Column, len(names))
+			for n, name := range names {
+				col, ok := columns[name]
+				if !ok {
+					return nil, fmt.Errorf("unknown column %q", name)
+				}
+				header[n] = col
+			}
+		}
+
+		record, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		v := reflect.New(t).Elem()
+		for n, cell := range record {
+			if cell == "" {
+				continue
+			}
+			if err := parseCell(fieldOf(v, header[n].Position, true), cell); err != nil {
+				line, _ := cr.FieldPos(n)
+				return nil, fmt.Errorf("line %d, column %q: %v", line, header[n].Name, err)
+			}
+		}
+		return v.Interface(), nil
+	}
+}
+
+// jsonlReader returns a function that reads the next row in a JSON Lines
+// file.
+func jsonlReader(r io.Reader, i interface{}, columns map[string]ecql.Column) func() (interface{}, error) {
+	t := structType(i)
+	dec := json.NewDecoder(r)
+	return func() (interface{}, error) {
+		var row map[string]json.RawMessage
+		if err := dec.Decode(&row); err != nil {
+			return nil, err
+		}
+		v := reflect.New(t).Elem()
+		for name, raw := range row {
+			col, ok := columns[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown column %q", name)
+			}
+			if string(raw) == "null" {
+				continue
+			}
+			f := fieldOf(v, col.Position, true)
+			if err := json.Unmarshal(raw, f.Addr().Interface()); err != nil {
+				return nil, fmt.Errorf("column %q: %v", name, err)
+			}
+		}
+		return v.Interface(), nil
+	}
+}
+
+// formatCell returns the CSV representation of the field f.
+func formatCell(f reflect.Value) (string, error) {
+	if !f.IsValid() {
+		return "", nil
+	}
+	if f.Kind() == reflect.String {
+		return f.String(), nil
+	}
+	b, err := json.Marshal(f.Interface())
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case string(b) == "null":
+		return "", nil
+	case b[0] == '"':
+		var s string
+		err := json.Unmarshal(b, &s)
+		return s, err
+	default:
+		return string(b), nil
+	}
+}
+
+// parseCell sets the value of a CSV cell in the field f.
+func parseCell(f reflect.Value, cell string) error {
+	if f.Kind() == reflect.String {
+		f.SetString(cell)
+		return nil
+	}
+	err := json.Unmarshal([]byte(cell), f.Addr().Interface())
+	if err != nil {
+		// JSON strings are written without quotes
+		if json.Unmarshal([]byte(strconv.Quote(cell)), f.Addr().Interface()) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// fieldOf returns the field of v in the given position. The nil pointers to
+// embedded structs are allocated if alloc is true, if not an invalid value is
+// returned.
+func fieldOf(v reflect.Value, position []int, alloc bool) reflect.Value {
+	for n, p := range position {
+		for n > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(p)
+	}
+	if v.Kind() == reflect.Ptr && v.IsNil() && !alloc {
+		return reflect.Value{}
+	}
+	return v
+}
+
+// columnsOf returns the columns of the table of i in the registry of the
+// session by name.
+func columnsOf(sess ecql.Session, i interface{}) map[string]ecql.Column {
+	columns := make(map[string]ecql.Column)
+	for _, col := range ecql.TableOf(sess, i).Columns {
+		columns[col.Name] = col
+	}
+	return columns
+}
+
+// structType returns the struct type of i.
+func structType(i interface{}) reflect.Type {
+	t := reflect.TypeOf(i)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
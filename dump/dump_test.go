@@ -0,0 +1,152 @@
+package dump
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/maraino/ecql"
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	ID    string    `cql:"id" cqltable:"events" cqlkey:"id"`
+	Time  time.Time `cql:"time"`
+	Count int       `cql:"count"`
+	Tags  []string  `cql:"tags"`
+	Note  *string   `cql:"note"`
+}
+
+// rowsRegistry is used by the test driver to map the rows.
+var rowsRegistry = ecql.NewRegistry()
+
+// testDriver returns the configured rows and records the rows inserted.
+type testDriver struct {
+	mu       sync.Mutex
+	rows     []event
+	inserted []event
+}
+
+// Iter returns the rows in the token range of the request if it is set.
+func (d *testDriver) Iter(req *ecql.Request) ecql.Rows {
+	if len(req.Values) != 2 {
+		return &testRows{rows: d.rows}
+	}
+	var rows []event
+	for _, row := range d.rows {
+		token, _ := ecql.Token(row)
+		if token >= req.Values[0].(int64) && token <= req.Values[1].(int64) {
+			rows = append(rows, row)
+		}
+	}
+	return &testRows{rows: rows}
+}
+
+func (d *testDriver) ExecBatch(b *ecql.BatchRequest) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, e := range b.Entries {
+		var ev event
+		m := rowsRegistry.Map(&ev)
+		for n, name := range []string{"id", "time", "count", "tags", "note"} {
+			if v := reflect.ValueOf(e.Values[n]); v.IsValid() && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+				reflect.ValueOf(m[name]).Elem().Set(v)
+			}
+		}
+		d.inserted = append(d.inserted, ev)
+	}
+	return nil
+}
+
+func (d *testDriver) ExecBatchCAS(b *ecql.BatchRequest, dest map[string]interface{}) (bool, error) {
+	return true, d.ExecBatch(b)
+}
+
+func (d *testDriver) Close() {}
+
+type testRows struct {
+	rows []event
+	pos  int
+}
+
+func (r *testRows) Columns() []gocql.ColumnInfo   { return nil }
+func (r *testRows) Scan(dest ...interface{}) bool { return false }
+func (r *testRows) NumRows() int                  { return len(r.rows) }
+func (r *testRows) WillSwitchPage() bool          { return false }
+func (r *testRows) PageState() []byte             { return nil }
+func (r *testRows) Info() ecql.QueryInfo          { return ecql.QueryInfo{} }
+func (r *testRows) Close() error                  { return nil }
+
+func (r *testRows) MapScan(m map[string]interface{}) bool {
+	if r.pos >= len(r.rows) {
+		return false
+	}
+	for name, v := range rowsRegistry.Map(r.rows[r.pos]) {
+		reflect.ValueOf(m[name]).Elem().Set(reflect.ValueOf(v))
+	}
+	r.pos++
+	return true
+}
+
+func TestExportImport(t *testing.T) {
+	note := "a note, with \"quotes\""
+	rows := []event{
+		{ID: "a", Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Count: 3, Tags: []string{"x", "y"}, Note: &note},
+		{ID: "b", Time: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+
+	for _, format := range []Format{CSV, JSONL} {
+		d := &testDriver{rows: rows}
+		sess := ecql.NewWithDriver(d)
+
+		var buf bytes.Buffer
+		assert.NoError(t, Export(sess, event{}, &buf, format))
+		if format == CSV {
+			lines := strings.Split(buf.String(), "\n")
+			assert.Equal(t, "id,time,count,tags,note", lines[0])
+			assert.Equal(t, `a,2020-01-02T03:04:05Z,3,"[""x"",""y""]","a note, with ""quotes"""`, lines[1])
+			assert.Equal(t, "b,2021-01-02T03:04:05Z,0,,", lines[2])
+		} else {
+			assert.Equal(t, `{"count":0,"id":"b","note":null,"tags":null,"time":"2021-01-02T03:04:05Z"}`, strings.Split(buf.String(), "\n")[1])
+		}
+
+		stats, err := Import(sess, &buf, event{}, format)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stats.Rows)
+		sort.Slice(d.inserted, func(i, j int) bool { return d.inserted[i].ID < d.inserted[j].ID })
+		assert.Equal(t, rows, d.inserted)
+	}
+}
+
+func TestExportRegistry(t *testing.T) {
+	ecql.DeleteRegistry()
+	d := &testDriver{rows: []event{{ID: "a"}}}
+	sess := ecql.NewWithDriver(d, ecql.WithRegistry(ecql.NewRegistry()))
+
+	var buf bytes.Buffer
+	assert.NoError(t, Export(sess, event{}, &buf, CSV))
+	assert.Equal(t, "id,time,count,tags,note\na,0001-01-01T00:00:00Z,0,,\n", buf.String())
+	assert.Empty(t, ecql.DefaultRegistry.Snapshot().Tables)
+}
+
+func TestImportErrors(t *testing.T) {
+	sess := ecql.NewWithDriver(&testDriver{})
+
+	_, err := Import(sess, strings.NewReader("id,foo\na,b\n"), event{}, CSV)
+	assert.EqualError(t, err, `unknown column "foo"`)
+
+	_, err = Import(sess, strings.NewReader("id,count\na,b\n"), event{}, CSV)
+	assert.Error(t, err)
+
+	_, err = Import(sess, strings.NewReader(`{"id":"a","foo":1}`), event{}, JSONL)
+	assert.EqualError(t, err, `unknown column "foo"`)
+
+	_, err = Import(sess, strings.NewReader(""), event{}, Format(5))
+	assert.Equal(t, ErrInvalidFormat, err)
+	assert.Equal(t, ErrInvalidFormat, Export(sess, event{}, &bytes.Buffer{}, Format(5)))
+}
@@ -0,0 +1,249 @@
+package dump
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/maraino/ecql"
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	// DefaultParquetSplits is the default number of token ranges scanned by
+	// the Parquet exports.
+	DefaultParquetSplits = 64
+
+	// DefaultParquetParallelism is the default number of token ranges
+	// scanned at the same time by the Parquet exports.
+	DefaultParquetParallelism = 4
+
+	// DefaultParquetRowGroupSize is the default maximum number of rows in a
+	// row group.
+	DefaultParquetRowGroupSize = 100000
+)
+
+// ParquetConfig contains the configuration of the Parquet exports.
+type ParquetConfig struct {
+	// Splits is the number of token ranges the table is split in.
+	Splits int
+	// Parallelism is the number of token ranges scanned at the same time.
+	Parallelism int
+	// RowGroupSize is the maximum number of rows in a row group.
+	RowGroupSize int64
+}
+
+func (c *ParquetConfig) defaults() {
+	if c.Splits <= 0 {
+		c.Splits = DefaultParquetSplits
+	}
+	if c.Parallelism <= 0 {
+		c.Parallelism = DefaultParquetParallelism
+	}
+	if c.RowGroupSize <= 0 {
+		c.RowGroupSize = DefaultParquetRowGroupSize
+	}
+}
+
+// parquetColumn is a column of the table and the function that converts the
+// values of its field to the values written in the Parquet file.
+type parquetColumn struct {
+	ecql.Column
+	node    parquet.Node
+	convert func(v reflect.Value) interface{}
+}
+
+// ParquetSchema returns the Parquet schema derived from the table of i. All
+// the columns are optional, strings, booleans, numbers, blobs, timestamps and
+// UUIDs are mapped to the equivalent Parquet types, and the rest of the
+// types, like collections or user defined types, are written as JSON. The
+// table is read from the registry of the session.
+func ParquetSchema(sess ecql.Session, i interface{}) *parquet.Schema {
+	table := ecql.TableOf(sess, i)
+	return parquetSchema(table.Name, parquetColumns(table, i))
+}
+
+// ExportParquet scans the table of i by token range in parallel and writes
+// all the rows to w as a single Parquet file. It returns the number of rows
+// written.
+func ExportParquet(sess ecql.Session, i interface{}, w io.Writer, config ParquetConfig) (int64, error) {
+	config.defaults()
+	table := ecql.TableOf(sess, i)
+	columns := parquetColumns(table, i)
+	pw := newParquetWriter(parquetSchema(table.Name, columns), w, config)
+
+	var mu sync.Mutex
+	var n int64
+	err := scanRanges(sess, i, config, func(split int, r ecql.TokenRange, next func() (reflect.Value, bool)) error {
+		for v, ok := next(); ok; v, ok = next() {
+			row := parquetRow(v, columns)
+			mu.Lock()
+			err := pw.Write(row)
+			n++
+			mu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if cerr := pw.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// ExportParquetSplits scans the table of i by token range in parallel and
+// writes a Parquet file per range, the files are created with the create
+// function and closed after writing them. It can be used to upload the files
+// to an object storage while they are written. It returns the number of rows
+// written.
+func ExportParquetSplits(sess ecql.Session, i interface{}, create func(split int, r ecql.TokenRange) (io.WriteCloser, error), config ParquetConfig) (int64, error) {
+	config.defaults()
+	table := ecql.TableOf(sess, i)
+	columns := parquetColumns(table, i)
+	schema := parquetSchema(table.Name, columns)
+
+	var mu sync.Mutex
+	var n int64
+	err := scanRanges(sess, i, config, func(split int, r ecql.TokenRange, next func() (reflect.Value, bool)) error {
+		f, err := create(split, r)
+		if err != nil {
+			return err
+		}
+		pw := newParquetWriter(schema, f, config)
+		var rows int64
+		for v, ok := next(); ok && err == nil; v, ok = next() {
+			err = pw.Write(parquetRow(v, columns))
+			rows++
+		}
+		if cerr := pw.Close(); err == nil {
+			err = cerr
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		mu.Lock()
+		n += rows
+		mu.Unlock()
+		return err
+	})
+	return n, err
+}
+
+// scanRanges scans the token ranges of the table of i in parallel, fn is
+// called with a function that returns the next row of the range.
+func scanRanges(sess ecql.Session, i interface{}, config ParquetConfig, fn func(split int, r ecql.TokenRange, next func() (reflect.Value, bool)) error) error {
+	t := structType(i)
+	return ecql.ScanTokenRanges(sess, i, config.Splits, config.Parallelism, func(split int, r ecql.TokenRange, iter ecql.Iter) error {
+		next := func() (reflect.Value, bool) {
+			v := reflect.New(t)
+			return v.Elem(), iter.TypeScan(v.Interface())
+		}
+		return fn(split, r, next)
+	})
+}
+
+func newParquetWriter(schema *parquet.Schema, w io.Writer, config ParquetConfig) *parquet.Writer {
+	return parquet.NewWriter(w, schema,
+		parquet.MaxRowsPerRowGroup(config.RowGroupSize),
+		parquet.Compression(&parquet.Snappy),
+	)
+}
+
+// parquetRow returns the row written for the struct v, the NULL values are
+// not set.
+func parquetRow(v reflect.Value, columns []parquetColumn) map[string]interface{} {
+	row := make(map[string]interface{}, len(columns))
+	for _, col := range columns {
+		f := fieldOf(v, col.Position, false)
+		for f.IsValid() && f.Kind() == reflect.Ptr {
+			if f.IsNil() {
+				f = reflect.Value{}
+			} else {
+				f = f.Elem()
+			}
+		}
+		if f.IsValid() {
+			row[col.Name] = col.convert(f)
+		}
+	}
+	return row
+}
+
+func parquetSchema(name string, columns []parquetColumn) *parquet.Schema {
+	group := make(parquet.Group, len(columns))
+	for _, col := range columns {
+		group[col.Name] = parquet.Optional(col.node)
+	}
+	return parquet.NewSchema(name, group)
+}
+
+// parquetColumns returns the columns of the table of i with their Parquet
+// types.
+func parquetColumns(table ecql.Table, i interface{}) []parquetColumn {
+	t := structType(i)
+	columns := make([]parquetColumn, len(table.Columns))
+	for n, col := range table.Columns {
+		columns[n].Column = col
+		columns[n].node, columns[n].convert = parquetType(fieldType(t, col.Position))
+	}
+	return columns
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(gocql.UUID{})
+)
+
+// parquetType returns the Parquet type of the Go type t and the function
+// that converts its values.
+func parquetType(t reflect.Type) (parquet.Node, func(v reflect.Value) interface{}) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return parquet.Timestamp(parquet.Millisecond), func(v reflect.Value) interface{} { return v.Interface() }
+	case t == uuidType:
+		return parquet.UUID(), func(v reflect.Value) interface{} { return v.Interface().(gocql.UUID).String() }
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return parquet.Leaf(parquet.ByteArrayType), func(v reflect.Value) interface{} { return v.Bytes() }
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return parquet.String(), func(v reflect.Value) interface{} { return v.String() }
+	case reflect.Bool:
+		return parquet.Leaf(parquet.BooleanType), func(v reflect.Value) interface{} { return v.Bool() }
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return parquet.Int(32), func(v reflect.Value) interface{} { return int32(v.Int()) }
+	case reflect.Int, reflect.Int64:
+		return parquet.Int(64), func(v reflect.Value) interface{} { return v.Int() }
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		return parquet.Int(64), func(v reflect.Value) interface{} { return int64(v.Uint()) }
+	case reflect.Float32:
+		return parquet.Leaf(parquet.FloatType), func(v reflect.Value) interface{} { return float32(v.Float()) }
+	case reflect.Float64:
+		return parquet.Leaf(parquet.DoubleType), func(v reflect.Value) interface{} { return v.Float() }
+	default:
+		return parquet.JSON(), func(v reflect.Value) interface{} {
+			b, _ := json.Marshal(v.Interface())
+			return b
+		}
+	}
+}
+
+// fieldType returns the type of the field in the given position.
+func fieldType(t reflect.Type, position []int) reflect.Type {
+	for _, p := range position {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		t = t.Field(p).Type
+	}
+	return t
+}
@@ -0,0 +1,127 @@
+package dump
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maraino/ecql"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// readParquet returns the rows in a Parquet file.
+func readParquet(t *testing.T, b []byte) []map[string]interface{} {
+	r := parquet.NewReader(bytes.NewReader(b))
+	defer r.Close()
+	var rows []map[string]interface{}
+	for {
+		row := make(map[string]interface{})
+		if err := r.Read(&row); err == io.EOF {
+			break
+		} else if !assert.NoError(t, err) {
+			break
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func testEvents(n int) []event {
+	note := "note"
+	rows := make([]event, n)
+	for i := range rows {
+		rows[i] = event{
+			ID:    fmt.Sprintf("id-%02d", i),
+			Time:  time.Date(2020, 1, 1, 0, i, 0, 0, time.UTC),
+			Count: i,
+		}
+		if i%2 == 0 {
+			rows[i].Tags = []string{"x"}
+			rows[i].Note = &note
+		}
+	}
+	return rows
+}
+
+func TestParquetSchema(t *testing.T) {
+	assert.Equal(t, `message events {
+	optional int64 count (INT(64,true));
+	optional binary id (STRING);
+	optional binary note (STRING);
+	optional binary tags (JSON);
+	optional int64 time (TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS));
+}`, ParquetSchema(ecql.NewWithDriver(&testDriver{}), event{}).String())
+
+	// The table is read from the registry of the session
+	r := ecql.NewRegistry()
+	r.RegisterAs(event{}, "events_2024")
+	sess := ecql.NewWithDriver(&testDriver{}, ecql.WithRegistry(r))
+	assert.Equal(t, "events_2024", ParquetSchema(sess, event{}).Name())
+}
+
+func TestExportParquet(t *testing.T) {
+	rows := testEvents(50)
+	sess := ecql.NewWithDriver(&testDriver{rows: rows})
+
+	var buf bytes.Buffer
+	n, err := ExportParquet(sess, event{}, &buf, ParquetConfig{Splits: 8, Parallelism: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), n)
+
+	written := readParquet(t, buf.Bytes())
+	assert.Len(t, written, 50)
+	sort.Slice(written, func(i, j int) bool { return written[i]["id"].(string) < written[j]["id"].(string) })
+	assert.Equal(t, "id-00", written[0]["id"])
+	assert.Equal(t, int64(0), written[0]["count"])
+	assert.Equal(t, "note", written[0]["note"])
+	assert.Nil(t, written[1]["note"])
+	assert.Equal(t, rows[1].Time.UnixMilli(), written[1]["time"])
+}
+
+func TestExportParquetSplits(t *testing.T) {
+	sess := ecql.NewWithDriver(&testDriver{rows: testEvents(50)})
+
+	var mu sync.Mutex
+	files := make(map[int]*bytes.Buffer)
+	create := func(split int, r ecql.TokenRange) (io.WriteCloser, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		files[split] = new(bytes.Buffer)
+		return nopCloser{files[split]}, nil
+	}
+
+	n, err := ExportParquetSplits(sess, event{}, create, ParquetConfig{Splits: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), n)
+	assert.Len(t, files, 4)
+
+	total := 0
+	for split, f := range files {
+		r := ecql.TokenRanges(4)[split]
+		for _, row := range readParquet(t, f.Bytes()) {
+			token, _ := ecql.Token(event{ID: row["id"].(string)})
+			assert.True(t, token >= r.Start && token <= r.End)
+			total++
+		}
+	}
+	assert.Equal(t, 50, total)
+
+	// Errors stop the export
+	errCreate := errors.New("create error")
+	_, err = ExportParquetSplits(sess, event{}, func(split int, r ecql.TokenRange) (io.WriteCloser, error) {
+		return nil, errCreate
+	}, ParquetConfig{Splits: 4})
+	assert.Equal(t, errCreate, err)
+}
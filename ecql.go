@@ -1,7 +1,12 @@
 package ecql
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/gocql/gocql"
 )
@@ -10,68 +15,385 @@ var EcqlDebug = (os.Getenv("ECQL_DEBUG") == "true")
 
 var ErrNotFound = gocql.ErrNotFound
 
-// Session is the interface used by users to interact with the database.
+// Session is the interface used by users to interact with the database. It is
+// safe for concurrent use by multiple goroutines.
 type Session interface {
 	Get(i interface{}, keys ...interface{}) error
+	MultiGet(dest interface{}, keys ...interface{}) error
+	SelectRange(dest interface{}, from, to time.Time, cond ...Condition) error
+	SelectBuckets(dest interface{}, from, to time.Time, cond ...Condition) error
 	Set(i interface{}) error
+	SaveIfChanged(i interface{}) (bool, error)
 	Del(i interface{}) error
 	Exists(i interface{}) (bool, error)
 	Select(i interface{}) Statement
 	Insert(i interface{}) Statement
 	Delete(i interface{}) Statement
+	DeleteRange(i interface{}, cond ...Condition) Statement
 	Update(i interface{}) Statement
 	Count(i interface{}) Statement
+	Truncate(i interface{}) error
+	DropTable(i interface{}) error
 	Batch() Batch
+	UnloggedBatch() Batch
+	Table(i interface{}, name string) Session
+	WithContext(ctx context.Context) Session
+	MaxRows(n int, truncated *bool) Session
+	WithTenant(ctx context.Context, id interface{}) Session
+	ClusterStatus() ClusterStatus
+	Ping(ctx context.Context) error
+	WaitUntilReady(ctx context.Context, backoff *RetryPolicy) error
+	Shutdown(ctx context.Context) error
+	DescribeTable(keyspace, table string) (Table, error)
+	ValidateSchema(types ...interface{}) ([]SchemaDiff, error)
+	AutoMigrate(types ...interface{}) error
+	AutoMigrateSnapshot(tables ...TableSnapshot) error
+	CreateKeyspace(name string, r Replication) error
+	DropKeyspace(name string) error
+	QueryRaw(cql string, values ...interface{}) RawQuery
+	CopyTable(src, dst interface{}, transform func(src interface{}) interface{}, config CopyConfig) (BulkStats, error)
 	Query(stmt string, args ...interface{}) *gocql.Query
 }
 
 type SessionImpl struct {
 	*gocql.Session
+	driver      Driver
+	base        Driver
+	cluster     *gocql.ClusterConfig
+	sem         chan struct{}
+	parallelism int
+	cache       Cache
+	versions    *cacheVersions
+	consistency *ConsistencyPreset
+	monitor     *hostMonitor
+	registry    *Registry
+	keyspace    string
+	unsetEmpty  bool
+	nullCells   nullCellsHook
+	middlewares []Middleware
+	retry       *RetryPolicy
+	priority    Priority
+	validation  func(i interface{}) error
+	ctx         context.Context
+	tenant      interface{}
+	policy      Policy
+	clock       Clock
+	uuids       UUIDSource
+	timeout     time.Duration
+	limits      Limits
+	maxRows     int
+	truncated   *bool
+	payload     map[string][]byte
+	drain       *drainer
+	hostEvents  []HostEventFunc
+	connRetry   *RetryPolicy
+	startup     StartupMode
+	startCtx    context.Context
+	tlsFiles    *TLSConfig
+	ctxHooks    []ContextHook
+	drainHeld   bool
+}
+
+// Option defines the functions used to configure a Session.
+type Option func(*SessionImpl)
+
+// WithMaxConcurrency sets the maximum number of statements that the session
+// executes asynchronously at the same time. It defaults to
+// DefaultMaxConcurrency.
+func WithMaxConcurrency(n int) Option {
+	return func(s *SessionImpl) {
+		if n > 0 {
+			s.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithMultiGetParallelism sets the number of concurrent SELECT statements
+// used by MultiGet. It defaults to DefaultMultiGetParallelism.
+func WithMultiGetParallelism(n int) Option {
+	return func(s *SessionImpl) {
+		if n > 0 {
+			s.parallelism = n
+		}
+	}
+}
+
+// WithRegistry sets the registry used to map the types to tables. It defaults
+// to DefaultRegistry.
+func WithRegistry(r *Registry) Option {
+	return func(s *SessionImpl) {
+		s.registry = r
+	}
+}
+
+// WithKeyspace sets the default keyspace of the session. NewSession uses the
+// keyspace in the cluster configuration by default.
+func WithKeyspace(keyspace string) Option {
+	return func(s *SessionImpl) {
+		s.keyspace = keyspace
+		if s.cluster != nil {
+			s.cluster.Keyspace = keyspace
+		}
+	}
 }
 
 // New creates a ecql.Session from an already existent gocql.Session.
-func New(s *gocql.Session) Session {
-	return &SessionImpl{
-		Session: s,
+func New(s *gocql.Session, opts ...Option) Session {
+	sess := newSessionImpl(nil, opts)
+	sess.Session = s
+	if sess.driver == nil {
+		sess.driver = NewGocqlDriver(s)
 	}
+	sess.initDriver()
+	return sess
 }
 
 // NewSession initializes a new ecql.Session with gocql.ConsterConfig.
-func NewSession(cfg gocql.ClusterConfig) (Session, error) {
-	s, err := gocql.NewSession(cfg)
+func NewSession(cfg gocql.ClusterConfig, opts ...Option) (Session, error) {
+	sess := newSessionImpl(&cfg, opts)
+	if sess.tlsFiles != nil {
+		config, err := sess.tlsFiles.Load()
+		if err != nil {
+			return nil, err
+		}
+		cfg.SslOpts = sslOptions(config)
+	}
+
+	// Allow per-statement host selection and keep track of the hosts
+	policy := cfg.PoolConfig.HostSelectionPolicy
+	if policy == nil {
+		policy = gocql.RoundRobinHostPolicy()
+	}
+	sess.monitor = newHostMonitor()
+	sess.monitor.listeners = sess.hostEvents
+	cfg.PoolConfig.HostSelectionPolicy = newHostPolicy(policy, sess.monitor)
+	cfg.QueryObserver = &queryObserver{monitor: sess.monitor, next: cfg.QueryObserver}
+
+	s, err := sess.connect(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return New(s), nil
+	sess.Session = s
+	if sess.driver == nil {
+		sess.driver = NewGocqlDriver(s)
+	}
+	sess.initDriver()
+	return sess, nil
+}
+
+// newSessionImpl creates a SessionImpl with the default values and applies
+// the given options. The options that modify the cluster configuration are
+// only applied if cfg is not nil.
+func newSessionImpl(cfg *gocql.ClusterConfig, opts []Option) *SessionImpl {
+	sess := &SessionImpl{
+		cluster:     cfg,
+		sem:         make(chan struct{}, DefaultMaxConcurrency),
+		parallelism: DefaultMultiGetParallelism,
+		registry:    DefaultRegistry,
+		drain:       newDrainer(),
+	}
+	if cfg != nil {
+		sess.keyspace = cfg.Keyspace
+	}
+	for _, opt := range opts {
+		opt(sess)
+	}
+	sess.cluster = nil
+	return sess
+}
+
+// getRegistry returns the registry of the session.
+func (s *SessionImpl) getRegistry() *Registry {
+	if s.registry == nil {
+		return DefaultRegistry
+	}
+	return s.registry
+}
+
+// request creates a Request with the defaults of the session.
+func (s *SessionImpl) request(cmd Command, table, stmt string, values []interface{}) *Request {
+	return &Request{
+		Context:           s.ctx,
+		Command:           cmd,
+		Table:             table,
+		Statement:         stmt,
+		Values:            values,
+		Consistency:       s.consistencyOf(cmd),
+		SerialConsistency: s.serialConsistency(),
+		Idempotent:        cmd == SelectCmd || cmd == CountCmd || cmd == InsertCmd || cmd == DeleteCmd,
+		Priority:          s.priority,
+		Timeout:           s.timeout,
+		Payload:           s.payload,
+		drainHeld:         s.drainHeld,
+	}
 }
 
 // Get executes a SELECT statements on the table defined in i and sets the
 // fields on i with the information present in the database.
 func (s *SessionImpl) Get(i interface{}, keys ...interface{}) error {
-	m, table := MapTable(i)
+	ctx := contextOf(s.ctx)
+	m, table := s.getRegistry().MapTable(i)
+	keys, err := s.scopeKeys(table, keys)
+	if err != nil {
+		return err
+	}
 	if cql, err := table.BuildQuery(selectQuery); err != nil {
 		return err
 	} else {
-		return s.Query(cql, keys...).MapScan(m)
+		req := s.request(SelectCmd, table.Name, cql, keys)
+		req.PartitionKey = table.partitionOf(keys)
+		if s.cache != nil {
+			req.cache = &cacheLookup{table: table.Name, keys: keys, statement: cql, dest: i}
+		}
+		if err := mapScanRow(s.getDriver().Iter(req), m); err != nil {
+			return err
+		}
+		table.scanned(structOf(i), m)
+		return afterScan(ctx, structOf(i))
+	}
+}
+
+// MultiGet executes one SELECT statement per key concurrently and appends the
+// rows found to dest, that must be a pointer to a slice of structs or
+// pointers to structs. Keys on tables with compound primary keys must be
+// passed as a []interface{} with the values in the primary key order. Keys
+// not found in the database are skipped, so the results are in the same order
+// than the keys, but dest might contain less elements.
+//
+// Per-partition queries are usually much faster than a SELECT with an IN
+// condition across partitions. The number of concurrent queries can be
+// configured with WithMultiGetParallelism.
+func (s *SessionImpl) MultiGet(dest interface{}, keys ...interface{}) error {
+	slice, elemType, isPtr, err := structSlice(dest)
+	if err != nil {
+		return err
+	}
+
+	results := make([]reflect.Value, len(keys))
+	errs := make([]error, len(keys))
+	indexes := make(chan int)
+
+	parallelism := s.parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultMultiGetParallelism
+	}
+	var wg sync.WaitGroup
+	for n := 0; n < parallelism && n < len(keys); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				v := reflect.New(elemType)
+				err := s.Get(v.Interface(), keyValues(keys[i])...)
+				switch err {
+				case nil:
+					results[i] = v
+				case ErrNotFound:
+				default:
+					errs[i] = err
+				}
+			}
+		}()
+	}
+	for i := range keys {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil {
+			return errs[i]
+		}
+	}
+
+	rows := s.appender(slice, isPtr)
+	for _, v := range results {
+		if v.IsValid() && !rows.append(v) {
+			break
+		}
+	}
+	return rows.err()
+}
+
+// keyValues returns the list of values of a key passed to MultiGet.
+func keyValues(key interface{}) []interface{} {
+	if values, ok := key.([]interface{}); ok {
+		return values
 	}
+	return []interface{}{key}
 }
 
 // Set executes an INSERT statement on the the table defined in i and
 // saves the information of i in the dtabase.
 func (s *SessionImpl) Set(i interface{}) error {
-	v, _, table := BindTable(i)
+	ctx := contextOf(s.ctx)
+	i, err := s.scope(i)
+	if err != nil {
+		return err
+	}
+	i = s.setBucket(s.generateIDs(i))
+	if p, err := beforeWrite(ctx, InsertCmd, i); err != nil {
+		return err
+	} else if p != nil {
+		i = p
+	}
+	v, m, table := s.getRegistry().BindTable(i)
+	if err := s.validate(table.Name, i); err != nil {
+		return err
+	}
+	ttl, err := table.ttlFrom(structOf(i), s.now())
+	if err != nil {
+		return err
+	}
 	if cql, err := table.BuildQuery(insertQuery); err != nil {
 		return err
 	} else {
-		return s.Query(cql, v...).Exec()
+		if ttl > 0 {
+			cql += fmt.Sprintf(" USING TTL %d", ttl)
+		}
+		if s.unsetEmpty || s.nullCells.fn != nil {
+			s.prepareWrite(table, table.columnNames(), v, s.unsetEmpty)
+		}
+		req := s.request(InsertCmd, table.Name, cql, v)
+		req.PartitionKey = table.partitionValues(m)
+		req.Row = m
+		err := s.getDriver().Iter(req).Close()
+		if s.cache != nil {
+			key := s.cacheKey(table.Name, table.keyValues(m))
+			if err == nil {
+				s.cacheSet(key, i)
+			} else {
+				s.cache.Delete(key)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return afterWrite(ctx, InsertCmd, i)
 	}
 }
 
 // Del extecutes a delete statement on the table defined in i to
-// remove the object i from the database.
+// remove the object i from the database. It returns a *KeyError if a column
+// of the primary key of i is empty.
 func (s *SessionImpl) Del(i interface{}) error {
-	m, table := MapTable(i)
+	ctx := contextOf(s.ctx)
+	i, err := s.scope(i)
+	if err != nil {
+		return err
+	}
+	i = s.setBucket(i)
+	if p, err := beforeWrite(ctx, DeleteCmd, i); err != nil {
+		return err
+	} else if p != nil {
+		i = p
+	}
+	m, table := s.getRegistry().MapTable(i)
+	if err := table.checkKey(structOf(i)); err != nil {
+		return err
+	}
 	if cql, err := table.BuildQuery(deleteQuery); err != nil {
 		return err
 	} else {
@@ -79,14 +401,31 @@ func (s *SessionImpl) Del(i interface{}) error {
 		for i, name := range table.KeyColumns {
 			keys[i] = m[name]
 		}
-		return s.Query(cql, keys...).Exec()
+		if s.cache != nil {
+			defer s.cache.Delete(s.cacheKey(table.Name, keys))
+		}
+		req := s.request(DeleteCmd, table.Name, cql, keys)
+		req.PartitionKey = table.partitionOf(keys)
+		req.Row = make(map[string]interface{}, len(keys))
+		for i, name := range table.KeyColumns {
+			req.Row[name] = keys[i]
+		}
+		if err := s.getDriver().Iter(req).Close(); err != nil {
+			return err
+		}
+		return afterWrite(ctx, DeleteCmd, i)
 	}
 }
 
 // Exists executes a count statement on the table defined in i and
 // returns if the object i exists in the database.
 func (s *SessionImpl) Exists(i interface{}) (bool, error) {
-	m, table := MapTable(i)
+	i, err := s.scope(i)
+	if err != nil {
+		return false, err
+	}
+	i = s.setBucket(i)
+	m, table := s.getRegistry().MapTable(i)
 	if cql, err := table.BuildQuery(countQuery); err != nil {
 		return false, err
 	} else {
@@ -95,7 +434,9 @@ func (s *SessionImpl) Exists(i interface{}) (bool, error) {
 			keys[i] = m[name]
 		}
 		var count int
-		err = s.Query(cql, keys...).Scan(&count)
+		req := s.request(CountCmd, table.Name, cql, keys)
+		req.PartitionKey = table.partitionOf(keys)
+		err = scanRow(s.getDriver().Iter(req), &count)
 		return count > 0, err
 	}
 }
@@ -110,14 +451,47 @@ func (s *SessionImpl) Insert(i interface{}) Statement {
 	return NewStatement(s).Do(InsertCmd).Bind(i)
 }
 
-// Select initializes an DELETE statement.
+// Delete initializes a DELETE statement of the row of i, with conditions on
+// all the columns of the primary key, partition and clustering columns:
+//
+//	err := sess.Delete(&event).Exec()
+//
+// The statement returns a *KeyError, matching ErrEmptyKey, if a column of the
+// primary key of i is empty, instead of deleting a different row or the
+// whole partition.
 func (s *SessionImpl) Delete(i interface{}) Statement {
-	return NewStatement(s).Do(DeleteCmd).FromType(i).Where(EqInt(i))
+	stmt := &StatementImpl{session: s}
+	i, err := s.scope(i)
+	if err != nil {
+		stmt.err = err
+		return stmt
+	}
+	i = s.setBucket(i)
+	m, table := s.getRegistry().MapTable(i)
+	if err := table.checkKey(structOf(i)); err != nil {
+		stmt.err = err
+		return stmt
+	}
+	stmt.Do(DeleteCmd).From(table.Name).Where(eqKey(m, table))
+	// Keep the key values to invalidate the cache
+	stmt.mapping, stmt.Table, stmt.bound = m, table, i
+	return stmt
 }
 
-// Update initializes an UPDATE statement.
+// Update initializes an UPDATE statement. If the type of i embeds Tracked and
+// i was loaded from the database, the columns changed are set.
 func (s *SessionImpl) Update(i interface{}) Statement {
-	return NewStatement(s).Do(UpdateCmd).Bind(i).Where(EqInt(i))
+	stmt := NewStatement(s).Do(UpdateCmd).Bind(i).(*StatementImpl)
+	if stmt.err != nil {
+		return stmt
+	}
+	i = stmt.bound
+	stmt.Where(eqKey(s.getRegistry().MapTable(i)))
+	if tr := stmt.Table.trackedOf(structOf(i)); tr != nil && tr.Loaded() {
+		stmt.ColumnNames = tr.changed(structOf(i), stmt.Table)
+		stmt.tracked, stmt.dest = tr, structOf(i)
+	}
+	return stmt
 }
 
 // Count initializes a SELECT COUNT(1) statement from the table defined by i.
@@ -125,8 +499,49 @@ func (s *SessionImpl) Count(i interface{}) Statement {
 	return NewStatement(s).Do(CountCmd).FromType(i)
 }
 
+// Truncate removes all the rows from the table defined by i. The rows stored
+// in the session cache are not removed.
+func (s *SessionImpl) Truncate(i interface{}) error {
+	return NewStatement(s).Do(TruncateCmd).FromType(i).Exec()
+}
+
+// DropTable removes the table defined by i if it exists.
+func (s *SessionImpl) DropTable(i interface{}) error {
+	return NewStatement(s).Do(DropTableCmd).FromType(i).Exec()
+}
+
 // Batch initializes a new LOGGED BATCH to combine multiple data modification statements
 // (INSERT, UPDATE, DELETE)
 func (s *SessionImpl) Batch() Batch {
 	return NewBatch(s, gocql.LoggedBatch)
 }
+
+// UnloggedBatch initializes a new UNLOGGED BATCH to combine multiple data
+// modification statements without the batch log.
+func (s *SessionImpl) UnloggedBatch() Batch {
+	return NewBatch(s, gocql.UnloggedBatch)
+}
+
+// Table returns a Session that uses the table name for the type of i instead
+// of the registered one. It allows to use the same type with time-bucketed or
+// per-tenant tables:
+//
+//	sess.Table(Event{}, "events_2024_06").Insert(&e).Exec()
+//
+// The returned session shares the connections and configuration with s.
+func (s *SessionImpl) Table(i interface{}, name string) Session {
+	sess := *s
+	sess.registry = s.getRegistry().overlay()
+	sess.registry.RegisterAs(i, name)
+	return &sess
+}
+
+// WithContext returns a Session that executes the statements with the given
+// context. The context is passed to the driver, the middlewares and the
+// lifecycle callbacks. The returned session shares the connections and
+// configuration with s.
+func (s *SessionImpl) WithContext(ctx context.Context) Session {
+	sess := *s
+	sess.ctx = ctx
+	return &sess
+}
@@ -1,6 +1,8 @@
 package ecqltest
 
 import (
+	"context"
+
 	"github.com/maraino/ecql"
 	"github.com/maraino/go-mock"
 )
@@ -31,3 +33,10 @@ func (m *Batch) ApplyCAS() (bool, error) {
 	ret1, _ := ret.Get(1).(error)
 	return ret0, ret1
 }
+
+// WithContext is mocks a call to this method.
+func (m *Batch) WithContext(ctx context.Context) ecql.Batch {
+	ret := m.Called(ctx)
+	ret0, _ := ret.Get(0).(ecql.Batch)
+	return ret0
+}
@@ -0,0 +1,204 @@
+package ecqltest
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/maraino/ecql"
+)
+
+// DefaultCassandraImage is the image used by StartCassandra.
+const DefaultCassandraImage = "cassandra:4.1"
+
+// CassandraConfig contains the configuration used by
+// StartCassandraWithConfig.
+type CassandraConfig struct {
+	// Image is the container image, it defaults to the environment variable
+	// ECQL_TEST_IMAGE or DefaultCassandraImage. Scylla images can be used
+	// too, for example with the arguments "--smp", "1".
+	Image string
+	// Args are the arguments passed to the container.
+	Args []string
+	// Hosts are the hosts of an existing cluster used instead of a
+	// container, they default to the comma separated list in the environment
+	// variable ECQL_TEST_HOSTS.
+	Hosts []string
+	// Keyspace is the keyspace created for the test, it defaults to a random
+	// name. It is dropped when the test finishes.
+	Keyspace string
+	// Types are the types whose tables are created. The tables of the types
+	// in the Registry are also created.
+	Types []interface{}
+	// Registry is the registry of the session, it defaults to
+	// ecql.DefaultRegistry.
+	Registry *ecql.Registry
+	// Options are the options used to create the session.
+	Options []ecql.Option
+	// Timeout is the maximum time to wait for the database, it defaults to
+	// 3 minutes.
+	Timeout time.Duration
+}
+
+// StartCassandra starts a disposable Cassandra container, creates a keyspace
+// with the tables of the given types and of the types registered in the
+// DefaultRegistry, and returns a session using it. The container is removed
+// when the test finishes, and the test is skipped if docker is not
+// available:
+//
+//	func TestUsers(t *testing.T) {
+//		sess := ecqltest.StartCassandra(t, User{})
+//		err := sess.Set(&User{ID: "1"})
+//		// ...
+//	}
+func StartCassandra(t testing.TB, types ...interface{}) ecql.Session {
+	return StartCassandraWithConfig(t, CassandraConfig{Types: types})
+}
+
+// StartCassandraWithConfig is like StartCassandra but using the given
+// configuration.
+func StartCassandraWithConfig(t testing.TB, config CassandraConfig) ecql.Session {
+	t.Helper()
+	if config.Image == "" {
+		config.Image = os.Getenv("ECQL_TEST_IMAGE")
+	}
+	if config.Image == "" {
+		config.Image = DefaultCassandraImage
+	}
+	if config.Hosts == nil && os.Getenv("ECQL_TEST_HOSTS") != "" {
+		config.Hosts = strings.Split(os.Getenv("ECQL_TEST_HOSTS"), ",")
+	}
+	if config.Keyspace == "" {
+		config.Keyspace = fmt.Sprintf("test_%d_%d", time.Now().Unix(), rand.Intn(1e6))
+	}
+	if config.Registry == nil {
+		config.Registry = ecql.DefaultRegistry
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 3 * time.Minute
+	}
+
+	hosts := config.Hosts
+	if hosts == nil {
+		hosts = []string{startContainer(t, config)}
+	}
+
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Timeout = 30 * time.Second
+	cluster.ConnectTimeout = 10 * time.Second
+	opts := append([]ecql.Option{ecql.WithRegistry(config.Registry)}, config.Options...)
+	sess, err := connect(*cluster, opts, config.Timeout)
+	if err != nil {
+		t.Fatalf("error connecting to %s: %s", strings.Join(hosts, ","), err)
+	}
+	if err := sess.CreateKeyspace(config.Keyspace, ecql.SimpleStrategy(1)); err != nil {
+		closeSession(sess)
+		t.Fatalf("error creating keyspace %s: %s", config.Keyspace, err)
+	}
+	closeSession(sess)
+
+	cluster.Keyspace = config.Keyspace
+	sess, err = connect(*cluster, opts, config.Timeout)
+	if err != nil {
+		t.Fatalf("error connecting to %s: %s", config.Keyspace, err)
+	}
+	t.Cleanup(func() {
+		sess.DropKeyspace(config.Keyspace)
+		closeSession(sess)
+	})
+
+	if err := migrate(sess, config); err != nil {
+		t.Fatalf("error creating tables: %s", err)
+	}
+	return sess
+}
+
+// startContainer runs the container and returns the address of its native
+// protocol port.
+func startContainer(t testing.TB, config CassandraConfig) string {
+	t.Helper()
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not available")
+	}
+	args := append([]string{"run", "-d", "--rm", "-P", config.Image}, config.Args...)
+	out, err := exec.Command("docker", args...).Output()
+	if err != nil {
+		t.Skipf("error starting %s: %s", config.Image, commandError(err))
+	}
+	id := strings.TrimSpace(string(out))
+	t.Cleanup(func() {
+		exec.Command("docker", "rm", "-f", id).Run()
+	})
+
+	out, err = exec.Command("docker", "port", id, "9042/tcp").Output()
+	if err != nil {
+		t.Fatalf("error reading the port of %s: %s", config.Image, commandError(err))
+	}
+	// The output contains one line per interface: 0.0.0.0:49153
+	line := strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0]
+	_, port, err := net.SplitHostPort(line)
+	if err != nil {
+		t.Fatalf("error reading the port of %s: %s", config.Image, err)
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// commandError returns the error of a command with its standard error.
+func commandError(err error) string {
+	if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+		return strings.TrimSpace(string(e.Stderr))
+	}
+	return err.Error()
+}
+
+// connect creates a session, retrying until the database accepts
+// connections or the timeout expires.
+func connect(cluster gocql.ClusterConfig, opts []ecql.Option, timeout time.Duration) (ecql.Session, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		sess, err := ecql.NewSession(cluster, opts...)
+		if err == nil {
+			return sess, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// closeSession closes the connections of a session.
+func closeSession(sess ecql.Session) {
+	if c, ok := sess.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+// migrate creates the tables of the types in the configuration and in the
+// registry. The types of the registry without a primary key, like the
+// embedded structs, and the ones on other keyspaces, like the system tables,
+// are skipped.
+func migrate(sess ecql.Session, config CassandraConfig) error {
+	if err := sess.AutoMigrate(config.Types...); err != nil {
+		return err
+	}
+	created := make(map[string]bool)
+	for _, i := range config.Types {
+		created[config.Registry.GetTable(i).Name] = true
+	}
+	for _, t := range config.Registry.Snapshot().Tables {
+		if created[t.Name] || len(t.PartitionColumns) == 0 || strings.Contains(t.Name, ".") {
+			continue
+		}
+		if err := sess.AutoMigrateSnapshot(t); err != nil {
+			return fmt.Errorf("%s: %s", t.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package ecqltest
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Clock is an ecql.Clock that only changes with Set or Advance:
+//
+//	clock := ecqltest.NewClock(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+//	sess := ecql.NewWithDriver(driver, ecql.WithClock(clock))
+//	clock.Advance(time.Hour)
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock with the given time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now implements ecql.Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the time of the clock.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	c.mu.Unlock()
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// gregorianEpoch is the start of the time of the version 1 UUIDs.
+var gregorianEpoch = time.Date(1582, time.October, 15, 0, 0, 0, 0, time.UTC)
+
+// UUIDs is an ecql.UUIDSource that generates the same sequence of UUIDs on
+// every run. The time UUIDs use the given time, a sequential clock sequence
+// and a zero node, and the random UUIDs are version 4 UUIDs with a sequential
+// number:
+//
+//	uuids := ecqltest.NewUUIDs()
+//	sess := ecql.NewWithDriver(driver, ecql.WithUUIDSource(uuids))
+type UUIDs struct {
+	mu   sync.Mutex
+	time uint32
+	rand uint64
+}
+
+// NewUUIDs returns a new UUIDs.
+func NewUUIDs() *UUIDs {
+	return new(UUIDs)
+}
+
+// TimeUUID implements ecql.UUIDSource.
+func (u *UUIDs) TimeUUID(t time.Time) gocql.UUID {
+	u.mu.Lock()
+	u.time++
+	clock := u.time
+	u.mu.Unlock()
+	// 100-nanosecond intervals since the Gregorian epoch
+	ts := (t.Unix()-gregorianEpoch.Unix())*1e7 + int64(t.Nanosecond()/100)
+	return gocql.TimeUUIDWith(ts, clock, make([]byte, 6))
+}
+
+// RandomUUID implements ecql.UUIDSource.
+func (u *UUIDs) RandomUUID() gocql.UUID {
+	u.mu.Lock()
+	u.rand++
+	n := u.rand
+	u.mu.Unlock()
+	var id gocql.UUID
+	binary.BigEndian.PutUint64(id[8:], n)
+	id[6] = 0x40 // version 4
+	id[8] = id[8]&0x3F | 0x80
+	return id
+}
@@ -0,0 +1,87 @@
+package ecqltest
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/maraino/ecql"
+	"github.com/maraino/go-mock"
+)
+
+// Driver is a mock implementation of the Driver interface.
+type Driver struct {
+	mock.Mock
+}
+
+func NewDriver() ecql.Driver {
+	return &Driver{}
+}
+
+func (m *Driver) Iter(req *ecql.Request) ecql.Rows {
+	result := m.Called(req)
+	return result.Get(0).(ecql.Rows)
+}
+
+func (m *Driver) ExecBatch(b *ecql.BatchRequest) error {
+	result := m.Called(b)
+	return result.Error(0)
+}
+
+func (m *Driver) ExecBatchCAS(b *ecql.BatchRequest, dest map[string]interface{}) (bool, error) {
+	result := m.Called(b, dest)
+	return result.Bool(0), result.Error(1)
+}
+
+func (m *Driver) Close() {
+	m.Called()
+}
+
+// Rows is a mock implementation of the Rows interface.
+type Rows struct {
+	mock.Mock
+}
+
+func NewRows() ecql.Rows {
+	return &Rows{}
+}
+
+func (m *Rows) Columns() []gocql.ColumnInfo {
+	result := m.Called()
+	ret0, _ := result.Get(0).([]gocql.ColumnInfo)
+	return ret0
+}
+
+func (m *Rows) Scan(dest ...interface{}) bool {
+	result := m.Called(dest...)
+	return result.Bool(0)
+}
+
+func (m *Rows) MapScan(dest map[string]interface{}) bool {
+	result := m.Called(dest)
+	return result.Bool(0)
+}
+
+func (m *Rows) NumRows() int {
+	result := m.Called()
+	return result.Int(0)
+}
+
+func (m *Rows) WillSwitchPage() bool {
+	result := m.Called()
+	return result.Bool(0)
+}
+
+func (m *Rows) PageState() []byte {
+	result := m.Called()
+	ret0, _ := result.Get(0).([]byte)
+	return ret0
+}
+
+func (m *Rows) Info() ecql.QueryInfo {
+	result := m.Called()
+	ret0, _ := result.Get(0).(ecql.QueryInfo)
+	return ret0
+}
+
+func (m *Rows) Close() error {
+	result := m.Called()
+	return result.Error(0)
+}
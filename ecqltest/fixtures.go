@@ -0,0 +1,234 @@
+package ecqltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maraino/ecql"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures contains the rows of a fixture file by table name. The rows are
+// pointers to structs of the registered types.
+type Fixtures map[string][]interface{}
+
+// ReadFixtures reads a YAML or JSON fixture file, depending on its extension.
+// The file contains the rows of each table as a list of objects with the
+// values of the columns:
+//
+//	users:
+//	  - id: "1"
+//	    name: alice
+//	    created: 2024-06-01T10:00:00Z
+//	  - id: "2"
+//	    name: bob
+//
+// The rows are decoded in the type with the table name in the registry of
+// the session, types are the types of the tables in the file.
+func ReadFixtures(sess ecql.Session, file string, types ...interface{}) (Fixtures, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string][]map[string]interface{}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &data)
+	default:
+		err = json.Unmarshal(b, &data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	tables := make(map[string]interface{})
+	for _, i := range types {
+		tables[ecql.TableOf(sess, i).Name] = i
+	}
+	fixtures := make(Fixtures)
+	for name, rows := range data {
+		i, ok := tables[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown table %q", file, name)
+		}
+		for n, row := range rows {
+			v, err := decodeRow(ecql.TableOf(sess, i), i, row)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s[%d]: %s", file, name, n, err)
+			}
+			fixtures[name] = append(fixtures[name], v)
+		}
+	}
+	return fixtures, nil
+}
+
+// LoadFixtures reads a fixture file with ReadFixtures and inserts its rows.
+// The test fails if the file cannot be read or a row cannot be inserted.
+func LoadFixtures(t testing.TB, sess ecql.Session, file string, types ...interface{}) Fixtures {
+	t.Helper()
+	fixtures, err := ReadFixtures(sess, file, types...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, rows := range fixtures {
+		for _, row := range rows {
+			if err := sess.Set(row); err != nil {
+				t.Fatalf("error inserting into %s: %s", name, err)
+			}
+		}
+	}
+	return fixtures
+}
+
+// AssertTableEquals asserts that the table of the type i contains exactly
+// the expected rows, in any order. The expected rows are a slice of structs
+// or pointers to structs, like the rows of Fixtures. Only the mapped columns
+// are compared, and times are compared in UTC:
+//
+//	ecqltest.AssertTableEquals(t, sess, User{}, []User{
+//		{ID: "1", Name: "alice"},
+//		{ID: "2", Name: "bob"},
+//	})
+func AssertTableEquals(t testing.TB, sess ecql.Session, i interface{}, expected interface{}, msgAndArgs ...interface{}) bool {
+	t.Helper()
+	table := ecql.TableOf(sess, i)
+	typ := structType(i)
+
+	var actual []string
+	v := reflect.New(typ)
+	it := sess.Select(v.Interface()).Iter()
+	for it.TypeScan(v.Interface()) {
+		actual = append(actual, rowString(table, v.Elem()))
+		v = reflect.New(typ)
+	}
+	if err := it.Close(); err != nil {
+		t.Errorf("error reading %s: %s", table.Name, err)
+		return false
+	}
+
+	rv := reflect.ValueOf(expected)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		t.Errorf("expected rows must be a slice, got %T", expected)
+		return false
+	}
+	want := make([]string, rv.Len())
+	for n := range want {
+		row := reflect.ValueOf(deref(rv.Index(n)))
+		if row.Type() != typ {
+			t.Errorf("expected row %d is a %s, not a %s", n, row.Type(), typ)
+			return false
+		}
+		want[n] = rowString(table, row)
+	}
+
+	sort.Strings(actual)
+	sort.Strings(want)
+	return assert.Equal(t, want, actual, msgAndArgs...)
+}
+
+// decodeRow returns a pointer to a struct like i with the values of row in
+// the columns of the table.
+func decodeRow(table ecql.Table, i interface{}, row map[string]interface{}) (interface{}, error) {
+	columns := make(map[string]ecql.Column)
+	for _, col := range table.Columns {
+		columns[col.Name] = col
+	}
+
+	v := reflect.New(structType(i))
+	for name, value := range row {
+		col, ok := columns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if value == nil {
+			continue
+		}
+		// YAML timestamps are decoded as time.Time, they are encoded as
+		// RFC 3339 strings by encoding/json.
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %s", name, err)
+		}
+		f := fieldOf(v.Elem(), col.Position)
+		if err := json.Unmarshal(b, f.Addr().Interface()); err != nil {
+			return nil, fmt.Errorf("column %q: %s", name, err)
+		}
+	}
+	return v.Interface(), nil
+}
+
+// rowString returns the JSON representation of the columns of the struct v,
+// used to compare rows regardless of the time zones and of the nil and empty
+// collections.
+func rowString(table ecql.Table, v reflect.Value) string {
+	row := make(map[string]interface{}, len(table.Columns))
+	for _, col := range table.Columns {
+		f := fieldOf(v, col.Position)
+		if !f.IsValid() {
+			continue
+		}
+		for f.Kind() == reflect.Ptr && !f.IsNil() {
+			f = f.Elem()
+		}
+		switch {
+		case f.Kind() == reflect.Ptr, f.Kind() == reflect.Interface && f.IsNil():
+			continue
+		case (f.Kind() == reflect.Slice || f.Kind() == reflect.Map) && f.Len() == 0:
+			continue
+		}
+		value := f.Interface()
+		if tm, ok := value.(time.Time); ok {
+			value = tm.UTC()
+		}
+		row[col.Name] = value
+	}
+	b, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Sprintf("%v", row)
+	}
+	return string(b)
+}
+
+// fieldOf returns the field of v in the given position, allocating the nil
+// pointers to embedded structs if v is addressable.
+func fieldOf(v reflect.Value, position []int) reflect.Value {
+	for n, p := range position {
+		for n > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(p)
+	}
+	return v
+}
+
+// structType returns the struct type of i.
+func structType(i interface{}) reflect.Type {
+	t := reflect.TypeOf(i)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// deref returns the struct pointed by v.
+func deref(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return v.Interface()
+}
@@ -1,6 +1,8 @@
 package ecqltest
 
 import (
+	"context"
+
 	"github.com/maraino/ecql"
 	"github.com/maraino/go-mock"
 )
@@ -18,6 +20,43 @@ func (m *Iter) TypeScan(i interface{}) bool {
 	return result.Bool(0)
 }
 
+func (m *Iter) Scan(dest ...interface{}) bool {
+	result := m.Called(dest...)
+	return result.Bool(0)
+}
+
+func (m *Iter) MapScan(mp map[string]interface{}) bool {
+	result := m.Called(mp)
+	return result.Bool(0)
+}
+
+func (m *Iter) WillSwitchPage() bool {
+	result := m.Called()
+	return result.Bool(0)
+}
+
+func (m *Iter) PageState() []byte {
+	result := m.Called()
+	ret0, _ := result.Get(0).([]byte)
+	return ret0
+}
+
+func (m *Iter) Lease(p *ecql.Pool) (interface{}, bool) {
+	result := m.Called(p)
+	return result.Get(0), result.Bool(1)
+}
+
+func (m *Iter) Chan(ctx context.Context, i interface{}) <-chan interface{} {
+	result := m.Called(ctx, i)
+	ret0, _ := result.Get(0).(<-chan interface{})
+	return ret0
+}
+
+func (m *Iter) ForEach(i interface{}, fn func() error) error {
+	result := m.Called(i, fn)
+	return result.Error(0)
+}
+
 func (m *Iter) Close() error {
 	result := m.Called()
 	return result.Error(0)
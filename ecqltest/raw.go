@@ -0,0 +1,61 @@
+package ecqltest
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/maraino/ecql"
+	"github.com/maraino/go-mock"
+)
+
+type RawQuery struct {
+	mock.Mock
+}
+
+func NewRawQuery() ecql.RawQuery {
+	return &RawQuery{}
+}
+
+func (m *RawQuery) ScanType(i interface{}) error {
+	var result = m.Called(i)
+	return result.Error(0)
+}
+
+func (m *RawQuery) SelectType(dest interface{}) error {
+	var result = m.Called(dest)
+	return result.Error(0)
+}
+
+func (m *RawQuery) Scan(dest ...interface{}) error {
+	var result = m.Called(dest...)
+	return result.Error(0)
+}
+
+func (m *RawQuery) MapRows() ([]map[string]interface{}, error) {
+	var result = m.Called()
+	ret0, _ := result.Get(0).([]map[string]interface{})
+	return ret0, result.Error(1)
+}
+
+func (m *RawQuery) Exec() error {
+	var result = m.Called()
+	return result.Error(0)
+}
+
+func (m *RawQuery) Iter() ecql.Iter {
+	var result = m.Called()
+	return result.Get(0).(ecql.Iter)
+}
+
+func (m *RawQuery) Consistency(c gocql.Consistency) ecql.RawQuery {
+	var result = m.Called(c)
+	return result.Get(0).(ecql.RawQuery)
+}
+
+func (m *RawQuery) Retry(p *ecql.RetryPolicy) ecql.RawQuery {
+	var result = m.Called(p)
+	return result.Get(0).(ecql.RawQuery)
+}
+
+func (m *RawQuery) Idempotent() ecql.RawQuery {
+	var result = m.Called()
+	return result.Get(0).(ecql.RawQuery)
+}
@@ -1,6 +1,9 @@
 package ecqltest
 
 import (
+	"context"
+	"time"
+
 	"github.com/gocql/gocql"
 	"github.com/maraino/ecql"
 	"github.com/maraino/go-mock"
@@ -20,11 +23,40 @@ func (m *Session) Get(i interface{}, keys ...interface{}) error {
 	return result.Error(0)
 }
 
+func (m *Session) MultiGet(dest interface{}, keys ...interface{}) error {
+	slice := append([]interface{}{dest}, keys...)
+	result := m.Called(slice...)
+	return result.Error(0)
+}
+
+func (m *Session) SelectBuckets(dest interface{}, from, to time.Time, cond ...ecql.Condition) error {
+	args := []interface{}{dest, from, to}
+	for _, c := range cond {
+		args = append(args, c)
+	}
+	result := m.Called(args...)
+	return result.Error(0)
+}
+
+func (m *Session) SelectRange(dest interface{}, from, to time.Time, cond ...ecql.Condition) error {
+	args := []interface{}{dest, from, to}
+	for _, c := range cond {
+		args = append(args, c)
+	}
+	result := m.Called(args...)
+	return result.Error(0)
+}
+
 func (m *Session) Set(i interface{}) error {
 	result := m.Called(i)
 	return result.Error(0)
 }
 
+func (m *Session) SaveIfChanged(i interface{}) (bool, error) {
+	result := m.Called(i)
+	return result.Bool(0), result.Error(1)
+}
+
 func (m *Session) Del(i interface{}) error {
 	result := m.Called(i)
 	return result.Error(0)
@@ -50,6 +82,15 @@ func (m *Session) Delete(i interface{}) ecql.Statement {
 	return result.Get(0).(ecql.Statement)
 }
 
+func (m *Session) DeleteRange(i interface{}, cond ...ecql.Condition) ecql.Statement {
+	args := []interface{}{i}
+	for _, c := range cond {
+		args = append(args, c)
+	}
+	result := m.Called(args...)
+	return result.Get(0).(ecql.Statement)
+}
+
 func (m *Session) Update(i interface{}) ecql.Statement {
 	result := m.Called(i)
 	return result.Get(0).(ecql.Statement)
@@ -60,11 +101,114 @@ func (m *Session) Count(i interface{}) ecql.Statement {
 	return result.Get(0).(ecql.Statement)
 }
 
+func (m *Session) Truncate(i interface{}) error {
+	result := m.Called(i)
+	return result.Error(0)
+}
+
+func (m *Session) DropTable(i interface{}) error {
+	result := m.Called(i)
+	return result.Error(0)
+}
+
 func (m *Session) Batch() ecql.Batch {
 	result := m.Called()
 	return result.Get(0).(ecql.Batch)
 }
 
+func (m *Session) UnloggedBatch() ecql.Batch {
+	result := m.Called()
+	return result.Get(0).(ecql.Batch)
+}
+
+func (m *Session) Table(i interface{}, name string) ecql.Session {
+	result := m.Called(i, name)
+	return result.Get(0).(ecql.Session)
+}
+
+func (m *Session) WithContext(ctx context.Context) ecql.Session {
+	result := m.Called(ctx)
+	return result.Get(0).(ecql.Session)
+}
+
+func (m *Session) MaxRows(n int, truncated *bool) ecql.Session {
+	result := m.Called(n, truncated)
+	return result.Get(0).(ecql.Session)
+}
+
+func (m *Session) WithTenant(ctx context.Context, id interface{}) ecql.Session {
+	result := m.Called(ctx, id)
+	return result.Get(0).(ecql.Session)
+}
+
+func (m *Session) DescribeTable(keyspace, table string) (ecql.Table, error) {
+	result := m.Called(keyspace, table)
+	ret0, _ := result.Get(0).(ecql.Table)
+	return ret0, result.Error(1)
+}
+
+func (m *Session) ValidateSchema(types ...interface{}) ([]ecql.SchemaDiff, error) {
+	result := m.Called(types...)
+	ret0, _ := result.Get(0).([]ecql.SchemaDiff)
+	return ret0, result.Error(1)
+}
+
+func (m *Session) AutoMigrate(types ...interface{}) error {
+	result := m.Called(types...)
+	return result.Error(0)
+}
+
+func (m *Session) AutoMigrateSnapshot(tables ...ecql.TableSnapshot) error {
+	args := make([]interface{}, len(tables))
+	for i := range tables {
+		args[i] = tables[i]
+	}
+	result := m.Called(args...)
+	return result.Error(0)
+}
+
+func (m *Session) CreateKeyspace(name string, r ecql.Replication) error {
+	result := m.Called(name, r)
+	return result.Error(0)
+}
+
+func (m *Session) DropKeyspace(name string) error {
+	result := m.Called(name)
+	return result.Error(0)
+}
+
+func (m *Session) QueryRaw(cql string, values ...interface{}) ecql.RawQuery {
+	args := append([]interface{}{cql}, values...)
+	result := m.Called(args...)
+	return result.Get(0).(ecql.RawQuery)
+}
+
+func (m *Session) CopyTable(src, dst interface{}, transform func(src interface{}) interface{}, config ecql.CopyConfig) (ecql.BulkStats, error) {
+	result := m.Called(src, dst, transform, config)
+	return result.Get(0).(ecql.BulkStats), result.Error(1)
+}
+
+func (m *Session) ClusterStatus() ecql.ClusterStatus {
+	result := m.Called()
+	ret0, _ := result.Get(0).(ecql.ClusterStatus)
+	return ret0
+}
+
+func (m *Session) Ping(ctx context.Context) error {
+	result := m.Called(ctx)
+	return result.Error(0)
+}
+
+func (m *Session) WaitUntilReady(ctx context.Context, backoff *ecql.RetryPolicy) error {
+	result := m.Called(ctx, backoff)
+	return result.Error(0)
+}
+
+func (m *Session) Shutdown(ctx context.Context) error {
+	result := m.Called(ctx)
+	return result.Error(0)
+}
+
 func (m *Session) Query(stmt string, args ...interface{}) *gocql.Query {
 	var result = m.Called(stmt, args)
 	return result.Get(0).(*gocql.Query)
@@ -1,6 +1,10 @@
 package ecqltest
 
 import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
 	"github.com/maraino/ecql"
 	"github.com/maraino/go-mock"
 )
@@ -23,11 +27,64 @@ func (m *Statement) Scan(i ...interface{}) error {
 	return result.Error(0)
 }
 
+func (m *Statement) MapRows() ([]map[string]interface{}, error) {
+	var result = m.Called()
+	ret0, _ := result.Get(0).([]map[string]interface{})
+	return ret0, result.Error(1)
+}
+
+func (m *Statement) Page(dest interface{}, cursor string, n int) (string, error) {
+	var result = m.Called(dest, cursor, n)
+	return result.String(0), result.Error(1)
+}
+
+func (m *Statement) Count() (int64, error) {
+	var result = m.Called()
+	return result.Int64(0), result.Error(1)
+}
+
+func (m *Statement) Exists() (bool, error) {
+	var result = m.Called()
+	return result.Bool(0), result.Error(1)
+}
+
+func (m *Statement) MinOf(col string) (interface{}, error) {
+	var result = m.Called(col)
+	return result.Get(0), result.Error(1)
+}
+
+func (m *Statement) MaxOf(col string) (interface{}, error) {
+	var result = m.Called(col)
+	return result.Get(0), result.Error(1)
+}
+
+func (m *Statement) SumOf(col string) (interface{}, error) {
+	var result = m.Called(col)
+	return result.Get(0), result.Error(1)
+}
+
+func (m *Statement) AvgOf(col string) (interface{}, error) {
+	var result = m.Called(col)
+	return result.Get(0), result.Error(1)
+}
+
 func (m *Statement) Exec() error {
 	var result = m.Called()
 	return result.Error(0)
 }
 
+func (m *Statement) ExecInfo() (ecql.QueryInfo, error) {
+	var result = m.Called()
+	ret0, _ := result.Get(0).(ecql.QueryInfo)
+	return ret0, result.Error(1)
+}
+
+func (m *Statement) ExecAsync() *ecql.Future {
+	var result = m.Called()
+	ret0, _ := result.Get(0).(*ecql.Future)
+	return ret0
+}
+
 func (m *Statement) Iter() ecql.Iter {
 	var result = m.Called()
 	return result.Get(0).(ecql.Iter)
@@ -38,6 +95,12 @@ func (m *Statement) BuildQuery() (string, []interface{}) {
 	return result.String(0), result.Get(1).([]interface{})
 }
 
+func (m *Statement) Template() (*ecql.StatementTemplate, error) {
+	var result = m.Called()
+	ret0, _ := result.Get(0).(*ecql.StatementTemplate)
+	return ret0, result.Error(1)
+}
+
 func (m *Statement) Do(cmd ecql.Command) ecql.Statement {
 	var result = m.Called(cmd)
 	return result.Get(0).(ecql.Statement)
@@ -48,6 +111,21 @@ func (m *Statement) From(table string) ecql.Statement {
 	return result.Get(0).(ecql.Statement)
 }
 
+func (m *Statement) IntoTable(name string) ecql.Statement {
+	var result = m.Called(name)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) OrderByAnn(column string, vector []float32) ecql.Statement {
+	var result = m.Called(column, vector)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) UnsetEmpty() ecql.Statement {
+	var result = m.Called()
+	return result.Get(0).(ecql.Statement)
+}
+
 func (m *Statement) FromType(i interface{}) ecql.Statement {
 	var result = m.Called(i)
 	return result.Get(0).(ecql.Statement)
@@ -77,6 +155,16 @@ func (m *Statement) Where(cond ...ecql.Condition) ecql.Statement {
 	return result.Get(0).(ecql.Statement)
 }
 
+func (m *Statement) AndWhere(cond ...ecql.Condition) ecql.Statement {
+	slice := make([]interface{}, len(cond))
+	for i, v := range cond {
+		slice[i] = v
+	}
+
+	var result = m.Called(slice...)
+	return result.Get(0).(ecql.Statement)
+}
+
 func (m *Statement) OrderBy(order ...ecql.OrderBy) ecql.Statement {
 	slice := make([]interface{}, len(order))
 	for i, v := range order {
@@ -111,6 +199,26 @@ func (m *Statement) Timestamp(microseconds int64) ecql.Statement {
 	return result.Get(0).(ecql.Statement)
 }
 
+func (m *Statement) RoutingKey(values ...interface{}) ecql.Statement {
+	var result = m.Called(values...)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) InDC(dc string) ecql.Statement {
+	var result = m.Called(dc)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) ReadFrom(dc string, c gocql.Consistency) ecql.Statement {
+	var result = m.Called(dc, c)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) Consistency(c gocql.Consistency) ecql.Statement {
+	var result = m.Called(c)
+	return result.Get(0).(ecql.Statement)
+}
+
 func (m *Statement) AllowFiltering() ecql.Statement {
 	var result = m.Called()
 	return result.Get(0).(ecql.Statement)
@@ -125,3 +233,43 @@ func (m *Statement) IfNotExists() ecql.Statement {
 	var result = m.Called()
 	return result.Get(0).(ecql.Statement)
 }
+
+func (m *Statement) Clone() ecql.Statement {
+	var result = m.Called()
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) Retry(p *ecql.RetryPolicy) ecql.Statement {
+	var result = m.Called(p)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) Idempotent() ecql.Statement {
+	var result = m.Called()
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) Priority(p ecql.Priority) ecql.Statement {
+	var result = m.Called(p)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) On(cluster string) ecql.Statement {
+	var result = m.Called(cluster)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) WithContext(ctx context.Context) ecql.Statement {
+	var result = m.Called(ctx)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) Timeout(d time.Duration) ecql.Statement {
+	var result = m.Called(d)
+	return result.Get(0).(ecql.Statement)
+}
+
+func (m *Statement) Payload(key string, value []byte) ecql.Statement {
+	var result = m.Called(key, value)
+	return result.Get(0).(ecql.Statement)
+}
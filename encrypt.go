@@ -0,0 +1,193 @@
+package ecql
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	// ErrNoKeyProvider is returned when an encrypted column is written or
+	// read without a KeyProvider in the registry.
+	ErrNoKeyProvider = errors.New("no key provider set for encrypted columns")
+
+	// ErrInvalidCiphertext is returned when the value of an encrypted column
+	// is not valid.
+	ErrInvalidCiphertext = errors.New("invalid encrypted value")
+)
+
+// keyVersionSize is the size of the key version prefix of encrypted values.
+const keyVersionSize = 4
+
+// KeyProvider provides the keys used to encrypt the columns with the
+// encrypted option, `cql:"ssn,encrypted"`. The keys must be AES keys of 16,
+// 24 or 32 bytes. A provider backed by a KMS can return data keys decrypted
+// by the KMS, it should cache them as they are requested on every read and
+// write. Key columns cannot be encrypted, registering a struct with an
+// encrypted key column panics.
+type KeyProvider interface {
+	// CurrentKey returns the key used to encrypt new values and its version.
+	CurrentKey() (version uint32, key []byte, err error)
+	// Key returns the key with the given version.
+	Key(version uint32) ([]byte, error)
+}
+
+// staticKeyProvider is a KeyProvider with a fixed set of keys.
+type staticKeyProvider struct {
+	keys    map[uint32][]byte
+	current uint32
+}
+
+// NewStaticKeyProvider returns a KeyProvider with the given keys by version
+// that encrypts new values with the current version.
+func NewStaticKeyProvider(keys map[uint32][]byte, current uint32) KeyProvider {
+	return &staticKeyProvider{keys: keys, current: current}
+}
+
+func (p *staticKeyProvider) CurrentKey() (uint32, []byte, error) {
+	key, err := p.Key(p.current)
+	return p.current, key, err
+}
+
+func (p *staticKeyProvider) Key(version uint32) ([]byte, error) {
+	key, ok := p.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("key version %d not found", version)
+	}
+	return key, nil
+}
+
+// keyring keeps the KeyProvider of a registry, the columns of registered
+// types keep a reference to it so the provider can be set at any time.
+type keyring struct {
+	mu       sync.RWMutex
+	provider KeyProvider
+}
+
+func (k *keyring) get() KeyProvider {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.provider
+}
+
+// SetKeyProvider sets the KeyProvider used by the DefaultRegistry.
+func SetKeyProvider(p KeyProvider) {
+	DefaultRegistry.SetKeyProvider(p)
+}
+
+// SetKeyProvider sets the KeyProvider used to encrypt and decrypt the
+// columns with the encrypted option. The values are encrypted with AES-GCM
+// and stored in blob columns, prefixed with the version of the key, so the
+// keys can be rotated: the new values are written with the current key, and
+// the values written with previous versions can still be read. Encrypted
+// columns cannot be part of the primary key, or used in conditions.
+func (r *Registry) SetKeyProvider(p KeyProvider) {
+	r.keys.mu.Lock()
+	r.keys.provider = p
+	r.keys.mu.Unlock()
+}
+
+// KeyVersion returns the version of the key used to encrypt the value of an
+// encrypted column. It can be used to find the rows to encrypt again with
+// the current key after a rotation.
+func KeyVersion(ciphertext []byte) (uint32, error) {
+	if len(ciphertext) < keyVersionSize {
+		return 0, ErrInvalidCiphertext
+	}
+	return binary.BigEndian.Uint32(ciphertext), nil
+}
+
+// encryptedRef marshals and unmarshals the values of an encrypted column,
+// the value is encoded as JSON before it is encrypted.
+type encryptedRef struct {
+	column Column
+	v      reflect.Value
+}
+
+// MarshalCQL implements gocql.Marshaler.
+func (e encryptedRef) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if (e.v.Kind() == reflect.Ptr || e.v.Kind() == reflect.Slice || e.v.Kind() == reflect.Map) && e.v.IsNil() {
+		return nil, nil
+	}
+	provider := e.column.keys.get()
+	if provider == nil {
+		return nil, ErrNoKeyProvider
+	}
+	version, key, err := provider.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(e.v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, keyVersionSize+aead.NonceSize(), keyVersionSize+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	binary.BigEndian.PutUint32(out, version)
+	nonce := out[keyVersionSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	// The column name is authenticated so values cannot be moved between
+	// columns.
+	return aead.Seal(out, nonce, plaintext, []byte(e.column.Name)), nil
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler.
+func (e encryptedRef) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if data == nil {
+		e.v.Set(reflect.Zero(e.v.Type()))
+		return nil
+	}
+	provider := e.column.keys.get()
+	if provider == nil {
+		return ErrNoKeyProvider
+	}
+	version, err := KeyVersion(data)
+	if err != nil {
+		return err
+	}
+	key, err := provider.Key(version)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	if len(data) < keyVersionSize+aead.NonceSize() {
+		return ErrInvalidCiphertext
+	}
+	nonce := data[keyVersionSize : keyVersionSize+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, data[keyVersionSize+aead.NonceSize():], []byte(e.column.Name))
+	if err != nil {
+		return fmt.Errorf("cannot decrypt column %s: %w", e.column.Name, err)
+	}
+	v := reflect.New(e.v.Type())
+	if err := json.Unmarshal(plaintext, v.Interface()); err != nil {
+		return err
+	}
+	e.v.Set(v.Elem())
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
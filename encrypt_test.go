@@ -0,0 +1,112 @@
+package ecql
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type secretStruct struct {
+	ID    string            `cql:"id" cqltable:"secrets" cqlkey:"id"`
+	SSN   string            `cql:"ssn,encrypted"`
+	Card  *int              `cql:"card,encrypted"`
+	Attrs map[string]string `cql:"attrs,encrypted"`
+	Time  time.Time         `cql:"time,encrypted"`
+}
+
+func TestEncryptedColumns(t *testing.T) {
+	r := NewRegistry()
+	keys := map[uint32][]byte{
+		1: bytes.Repeat([]byte{1}, 32),
+		2: bytes.Repeat([]byte{2}, 16),
+	}
+	table := r.GetTable(secretStruct{})
+	assert.True(t, table.Columns[1].Encrypted)
+	assert.Equal(t, "blob", table.Columns[1].Type)
+
+	card := 4242
+	src := secretStruct{ID: "a", SSN: "123-45-6789", Card: &card, Time: time.Unix(1600000000, 0).UTC()}
+	values := r.Bind(src)
+	marshal := func(v interface{}) ([]byte, error) {
+		return v.(gocql.Marshaler).MarshalCQL(nil)
+	}
+
+	// Without provider
+	_, err := marshal(values[1])
+	assert.Equal(t, ErrNoKeyProvider, err)
+
+	r.SetKeyProvider(NewStaticKeyProvider(keys, 1))
+	ssn, err := marshal(values[1])
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(ssn, []byte("123")))
+	version, err := KeyVersion(ssn)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), version)
+
+	attrs, err := marshal(values[3])
+	assert.NoError(t, err)
+	assert.Nil(t, attrs)
+
+	// Rotation, the old values can still be read
+	r.SetKeyProvider(NewStaticKeyProvider(keys, 2))
+	cardData, err := marshal(values[2])
+	assert.NoError(t, err)
+	version, _ = KeyVersion(cardData)
+	assert.Equal(t, uint32(2), version)
+	timeData, err := marshal(values[4])
+	assert.NoError(t, err)
+
+	dst := secretStruct{ID: "a"}
+	m, _ := r.MapTable(&dst)
+	unmarshal := func(col string, data []byte) error {
+		return m[col].(gocql.Unmarshaler).UnmarshalCQL(nil, data)
+	}
+	assert.NoError(t, unmarshal("ssn", ssn))
+	assert.NoError(t, unmarshal("card", cardData))
+	assert.NoError(t, unmarshal("attrs", nil))
+	assert.NoError(t, unmarshal("time", timeData))
+	assert.Equal(t, src, dst)
+
+	// Invalid values
+	ssn[len(ssn)-1]++
+	assert.Error(t, unmarshal("ssn", ssn))
+	assert.Equal(t, ErrInvalidCiphertext, unmarshal("ssn", []byte{0, 0}))
+	assert.Error(t, unmarshal("card", []byte{0, 0, 0, 3, 1, 2, 3}))
+
+	// Values cannot be moved between columns
+	assert.Error(t, unmarshal("card", timeData))
+}
+
+func TestEncryptedColumnsSchema(t *testing.T) {
+	r := NewRegistry()
+	table := r.GetTable(secretStruct{})
+	cqlTypes, err := columnTypes(reflect.TypeOf(secretStruct{}), table)
+	assert.NoError(t, err)
+	assert.Equal(t, "blob", cqlTypes["ssn"])
+
+	var d SchemaDiff
+	d.compare(reflect.TypeOf(secretStruct{}), table, Table{Columns: []Column{
+		{Name: "id", Type: "text"}, {Name: "ssn", Type: "blob"}, {Name: "card", Type: "blob"},
+		{Name: "attrs", Type: "blob"}, {Name: "time", Type: "text"},
+	}})
+	assert.Equal(t, []TypeMismatch{{Column: "time", GoType: "time.Time", CQLType: "text"}}, d.Types)
+}
+
+func TestEncryptedKeyColumns(t *testing.T) {
+	r := NewRegistry()
+	assert.Panics(t, func() {
+		r.Register(struct {
+			ID string `cql:"id,encrypted" cqltable:"secrets"`
+		}{})
+	})
+	assert.Panics(t, func() {
+		r.Register(struct {
+			ID  string `cql:"id" cqltable:"secrets" cqlkey:"id,ssn"`
+			SSN string `cql:"ssn,encrypted"`
+		}{})
+	})
+}
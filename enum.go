@@ -0,0 +1,164 @@
+package ecql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+// Enum is implemented by the types with a fixed set of values. String types
+// are stored as text and must be one of the values, integer types are stored
+// as int and must be a valid index of the values, or as text if the column
+// has the option type=text, in this case the value is the name at the index.
+//
+//	type Status int
+//
+//	func (Status) EnumValues() []string {
+//		return []string{"pending", "active", "deleted"}
+//	}
+//
+// Fields of types that do not implement Enum can use the tag option enum with
+// the values separated by |, `cql:"color,enum=red|green|blue"`.
+type Enum interface {
+	EnumValues() []string
+}
+
+// EnumError is the error returned when a value of an enum column is not
+// valid.
+type EnumError struct {
+	Column string
+	Value  interface{}
+}
+
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("invalid value %v for enum column %s", e.Value, e.Column)
+}
+
+// enumValues returns the values of the enumeration of the type t, and the
+// ones in the tag options.
+func enumValues(t reflect.Type, opts tagOptions) []string {
+	if values, ok := opts.value("enum"); ok {
+		return strings.Split(values, "|")
+	}
+	if t.Implements(enumType) {
+		return reflect.Zero(t).Interface().(Enum).EnumValues()
+	}
+	if reflect.PtrTo(t).Implements(enumType) {
+		return reflect.New(t).Interface().(Enum).EnumValues()
+	}
+	return nil
+}
+
+// enumRef marshals and unmarshals the values of an enum column.
+type enumRef struct {
+	column Column
+	v      reflect.Value
+}
+
+// asText returns true if the value is stored as text.
+func (e enumRef) asText() bool {
+	return e.v.Kind() == reflect.String || isTextType(e.column.Type)
+}
+
+// MarshalCQL implements gocql.Marshaler.
+func (e enumRef) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if e.v.Kind() == reflect.String {
+		if !e.valid(e.v.String()) {
+			return nil, &EnumError{Column: e.column.Name, Value: e.v.String()}
+		}
+		return gocql.Marshal(info, e.v.String())
+	}
+
+	n, ok := e.index()
+	if !ok {
+		return nil, &EnumError{Column: e.column.Name, Value: n}
+	}
+	if e.asText() {
+		return gocql.Marshal(info, e.column.Enum[n])
+	}
+	return gocql.Marshal(info, n)
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler.
+func (e enumRef) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if data == nil {
+		e.v.Set(reflect.Zero(e.v.Type()))
+		return nil
+	}
+
+	if e.asText() {
+		var s string
+		if err := gocql.Unmarshal(info, data, &s); err != nil {
+			return err
+		}
+		if e.v.Kind() == reflect.String {
+			if !e.valid(s) {
+				return &EnumError{Column: e.column.Name, Value: s}
+			}
+			e.v.SetString(s)
+			return nil
+		}
+		for i, name := range e.column.Enum {
+			if name == s {
+				e.setIndex(int64(i))
+				return nil
+			}
+		}
+		return &EnumError{Column: e.column.Name, Value: s}
+	}
+
+	var n int64
+	if err := gocql.Unmarshal(info, data, &n); err != nil {
+		return err
+	}
+	if n < 0 || n >= int64(len(e.column.Enum)) {
+		return &EnumError{Column: e.column.Name, Value: n}
+	}
+	e.setIndex(n)
+	return nil
+}
+
+// index returns the index of an integer value and if it is a valid index of
+// the values.
+func (e enumRef) index() (int64, bool) {
+	switch e.v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := e.v.Uint()
+		return int64(n), n < uint64(len(e.column.Enum))
+	default:
+		n := e.v.Int()
+		return n, n >= 0 && n < int64(len(e.column.Enum))
+	}
+}
+
+// setIndex sets the index n in an integer value.
+func (e enumRef) setIndex(n int64) {
+	switch e.v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.v.SetUint(uint64(n))
+	default:
+		e.v.SetInt(n)
+	}
+}
+
+func (e enumRef) valid(s string) bool {
+	for _, name := range e.column.Enum {
+		if name == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isTextType(cqlType string) bool {
+	switch cqlType {
+	case "text", "varchar", "ascii":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,112 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatus int
+
+func (testStatus) EnumValues() []string {
+	return []string{"pending", "active", "deleted"}
+}
+
+type testLevel int
+
+func (*testLevel) EnumValues() []string {
+	return []string{"low", "high"}
+}
+
+type testColor uint8
+
+func (testColor) EnumValues() []string {
+	return []string{"red", "green", "blue"}
+}
+
+type enumStruct struct {
+	ID     string     `cql:"id" cqltable:"enums"`
+	Status testStatus `cql:"status"`
+	Level  testLevel  `cql:"level,type=text"`
+	Color  string     `cql:"color,enum=red|green|blue"`
+}
+
+func TestEnumColumns(t *testing.T) {
+	DeleteRegistry()
+	table := GetTable(enumStruct{})
+	assert.Nil(t, table.Columns[0].Enum)
+	assert.Equal(t, []string{"pending", "active", "deleted"}, table.Columns[1].Enum)
+	assert.Equal(t, []string{"low", "high"}, table.Columns[2].Enum)
+	assert.Equal(t, []string{"red", "green", "blue"}, table.Columns[3].Enum)
+
+	sess, d := newTestSession(WithKeyspace("ks"))
+	assert.NoError(t, sess.AutoMigrate(enumStruct{}))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS enums (id text, status int, level text, color text, PRIMARY KEY (id))", d.last().Statement)
+}
+
+func TestEnumMarshal(t *testing.T) {
+	DeleteRegistry()
+	intInfo := gocql.NewNativeType(4, gocql.TypeInt, "")
+	textInfo := gocql.NewNativeType(4, gocql.TypeVarchar, "")
+
+	e := enumStruct{ID: "a", Status: 1, Level: 1, Color: "green"}
+	m := Map(&e)
+
+	data, err := m["status"].(gocql.Marshaler).MarshalCQL(intInfo)
+	assert.NoError(t, err)
+	assert.NoError(t, m["status"].(gocql.Unmarshaler).UnmarshalCQL(intInfo, data))
+	assert.Equal(t, testStatus(1), e.Status)
+
+	data, err = m["level"].(gocql.Marshaler).MarshalCQL(textInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "high", string(data))
+	assert.NoError(t, m["level"].(gocql.Unmarshaler).UnmarshalCQL(textInfo, []byte("low")))
+	assert.Equal(t, testLevel(0), e.Level)
+
+	assert.NoError(t, m["color"].(gocql.Unmarshaler).UnmarshalCQL(textInfo, []byte("blue")))
+	assert.Equal(t, "blue", e.Color)
+
+	// Invalid values
+	err = m["color"].(gocql.Unmarshaler).UnmarshalCQL(textInfo, []byte("pink"))
+	assert.Equal(t, &EnumError{Column: "color", Value: "pink"}, err)
+	assert.Equal(t, "invalid value pink for enum column color", err.Error())
+	assert.Equal(t, "blue", e.Color)
+
+	data, _ = gocql.Marshal(intInfo, 7)
+	assert.Equal(t, &EnumError{Column: "status", Value: int64(7)}, m["status"].(gocql.Unmarshaler).UnmarshalCQL(intInfo, data))
+	assert.Equal(t, &EnumError{Column: "level", Value: "medium"}, m["level"].(gocql.Unmarshaler).UnmarshalCQL(textInfo, []byte("medium")))
+
+	e.Status = 3
+	_, err = Bind(e)[1].(gocql.Marshaler).MarshalCQL(intInfo)
+	assert.Equal(t, &EnumError{Column: "status", Value: int64(3)}, err)
+}
+
+func TestEnumUnsigned(t *testing.T) {
+	DeleteRegistry()
+	intInfo := gocql.NewNativeType(4, gocql.TypeInt, "")
+	textInfo := gocql.NewNativeType(4, gocql.TypeVarchar, "")
+
+	e := struct {
+		ID    string    `cql:"id" cqltable:"colors"`
+		Color testColor `cql:"color"`
+		Name  testColor `cql:"name,type=text"`
+	}{ID: "a", Color: 2, Name: 1}
+	m := Map(&e)
+
+	data, err := m["color"].(gocql.Marshaler).MarshalCQL(intInfo)
+	assert.NoError(t, err)
+	e.Color = 0
+	assert.NoError(t, m["color"].(gocql.Unmarshaler).UnmarshalCQL(intInfo, data))
+	assert.Equal(t, testColor(2), e.Color)
+
+	data, err = m["name"].(gocql.Marshaler).MarshalCQL(textInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "green", string(data))
+	assert.NoError(t, m["name"].(gocql.Unmarshaler).UnmarshalCQL(textInfo, []byte("blue")))
+	assert.Equal(t, testColor(2), e.Name)
+
+	e.Color = 3
+	_, err = m["color"].(gocql.Marshaler).MarshalCQL(intInfo)
+	assert.Equal(t, &EnumError{Column: "color", Value: int64(3)}, err)
+}
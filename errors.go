@@ -1,8 +1,199 @@
 package ecql
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 
+	"github.com/gocql/gocql"
+)
+
+var (
+	ErrInvalidQueryType   = errors.New("invalid query type")
+	ErrInvalidCommand     = errors.New("invalid cql command")
+	ErrInvalidDestination = errors.New("invalid destination, a pointer to a slice of structs is required")
+	ErrNotSharded         = errors.New("type not registered with a sharding range")
+	ErrNoKeyspace         = errors.New("keyspace not defined")
+	ErrUnsupportedType    = errors.New("unsupported type, a cql type cannot be inferred")
+	ErrInvalidVector      = errors.New("invalid vector, data length is not a multiple of 4")
+	ErrInvalidDuration    = errors.New("invalid duration, durations with months cannot be converted")
+	ErrInvalidDecimal     = errors.New("invalid decimal, NaN and infinite values cannot be converted")
+	ErrEmptyKey           = errors.New("empty key column")
+)
+
+// KeyError is the error returned when a row is deleted by type and one of the
+// columns of its primary key is empty. It matches ErrEmptyKey with errors.Is.
+type KeyError struct {
+	Table  string
+	Column string
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%v %s in table %s", ErrEmptyKey, e.Column, e.Table)
+}
+
+// Is reports if target is ErrEmptyKey.
+func (e *KeyError) Is(target error) bool {
+	return target == ErrEmptyKey
+}
+
+// The kinds of the errors returned by the database. The errors returned by
+// the statements are *QueryError values that match one of these with
+// errors.Is:
+//
+//	if errors.Is(err, ecql.ErrTimeout) {
+//		// retry
+//	}
 var (
-	ErrInvalidQueryType = errors.New("invalid query type")
-	ErrInvalidCommand   = errors.New("invalid cql command")
+	ErrTimeout       = errors.New("timeout")
+	ErrUnavailable   = errors.New("unavailable")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrInvalidQuery  = errors.New("invalid query")
 )
+
+// QueryError is the error returned when the execution of a statement fails.
+// Kind is one of ErrTimeout, ErrUnavailable, ErrAlreadyExists or
+// ErrInvalidQuery, or nil if the error is not classified. Command, Table and
+// CQL describe the failed statement, Table is empty on batches. Args
+// summarizes the bound values with their types and lengths, the values are
+// not included to not leak them in the logs. Annotations are the annotations
+// of the context of the statement, see Annotate. Err is the error returned by
+// the driver, and it can be inspected with errors.As:
+//
+//	var unavailable *gocql.RequestErrUnavailable
+//	if errors.As(err, &unavailable) {
+//		// ...
+//	}
+type QueryError struct {
+	Kind        error
+	Command     Command
+	Table       string
+	CQL         string
+	Args        []string
+	Annotations map[string]string
+	Err         error
+}
+
+func (e *QueryError) Error() string {
+	msg := fmt.Sprintf("%v", e.Err)
+	if e.Table != "" {
+		msg += fmt.Sprintf(" [%s %s]", e.Command, e.Table)
+	}
+	msg += fmt.Sprintf(" [cql: %s]", e.CQL)
+	if len(e.Args) > 0 {
+		msg += fmt.Sprintf(" [args: %s]", strings.Join(e.Args, ", "))
+	}
+	if len(e.Annotations) > 0 {
+		msg += fmt.Sprintf(" [%s]", formatAnnotations(e.Annotations))
+	}
+	return msg
+}
+
+// Unwrap returns the error returned by the driver.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports if target is the kind of the error.
+func (e *QueryError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// wrapError returns err as a *QueryError of the request. ErrNotFound is not
+// wrapped.
+func wrapError(err error, req *Request) error {
+	if err == nil || err == ErrNotFound {
+		return err
+	}
+	if _, ok := err.(*QueryError); ok {
+		return err
+	}
+	return newQueryError(req, err)
+}
+
+// wrapBatchError is like wrapError but for batches.
+func wrapBatchError(err error, b *BatchRequest) error {
+	if err == nil {
+		return err
+	}
+	if _, ok := err.(*QueryError); ok {
+		return err
+	}
+	return newBatchError(b, err)
+}
+
+// newQueryError returns the *QueryError of the request with the given error.
+func newQueryError(req *Request, err error) *QueryError {
+	return &QueryError{
+		Kind:        errorKind(err),
+		Command:     req.Command,
+		Table:       req.Table,
+		CQL:         req.Statement,
+		Args:        summarizeArgs(req.Values),
+		Annotations: req.Annotations,
+		Err:         err,
+	}
+}
+
+// newBatchError returns the *QueryError of the batch with the given error.
+func newBatchError(b *BatchRequest, err error) *QueryError {
+	var values []interface{}
+	for i := range b.Entries {
+		values = append(values, b.Entries[i].Values...)
+	}
+	return &QueryError{Kind: errorKind(err), CQL: b.cql(), Args: summarizeArgs(values), Annotations: b.Annotations, Err: err}
+}
+
+// summarizeArgs returns the types of the values, with the length of the
+// strings and collections: "string(5)", "int", "[]string(2)" or "nil".
+func summarizeArgs(values []interface{}) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	args := make([]string, len(values))
+	for i, v := range values {
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		switch {
+		case rv.Kind() == reflect.Ptr:
+			args[i] = "nil"
+		case !rv.IsValid():
+			args[i] = "nil"
+		case rv.Kind() == reflect.String, rv.Kind() == reflect.Slice, rv.Kind() == reflect.Map, rv.Kind() == reflect.Array:
+			args[i] = fmt.Sprintf("%s(%d)", rv.Type(), rv.Len())
+		default:
+			args[i] = rv.Type().String()
+		}
+	}
+	return args
+}
+
+// errorKind returns the kind of a driver error.
+func errorKind(err error) error {
+	var reqErr gocql.RequestError
+	if errors.As(err, &reqErr) {
+		switch reqErr.Code() {
+		case gocql.ErrCodeReadTimeout, gocql.ErrCodeWriteTimeout:
+			return ErrTimeout
+		case gocql.ErrCodeUnavailable:
+			return ErrUnavailable
+		case gocql.ErrCodeAlreadyExists:
+			return ErrAlreadyExists
+		case gocql.ErrCodeSyntax, gocql.ErrCodeInvalid:
+			return ErrInvalidQuery
+		}
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, gocql.ErrTimeoutNoResponse), errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, gocql.ErrNoConnections), errors.Is(err, gocql.ErrUnavailable):
+		return ErrUnavailable
+	}
+	return nil
+}
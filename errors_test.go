@@ -0,0 +1,101 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequestError struct {
+	code int
+}
+
+func (e testRequestError) Code() int       { return e.code }
+func (e testRequestError) Message() string { return "message" }
+func (e testRequestError) Error() string   { return "request error" }
+
+func TestErrorKind(t *testing.T) {
+	var tests = []struct {
+		err  error
+		kind error
+	}{
+		{testRequestError{gocql.ErrCodeReadTimeout}, ErrTimeout},
+		{testRequestError{gocql.ErrCodeWriteTimeout}, ErrTimeout},
+		{testRequestError{gocql.ErrCodeUnavailable}, ErrUnavailable},
+		{testRequestError{gocql.ErrCodeAlreadyExists}, ErrAlreadyExists},
+		{testRequestError{gocql.ErrCodeSyntax}, ErrInvalidQuery},
+		{testRequestError{gocql.ErrCodeInvalid}, ErrInvalidQuery},
+		{testRequestError{gocql.ErrCodeServer}, nil},
+		{gocql.ErrTimeoutNoResponse, ErrTimeout},
+		{context.DeadlineExceeded, ErrTimeout},
+		{gocql.ErrNoConnections, ErrUnavailable},
+		{errors.New("foo"), nil},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.kind, errorKind(tc.err), tc.err.Error())
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	d.err = testRequestError{gocql.ErrCodeUnavailable}
+	err := sess.Set(testStruct{F1: "foo"})
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.False(t, errors.Is(err, ErrTimeout))
+	var qe *QueryError
+	assert.True(t, errors.As(err, &qe))
+	assert.Equal(t, "INSERT INTO mytable (f1,f22,f3,f4) VALUES (?,?,?,?)", qe.CQL)
+	var reqErr gocql.RequestError
+	assert.True(t, errors.As(err, &reqErr))
+	assert.Equal(t, InsertCmd, qe.Command)
+	assert.Equal(t, "mytable", qe.Table)
+	assert.Equal(t, []string{"string(3)", "int", "map[string]string(0)", "nil"}, qe.Args)
+	assert.Equal(t, "request error [INSERT mytable] [cql: INSERT INTO mytable (f1,f22,f3,f4) VALUES (?,?,?,?)] [args: string(3), int, map[string]string(0), nil]", err.Error())
+
+	err = sess.Batch().Add(sess.Insert(testStruct{F1: "foo"}), sess.Delete(testStruct{F1: "foo"})).Apply()
+	assert.True(t, errors.As(err, &qe))
+	assert.Equal(t, "BEGIN BATCH INSERT INTO mytable (f1,f22,f3,f4) VALUES (?,?,?,?); DELETE FROM mytable WHERE f1 = ? APPLY BATCH", qe.CQL)
+	assert.Equal(t, "", qe.Table)
+	assert.Len(t, qe.Args, 5)
+
+	// The values are not in the message
+	d.err = testRequestError{gocql.ErrCodeInvalid}
+	err = sess.Select(testStruct{}).Where(Eq("f1", "secret"), In("f22", 1, 2)).Exec()
+	assert.True(t, errors.Is(err, ErrInvalidQuery))
+	assert.NotContains(t, err.Error(), "secret")
+	assert.Equal(t, "request error [SELECT mytable] [cql: SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ? AND f22 IN (?,?)] [args: string(6), int, int]", err.Error())
+
+	// Not found is not wrapped
+	d.err = nil
+	var ts testStruct
+	assert.Equal(t, ErrNotFound, sess.Get(&ts, "foo"))
+}
+
+func TestDeleteEmptyKey(t *testing.T) {
+	sess, d := newTestSession()
+	id := MustUUID("00000001-0000-1000-8000-000000000000")
+
+	assert.NoError(t, sess.Delete(pageStruct{Stream: "s", Seq: 1, ID: id}).Exec())
+	assert.Equal(t, "DELETE FROM pages WHERE stream = ? AND seq = ? AND id = ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"s", 1, id}, derefValues(d.last()))
+
+	n := len(d.requests)
+	err := sess.Delete(pageStruct{Stream: "s", Seq: 1}).Exec()
+	assert.True(t, errors.Is(err, ErrEmptyKey))
+	assert.Equal(t, &KeyError{Table: "pages", Column: "id"}, err)
+	assert.Equal(t, "empty key column id in table pages", err.Error())
+
+	err = sess.Del(&pageStruct{Seq: 1, ID: id})
+	assert.Equal(t, &KeyError{Table: "pages", Column: "stream"}, err)
+	assert.Len(t, d.requests, n)
+	// Nil embedded pointers
+	table := sess.getRegistry().GetTable(&pageEmbeddedStruct{PageEmbedded: &PageEmbedded{}})
+	err = table.checkKey(structOf(pageEmbeddedStruct{Stream: "s"}))
+	assert.Equal(t, &KeyError{Table: "embedded_pages", Column: "seq"}, err)
+	assert.NoError(t, table.checkKey(structOf(pageEmbeddedStruct{Stream: "s", PageEmbedded: &PageEmbedded{Seq: 1}})))
+}
@@ -0,0 +1,25 @@
+package ecql
+
+// Exists executes the statement selecting only the first key column of one
+// row, and returns true if a row matches the conditions:
+//
+//	ok, err := sess.Select(Event{}).Where(Eq("stream", id), Gt("time", t)).Exists()
+func (s *StatementImpl) Exists() (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	stmt := s.Clone().(*StatementImpl)
+	stmt.Command = SelectCmd
+	stmt.ColumnNames = []string{"*"}
+	if len(s.Table.KeyColumns) > 0 {
+		stmt.ColumnNames = []string{s.Table.KeyColumns[0]}
+	}
+	stmt.Orders, stmt.AnnColumn, stmt.LimitValue = nil, "", 1
+
+	iter := stmt.Iter()
+	found := iter.MapScan(make(map[string]interface{}))
+	if err := iter.Close(); err != nil {
+		return false, err
+	}
+	return found, nil
+}
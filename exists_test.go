@@ -0,0 +1,34 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementExists(t *testing.T) {
+	sess, d := newTestSession()
+	stmt := sess.Select(testStruct{}).Where(Eq("f1", "a")).OrderBy(Desc("f22")).Limit(10)
+
+	d.result([]string{"f1"}, []interface{}{"a"})
+	ok, err := stmt.Exists()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT f1 FROM mytable WHERE f1 = ? LIMIT 1", d.last().Statement)
+	assert.Equal(t, []interface{}{"a"}, d.last().Values)
+
+	d.result([]string{"f1"})
+	ok, err = stmt.Exists()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// Without a registered type
+	NewStatement(sess).Do(SelectCmd).From("mytable").Where(Eq("f1", "a")).Exists()
+	assert.Equal(t, "SELECT * FROM mytable WHERE f1 = ? LIMIT 1", d.last().Statement)
+
+	d.err = errors.New("exists error")
+	ok, err = stmt.Exists()
+	assert.True(t, errors.Is(err, d.err))
+	assert.False(t, ok)
+}
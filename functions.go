@@ -0,0 +1,26 @@
+package ecql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fn returns a call to the CQL function name with the given arguments. It is
+// used to select computed values with Statement.Columns:
+//
+//	sess.Select(&e).Columns("id", As(Fn("toTimestamp", "id"), "created")).Iter()
+func Fn(name string, args ...string) string {
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
+
+// Cast returns the expression CAST(column AS cqlType).
+func Cast(column, cqlType string) string {
+	return fmt.Sprintf("CAST(%s AS %s)", column, cqlType)
+}
+
+// As returns the expression expr with the given alias. The alias is the
+// column name of the result, so it is used to scan the value into the field
+// with the same name on TypeScan and Iter.
+func As(expr, alias string) string {
+	return fmt.Sprintf("%s AS %s", expr, alias)
+}
@@ -0,0 +1,24 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctions(t *testing.T) {
+	assert.Equal(t, "now()", Fn("now"))
+	assert.Equal(t, "toTimestamp(id)", Fn("toTimestamp", "id"))
+	assert.Equal(t, "token(id, bucket)", Fn("token", "id", "bucket"))
+	assert.Equal(t, "CAST(f22 AS text)", Cast("f22", "text"))
+	assert.Equal(t, "CAST(f22 AS text) AS f1", As(Cast("f22", "text"), "f1"))
+
+	DeleteRegistry()
+	sess, d := newTestSession()
+	d.result([]string{"f1", "f22"}, []interface{}{"123", 123})
+	var ts testStruct
+	assert.NoError(t, sess.Select(&ts).Columns(As(Cast("f22", "text"), "f1"), "f22").Where(Eq("f1", "foo")).TypeScan())
+	assert.Equal(t, "SELECT CAST(f22 AS text) AS f1, f22 FROM mytable WHERE f1 = ?", d.last().Statement)
+	assert.Equal(t, "123", ts.F1)
+	assert.Equal(t, 123, ts.F2)
+}
@@ -0,0 +1,78 @@
+package ecql
+
+// DefaultMaxConcurrency is the default number of statements that a Session
+// executes asynchronously at the same time.
+var DefaultMaxConcurrency = 128
+
+// DefaultMultiGetParallelism is the default number of concurrent SELECT
+// statements that Session.MultiGet uses.
+var DefaultMultiGetParallelism = 16
+
+// Future represents the result of a statement executed asynchronously.
+type Future struct {
+	done chan struct{}
+	err  error
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+func (f *Future) complete(err error) {
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel that is closed when the statement finishes.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the statement finishes and returns its error.
+func (f *Future) Wait() error {
+	<-f.done
+	return f.err
+}
+
+// WaitAll waits for all the futures to finish and returns the first error
+// found, if any.
+func WaitAll(futures ...*Future) error {
+	var err error
+	for _, f := range futures {
+		if e := f.Wait(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// async runs fn in a new goroutine and returns a Future with its result. The
+// number of functions running at the same time is bounded by the session, if
+// the limit is reached async blocks until a slot is released. Sessions
+// created without a constructor are not bounded. After Shutdown the future
+// fails with ErrSessionClosed.
+//
+// The function is counted as one statement in flight until it returns, so the
+// statements executed with the session passed to fn are not counted again.
+func (s *SessionImpl) async(fn func(sess *SessionImpl) error) *Future {
+	f := newFuture()
+	if !s.drain.acquire() {
+		f.complete(ErrSessionClosed)
+		return f
+	}
+	sess := *s
+	sess.drainHeld = true
+	if s.sem != nil {
+		s.sem <- struct{}{}
+	}
+	go func() {
+		defer func() {
+			if s.sem != nil {
+				<-s.sem
+			}
+			s.drain.release()
+		}()
+		f.complete(fn(&sess))
+	}()
+	return f
+}
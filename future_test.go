@@ -0,0 +1,53 @@
+package ecql
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuture(t *testing.T) {
+	s := &SessionImpl{}
+	WithMaxConcurrency(2)(s)
+
+	var running, max int32
+	fn := func(*SessionImpl) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	var futures []*Future
+	for i := 0; i < 6; i++ {
+		futures = append(futures, s.async(fn))
+	}
+	assert.NoError(t, WaitAll(futures...))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&max))
+
+	errFoo := errors.New("foo")
+	f := s.async(func(*SessionImpl) error { return errFoo })
+	<-f.Done()
+	assert.Equal(t, errFoo, f.Wait())
+	assert.Equal(t, errFoo, WaitAll(s.async(fn), f))
+}
+
+func TestExecAsyncClone(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	stmt := sess.Update(testStruct{F1: "a"}).Set("f22", 1)
+	f := stmt.ExecAsync()
+	// The changes after ExecAsync are not executed
+	stmt.Set("f22", 2)
+	assert.NoError(t, f.Wait())
+	assert.Equal(t, []interface{}{1, "a"}, derefValues(d.last()))
+}
@@ -0,0 +1,157 @@
+package ecql
+
+import (
+	"context"
+	"reflect"
+)
+
+// BeforeInserter is implemented by the types that run code before they are
+// inserted, for example to set derived fields. The changes in the struct are
+// written.
+//
+// The lifecycle callbacks are called by Get, Set and Del, by the statements
+// built from structs with Insert, Update and Delete, and by the scans of
+// structs. If a callback returns an error, it is returned and, on the
+// callbacks called before a write, the statement is not executed. The
+// callbacks called after a write are only called if the write succeeds, on
+// batches they are called after the batch is applied.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter is implemented by the types that run code after they are
+// inserted.
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is implemented by the types that run code before they are
+// updated. The changes in the struct are written.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater is implemented by the types that run code after they are
+// updated.
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDeleter is implemented by the types that run code before they are
+// deleted.
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDeleter is implemented by the types that run code after they are
+// deleted.
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// AfterScanner is implemented by the types that run code after a row is
+// scanned into them, for example to decrypt or compute fields.
+type AfterScanner interface {
+	AfterScan(ctx context.Context) error
+}
+
+// beforeWrite calls the callback of i before executing cmd. It returns the
+// struct modified by the callback, a pointer to a copy of i if the callback
+// has a pointer receiver and i is not a pointer, or nil if there is no
+// callback.
+func beforeWrite(ctx context.Context, cmd Command, i interface{}) (interface{}, error) {
+	p, ok := hookPointer(i, func(v interface{}) bool {
+		switch cmd {
+		case InsertCmd:
+			_, ok := v.(BeforeInserter)
+			return ok
+		case UpdateCmd:
+			_, ok := v.(BeforeUpdater)
+			return ok
+		case DeleteCmd:
+			_, ok := v.(BeforeDeleter)
+			return ok
+		}
+		return false
+	})
+	if !ok {
+		return nil, nil
+	}
+
+	var err error
+	switch cmd {
+	case InsertCmd:
+		err = p.(BeforeInserter).BeforeInsert(ctx)
+	case UpdateCmd:
+		err = p.(BeforeUpdater).BeforeUpdate(ctx)
+	case DeleteCmd:
+		err = p.(BeforeDeleter).BeforeDelete(ctx)
+	}
+	return p, err
+}
+
+// afterWrite calls the callback of i after executing cmd.
+func afterWrite(ctx context.Context, cmd Command, i interface{}) error {
+	p, ok := hookPointer(i, func(v interface{}) bool {
+		switch v.(type) {
+		case AfterInserter, AfterUpdater, AfterDeleter:
+			return true
+		}
+		return false
+	})
+	if !ok {
+		return nil
+	}
+	switch cmd {
+	case InsertCmd:
+		if h, ok := p.(AfterInserter); ok {
+			return h.AfterInsert(ctx)
+		}
+	case UpdateCmd:
+		if h, ok := p.(AfterUpdater); ok {
+			return h.AfterUpdate(ctx)
+		}
+	case DeleteCmd:
+		if h, ok := p.(AfterDeleter); ok {
+			return h.AfterDelete(ctx)
+		}
+	}
+	return nil
+}
+
+// afterScan calls the AfterScan callback of the struct v.
+func afterScan(ctx context.Context, v reflect.Value) error {
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	if h, ok := v.Interface().(AfterScanner); ok {
+		return h.AfterScan(ctx)
+	}
+	return nil
+}
+
+// hookPointer returns i, or a pointer to a copy of i if the callbacks are
+// implemented with pointer receivers, if has returns true.
+func hookPointer(i interface{}, has func(v interface{}) bool) (interface{}, bool) {
+	if has(i) {
+		return i, true
+	}
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	if has(p.Interface()) {
+		return p.Interface(), true
+	}
+	return nil, false
+}
+
+// contextOf returns ctx, or the background context if ctx is nil.
+func contextOf(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
@@ -0,0 +1,105 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookStruct struct {
+	ID    string    `cql:"id" cqltable:"hooks" cqlkey:"id"`
+	Name  string    `cql:"name"`
+	Lower string    `cql:"lower"`
+	calls *[]string `cql:"-"`
+}
+
+func (h *hookStruct) call(name string) error {
+	if h.calls != nil {
+		*h.calls = append(*h.calls, name)
+	}
+	if h.Name == "fail-"+name {
+		return errors.New(name + " failed")
+	}
+	return nil
+}
+
+func (h *hookStruct) BeforeInsert(ctx context.Context) error {
+	h.Lower = strings.ToLower(h.Name)
+	return h.call("BeforeInsert")
+}
+
+func (h *hookStruct) AfterInsert(ctx context.Context) error { return h.call("AfterInsert") }
+func (h *hookStruct) BeforeUpdate(ctx context.Context) error {
+	h.Lower = strings.ToLower(h.Name)
+	return h.call("BeforeUpdate")
+}
+func (h *hookStruct) AfterUpdate(ctx context.Context) error  { return h.call("AfterUpdate") }
+func (h *hookStruct) BeforeDelete(ctx context.Context) error { return h.call("BeforeDelete") }
+func (h *hookStruct) AfterDelete(ctx context.Context) error  { return h.call("AfterDelete") }
+func (h *hookStruct) AfterScan(ctx context.Context) error {
+	h.Lower = strings.ToLower(h.Name)
+	return h.call("AfterScan")
+}
+
+func TestLifecycleCallbacks(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	var calls []string
+
+	// Set and Insert, the values set by the callback are written
+	assert.NoError(t, sess.Set(hookStruct{ID: "a", Name: "Foo", calls: &calls}))
+	assert.Equal(t, []interface{}{"a", "Foo", "foo"}, d.last().Values)
+	h := &hookStruct{ID: "a", Name: "Bar", calls: &calls}
+	assert.NoError(t, sess.Insert(h).Exec())
+	assert.Equal(t, []interface{}{"a", "Bar", "bar"}, d.last().Values)
+	assert.Equal(t, "bar", h.Lower)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert", "BeforeInsert", "AfterInsert"}, calls)
+
+	// Update, Delete and Del
+	calls = nil
+	assert.NoError(t, sess.Update(&hookStruct{ID: "a", Name: "Baz", calls: &calls}).Columns("name", "lower").Exec())
+	assert.Contains(t, d.last().Values, "baz")
+	assert.NoError(t, sess.Delete(&hookStruct{ID: "a", calls: &calls}).Exec())
+	assert.NoError(t, sess.Del(&hookStruct{ID: "a", calls: &calls}))
+	assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate", "BeforeDelete", "AfterDelete", "BeforeDelete", "AfterDelete"}, calls)
+
+	// Errors
+	calls = nil
+	n := len(d.requests)
+	assert.EqualError(t, sess.Set(hookStruct{ID: "a", Name: "fail-BeforeInsert", calls: &calls}), "BeforeInsert failed")
+	assert.EqualError(t, sess.Insert(hookStruct{ID: "a", Name: "fail-BeforeInsert", calls: &calls}).Exec(), "BeforeInsert failed")
+	assert.Len(t, d.requests, n)
+	assert.EqualError(t, sess.Set(hookStruct{ID: "a", Name: "fail-AfterInsert"}), "AfterInsert failed")
+	d.err = errors.New("write failed")
+	assert.Equal(t, d.err, errors.Unwrap(sess.Set(hookStruct{ID: "a", calls: &calls})))
+	assert.Equal(t, []string{"BeforeInsert", "BeforeInsert", "BeforeInsert"}, calls)
+	d.err = nil
+
+	// Batches
+	calls = nil
+	assert.NoError(t, sess.Batch().Add(
+		sess.Insert(hookStruct{ID: "a", calls: &calls}),
+		sess.Delete(hookStruct{ID: "b", calls: &calls}),
+	).Apply())
+	assert.Equal(t, []string{"BeforeInsert", "BeforeDelete", "AfterInsert", "AfterDelete"}, calls)
+
+	// Scans
+	d.result([]string{"id", "name", "lower"}, []interface{}{"a", "Foo", ""}, []interface{}{"b", "Bar", ""})
+	var got hookStruct
+	assert.NoError(t, sess.Get(&got, "a"))
+	assert.Equal(t, "foo", got.Lower)
+
+	d.result([]string{"id", "name", "lower"}, []interface{}{"a", "Foo", ""}, []interface{}{"b", "Bar", ""})
+	var rows []hookStruct
+	assert.NoError(t, sess.QueryRaw("SELECT * FROM hooks").SelectType(&rows))
+	assert.Equal(t, "bar", rows[1].Lower)
+
+	d.result([]string{"id", "name", "lower"}, []interface{}{"a", "fail-AfterScan", ""})
+	iter := sess.Select(hookStruct{}).Iter()
+	assert.False(t, iter.TypeScan(&got))
+	assert.EqualError(t, iter.Close(), "AfterScan failed")
+
+}
@@ -0,0 +1,79 @@
+package ecql
+
+import "github.com/gocql/gocql"
+
+// HostEventType is the type of a HostEvent.
+type HostEventType int
+
+const (
+	// HostAddedEvent is sent when a host joins the cluster.
+	HostAddedEvent HostEventType = iota
+	// HostRemovedEvent is sent when a host leaves the cluster.
+	HostRemovedEvent
+	// HostUpEvent is sent when a host that was down is up again.
+	HostUpEvent
+	// HostDownEvent is sent when a host is marked as down.
+	HostDownEvent
+)
+
+var hostEventNames = map[HostEventType]string{
+	HostAddedEvent:   "added",
+	HostRemovedEvent: "removed",
+	HostUpEvent:      "up",
+	HostDownEvent:    "down",
+}
+
+// String returns the name of the event type.
+func (t HostEventType) String() string {
+	return hostEventNames[t]
+}
+
+// HostEvent is a change in the membership or the state of a host of the
+// cluster.
+type HostEvent struct {
+	Type       HostEventType
+	HostID     string
+	Address    string
+	DataCenter string
+	Rack       string
+}
+
+// HostEventFunc is the function called on the changes of the hosts.
+type HostEventFunc func(e HostEvent)
+
+// WithHostEvents calls fn when a host is added or removed from the cluster,
+// or when it goes up or down. The functions are called synchronously by the
+// driver, so they must not block:
+//
+//	sess, err := ecql.NewSession(cfg, ecql.WithHostEvents(func(e ecql.HostEvent) {
+//		log.Printf("host %s (%s) %s", e.Address, e.DataCenter, e.Type)
+//	}))
+//
+// The events are only available on sessions created with NewSession.
+func WithHostEvents(fn HostEventFunc) Option {
+	return func(s *SessionImpl) {
+		s.hostEvents = append(s.hostEvents, fn)
+	}
+}
+
+// newHostEvent returns the event of the given type for the host.
+func newHostEvent(t HostEventType, host *gocql.HostInfo) HostEvent {
+	return HostEvent{
+		Type:       t,
+		HostID:     host.HostID(),
+		Address:    host.ConnectAddressAndPort(),
+		DataCenter: host.DataCenter(),
+		Rack:       host.Rack(),
+	}
+}
+
+// notify calls the listeners of the monitor with the event.
+func (m *hostMonitor) notify(t HostEventType, host *gocql.HostInfo) {
+	if len(m.listeners) == 0 {
+		return
+	}
+	e := newHostEvent(t, host)
+	for _, fn := range m.listeners {
+		fn(e)
+	}
+}
@@ -0,0 +1,37 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostEvents(t *testing.T) {
+	var events []HostEvent
+	sess, _ := newTestSession(WithHostEvents(func(e HostEvent) {
+		events = append(events, e)
+	}))
+	assert.Len(t, sess.hostEvents, 1)
+
+	m := newHostMonitor()
+	m.listeners = sess.hostEvents
+
+	h1 := &gocql.HostInfo{}
+	h1.SetHostID("host-1")
+	m.add(h1)
+	m.setUp(h1, true)
+	m.setUp(h1, false)
+	m.setUp(h1, false)
+	m.setUp(h1, true)
+	m.remove(h1)
+	m.remove(h1)
+
+	var types []HostEventType
+	for _, e := range events {
+		assert.Equal(t, "host-1", e.HostID)
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []HostEventType{HostAddedEvent, HostDownEvent, HostUpEvent, HostRemovedEvent}, types)
+	assert.Equal(t, "down", HostDownEvent.String())
+}
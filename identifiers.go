@@ -0,0 +1,249 @@
+package ecql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidIdentifier is the error returned when a table, keyspace or
+// column name is not a valid CQL identifier.
+var ErrInvalidIdentifier = errors.New("ecql: invalid identifier")
+
+// reservedWords are the reserved keywords of CQL, they can only be used as
+// identifiers if they are quoted.
+var reservedWords = map[string]bool{
+	"ADD": true, "ALLOW": true, "ALTER": true, "AND": true, "APPLY": true,
+	"ASC": true, "AUTHORIZE": true, "BATCH": true, "BEGIN": true, "BY": true,
+	"COLUMNFAMILY": true, "CREATE": true, "DELETE": true, "DESC": true,
+	"DESCRIBE": true, "DROP": true, "ENTRIES": true, "EXECUTE": true,
+	"FROM": true, "FULL": true, "GRANT": true, "IF": true, "IN": true,
+	"INDEX": true, "INFINITY": true, "INSERT": true, "INTO": true, "IS": true,
+	"KEYSPACE": true, "LIMIT": true, "MATERIALIZED": true, "MBEAN": true,
+	"MBEANS": true, "MODIFY": true, "NAN": true, "NORECURSIVE": true,
+	"NOT": true, "NULL": true, "OF": true, "ON": true, "OR": true,
+	"ORDER": true, "PRIMARY": true, "RENAME": true, "REPLACE": true,
+	"REVOKE": true, "SCHEMA": true, "SELECT": true, "SET": true,
+	"TABLE": true, "TO": true, "TOKEN": true, "TRUNCATE": true,
+	"UNLOGGED": true, "UNSET": true, "UPDATE": true, "USE": true,
+	"USING": true, "VIEW": true, "WHERE": true, "WITH": true,
+}
+
+// identRegexp matches the identifiers that can be used without quotes,
+// ignoring the reserved words.
+var identRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// safeIdentRegexp matches the unquoted and quoted identifiers, the quotes
+// inside a quoted identifier must be escaped doubling them.
+var safeIdentRegexp = regexp.MustCompile(`^(?:[A-Za-z_][A-Za-z0-9_]*|"(?:[^"]|"")+")$`)
+
+// safeTableRegexp matches a table name optionally prefixed by the keyspace.
+var safeTableRegexp = regexp.MustCompile(`^(?:[A-Za-z_][A-Za-z0-9_]*|"(?:[^"]|"")+")(?:\.(?:[A-Za-z_][A-Za-z0-9_]*|"(?:[^"]|"")+"))?$`)
+
+// literalRegexp matches the numeric literals and the wildcard that can be
+// used as the arguments of the functions in a selector.
+var literalRegexp = regexp.MustCompile(`^(?:\*|-?[0-9]+(?:\.[0-9]+)?)$`)
+
+// ValidIdentifier returns true if name is an identifier that can be safely
+// used in a statement, either an unquoted name or a quoted one. Names that
+// come from the configuration or from the users should be checked before
+// using them in From, Columns or the conditions.
+func ValidIdentifier(name string) bool {
+	return safeIdentRegexp.MatchString(name)
+}
+
+// checkIdent returns an ErrInvalidIdentifier if name is not a valid
+// identifier.
+func checkIdent(name string) error {
+	if !ValidIdentifier(name) {
+		return invalidIdentifier(name)
+	}
+	return nil
+}
+
+// checkTable returns an ErrInvalidIdentifier if name is not a valid table
+// name, optionally prefixed by the keyspace.
+func checkTable(name string) error {
+	if !safeTableRegexp.MatchString(name) {
+		return invalidIdentifier(name)
+	}
+	return nil
+}
+
+// checkSelector returns an ErrInvalidIdentifier if expr is not a column
+// name, or a function call, cast or alias built with Fn, Cast and As.
+func checkSelector(expr string) error {
+	if !validSelector(expr) {
+		return invalidIdentifier(expr)
+	}
+	return nil
+}
+
+func validSelector(expr string) bool {
+	// Aliases and casts: expr AS name
+	if i := strings.LastIndex(expr, " AS "); i > 0 && ValidIdentifier(expr[i+4:]) {
+		expr = expr[:i]
+	}
+	if ValidIdentifier(expr) || literalRegexp.MatchString(expr) {
+		return true
+	}
+
+	// Function calls: name(arg, ...)
+	i := strings.IndexByte(expr, '(')
+	if i < 1 || expr[len(expr)-1] != ')' || !identRegexp.MatchString(expr[:i]) {
+		return false
+	}
+	args := expr[i+1 : len(expr)-1]
+	if args == "" {
+		return true
+	}
+	depth, start := 0, 0
+	for j := 0; j < len(args); j++ {
+		switch args[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				if !validSelector(strings.TrimSpace(args[start:j])) {
+					return false
+				}
+				start = j + 1
+			}
+		}
+		if depth < 0 {
+			return false
+		}
+	}
+	return depth == 0 && validSelector(strings.TrimSpace(args[start:]))
+}
+
+func invalidIdentifier(name string) error {
+	return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+}
+
+// IsReserved returns true if name is a reserved keyword of CQL.
+func IsReserved(name string) bool {
+	return reservedWords[strings.ToUpper(name)]
+}
+
+// QuoteIdent returns name as a quoted identifier if it is required to keep
+// its case or because it is a reserved word, otherwise name is returned
+// unchanged:
+//
+//	QuoteIdent("token")   // "token"
+//	QuoteIdent("UserID")  // "UserID"
+//	QuoteIdent("user_id") // user_id
+//
+// It can be used in the conditions on the columns of tables with
+// case-sensitive names, Eq(QuoteIdent("UserID"), id).
+func QuoteIdent(name string) string {
+	if isQuoted(name) || (name == strings.ToLower(name) && identRegexp.MatchString(name) && !IsReserved(name)) {
+		return name
+	}
+	return quote(name)
+}
+
+// quoteName returns the identifier name quoted if it is a reserved word. If
+// caseSensitive is true the names with uppercase letters are also quoted,
+// otherwise the quoted reserved words are lowercase, like the unquoted
+// identifiers in CQL. The names that are not identifiers, like function
+// calls, are not changed.
+func quoteName(name string, caseSensitive bool) string {
+	if !identRegexp.MatchString(name) {
+		return name
+	}
+	switch lower := strings.ToLower(name); {
+	case caseSensitive && name != lower:
+		return quote(name)
+	case IsReserved(name) && caseSensitive:
+		return quote(name)
+	case IsReserved(name):
+		return quote(lower)
+	default:
+		return name
+	}
+}
+
+// quoteTable returns the name of a table, optionally prefixed by the
+// keyspace, with its parts quoted if necessary.
+func quoteTable(name string, caseSensitive bool) string {
+	if i := strings.IndexByte(name, '.'); i > 0 && !isQuoted(name) {
+		return quoteName(name[:i], caseSensitive) + "." + quoteName(name[i+1:], caseSensitive)
+	}
+	return quoteName(name, caseSensitive)
+}
+
+// ident returns the column name quoted if it is a reserved word. It is used
+// by the conditions, that do not know if a table is case-sensitive.
+func ident(name string) string {
+	return quoteName(name, false)
+}
+
+// unquote returns the name of a quoted identifier.
+func unquote(name string) string {
+	if isQuoted(name) {
+		return strings.Replace(name[1:len(name)-1], `""`, `"`, -1)
+	}
+	return name
+}
+
+func isQuoted(name string) bool {
+	return len(name) > 1 && name[0] == '"' && name[len(name)-1] == '"'
+}
+
+func quote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// quote returns the column name quoted if necessary.
+func (t *Table) quote(name string) string {
+	return quoteName(name, t.CaseSensitive)
+}
+
+// quoteAll returns the column names quoted if necessary.
+func (t *Table) quoteAll(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = t.quote(name)
+	}
+	return quoted
+}
+
+// quotedName returns the name of the table quoted if necessary.
+func (t *Table) quotedName() string {
+	return quoteTable(t.Name, t.CaseSensitive)
+}
+
+// checkIdents returns an ErrInvalidIdentifier if the table, the columns or
+// the orders of the statement are not valid identifiers. Raw statements are
+// not checked.
+func (s *StatementImpl) checkIdents() error {
+	if s.rawCQL != "" {
+		return nil
+	}
+	if err := checkTable(s.Table.Name); err != nil {
+		return err
+	}
+	for _, col := range s.ColumnNames {
+		if err := checkSelector(col); err != nil {
+			return err
+		}
+	}
+	for col := range s.Assignments {
+		if err := checkIdent(col); err != nil {
+			return err
+		}
+	}
+	for _, o := range s.Orders {
+		if err := checkIdent(o.Column); err != nil {
+			return err
+		}
+	}
+	if s.AnnColumn != "" {
+		return checkIdent(s.AnnColumn)
+	}
+	return nil
+}
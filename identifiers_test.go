@@ -0,0 +1,125 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type orderStruct struct {
+	ID     string `cql:"OrderID" cqltable:"Order,quoted" cqlkey:"OrderID,token"`
+	Token  int    `cql:"token"`
+	Amount int    `cql:"amount"`
+}
+
+type reservedStruct struct {
+	Key   string `cql:"key" cqltable:"app.order" cqlkey:"key,index"`
+	Index int    `cql:"index"`
+	Desc  string `cql:"Desc"`
+}
+
+func TestQuoteIdent(t *testing.T) {
+	var tests = []struct {
+		name, quoted string
+	}{
+		{"user_id", "user_id"},
+		{"token", `"token"`},
+		{"Token", `"Token"`},
+		{"UserID", `"UserID"`},
+		{`a"b`, `"a""b"`},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.quoted, QuoteIdent(tc.name))
+		assert.Equal(t, tc.name, unquote(QuoteIdent(tc.name)))
+	}
+	assert.Equal(t, `"UserID"`, QuoteIdent(`"UserID"`))
+
+	assert.True(t, IsReserved("order"))
+	assert.False(t, IsReserved("orders"))
+	assert.Equal(t, `"order"`, quoteName("Order", false))
+	assert.Equal(t, `"Order"`, quoteName("Order", true))
+	assert.Equal(t, "UserID", quoteName("UserID", false))
+	assert.Equal(t, "count(1)", quoteName("count(1)", true))
+	assert.Equal(t, `ks."table"`, quoteTable("ks.table", false))
+}
+
+func TestQuotedStatements(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	table := GetTable(orderStruct{})
+	assert.True(t, table.CaseSensitive)
+	assert.Equal(t, "Order", table.Name)
+
+	assert.NoError(t, sess.Set(orderStruct{ID: "a", Token: 1}))
+	assert.Equal(t, `INSERT INTO "Order" ("OrderID","token",amount) VALUES (?,?,?)`, d.last().Statement)
+	var o orderStruct
+	d.result([]string{"OrderID", "token", "amount"}, []interface{}{"a", 1, 2})
+	assert.NoError(t, sess.Get(&o, "a", 1))
+	assert.Equal(t, orderStruct{ID: "a", Token: 1, Amount: 2}, o)
+	assert.Equal(t, `SELECT "OrderID","token",amount FROM "Order" WHERE "OrderID" = ? AND "token" = ?`, d.last().Statement)
+	assert.NoError(t, sess.Update(orderStruct{ID: "a", Token: 1}).Columns("amount").Exec())
+	assert.Equal(t, `UPDATE "Order" SET amount = ? WHERE "OrderID" = ? AND "token" = ?`, d.last().Statement)
+	assert.Equal(t, []interface{}{"a"}, d.last().PartitionKey)
+	assert.NoError(t, sess.Select(orderStruct{}).Where(Eq(QuoteIdent("OrderID"), "a")).OrderBy(Desc("token")).Exec())
+	assert.Equal(t, `SELECT "OrderID","token",amount FROM "Order" WHERE "OrderID" = ? ORDER BY "token" DESC`, d.last().Statement)
+	assert.Equal(t, []interface{}{"a"}, d.last().PartitionKey)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS "Order" ("OrderID" text, "token" int, amount int, PRIMARY KEY ("OrderID", "token"))`,
+		createTableQuery(table, map[string]string{"OrderID": "text", "token": "int", "amount": "int"}))
+
+	// Only the reserved words are quoted
+	assert.NoError(t, sess.Set(reservedStruct{Key: "a"}))
+	assert.Equal(t, `INSERT INTO app."order" (key,"index","desc") VALUES (?,?,?)`, d.last().Statement)
+	assert.NoError(t, sess.Delete(reservedStruct{Key: "a", Index: 1}).Exec())
+	assert.Equal(t, `DELETE FROM app."order" WHERE key = ? AND "index" = ?`, d.last().Statement)
+	assert.NoError(t, sess.Select(reservedStruct{}).Where(Eq("key", "a"), Gt("index", 1)).Exec())
+	assert.Equal(t, `SELECT key,"index","desc" FROM app."order" WHERE key = ? AND "index" > ?`, d.last().Statement)
+	assert.Equal(t, []interface{}{"a"}, d.last().PartitionKey)
+}
+
+func TestInvalidIdentifiers(t *testing.T) {
+	assert.True(t, ValidIdentifier("user_id"))
+	assert.True(t, ValidIdentifier(`"User ID"`))
+	assert.True(t, ValidIdentifier(`"a""b"`))
+	assert.False(t, ValidIdentifier(""))
+	assert.False(t, ValidIdentifier("1abc"))
+	assert.False(t, ValidIdentifier("users; DROP TABLE users"))
+	assert.False(t, ValidIdentifier(`"a"b"`))
+
+	assert.NoError(t, checkTable("ks.users"))
+	assert.Error(t, checkTable("ks.users.x"))
+	for _, expr := range []string{"f1", "count(1)", "COUNT(*)", Fn("toTimestamp", "id"), As(Cast("f22", "text"), "s"), As(Fn("ttl", `"token"`), "t")} {
+		assert.NoError(t, checkSelector(expr), expr)
+	}
+	for _, expr := range []string{"f1 FROM users --", "f1, f2", "f(x", "f(x))", "f('a')"} {
+		assert.Error(t, checkSelector(expr), expr)
+	}
+
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	var tests = []struct {
+		name string
+		stmt Statement
+	}{
+		{"from", NewStatement(sess).Do(SelectCmd).From("users; DROP TABLE users")},
+		{"columns", sess.Select(&testStruct{}).Columns("f1 FROM mytable; --")},
+		{"condition", sess.Select(&testStruct{}).Where(Eq("f1 = 1 OR f1", 2))},
+		{"and", sess.Select(&testStruct{}).Where(Eq("f1", 1)).AndWhere(In("f1)", 2))},
+		{"set", sess.Update(&testStruct{}).Set("f22 = 1, f3", 2)},
+		{"order", sess.Select(&testStruct{}).OrderBy(Desc("f1 DESC;"))},
+		{"into", sess.Insert(&testStruct{F1: "a"}).IntoTable("mytable (f1) VALUES ('a');")},
+	}
+	for _, tc := range tests {
+		err := tc.stmt.Exec()
+		assert.True(t, errors.Is(err, ErrInvalidIdentifier), tc.name)
+	}
+	err := sess.Table(testStruct{}, "mytable WHERE").Set(testStruct{F1: "a"})
+	assert.True(t, errors.Is(err, ErrInvalidIdentifier))
+	assert.Len(t, d.requests, 0)
+
+	err = sess.CreateKeyspace("ks WITH x", SimpleStrategy(1))
+	assert.True(t, errors.Is(err, ErrInvalidIdentifier))
+	assert.Equal(t, `ecql: invalid identifier: "ks WITH x"`, err.Error())
+}
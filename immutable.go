@@ -0,0 +1,226 @@
+package ecql
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Immutable returns a Statement that never modifies s. Each builder method
+// returns a new statement with the change applied, and the statements are
+// executed on a copy, so the same value can be shared and composed by
+// multiple goroutines without synchronization:
+//
+//	var byTenant = ecql.Immutable(sess.Select(Event{}).Where(Eq("tenant", tenantID)))
+//
+//	recent := byTenant.Limit(10)
+//	clicks := byTenant.AndWhere(Eq("kind", "click"))
+//
+// The statements created with a struct keep the references to its fields,
+// the shared statements must scan the results with Iter or MapRows instead
+// of TypeScan.
+func Immutable(s Statement) Statement {
+	return immutableStatement{s.Clone()}
+}
+
+// immutableStatement is the Statement returned by Immutable. It keeps a
+// private statement that is only cloned.
+type immutableStatement struct {
+	s Statement
+}
+
+// with returns a new immutable statement with the change of fn.
+func (s immutableStatement) with(fn func(Statement) Statement) Statement {
+	return immutableStatement{fn(s.s.Clone())}
+}
+
+func (s immutableStatement) TypeScan() error {
+	return s.s.Clone().TypeScan()
+}
+
+func (s immutableStatement) Scan(i ...interface{}) error {
+	return s.s.Clone().Scan(i...)
+}
+
+func (s immutableStatement) MapRows() ([]map[string]interface{}, error) {
+	return s.s.Clone().MapRows()
+}
+
+func (s immutableStatement) Page(dest interface{}, cursor string, n int) (string, error) {
+	return s.s.Clone().Page(dest, cursor, n)
+}
+
+func (s immutableStatement) Count() (int64, error) {
+	return s.s.Count()
+}
+
+func (s immutableStatement) Exists() (bool, error) {
+	return s.s.Exists()
+}
+
+func (s immutableStatement) MinOf(col string) (interface{}, error) {
+	return s.s.MinOf(col)
+}
+
+func (s immutableStatement) MaxOf(col string) (interface{}, error) {
+	return s.s.MaxOf(col)
+}
+
+func (s immutableStatement) SumOf(col string) (interface{}, error) {
+	return s.s.SumOf(col)
+}
+
+func (s immutableStatement) AvgOf(col string) (interface{}, error) {
+	return s.s.AvgOf(col)
+}
+
+func (s immutableStatement) Exec() error {
+	return s.s.Clone().Exec()
+}
+
+func (s immutableStatement) ExecInfo() (QueryInfo, error) {
+	return s.s.Clone().ExecInfo()
+}
+
+func (s immutableStatement) ExecAsync() *Future {
+	return s.s.Clone().ExecAsync()
+}
+
+func (s immutableStatement) Iter() Iter {
+	return s.s.Clone().Iter()
+}
+
+func (s immutableStatement) BuildQuery() (string, []interface{}) {
+	return s.s.Clone().BuildQuery()
+}
+
+func (s immutableStatement) Template() (*StatementTemplate, error) {
+	return s.s.Template()
+}
+
+func (s immutableStatement) Do(cmd Command) Statement {
+	return s.with(func(c Statement) Statement { return c.Do(cmd) })
+}
+
+func (s immutableStatement) From(table string) Statement {
+	return s.with(func(c Statement) Statement { return c.From(table) })
+}
+
+func (s immutableStatement) IntoTable(name string) Statement {
+	return s.with(func(c Statement) Statement { return c.IntoTable(name) })
+}
+
+func (s immutableStatement) FromType(i interface{}) Statement {
+	return s.with(func(c Statement) Statement { return c.FromType(i) })
+}
+
+func (s immutableStatement) Columns(columns ...string) Statement {
+	return s.with(func(c Statement) Statement { return c.Columns(columns...) })
+}
+
+func (s immutableStatement) Set(column string, value interface{}) Statement {
+	return s.with(func(c Statement) Statement { return c.Set(column, value) })
+}
+
+func (s immutableStatement) Where(cond ...Condition) Statement {
+	return s.with(func(c Statement) Statement { return c.Where(cond...) })
+}
+
+func (s immutableStatement) AndWhere(cond ...Condition) Statement {
+	return s.with(func(c Statement) Statement { return c.AndWhere(cond...) })
+}
+
+func (s immutableStatement) OrderBy(order ...OrderBy) Statement {
+	return s.with(func(c Statement) Statement { return c.OrderBy(order...) })
+}
+
+func (s immutableStatement) OrderByAnn(column string, vector []float32) Statement {
+	vector = append([]float32(nil), vector...)
+	return s.with(func(c Statement) Statement { return c.OrderByAnn(column, vector) })
+}
+
+func (s immutableStatement) AllowFiltering() Statement {
+	return s.with(func(c Statement) Statement { return c.AllowFiltering() })
+}
+
+func (s immutableStatement) IfExists() Statement {
+	return s.with(func(c Statement) Statement { return c.IfExists() })
+}
+
+func (s immutableStatement) IfNotExists() Statement {
+	return s.with(func(c Statement) Statement { return c.IfNotExists() })
+}
+
+func (s immutableStatement) UnsetEmpty() Statement {
+	return s.with(func(c Statement) Statement { return c.UnsetEmpty() })
+}
+
+func (s immutableStatement) Bind(i interface{}) Statement {
+	return s.with(func(c Statement) Statement { return c.Bind(i) })
+}
+
+func (s immutableStatement) Map(i interface{}) Statement {
+	return s.with(func(c Statement) Statement { return c.Map(i) })
+}
+
+func (s immutableStatement) Limit(n int) Statement {
+	return s.with(func(c Statement) Statement { return c.Limit(n) })
+}
+
+func (s immutableStatement) TTL(seconds int) Statement {
+	return s.with(func(c Statement) Statement { return c.TTL(seconds) })
+}
+
+func (s immutableStatement) Timestamp(microseconds int64) Statement {
+	return s.with(func(c Statement) Statement { return c.Timestamp(microseconds) })
+}
+
+func (s immutableStatement) RoutingKey(values ...interface{}) Statement {
+	return s.with(func(c Statement) Statement { return c.RoutingKey(values...) })
+}
+
+func (s immutableStatement) InDC(dc string) Statement {
+	return s.with(func(c Statement) Statement { return c.InDC(dc) })
+}
+
+func (s immutableStatement) ReadFrom(dc string, c gocql.Consistency) Statement {
+	return s.with(func(st Statement) Statement { return st.ReadFrom(dc, c) })
+}
+
+func (s immutableStatement) Consistency(c gocql.Consistency) Statement {
+	return s.with(func(st Statement) Statement { return st.Consistency(c) })
+}
+
+func (s immutableStatement) Retry(p *RetryPolicy) Statement {
+	return s.with(func(c Statement) Statement { return c.Retry(p) })
+}
+
+func (s immutableStatement) Idempotent() Statement {
+	return s.with(func(c Statement) Statement { return c.Idempotent() })
+}
+
+func (s immutableStatement) Priority(p Priority) Statement {
+	return s.with(func(c Statement) Statement { return c.Priority(p) })
+}
+
+func (s immutableStatement) WithContext(ctx context.Context) Statement {
+	return s.with(func(c Statement) Statement { return c.WithContext(ctx) })
+}
+
+func (s immutableStatement) Timeout(d time.Duration) Statement {
+	return s.with(func(c Statement) Statement { return c.Timeout(d) })
+}
+
+func (s immutableStatement) Payload(key string, value []byte) Statement {
+	return s.with(func(c Statement) Statement { return c.Payload(key, value) })
+}
+
+func (s immutableStatement) On(cluster string) Statement {
+	return s.with(func(c Statement) Statement { return c.On(cluster) })
+}
+
+// Clone returns s, immutable statements do not need to be cloned.
+func (s immutableStatement) Clone() Statement {
+	return s
+}
@@ -0,0 +1,54 @@
+package ecql
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutable(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	base := Immutable(NewStatement(sess).Do(SelectCmd).From("events").Where(Eq("tenant", "a")))
+	recent := base.OrderBy(Desc("time")).Limit(10)
+	clicks := base.AndWhere(Eq("kind", "click"))
+	assert.Equal(t, base, base.Clone())
+
+	cql, args := base.BuildQuery()
+	assert.Equal(t, "SELECT * FROM events WHERE tenant = ?", cql)
+	assert.Equal(t, []interface{}{"a"}, args)
+	cql, _ = recent.BuildQuery()
+	assert.Equal(t, "SELECT * FROM events WHERE tenant = ? ORDER BY time DESC LIMIT 10", cql)
+	cql, args = clicks.BuildQuery()
+	assert.Equal(t, "SELECT * FROM events WHERE tenant = ? AND kind = ?", cql)
+	assert.Equal(t, []interface{}{"a", "click"}, args)
+
+	// The statement passed is not modified either
+	stmt := NewStatement(sess).Do(SelectCmd).From("events")
+	Immutable(stmt).Limit(1)
+	stmt.Where(Eq("tenant", "b"))
+	cql, _ = base.BuildQuery()
+	assert.Equal(t, "SELECT * FROM events WHERE tenant = ?", cql)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, base.AndWhere(Eq("id", i)).Limit(i).Exec())
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, d.requests, 10)
+
+	ts := testStruct{F1: "foo"}
+	insert := Immutable(sess.Insert(&ts))
+	stmts := []Statement{insert, insert.TTL(10)}
+	assert.NoError(t, sess.Batch().Add(stmts...).Apply())
+	assert.Equal(t, insert, stmts[0])
+	assert.Len(t, d.batches[0].Entries, 2)
+	assert.Equal(t, "INSERT INTO mytable (f1,f22,f3,f4) VALUES (?,?,?,?)", d.batches[0].Entries[0].Statement)
+	assert.Equal(t, "INSERT INTO mytable (f1,f22,f3,f4) VALUES (?,?,?,?) USING TTL 10", d.batches[0].Entries[1].Statement)
+}
@@ -0,0 +1,31 @@
+package ecql
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// QueryInfo contains the information that gocql reports about the execution
+// of a statement.
+type QueryInfo struct {
+	// Attempts is the number of times the statement was sent to the cluster.
+	Attempts int
+	// Latency is the average latency of all the attempts.
+	Latency time.Duration
+	// Consistency is the consistency level used to execute the statement.
+	Consistency gocql.Consistency
+	// Rows is the number of rows returned in the first page of the result.
+	Rows int
+	// Applied reports if a conditional statement (IF EXISTS, IF NOT EXISTS)
+	// was applied. On unconditional statements it is true if there was no
+	// error.
+	Applied bool
+	// Previous contains the current values of the row if a conditional
+	// statement was not applied.
+	Previous map[string]interface{}
+	// Warnings are the warnings returned by the server, like the ones of
+	// aggregations without a partition key or large batches. They require
+	// the protocol version 4 or later.
+	Warnings []string
+}
@@ -3,6 +3,7 @@
 package ecql
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -854,6 +855,325 @@ func TestRaw(t *testing.T) {
 	assert.NoError(t, iter.Close())
 }
 
+func TestExecInfo(t *testing.T) {
+	initialize(t)
+
+	newTW := tweet{
+		ID:       gocql.TimeUUID(),
+		Timeline: "me",
+		Text:     "Here's a new tweet",
+		Time:     Now().UTC(),
+	}
+
+	info, err := testSession.Insert(newTW).ExecInfo()
+	assert.NoError(t, err)
+	assert.True(t, info.Applied)
+	assert.True(t, info.Attempts > 0)
+	assert.Nil(t, info.Previous)
+
+	info, err = testSession.Insert(newTW).IfNotExists().ExecInfo()
+	assert.NoError(t, err)
+	assert.False(t, info.Applied)
+	assert.Equal(t, newTW.Text, info.Previous["text"])
+
+	info, err = testSession.Update(tweet{ID: gocql.TimeUUID()}).Set("text", "foobar tweet").IfExists().ExecInfo()
+	assert.NoError(t, err)
+	assert.False(t, info.Applied)
+}
+
+func TestExecAsync(t *testing.T) {
+	initialize(t)
+
+	var futures []*Future
+	var tweets []tweet
+	for i := 0; i < 10; i++ {
+		tw := tweet{
+			ID:       gocql.TimeUUID(),
+			Timeline: "me",
+			Text:     fmt.Sprintf("Async tweet %d", i),
+			Time:     Now().UTC(),
+		}
+		tweets = append(tweets, tw)
+		futures = append(futures, testSession.Insert(tw).ExecAsync())
+	}
+	assert.NoError(t, WaitAll(futures...))
+
+	for _, tw := range tweets {
+		var res tweet
+		assert.NoError(t, testSession.Get(&res, tw.ID))
+		assert.Equal(t, tw, res)
+	}
+}
+
+func TestMultiGet(t *testing.T) {
+	initialize(t)
+
+	var tweets []tweet
+	err := testSession.MultiGet(&tweets,
+		"a5450908-17d7-11e6-b9ec-542696d5770f",
+		gocql.TimeUUID(),
+		"619f33d2-1952-11e6-9f53-542696d5770f")
+	assert.NoError(t, err)
+	assert.Len(t, tweets, 2)
+	assert.Equal(t, "hello world!", tweets[0].Text)
+	assert.Equal(t, "ciao world!", tweets[1].Text)
+
+	var timelines []*timeline
+	err = testSession.MultiGet(&timelines,
+		[]interface{}{"ecql", time.Date(2016, 1, 1, 11, 11, 11, 0, time.UTC)},
+		[]interface{}{"ecql", time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+	assert.Len(t, timelines, 2)
+	assert.Equal(t, "619f33d2-1952-11e6-9f53-542696d5770f", timelines[0].Tweet.String())
+	assert.Equal(t, "a5450908-17d7-11e6-b9ec-542696d5770f", timelines[1].Tweet.String())
+
+	assert.Equal(t, ErrInvalidDestination, testSession.MultiGet(tweets, "ecql"))
+}
+
+func TestCoalescer(t *testing.T) {
+	initialize(t)
+
+	var errs []error
+	c := NewCoalescer(testSession, CoalescerConfig{
+		MaxBatchSize: 3,
+		Window:       time.Hour,
+		OnError:      func(err error) { errs = append(errs, err) },
+	})
+
+	base := time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		tl := timeline{
+			ID:    "coalescer",
+			Time:  base.Add(time.Duration(i) * time.Minute),
+			Tweet: gocql.TimeUUID(),
+		}
+		assert.NoError(t, c.Insert(tl))
+	}
+
+	// First group flushed on the third insert
+	var count int
+	assert.NoError(t, testSession.Count(timeline{}).Where(Eq("id", "coalescer")).Scan(&count))
+	assert.Equal(t, 3, count)
+
+	assert.NoError(t, c.Close())
+	assert.NoError(t, testSession.Count(timeline{}).Where(Eq("id", "coalescer")).Scan(&count))
+	assert.Equal(t, 5, count)
+	assert.Empty(t, errs)
+}
+
+func TestCache(t *testing.T) {
+	initialize(t)
+
+	cache := NewLRUCache(100)
+	sess := New(testSession.(*SessionImpl).Session, WithCache(cache))
+
+	var tw tweet
+	assert.NoError(t, sess.Get(&tw, "a5450908-17d7-11e6-b9ec-542696d5770f"))
+	assert.Equal(t, 1, cache.Len())
+
+	// Served from the cache
+	testSession.Update(tw).Set("text", "updated").Exec()
+	var cached tweet
+	assert.NoError(t, sess.Get(&cached, "a5450908-17d7-11e6-b9ec-542696d5770f"))
+	assert.Equal(t, "hello world!", cached.Text)
+
+	// Invalidated on update
+	assert.NoError(t, sess.Update(tw).Set("text", "updated again").Exec())
+	assert.Equal(t, 0, cache.Len())
+	assert.NoError(t, sess.Get(&cached, "a5450908-17d7-11e6-b9ec-542696d5770f"))
+	assert.Equal(t, "updated again", cached.Text)
+
+	// Write-through
+	cached.Text = "write-through"
+	assert.NoError(t, sess.Set(cached))
+	assert.Equal(t, 1, cache.Len())
+
+	assert.NoError(t, sess.Del(cached))
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, ErrNotFound, sess.Get(&cached, "a5450908-17d7-11e6-b9ec-542696d5770f"))
+}
+
+func TestRoutingKeyAndDC(t *testing.T) {
+	initialize(t)
+
+	var tw tweet
+	id := MustUUID("a5450908-17d7-11e6-b9ec-542696d5770f")
+	err := testSession.Select(&tw).Where(Raw("token(id) = token(?)", id)).RoutingKey(id).TypeScan()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world!", tw.Text)
+
+	// Single node clusters use datacenter1 by default
+	err = testSession.Select(&tw).Where(Eq("id", id)).InDC("datacenter1").TypeScan()
+	assert.NoError(t, err)
+
+	err = testSession.Select(&tw).Where(Eq("id", id)).InDC("unknown").TypeScan()
+	assert.Error(t, err)
+}
+
+func TestClusterStatus(t *testing.T) {
+	initialize(t)
+
+	var tw tweet
+	assert.NoError(t, testSession.Get(&tw, "a5450908-17d7-11e6-b9ec-542696d5770f"))
+
+	status := testSession.ClusterStatus()
+	assert.NotEmpty(t, status.Hosts)
+	assert.Equal(t, len(status.Hosts), status.Up())
+	assert.NotEmpty(t, status.Hosts[0].Tokens)
+	assert.True(t, status.Hosts[0].Queries > 0)
+}
+
+func TestDescribeTableLive(t *testing.T) {
+	table, err := testSession.DescribeTable("test_ecql", "timeline")
+	assert.NoError(t, err)
+	assert.Equal(t, "timeline", table.Name)
+	assert.Equal(t, []string{"id"}, table.PartitionColumns)
+	assert.Equal(t, []string{"time"}, table.ClusteringColumns)
+	assert.Equal(t, []OrderBy{Asc("time")}, table.ClusteringOrder)
+	assert.Equal(t, []Column{{Name: "id", Type: "text"}, {Name: "time", Type: "timestamp"}, {Name: "tweet", Type: "uuid"}}, table.Columns)
+
+	_, err = testSession.DescribeTable("test_ecql", "missing")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestValidateSchemaLive(t *testing.T) {
+	diffs, err := testSession.ValidateSchema(tweet{}, timeline{}, views{})
+	assert.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	type tweetV2 struct {
+		ID    gocql.UUID `cql:"id" cqltable:"tweet" cqlkey:"id"`
+		Text  string     `cql:"text"`
+		Likes int        `cql:"likes"`
+	}
+	diffs, err = testSession.ValidateSchema(tweetV2{})
+	assert.NoError(t, err)
+	assert.Equal(t, []SchemaDiff{{Table: "tweet", Missing: []string{"likes"}, Unmapped: []string{"time", "timeline"}}}, diffs)
+}
+
+func TestAutoMigrateLive(t *testing.T) {
+	type migration struct {
+		ID   gocql.UUID `cql:"id" cqltable:"migration" cqlkey:"id"`
+		Name string     `cql:"name"`
+	}
+	type migrationV2 struct {
+		ID    gocql.UUID `cql:"id" cqltable:"migration" cqlkey:"id"`
+		Name  string     `cql:"name"`
+		Count int64      `cql:"count"`
+	}
+
+	assert.NoError(t, testSession.AutoMigrate(migration{}))
+	assert.NoError(t, testSession.AutoMigrate(migrationV2{}))
+	diffs, err := testSession.ValidateSchema(migrationV2{})
+	assert.NoError(t, err)
+	assert.Empty(t, diffs)
+	assert.NoError(t, testSession.DropTable(migration{}))
+}
+
+func TestKeyspaceLive(t *testing.T) {
+	assert.NoError(t, testSession.CreateKeyspace("test_ecql_tmp", SimpleStrategy(1)))
+	assert.NoError(t, testSession.CreateKeyspace("test_ecql_tmp", SimpleStrategy(1)))
+	assert.NoError(t, testSession.DropKeyspace("test_ecql_tmp"))
+}
+
+func TestTTLLive(t *testing.T) {
+	initialize(t)
+
+	type tweetTTL struct {
+		ID      gocql.UUID `cql:"id" cqltable:"tweet" cqlkey:"id"`
+		Text    string     `cql:"text"`
+		Expires TTL        `cql:",ttl=text"`
+	}
+
+	tw := tweetTTL{ID: gocql.TimeUUID(), Text: "expiring"}
+	assert.NoError(t, testSession.Insert(tw).TTL(60).Exec())
+
+	var got tweetTTL
+	assert.NoError(t, testSession.Get(&got, tw.ID))
+	assert.Equal(t, "expiring", got.Text)
+	assert.True(t, got.Expires.Seconds > 0 && got.Expires.Seconds <= 60)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), got.Expires.ExpiresAt(), 5*time.Second)
+}
+
+func TestOmitEmptyLive(t *testing.T) {
+	initialize(t)
+
+	type tweetOmit struct {
+		ID       gocql.UUID `cql:"id" cqltable:"tweet" cqlkey:"id"`
+		Timeline string     `cql:"timeline,omitempty"`
+		Text     *string    `cql:"text,omitempty"`
+	}
+
+	id, _ := gocql.ParseUUID("a5450908-17d7-11e6-b9ec-542696d5770f")
+	assert.NoError(t, testSession.Insert(tweetOmit{ID: id, Timeline: "omitted"}).Exec())
+
+	var tw tweet
+	assert.NoError(t, testSession.Get(&tw, id))
+	assert.Equal(t, "omitted", tw.Timeline)
+	assert.Equal(t, "hello world!", tw.Text)
+}
+
+func TestFunctionsLive(t *testing.T) {
+	var tw tweet
+	err := testSession.Select(&tw).Columns("id", As(Cast("id", "text"), "text")).Where(Eq("id", MustUUID("a5450908-17d7-11e6-b9ec-542696d5770f"))).TypeScan()
+	assert.NoError(t, err)
+	assert.Equal(t, "a5450908-17d7-11e6-b9ec-542696d5770f", tw.Text)
+}
+
+func TestQueryRawLive(t *testing.T) {
+	var tw tweet
+	err := testSession.QueryRaw("SELECT * FROM tweet WHERE id = ?", MustUUID("a5450908-17d7-11e6-b9ec-542696d5770f")).ScanType(&tw)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world!", tw.Text)
+
+	var tweets []tweet
+	assert.NoError(t, testSession.QueryRaw("SELECT * FROM tweet LIMIT 1").SelectType(&tweets))
+	assert.Len(t, tweets, 1)
+}
+
+func TestQueryErrorLive(t *testing.T) {
+	err := testSession.QueryRaw("SELECT * FROM missing_table").Exec()
+	assert.True(t, errors.Is(err, ErrInvalidQuery))
+
+	err = testSession.QueryRaw("CREATE TABLE users (id text PRIMARY KEY)").Exec()
+	assert.True(t, errors.Is(err, ErrAlreadyExists))
+}
+
+func TestBulkWriterLive(t *testing.T) {
+	initialize(t)
+
+	w := NewBulkWriter(testSession, BulkWriterConfig{BatchSize: 3})
+	base := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		tl := timeline{
+			ID:    "bulk",
+			Time:  base.Add(time.Duration(i) * time.Minute),
+			Tweet: gocql.TimeUUID(),
+		}
+		assert.NoError(t, w.Write(tl))
+	}
+	assert.NoError(t, w.Close())
+
+	var count int
+	assert.NoError(t, testSession.Count(timeline{}).Where(Eq("id", "bulk")).Scan(&count))
+	assert.Equal(t, 10, count)
+	assert.Equal(t, 10, w.Stats().Rows)
+	assert.Equal(t, 4, w.Stats().Batches)
+}
+
+func TestTokenRangesLive(t *testing.T) {
+	initialize(t)
+
+	var total, count int
+	assert.NoError(t, testSession.Count(tweet{}).Scan(&total))
+	for _, r := range TokenRanges(5) {
+		var n int
+		assert.NoError(t, testSession.Count(tweet{}).Where(r.Condition(TableOf(testSession, tweet{}))).Scan(&n))
+		count += n
+	}
+	assert.Equal(t, total, count)
+}
+
 func TestMain(m *testing.M) {
 	flag.Parse()
 
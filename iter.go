@@ -1,37 +1,215 @@
 package ecql
 
 import (
-	"github.com/gocql/gocql"
+	"context"
+	"reflect"
 )
 
+// Iter is the iterator over the results of a statement. The statement is
+// executed on the first call to TypeScan or Scan, and the iterator is closed
+// automatically when there are no more rows, but Close must always be called
+// to get the error of the iteration.
+//
+//	iter := sess.Select(&t).Where(Eq("id", id)).Iter()
+//	for iter.TypeScan(&t) {
+//		// ...
+//	}
+//	if err := iter.Close(); err != nil {
+//		// ...
+//	}
 type Iter interface {
 	TypeScan(i interface{}) bool
+	Scan(dest ...interface{}) bool
+	MapScan(m map[string]interface{}) bool
+	WillSwitchPage() bool
+	PageState() []byte
+	Lease(p *Pool) (interface{}, bool)
+	Chan(ctx context.Context, i interface{}) <-chan interface{}
+	ForEach(i interface{}, fn func() error) error
 	Close() error
 }
 
 type IterImpl struct {
-	iter      *gocql.Iter
+	rows      Rows
 	statement *StatementImpl
-	query     *gocql.Query
 	err       error
+	closed    bool
+	ctx       context.Context
 }
 
-func (it *IterImpl) TypeScan(i interface{}) bool {
-	m := Map(i)
-	if it.iter == nil {
-		if query, err := it.statement.query(); err != nil {
+// start executes the statement if it has not been executed yet, it returns
+// false if the iterator is closed or the statement cannot be executed.
+func (it *IterImpl) start() bool {
+	if it.closed || it.err != nil {
+		return false
+	}
+	if it.rows == nil {
+		if req, err := it.statement.request(); err != nil {
 			it.err = err
 			return false
 		} else {
-			it.iter = query.Iter()
+			it.rows = it.statement.session.getDriver().Iter(req)
+			it.ctx = contextOf(req.Context)
 		}
 	}
-	return it.iter.MapScan(m)
+	return true
 }
 
-func (it *IterImpl) Close() error {
-	if it.err != nil {
-		return it.err
+// finish closes the underlying rows and keeps the first error found.
+func (it *IterImpl) finish() {
+	if it.closed {
+		return
+	}
+	it.closed = true
+	if it.rows != nil {
+		if err := it.rows.Close(); err != nil && it.err == nil {
+			it.err = err
+		}
 	}
-	return it.iter.Close()
+}
+
+// TypeScan sets the values of the next row in the struct i. It returns false
+// if there are no more rows or on error.
+func (it *IterImpl) TypeScan(i interface{}) bool {
+	m, table := it.statement.session.getRegistry().MapTable(i)
+	if !it.start() {
+		return false
+	}
+	if it.rows.MapScan(m) {
+		table.scanned(structOf(i), m)
+		if err := afterScan(it.ctx, structOf(i)); err != nil {
+			it.err = err
+			it.finish()
+			return false
+		}
+		return true
+	}
+	it.finish()
+	return false
+}
+
+// Scan copies the columns of the next row into the values pointed by dest.
+// It returns false if there are no more rows or on error.
+func (it *IterImpl) Scan(dest ...interface{}) bool {
+	if !it.start() {
+		return false
+	}
+	if it.rows.Scan(dest...) {
+		return true
+	}
+	it.finish()
+	return false
+}
+
+// MapScan sets in m the values of the next row using the column names as
+// keys. It returns false if there are no more rows or on error.
+func (it *IterImpl) MapScan(m map[string]interface{}) bool {
+	if !it.start() {
+		return false
+	}
+	if it.rows.MapScan(m) {
+		return true
+	}
+	it.finish()
+	return false
+}
+
+// WillSwitchPage returns true if the next call to TypeScan or Scan will fetch
+// a new page of results from the database.
+func (it *IterImpl) WillSwitchPage() bool {
+	if it.rows == nil || it.closed {
+		return false
+	}
+	return it.rows.WillSwitchPage()
+}
+
+// PageState returns the paging state of the current page, it can be used to
+// resume the iteration in a new statement.
+func (it *IterImpl) PageState() []byte {
+	if it.rows == nil {
+		return nil
+	}
+	return it.rows.PageState()
+}
+
+// Lease returns a struct from the pool with the values of the next row. It
+// returns false if there are no more rows or on error. The struct should be
+// returned to the pool with Put when it is no longer used.
+func (it *IterImpl) Lease(p *Pool) (interface{}, bool) {
+	v := p.Get()
+	if !it.TypeScan(v) {
+		p.Put(v)
+		return nil, false
+	}
+	return v, true
+}
+
+// Chan returns a channel that receives a new pointer to a struct of the type
+// of i for each row. The channel is closed when there are no more rows, on
+// error, or when the context is done. Close must be called after the channel
+// is closed to get the error of the iteration, if the context is done Close
+// returns the context error. The rows are read in a goroutine that only
+// stops when all the rows are sent or the context is done, so callers that
+// stop receiving before the channel is closed must cancel the context.
+//
+//	ctx, cancel := context.WithCancel(ctx)
+//	defer cancel()
+//	for v := range sess.Select(Tweet{}).Iter().Chan(ctx, Tweet{}) {
+//		tw := v.(*Tweet)
+//		// ...
+//	}
+//
+// If i is a *Pool the structs are leased from the pool.
+func (it *IterImpl) Chan(ctx context.Context, i interface{}) <-chan interface{} {
+	next := func() (interface{}, bool) {
+		v := reflect.New(structOf(i).Type()).Interface()
+		return v, it.TypeScan(v)
+	}
+	if p, ok := i.(*Pool); ok {
+		next = func() (interface{}, bool) {
+			return it.Lease(p)
+		}
+	}
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			v, ok := next()
+			if !ok {
+				return
+			}
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				if p, ok := i.(*Pool); ok {
+					p.Put(v)
+				}
+			}
+		}
+		if it.err == nil {
+			it.err = ctx.Err()
+		}
+		it.finish()
+	}()
+	return ch
+}
+
+// ForEach sets the values of each row in i and calls fn. The iteration stops
+// if fn returns an error. ForEach closes the iterator and returns the error
+// returned by fn or the error of the iteration.
+func (it *IterImpl) ForEach(i interface{}, fn func() error) error {
+	for it.TypeScan(i) {
+		if err := fn(); err != nil {
+			it.finish()
+			return err
+		}
+	}
+	return it.Close()
+}
+
+// Close closes the iterator and returns the error of the iteration if any.
+// It is safe to call Close multiple times.
+func (it *IterImpl) Close() error {
+	it.finish()
+	return it.err
 }
@@ -0,0 +1,128 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterScan(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 1}, []interface{}{"bar", 2})
+
+	var f1 string
+	var f22 int
+	var count int
+	iter := sess.Select(testStruct{}).Columns("f1", "f22").Iter()
+	for iter.Scan(&f1, &f22) {
+		count++
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "bar", f1)
+	assert.Equal(t, 2, f22)
+	assert.False(t, iter.WillSwitchPage())
+	assert.NoError(t, iter.Close())
+	assert.NoError(t, iter.Close())
+	assert.False(t, iter.Scan(&f1, &f22))
+	assert.Len(t, d.requests, 1)
+}
+
+func TestIterClose(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	// Never executed
+	iter := sess.Select(testStruct{}).Iter()
+	assert.NoError(t, iter.Close())
+	assert.Nil(t, iter.PageState())
+	assert.Len(t, d.requests, 0)
+
+	// Driver error
+	errFoo := errors.New("foo")
+	d.err = errFoo
+	var ts testStruct
+	iter = sess.Select(&ts).Iter()
+	assert.False(t, iter.TypeScan(&ts))
+	assert.True(t, errors.Is(iter.Close(), errFoo))
+
+	// Statement error
+	iter = sess.Select(&ts).RoutingKey(struct{}{}).Iter()
+	assert.False(t, iter.TypeScan(&ts))
+	assert.Error(t, iter.Close())
+}
+
+func TestIterChan(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 1}, []interface{}{"bar", 2}, []interface{}{"zar", 3})
+
+	var names []string
+	iter := sess.Select(testStruct{}).Iter()
+	for v := range iter.Chan(context.Background(), testStruct{}) {
+		names = append(names, v.(*testStruct).F1)
+	}
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, []string{"foo", "bar", "zar"}, names)
+
+	// Cancel after the first row
+	ctx, cancel := context.WithCancel(context.Background())
+	iter = sess.Select(testStruct{}).Iter()
+	ch := iter.Chan(ctx, &testStruct{})
+	v := <-ch
+	assert.Equal(t, "foo", v.(*testStruct).F1)
+	cancel()
+	for range ch {
+	}
+	assert.Equal(t, context.Canceled, iter.Close())
+	// Canceled before the first row
+	n := len(d.requests)
+	iter = sess.Select(testStruct{}).Iter()
+	ch = iter.Chan(ctx, testStruct{})
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Equal(t, context.Canceled, iter.Close())
+	assert.Len(t, d.requests, n)
+}
+
+func TestIterForEach(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 1}, []interface{}{"bar", 2})
+
+	var ts testStruct
+	var sum int
+	err := sess.Select(&ts).Iter().ForEach(&ts, func() error {
+		sum += ts.F2
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, sum)
+
+	errStop := errors.New("stop")
+	err = sess.Select(&ts).Iter().ForEach(&ts, func() error {
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+}
+
+func TestStatementMapRows(t *testing.T) {
+	sess, d := newTestSession()
+	d.result([]string{"id", "name"}, []interface{}{1, "foo"}, []interface{}{2, "bar"})
+
+	rows, err := NewStatement(sess).Do(SelectCmd).From("raw").Where(Gt("id", 0)).AllowFiltering().MapRows()
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"id": 1, "name": "foo"},
+		{"id": 2, "name": "bar"},
+	}, rows)
+	assert.Equal(t, "SELECT * FROM raw WHERE id > ? ALLOW FILTERING", d.last().Statement)
+
+	d.result([]string{"id"})
+	d.err = errors.New("boom")
+	rows, err = NewStatement(sess).Do(SelectCmd).From("raw").MapRows()
+	assert.True(t, errors.Is(err, d.err))
+	assert.Nil(t, rows)
+}
@@ -0,0 +1,171 @@
+package ecql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidCursor is returned by Page when the cursor is not valid for the
+// statement.
+var ErrInvalidCursor = errors.New("ecql: invalid cursor")
+
+// ErrNoClusteringColumns is returned by Page on tables without clustering
+// columns.
+var ErrNoClusteringColumns = errors.New("ecql: keyset pagination requires clustering columns")
+
+// ErrMixedPageOrder is returned by Page if the clustering columns are not
+// read in the same direction, the rows after a cursor cannot be selected with
+// a single condition.
+var ErrMixedPageOrder = errors.New("ecql: keyset pagination requires the same direction in all the clustering columns")
+
+// ErrInvalidPageSize is returned by Page if the number of rows is not
+// positive.
+var ErrInvalidPageSize = errors.New("ecql: invalid page size")
+
+// cursor is the decoded value of a Page cursor.
+type cursor struct {
+	Table string            `json:"t"`
+	Key   []json.RawMessage `json:"k"`
+}
+
+// Page reads up to n rows of a SELECT statement after the row of the cursor,
+// appends them to dest, and returns the cursor of the last row or an empty
+// string if there are no more rows. An empty cursor starts from the first
+// row:
+//
+//	stmt := sess.Select(Event{}).Where(Eq("stream", id))
+//	var events []Event
+//	next, err := stmt.Page(&events, cursor, 100)
+//
+// Unlike the page state, the cursors contain the values of the clustering
+// columns of the last row, so they do not expire and can be stored by the
+// clients. The rows after the cursor are selected with a condition like
+// (c1, c2) > (?, ?), or < if the rows are read in descending order, so the
+// statement must restrict the partition key. The rows are read in the
+// clustering order of the table, or the reversed one if the first order of
+// the statement is not the clustering one, and it must have the same
+// direction for all the clustering columns, ErrMixedPageOrder is returned
+// otherwise.
+func (s *StatementImpl) Page(dest interface{}, cursor string, n int) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	if n <= 0 {
+		return "", ErrInvalidPageSize
+	}
+	slice, elemType, isPtr, err := structSlice(dest)
+	if err != nil {
+		return "", err
+	}
+	table := s.session.getRegistry().GetTable(reflect.New(elemType).Interface())
+	columns := table.ClusteringColumns
+	if len(columns) == 0 {
+		return "", ErrNoClusteringColumns
+	}
+
+	op, err := pageOperator(table, s.Orders)
+	if err != nil {
+		return "", err
+	}
+
+	stmt := s.Clone().(*StatementImpl)
+	if cursor != "" {
+		values, err := decodeCursor(cursor, table, elemType)
+		if err != nil {
+			return "", err
+		}
+		stmt.AndWhere(Condition{
+			CQLFragment: fmt.Sprintf("(%s) %s (%s)", strings.Join(table.quoteAll(columns), ","), op, qms(len(columns))),
+			Values:      values,
+		})
+	}
+	// An extra row is read to know if there are more rows
+	stmt.Limit(n + 1)
+
+	var last reflect.Value
+	count := 0
+	v := reflect.New(elemType)
+	iter := stmt.Iter()
+	for count < n && iter.TypeScan(v.Interface()) {
+		if isPtr {
+			slice.Set(reflect.Append(slice, v))
+		} else {
+			slice.Set(reflect.Append(slice, v.Elem()))
+		}
+		last, v = v, reflect.New(elemType)
+		count++
+	}
+	more := count == n && iter.TypeScan(v.Interface())
+	if err := iter.Close(); err != nil || !more {
+		return "", err
+	}
+	return encodeCursor(table, last.Elem())
+}
+
+// pageOperator returns the operator that selects the rows after a cursor
+// with the given orders, > if the rows are read in ascending order and < if
+// they are read in descending order.
+func pageOperator(table Table, orders []OrderBy) (string, error) {
+	clustering := table.clusteringOrder()
+	reversed := len(orders) > 0 && orders[0].OrderType != clustering[0].OrderType
+	for _, o := range clustering[1:] {
+		if o.OrderType != clustering[0].OrderType {
+			return "", ErrMixedPageOrder
+		}
+	}
+	if (clustering[0].OrderType == DescOrder) != reversed {
+		return "<", nil
+	}
+	return ">", nil
+}
+
+// encodeCursor returns the cursor with the clustering columns of v.
+func encodeCursor(table Table, v reflect.Value) (string, error) {
+	c := cursor{Table: table.Name}
+	for _, name := range table.ClusteringColumns {
+		field, ok := table.field(name, v)
+		if !ok {
+			return "", ErrInvalidCursor
+		}
+		b, err := json.Marshal(field.Interface())
+		if err != nil {
+			return "", err
+		}
+		c.Key = append(c.Key, b)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor returns the values of the clustering columns in the cursor,
+// using the types of the fields of the struct type t.
+func decodeCursor(s string, table Table, t reflect.Type) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Table != table.Name || len(c.Key) != len(table.ClusteringColumns) {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, len(c.Key))
+	for i, name := range table.ClusteringColumns {
+		col, ok := table.column(name)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		v := reflect.New(t.FieldByIndex(col.Position).Type)
+		if err := json.Unmarshal(c.Key[i], v.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = v.Elem().Interface()
+	}
+	return values, nil
+}
@@ -0,0 +1,136 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type pageStruct struct {
+	Stream string     `cql:"stream" cqltable:"pages" cqlkey:"stream,seq,id"`
+	Seq    int        `cql:"seq"`
+	ID     gocql.UUID `cql:"id"`
+	Text   string     `cql:"text"`
+}
+
+func TestPage(t *testing.T) {
+	sess, d := newTestSession()
+	id := MustUUID("00000001-0000-1000-8000-000000000000")
+	columns := []string{"stream", "seq", "id", "text"}
+	d.result(columns,
+		[]interface{}{"s", 1, id, "a"},
+		[]interface{}{"s", 2, id, "b"},
+		[]interface{}{"s", 3, id, "c"},
+	)
+
+	// First page
+	stmt := sess.Select(pageStruct{}).Where(Eq("stream", "s"))
+	var page []pageStruct
+	next, err := stmt.Page(&page, "", 2)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, next)
+	assert.Equal(t, []pageStruct{{"s", 1, id, "a"}, {"s", 2, id, "b"}}, page)
+	assert.Equal(t, "SELECT stream,seq,id,text FROM pages WHERE stream = ? LIMIT 3", d.last().Statement)
+
+	// Next page
+	var page2 []*pageStruct
+	d.result(columns, []interface{}{"s", 3, id, "c"})
+	last, err := stmt.Page(&page2, next, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "", last)
+	assert.Equal(t, []*pageStruct{{"s", 3, id, "c"}}, page2)
+	assert.Equal(t, "SELECT stream,seq,id,text FROM pages WHERE stream = ? AND (seq,id) > (?,?) LIMIT 3", d.last().Statement)
+	assert.Equal(t, []interface{}{"s", 2, id}, d.last().Values)
+
+	// The statement is not modified
+	query, _ := stmt.BuildQuery()
+	assert.Equal(t, "SELECT stream,seq,id,text FROM pages WHERE stream = ?", query)
+
+	// Descending order
+	sess.Select(pageStruct{}).Where(Eq("stream", "s")).OrderBy(Desc("seq"), Desc("id")).Page(&page2, next, 10)
+	assert.Equal(t, "SELECT stream,seq,id,text FROM pages WHERE stream = ? AND (seq,id) < (?,?) ORDER BY seq DESC, id DESC LIMIT 11", d.last().Statement)
+
+	// Errors
+	_, err = stmt.Page(&page, "foo", 2)
+	assert.Equal(t, ErrInvalidCursor, err)
+	other, _ := encodeCursor(sess.getRegistry().GetTable(bucketStruct{}), structOf(bucketStruct{Sensor: "s"}))
+	_, err = stmt.Page(&page, other, 2)
+	assert.Equal(t, ErrInvalidCursor, err)
+	_, err = sess.Select(testStruct{}).Page(&[]testStruct{}, "", 2)
+	assert.Equal(t, ErrNoClusteringColumns, err)
+	for _, n := range []int{0, -1} {
+		_, err = stmt.Page(&page, "", n)
+		assert.Equal(t, ErrInvalidPageSize, err)
+	}
+	d.err = errors.New("page error")
+	_, err = stmt.Page(&page, "", 2)
+	assert.True(t, errors.Is(err, d.err))
+}
+
+type descPageStruct struct {
+	Stream string `cql:"stream" cqltable:"desc_pages" cqlkey:"stream,seq desc"`
+	Seq    int    `cql:"seq"`
+}
+
+type mixedPageStruct struct {
+	Stream string `cql:"stream" cqltable:"mixed_pages" cqlkey:"stream,seq desc,id"`
+	Seq    int    `cql:"seq"`
+	ID     int    `cql:"id"`
+}
+
+func TestPageClusteringOrder(t *testing.T) {
+	sess, d := newTestSession()
+	d.result([]string{"stream", "seq"}, []interface{}{"s", 3}, []interface{}{"s", 2}, []interface{}{"s", 1})
+
+	// Descending tables read the rows before the cursor
+	stmt := sess.Select(descPageStruct{}).Where(Eq("stream", "s"))
+	var page []descPageStruct
+	next, err := stmt.Page(&page, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []descPageStruct{{"s", 3}, {"s", 2}}, page)
+	d.result([]string{"stream", "seq"}, []interface{}{"s", 1})
+	_, err = stmt.Page(&page, next, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT stream,seq FROM desc_pages WHERE stream = ? AND (seq) < (?) LIMIT 3", d.last().Statement)
+	assert.Equal(t, []interface{}{"s", 2}, d.last().Values)
+
+	// Reversed order
+	_, err = sess.Select(descPageStruct{}).Where(Eq("stream", "s")).OrderBy(Asc("seq")).Page(&page, next, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT stream,seq FROM desc_pages WHERE stream = ? AND (seq) > (?) ORDER BY seq ASC LIMIT 3", d.last().Statement)
+
+	// Mixed directions
+	n := len(d.requests)
+	_, err = sess.Select(mixedPageStruct{}).Where(Eq("stream", "s")).Page(&[]mixedPageStruct{}, "", 2)
+	assert.Equal(t, ErrMixedPageOrder, err)
+	assert.Len(t, d.requests, n)
+}
+
+type PageEmbedded struct {
+	Seq int `cql:"seq"`
+}
+
+type pageEmbeddedStruct struct {
+	Stream string `cql:"stream" cqltable:"embedded_pages" cqlkey:"stream,seq"`
+	*PageEmbedded
+}
+
+func TestPageCursorEmbedded(t *testing.T) {
+	sess, _ := newTestSession()
+	table := sess.getRegistry().GetTable(&pageEmbeddedStruct{PageEmbedded: &PageEmbedded{}})
+
+	// Nil embedded pointers
+	cursor, err := encodeCursor(table, structOf(pageEmbeddedStruct{Stream: "s"}))
+	assert.NoError(t, err)
+	values, err := decodeCursor(cursor, table, structOf(pageEmbeddedStruct{}).Type())
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{0}, values)
+
+	cursor, err = encodeCursor(table, structOf(&pageEmbeddedStruct{Stream: "s", PageEmbedded: &PageEmbedded{Seq: 3}}))
+	assert.NoError(t, err)
+	values, err = decodeCursor(cursor, table, structOf(pageEmbeddedStruct{}).Type())
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{3}, values)
+}
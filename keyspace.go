@@ -0,0 +1,71 @@
+package ecql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Replication defines the replication strategy of a keyspace.
+type Replication struct {
+	Class  string
+	Factor int
+	DCs    map[string]int
+}
+
+// SimpleStrategy returns the replication strategy that places n replicas in
+// the cluster without considering the data centers.
+func SimpleStrategy(n int) Replication {
+	return Replication{Class: "SimpleStrategy", Factor: n}
+}
+
+// NetworkTopologyStrategy returns the replication strategy that places the
+// given number of replicas in each data center.
+//
+//	NetworkTopologyStrategy(map[string]int{"dc1": 3, "dc2": 2})
+func NetworkTopologyStrategy(dcs map[string]int) Replication {
+	return Replication{Class: "NetworkTopologyStrategy", DCs: dcs}
+}
+
+// String returns the CQL map of the replication. The class and the names of
+// the data centers are escaped as CQL strings.
+func (r Replication) String() string {
+	options := []string{"'class': " + cqlString(r.Class)}
+	if r.Factor > 0 {
+		options = append(options, fmt.Sprintf("'replication_factor': %d", r.Factor))
+	}
+
+	dcs := make([]string, 0, len(r.DCs))
+	for dc := range r.DCs {
+		dcs = append(dcs, dc)
+	}
+	sort.Strings(dcs)
+	for _, dc := range dcs {
+		options = append(options, fmt.Sprintf("%s: %d", cqlString(dc), r.DCs[dc]))
+	}
+
+	return "{" + strings.Join(options, ", ") + "}"
+}
+
+// cqlString returns s as a CQL string literal, the single quotes are
+// escaped by doubling them.
+func cqlString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// CreateKeyspace creates the keyspace with the given replication if it does
+// not exist.
+func (s *SessionImpl) CreateKeyspace(name string, r Replication) error {
+	if err := checkIdent(name); err != nil {
+		return err
+	}
+	return s.execSchema("", fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH replication = %s", name, r))
+}
+
+// DropKeyspace removes the keyspace and all its tables if it exists.
+func (s *SessionImpl) DropKeyspace(name string) error {
+	if err := checkIdent(name); err != nil {
+		return err
+	}
+	return s.execSchema("", fmt.Sprintf("DROP KEYSPACE IF EXISTS %s", name))
+}
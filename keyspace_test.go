@@ -0,0 +1,26 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyspace(t *testing.T) {
+	sess, d := newTestSession()
+
+	assert.NoError(t, sess.CreateKeyspace("ks1", SimpleStrategy(3)))
+	assert.Equal(t, SchemaCmd, d.last().Command)
+	assert.Equal(t, "CREATE KEYSPACE IF NOT EXISTS ks1 WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 3}", d.last().Statement)
+
+	assert.NoError(t, sess.CreateKeyspace("ks2", NetworkTopologyStrategy(map[string]int{"us-west": 3, "eu-central": 2})))
+	assert.Equal(t, "CREATE KEYSPACE IF NOT EXISTS ks2 WITH replication = {'class': 'NetworkTopologyStrategy', 'eu-central': 2, 'us-west': 3}", d.last().Statement)
+
+	assert.NoError(t, sess.DropKeyspace("ks1"))
+	assert.Equal(t, "DROP KEYSPACE IF EXISTS ks1", d.last().Statement)
+	// The strings are escaped
+	r := NetworkTopologyStrategy(map[string]int{"dc1': 1}; DROP KEYSPACE ks1; --": 1})
+	r.Class = "NetworkTopologyStrategy', 'x"
+	assert.NoError(t, sess.CreateKeyspace("ks3", r))
+	assert.Equal(t, "CREATE KEYSPACE IF NOT EXISTS ks3 WITH replication = {'class': 'NetworkTopologyStrategy'', ''x', 'dc1'': 1}; DROP KEYSPACE ks1; --': 1}", d.last().Statement)
+}
@@ -0,0 +1,114 @@
+package ecql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrLimitExceeded is matched by the errors returned when a request exceeds
+// the limits of the session.
+var ErrLimitExceeded = errors.New("ecql: limit exceeded")
+
+// Limits are the guardrails checked before the requests are sent, so the
+// statements that Cassandra would reject, or log warnings about, fail early
+// with a descriptive error. A zero value disables a limit.
+type Limits struct {
+	// MaxValues is the maximum number of bound values of a statement.
+	MaxValues int
+	// MaxBatchStatements is the maximum number of statements in a batch.
+	MaxBatchStatements int
+	// MaxBatchBytes is the maximum estimated size of the values of a batch,
+	// like batch_size_fail_threshold in cassandra.yaml.
+	MaxBatchBytes int
+}
+
+// LimitError is the error returned when a request exceeds one of the Limits.
+// It matches ErrLimitExceeded with errors.Is.
+type LimitError struct {
+	Limit string
+	Table string
+	Value int
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	if e.Table != "" {
+		return fmt.Sprintf("%v: %d %s on table %s, the maximum is %d", ErrLimitExceeded, e.Value, e.Limit, e.Table, e.Max)
+	}
+	return fmt.Sprintf("%v: %d %s, the maximum is %d", ErrLimitExceeded, e.Value, e.Limit, e.Max)
+}
+
+// Is reports if target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// WithLimits sets the limits of the requests of the session:
+//
+//	sess, err := ecql.NewSession(cfg, ecql.WithLimits(ecql.Limits{
+//		MaxValues:          1000,
+//		MaxBatchStatements: 100,
+//		MaxBatchBytes:      50 << 10,
+//	}))
+func WithLimits(l Limits) Option {
+	return func(s *SessionImpl) {
+		s.limits = l
+	}
+}
+
+// check returns a *LimitError if the request exceeds the limits.
+func (l Limits) check(req *Request) error {
+	if l.MaxValues > 0 && len(req.Values) > l.MaxValues {
+		return &LimitError{Limit: "bound values", Table: req.Table, Value: len(req.Values), Max: l.MaxValues}
+	}
+	return nil
+}
+
+// checkBatch returns a *LimitError if the batch or its statements exceed the
+// limits.
+func (l Limits) checkBatch(b *BatchRequest) error {
+	if l.MaxBatchStatements > 0 && len(b.Entries) > l.MaxBatchStatements {
+		return &LimitError{Limit: "batch statements", Value: len(b.Entries), Max: l.MaxBatchStatements}
+	}
+	size := 0
+	for i := range b.Entries {
+		if err := l.check(&b.Entries[i]); err != nil {
+			return err
+		}
+		for _, v := range b.Entries[i].Values {
+			size += sizeOf(reflect.ValueOf(v))
+		}
+	}
+	if l.MaxBatchBytes > 0 && size > l.MaxBatchBytes {
+		return &LimitError{Limit: "batch bytes", Value: size, Max: l.MaxBatchBytes}
+	}
+	return nil
+}
+
+// limitsDriver is the Driver that checks the limits of a session.
+type limitsDriver struct {
+	Driver
+	limits Limits
+}
+
+func (d limitsDriver) Iter(req *Request) Rows {
+	if err := d.limits.check(req); err != nil {
+		return errorRows{err: err}
+	}
+	return d.Driver.Iter(req)
+}
+
+func (d limitsDriver) ExecBatch(b *BatchRequest) error {
+	if err := d.limits.checkBatch(b); err != nil {
+		return err
+	}
+	return d.Driver.ExecBatch(b)
+}
+
+func (d limitsDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	if err := d.limits.checkBatch(b); err != nil {
+		return false, err
+	}
+	return d.Driver.ExecBatchCAS(b, dest)
+}
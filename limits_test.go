@@ -0,0 +1,32 @@
+package ecql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits(t *testing.T) {
+	sess, d := newTestSession(WithLimits(Limits{MaxValues: 4, MaxBatchStatements: 2, MaxBatchBytes: 100}))
+
+	assert.NoError(t, sess.Set(testStruct{F1: "a"}))
+	assert.NoError(t, sess.Select(testStruct{}).Where(In("f1", "a", "b", "c", "d")).Exec())
+	n := len(d.requests)
+	err := sess.Select(testStruct{}).Where(In("f1", "a", "b", "c", "d", "e")).Exec()
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	assert.Equal(t, &LimitError{Limit: "bound values", Table: "mytable", Value: 5, Max: 4}, err)
+	assert.Equal(t, "ecql: limit exceeded: 5 bound values on table mytable, the maximum is 4", err.Error())
+	assert.Len(t, d.requests, n)
+
+	assert.NoError(t, sess.Batch().Add(sess.Insert(testStruct{F1: "a"}), sess.Insert(testStruct{F1: "b"})).Apply())
+	assert.Len(t, d.batches, 1)
+	err = sess.Batch().Add(sess.Insert(testStruct{F1: "a"}), sess.Insert(testStruct{F1: "b"}), sess.Insert(testStruct{F1: "c"})).Apply()
+	assert.Equal(t, &LimitError{Limit: "batch statements", Value: 3, Max: 2}, err)
+	assert.Equal(t, "ecql: limit exceeded: 3 batch statements, the maximum is 2", err.Error())
+	err = sess.Batch().Add(sess.Insert(testStruct{F1: strings.Repeat("a", 101)})).Apply()
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	assert.Equal(t, "batch bytes", err.(*LimitError).Limit)
+	assert.Len(t, d.batches, 1)
+}
@@ -1,7 +1,9 @@
 package ecql
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -10,10 +12,28 @@ var (
 	// TAG_COLUMNS is the tag used in the structs to set the column name for a field.
 	// If a name is not set, the name would be the lowercase version of the field.
 	// If you want to skip a field you can use `cql:"-"`
+	// A map[string]interface{} field with the tag `cql:",remaining"` gets the
+	// columns not mapped to other fields on reads, and its values are written
+	// as columns on inserts. A field with the tag `cql:",ttl=col"` gets the
+	// remaining time to live of the column col on reads, see TTL. A []float32
+	// or Vector field with the tag `cql:"name,vector=n"` maps to a column of
+	// type vector<float, n>. The CQL type of a column can be set using the
+	// option type, `cql:"name,type=date"`, it is used to generate the schema
+	// and to convert the values if necessary. Fields with the option omitempty
+	// are not set on writes if they have the zero value, avoiding the creation
+	// of tombstones, `cql:"name,omitempty"`. Empty UUID fields with the
+	// option auto get a generated UUID on inserts, a time UUID if the type is
+	// timeuuid, `cql:"id,auto,type=timeuuid"`. The option bucket defines the
+	// bucket column of a time-series table, see TimeBucket. The option
+	// ttlfrom sets the time to live of the inserts using a time column,
+	// `cql:",ttlfrom=expires_at"`, see TTLFrom. Float64 fields can be stored
+	// in decimal columns using `cql:"name,type=decimal"`.
 	TAG_COLUMN = "cql"
 
 	// TAG_TABLE is the tag used in the structs to define the table for a type.
-	// If the table is not set it defaults to the type name in lowercase.
+	// If the table is not set it defaults to the type name in lowercase. The
+	// option quoted makes the names of the table and columns case-sensitive,
+	// `cqltable:"Orders,quoted"`.
 	TAG_TABLE = "cqltable"
 
 	// TAG_KEY defines the primary key for the table.
@@ -22,42 +42,92 @@ var (
 	TAG_KEY = "cqlkey"
 )
 
-var registry = newSyncRegistry()
+// DefaultRegistry is the registry used by the package functions and by the
+// sessions created without the WithRegistry option.
+var DefaultRegistry = NewRegistry()
 
-type syncRegistry struct {
+var remainingType = reflect.TypeOf(map[string]interface{}{})
+
+// Registry keeps the Table information of the registered types. Sessions use
+// the DefaultRegistry unless a different one is set using WithRegistry, this
+// allows to map the same type differently in two sessions.
+type Registry struct {
 	sync.RWMutex
-	data map[reflect.Type]Table
+	data   map[reflect.Type]Table
+	parent *Registry
+	keys   *keyring
 }
 
-func newSyncRegistry() *syncRegistry {
-	return &syncRegistry{
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
 		data: make(map[reflect.Type]Table),
+		keys: new(keyring),
 	}
 }
 
-func (r *syncRegistry) clear() {
+// Clear removes all the types from the registry.
+func (r *Registry) Clear() {
 	r.Lock()
 	r.data = make(map[reflect.Type]Table)
 	r.Unlock()
 }
 
-func (r *syncRegistry) set(t reflect.Type, table Table) {
+func (r *Registry) set(t reflect.Type, table Table) {
 	r.Lock()
 	r.data[t] = table
 	r.Unlock()
 }
 
-func (r *syncRegistry) get(t reflect.Type) (Table, bool) {
+func (r *Registry) get(t reflect.Type) (Table, bool) {
 	r.RLock()
 	table, ok := r.data[t]
 	r.RUnlock()
+	if !ok && r.parent != nil {
+		return r.parent.get(t)
+	}
 	return table, ok
 }
 
+// overlay returns a new registry that uses r for the types not registered in
+// it.
+func (r *Registry) overlay() *Registry {
+	o := NewRegistry()
+	o.parent, o.keys = r, r.keys
+	return o
+}
+
+// Register adds the passed struct to the registry. See Register for more
+// information.
+func (r *Registry) Register(i interface{}) {
+	r.register(i)
+}
+
+// RegisterAs adds the passed struct to the registry using the given table
+// name instead of the one defined in the struct.
+func (r *Registry) RegisterAs(i interface{}, name string) {
+	table := r.register(i)
+	table.Name = name
+	r.set(structOf(i).Type(), table)
+}
+
+// registryOf returns the registry used by the session s.
+func registryOf(s Session) *Registry {
+	if sess, ok := s.(*SessionImpl); ok {
+		return sess.getRegistry()
+	}
+	return DefaultRegistry
+}
+
+// TableOf returns the Table of i in the registry used by the session s.
+func TableOf(s Session, i interface{}) Table {
+	return registryOf(s).GetTable(i)
+}
+
 // Delete registry cleans the registry.
 // This would be mainly used in unit testing.
 func DeleteRegistry() {
-	registry.clear()
+	DefaultRegistry.Clear()
 }
 
 // Register adds the passed struct to the registry to be able to use gocql
@@ -66,7 +136,7 @@ func DeleteRegistry() {
 // It maps the columns using the struct tag 'cql' or the lowercase of the
 // field name. You can skip the mapping of one field using the tag `cql:"-"`
 func Register(i interface{}) {
-	register(i)
+	DefaultRegistry.Register(i)
 }
 
 // Map creates a new map[string]interface{} where each member in the map
@@ -80,7 +150,12 @@ func Register(i interface{}) {
 // 	m := cql.Map(&t)
 // 	err := query.MapScan(m)
 func Map(i interface{}) map[string]interface{} {
-	columns, _ := MapTable(i)
+	return DefaultRegistry.Map(i)
+}
+
+// Map is like the package function Map but using the registry r.
+func (r *Registry) Map(i interface{}) map[string]interface{} {
+	columns, _ := r.MapTable(i)
 	return columns
 }
 
@@ -96,99 +171,119 @@ func Map(i interface{}) map[string]interface{} {
 // 	m, _ := cql.MapTable(&t)
 // 	err := query.MapScan(m)
 func MapTable(i interface{}) (map[string]interface{}, Table) {
+	return DefaultRegistry.MapTable(i)
+}
+
+// MapTable is like the package function MapTable but using the registry r.
+func (r *Registry) MapTable(i interface{}) (map[string]interface{}, Table) {
 	v := structOf(i)
 	t := v.Type()
 
 	// Get the table or register on the fly if necessary
-	table, ok := registry.get(t)
+	table, ok := r.get(t)
 	if !ok {
-		table = register(i)
+		table = r.register(i)
 	}
 
-	columns := make(map[string]interface{})
-	for _, col := range table.Columns {
-		var field reflect.Value
-		for i, p := range col.Position {
-			field = v.Field(p)
-			// Don't bother prepping for next position on last
-			if len(col.Position) == i+1 {
-				break
-			}
-			next := field
-			if field.CanAddr() {
-				next = field.Addr()
-			}
-			if next.Kind() != reflect.Struct {
-				break
-			}
-			if next.CanInterface() {
-				v = structOf(next.Interface())
-			}
-		}
-		if field.CanAddr() {
-			columns[col.Name] = field.Addr().Interface()
-		} else {
-			columns[col.Name] = field.Interface()
-		}
+	// The TTL columns are only used on reads
+	cols := table.Columns
+	if len(table.TTLColumns) > 0 {
+		cols = append(cols[:len(cols):len(cols)], table.TTLColumns...)
+	}
+
+	columns := make(map[string]interface{}, len(cols))
+	for i, col := range cols {
+		columns[col.Name] = table.ref(i, col, v)
 	}
-	return columns, table
+	return columns, table.shard(columns)
 }
 
 // Bind returns the values of i to bind in insert queries.
 func Bind(i interface{}) []interface{} {
-	columns, _, _ := BindTable(i)
+	return DefaultRegistry.Bind(i)
+}
+
+// Bind is like the package function Bind but using the registry r.
+func (r *Registry) Bind(i interface{}) []interface{} {
+	v := structOf(i)
+	table, ok := r.get(v.Type())
+	if !ok || table.Remaining != nil {
+		columns, _, _ := r.BindTable(i)
+		return columns
+	}
+
+	// The mapping is not required without remaining columns
+	columns := make([]interface{}, len(table.Columns))
+	for i, col := range table.Columns {
+		columns[i] = table.value(i, col, v)
+	}
 	return columns
 }
 
 // BindTables returns the values of i to bind in insert queries and the Table
 // with the information about the type.
 func BindTable(i interface{}) ([]interface{}, map[string]interface{}, Table) {
+	return DefaultRegistry.BindTable(i)
+}
+
+// BindTable is like the package function BindTable but using the registry r.
+func (r *Registry) BindTable(i interface{}) ([]interface{}, map[string]interface{}, Table) {
 	v := structOf(i)
 	t := v.Type()
 
 	// Get the table or register on the fly if necessary
-	table, ok := registry.get(t)
+	table, ok := r.get(t)
 	if !ok {
-		table = register(i)
+		table = r.register(i)
 	}
 
 	columns := make([]interface{}, len(table.Columns))
-	mapping := make(map[string]interface{})
+	mapping := make(map[string]interface{}, len(table.Columns))
 	for i, col := range table.Columns {
-		var field reflect.Value
-		for i, p := range col.Position {
-			field = v.Field(p)
-			// Don't bother prepping for next position on last
-			if len(col.Position) == i+1 {
-				break
-			}
-			next := field
-			if field.CanAddr() {
-				next = field.Addr()
-			}
-			if next.Kind() != reflect.Struct {
-				break
-			}
-			if next.CanInterface() {
-				v = structOf(next.Interface())
+		columns[i] = table.value(i, col, v)
+		mapping[col.Name] = columns[i]
+	}
+
+	// Add the columns in the remaining field
+	if extra := table.remainingOf(structOf(i)); len(extra) > 0 {
+		names := make([]string, 0, len(extra))
+		for name := range extra {
+			if _, ok := mapping[name]; !ok {
+				names = append(names, name)
 			}
 		}
-
-		columns[i] = field.Interface()
-		mapping[col.Name] = columns[i]
+		sort.Strings(names)
+
+		cols := make([]Column, len(table.Columns), len(table.Columns)+len(names))
+		copy(cols, table.Columns)
+		for _, name := range names {
+			cols = append(cols, Column{Name: name})
+			columns = append(columns, extra[name])
+			mapping[name] = extra[name]
+		}
+		table.Columns = cols
 	}
-	return columns, mapping, table
+
+	return columns, mapping, table.shard(mapping)
 }
 
 // GetTable returns the Table with the information about the type of i.
 func GetTable(i interface{}) Table {
+	return DefaultRegistry.GetTable(i)
+}
+
+// GetTable is like the package function GetTable but using the registry r.
+func (r *Registry) GetTable(i interface{}) Table {
 	v := structOf(i)
 	t := v.Type()
 
 	// Get the table or register on the fly if necessary
-	table, ok := registry.get(t)
+	table, ok := r.get(t)
 	if !ok {
-		table = register(i)
+		table = r.register(i)
+	}
+	if table.Sharding != nil {
+		_, table = r.MapTable(i)
 	}
 
 	return table
@@ -209,7 +304,17 @@ func structOf(i interface{}) reflect.Value {
 	panic("register type is not struct")
 }
 
-func register(i interface{}) Table {
+// deref returns the value pointed by v if v is a pointer, MapTable returns
+// references to the fields of addressable structs.
+func deref(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		return rv.Elem().Interface()
+	}
+	return v
+}
+
+func (r *Registry) register(i interface{}) Table {
 	v := structOf(i)
 	t := v.Type()
 
@@ -220,14 +325,38 @@ func register(i interface{}) Table {
 	for i, n := 0, t.NumField(); i < n; i++ {
 		field := t.Field(i)
 
+		// Embedded Tracked enables the change tracking
+		if field.Anonymous && field.Type == trackedType {
+			table.Tracked = []int{i}
+			continue
+		}
+
 		// Embed fields from anonymous structs--but not at the expense of explicit tags
 		if field.Anonymous && v.Field(i).CanInterface() {
-			_, tt := MapTable(v.Field(i).Interface())
+			_, tt := r.MapTable(v.Field(i).Interface())
 			if len(tt.Name) > 0 && len(table.Name) == 0 {
 				table.Name = tt.Name
 			}
 			if len(tt.KeyColumns) > 0 && len(table.KeyColumns) == 0 {
 				table.KeyColumns = tt.KeyColumns
+				table.PartitionColumns = tt.PartitionColumns
+				table.ClusteringColumns = tt.ClusteringColumns
+				table.ClusteringOrder = tt.ClusteringOrder
+			}
+			if tt.TenantColumn != "" && table.TenantColumn == "" {
+				table.TenantColumn = tt.TenantColumn
+			}
+			if tt.Bucket != nil && table.Bucket == nil {
+				table.Bucket = tt.Bucket
+			}
+			if tt.CaseSensitive {
+				table.CaseSensitive = true
+			}
+			if tt.TTLFrom != "" && table.TTLFrom == "" {
+				table.TTLFrom = tt.TTLFrom
+			}
+			if tt.Remaining != nil && table.Remaining == nil {
+				table.Remaining = append([]int{i}, tt.Remaining...)
 			}
 			if len(tt.Columns) > 0 {
 				for _, col := range tt.Columns {
@@ -235,35 +364,134 @@ func register(i interface{}) Table {
 					table.Columns = append(table.Columns, col)
 				}
 			}
+			for _, col := range tt.TTLColumns {
+				col.Position = append([]int{i}, col.Position...)
+				table.TTLColumns = append(table.TTLColumns, col)
+			}
 		}
 
 		// Get table if available
-		name := field.Tag.Get(TAG_TABLE)
+		name, tableOpts := parseTag(field.Tag.Get(TAG_TABLE))
 		if name != "" {
 			table.Name = name
 		}
+		if tableOpts.has("quoted") {
+			table.CaseSensitive = true
+		}
 
 		// Get the key columns
 		name = field.Tag.Get(TAG_KEY)
 		if name != "" {
-			table.KeyColumns = strings.Split(name, ",")
+			table.setKey(name)
 		}
 
 		// Get columns or field name
-		name = field.Tag.Get(TAG_COLUMN)
+		name, opts := parseTag(field.Tag.Get(TAG_COLUMN))
+		var colType string
+		if opts.has("remaining") && field.Type == remainingType {
+			table.Remaining = []int{i}
+			continue
+		}
+		if typ, ok := opts.value("type"); ok {
+			colType = typ
+		}
+		if n, ok := opts.value("vector"); ok {
+			colType = fmt.Sprintf("vector<float, %s>", n)
+		}
+		if col, ok := opts.value("ttlfrom"); ok {
+			table.TTLFrom = col
+			if name == "" {
+				continue
+			}
+		}
+		if col, ok := opts.value("ttl"); ok {
+			table.TTLColumns = append(table.TTLColumns, Column{Name: "ttl(" + col + ")", Position: []int{i}})
+			continue
+		}
 		if name == "" {
 			name = strings.ToLower(field.Name)
 		}
 		if name != "-" {
-			table.Columns = append(table.Columns, Column{name, []int{i}})
+			col := Column{
+				Name:      name,
+				Position:  []int{i},
+				Type:      colType,
+				Enum:      enumValues(field.Type, opts),
+				OmitEmpty: opts.has("omitempty"),
+				Auto:      opts.has("auto"),
+			}
+			if col.Auto && !isAutoType(field.Type) {
+				panic("auto column " + name + " is not a UUID")
+			}
+			if opts.has("encrypted") {
+				col.Type, col.Enum, col.Encrypted, col.keys = "blob", nil, true, r.keys
+			} else if s := serializerOption(opts); s != "" {
+				col.Enum, col.Serializer, col.Compressed = nil, s, opts.has("gzip")
+				if col.Type == "" {
+					col.Type = "blob"
+				}
+				if col.Compressed && col.Type != "blob" {
+					panic("gzip column " + name + " is not a blob")
+				}
+			}
+			if opts.has("tenant") {
+				table.TenantColumn = name
+			}
+			if b, ok := opts.value("bucket"); ok {
+				table.Bucket = parseBucket(name, b)
+			}
+			table.Columns = append(table.Columns, col)
 		}
 	}
 
 	// If no key is explicitly given, assume the first field is implicitly the key
 	if len(table.KeyColumns) == 0 && len(table.Columns) > 0 {
-		table.KeyColumns = []string{table.Columns[0].Name}
+		table.setKey(table.Columns[0].Name)
+	}
+
+	// Key columns are always set, and they cannot be encrypted as the
+	// ciphertexts of the same value are different
+	for i := range table.Columns {
+		if !table.isKey(table.Columns[i].Name) {
+			continue
+		}
+		if table.Columns[i].Encrypted {
+			panic("encrypted key column " + table.Columns[i].Name)
+		}
+		table.Columns[i].OmitEmpty = false
 	}
 
-	registry.set(t, table)
+	table.setAccessors(t)
+	r.set(t, table)
 	return table
 }
+
+// tagOptions are the options in a column tag after the name.
+type tagOptions []string
+
+// parseTag splits a column tag like `cql:"name,opt1,opt2"` in the name and
+// the options.
+func parseTag(tag string) (string, tagOptions) {
+	parts := strings.Split(tag, ",")
+	return parts[0], tagOptions(parts[1:])
+}
+
+// value returns the value of an option like `key=value`.
+func (o tagOptions) value(key string) (string, bool) {
+	for _, s := range o {
+		if strings.HasPrefix(s, key+"=") {
+			return s[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// has returns true if opt is in the options.
+func (o tagOptions) has(opt string) bool {
+	for _, s := range o {
+		if s == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -29,7 +29,7 @@ func TestRegister(t *testing.T) {
 	for _, tc := range tests {
 		DeleteRegistry()
 		Register(tc.I)
-		table, ok := registry.get(typ)
+		table, ok := DefaultRegistry.get(typ)
 		assert.True(t, ok)
 		assert.Equal(t, "mytable", table.Name)
 		assert.Equal(t, []string{"f1"}, table.KeyColumns)
@@ -157,3 +157,161 @@ func TestStructOfPanic2(t *testing.T) {
 	s := "string"
 	Register(&s)
 }
+
+func TestSetKey(t *testing.T) {
+	var tests = []struct {
+		key        string
+		keys       []string
+		partition  []string
+		clustering []string
+	}{
+		{"id", []string{"id"}, []string{"id"}, nil},
+		{"id,time", []string{"id", "time"}, []string{"id"}, []string{"time"}},
+		{"id, time, seq", []string{"id", "time", "seq"}, []string{"id"}, []string{"time", "seq"}},
+		{"id, time desc, seq", []string{"id", "time", "seq"}, []string{"id"}, []string{"time", "seq"}},
+	}
+
+	for _, tc := range tests {
+		var table Table
+		table.setKey(tc.key)
+		assert.Equal(t, tc.keys, table.KeyColumns, tc.key)
+		assert.Equal(t, tc.partition, table.PartitionColumns, tc.key)
+		assert.Equal(t, tc.clustering, table.ClusteringColumns, tc.key)
+	}
+}
+
+type remainingStruct struct {
+	ID    string                 `cql:"id" cqltable:"docs"`
+	Name  string                 `cql:"name"`
+	Attrs map[string]interface{} `cql:",remaining"`
+}
+
+func TestRemaining(t *testing.T) {
+	DeleteRegistry()
+	table := GetTable(remainingStruct{})
+	assert.Equal(t, []int{2}, table.Remaining)
+	assert.Len(t, table.Columns, 2)
+
+	sess, d := newTestSession()
+	d.result([]string{"id", "name", "color", "size"}, []interface{}{"a", "foo", "red", 3})
+	var rs remainingStruct
+	assert.NoError(t, sess.Get(&rs, "a"))
+	assert.Equal(t, "SELECT * FROM docs WHERE id = ?", d.last().Statement)
+	assert.Equal(t, remainingStruct{"a", "foo", map[string]interface{}{"color": "red", "size": 3}}, rs)
+
+	rs.Attrs["name"] = "ignored"
+	assert.NoError(t, sess.Set(&rs))
+	assert.Equal(t, "INSERT INTO docs (id,name,color,size) VALUES (?,?,?,?)", d.last().Statement)
+	assert.Equal(t, []interface{}{"a", "foo", "red", 3}, d.last().Values)
+
+	// The registered table is not modified
+	assert.Len(t, GetTable(remainingStruct{}).Columns, 2)
+}
+
+func TestRegistry(t *testing.T) {
+	DeleteRegistry()
+	r := NewRegistry()
+	r.RegisterAs(testStruct{}, "othertable")
+	assert.Equal(t, "othertable", r.GetTable(testStruct{}).Name)
+	assert.Equal(t, "mytable", GetTable(testStruct{}).Name)
+
+	sess1, d1 := newTestSession()
+	sess2, d2 := newTestSession(WithRegistry(r))
+	d1.result([]string{"f1"}, []interface{}{"foo"})
+	d2.result([]string{"f1"}, []interface{}{"foo"})
+
+	var ts testStruct
+	assert.NoError(t, sess1.Get(&ts, "foo"))
+	assert.NoError(t, sess2.Get(&ts, "foo"))
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ?", d1.last().Statement)
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM othertable WHERE f1 = ?", d2.last().Statement)
+
+	assert.NoError(t, sess2.Update(&ts).Columns("f22").Exec())
+	assert.Equal(t, "UPDATE othertable SET f22 = ? WHERE f1 = ?", d2.last().Statement)
+	assert.NoError(t, sess2.Delete(&ts).Exec())
+	assert.Equal(t, "DELETE FROM othertable WHERE f1 = ?", d2.last().Statement)
+
+	r.Clear()
+	assert.Equal(t, "mytable", r.GetTable(testStruct{}).Name)
+}
+
+func TestTableOverride(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	d.result([]string{"f1"}, []interface{}{"foo"})
+
+	ts := testStruct{F1: "foo"}
+	assert.NoError(t, sess.Insert(&ts).IntoTable("mytable_2024").Exec())
+	assert.Equal(t, "INSERT INTO mytable_2024 (f1,f22,f3,f4) VALUES (?,?,?,?)", d.last().Statement)
+
+	s2024 := sess.Table(testStruct{}, "mytable_2024")
+	assert.NoError(t, s2024.Get(&ts, "foo"))
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable_2024 WHERE f1 = ?", d.last().Statement)
+	assert.NoError(t, s2024.Del(&ts))
+	assert.Equal(t, "DELETE FROM mytable_2024 WHERE f1 = ?", d.last().Statement)
+
+	// Other types and the original session are not modified
+	assert.Equal(t, "mytable", GetTable(testStruct{}).Name)
+	assert.NoError(t, sess.Get(&ts, "foo"))
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ?", d.last().Statement)
+	assert.Equal(t, "docs", s2024.(*SessionImpl).getRegistry().GetTable(remainingStruct{}).Name)
+}
+
+type BenchBase struct {
+	Created int64  `cql:"created"`
+	Owner   string `cql:"owner"`
+}
+
+type benchStruct struct {
+	ID        string `cql:"id" cqltable:"bench" cqlkey:"id"`
+	Name      string `cql:"name"`
+	Count     int    `cql:"count"`
+	Score     float64
+	Tags      []string
+	BenchBase `cql:"-"`
+}
+
+func newBenchStruct() *benchStruct {
+	return &benchStruct{
+		ID: "id", Name: "name", Count: 1, Score: 2, Tags: []string{"a"},
+		BenchBase: BenchBase{Created: 3, Owner: "owner"},
+	}
+}
+
+func TestBindEmbedded(t *testing.T) {
+	r := NewRegistry()
+	v := newBenchStruct()
+	values, mapping, table := r.BindTable(v)
+	assert.Equal(t, "bench", table.Name)
+	assert.Equal(t, []interface{}{"id", "name", 1, float64(2), []string{"a"}, int64(3), "owner"}, values)
+	assert.Equal(t, "owner", mapping["owner"])
+
+	m, _ := r.MapTable(v)
+	*m["owner"].(*string) = "foo"
+	assert.Equal(t, "foo", v.Owner)
+}
+
+// benchmarkRegistry runs fn with a registry where the bench struct is
+// registered.
+func benchmarkRegistry(b *testing.B, fn func(r *Registry, v *benchStruct)) {
+	r := NewRegistry()
+	v := newBenchStruct()
+	r.Register(v)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fn(r, v)
+	}
+}
+
+func BenchmarkBind(b *testing.B) {
+	benchmarkRegistry(b, func(r *Registry, v *benchStruct) { r.Bind(v) })
+}
+
+func BenchmarkBindTable(b *testing.B) {
+	benchmarkRegistry(b, func(r *Registry, v *benchStruct) { r.BindTable(v) })
+}
+
+func BenchmarkMapTable(b *testing.B) {
+	benchmarkRegistry(b, func(r *Registry, v *benchStruct) { r.MapTable(v) })
+}
@@ -0,0 +1,375 @@
+package ecql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redacted is the value of the text columns masked with Redact.
+const Redacted = "[REDACTED]"
+
+// ErrMaskedJSON is returned by the JSON selects of tables with columns that
+// must be masked, the values of the JSON documents cannot be masked.
+var ErrMaskedJSON = errors.New("ecql: cannot select masked columns as JSON")
+
+// MaskFunc returns the masked value of a column, the values that cannot be
+// set in the destination are replaced by the zero value.
+type MaskFunc func(v interface{}) interface{}
+
+// Redact replaces the text values with Redacted, and the rest of values with
+// the zero value.
+func Redact(v interface{}) interface{} {
+	if _, ok := v.(string); ok {
+		return Redacted
+	}
+	return nil
+}
+
+// Hash replaces the values with the hex encoded SHA-256 of the value, so the
+// masked values can still be compared. Only text columns can be hashed, the
+// rest of values are replaced with the zero value.
+func Hash(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeepLast returns a MaskFunc that replaces all the characters of the text
+// values but the last n with '*'.
+func KeepLast(n int) MaskFunc {
+	return func(v interface{}) interface{} {
+		s, ok := v.(string)
+		if !ok {
+			return nil
+		}
+		r := []rune(s)
+		for i := 0; i < len(r)-n; i++ {
+			r[i] = '*'
+		}
+		return string(r)
+	}
+}
+
+type permissionsKey struct{}
+
+// WithPermissions returns a copy of ctx with the given permissions added.
+func WithPermissions(ctx context.Context, permissions ...string) context.Context {
+	perms, _ := ctx.Value(permissionsKey{}).([]string)
+	perms = append(perms[:len(perms):len(perms)], permissions...)
+	return context.WithValue(ctx, permissionsKey{}, perms)
+}
+
+// HasPermission returns true if the permission was added to the context with
+// WithPermissions.
+func HasPermission(ctx context.Context, permission string) bool {
+	if ctx == nil {
+		return false
+	}
+	perms, _ := ctx.Value(permissionsKey{}).([]string)
+	for _, p := range perms {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskRule defines the mask of a column. The values are masked unless the
+// context of the statement has the permission.
+type MaskRule struct {
+	Table      string
+	Column     string
+	Permission string
+	Mask       MaskFunc
+}
+
+// MaskingConfig contains the configuration of a Masking.
+type MaskingConfig struct {
+	Rules []MaskRule
+	// Allowed returns true if the context has the permission, it defaults to
+	// HasPermission.
+	Allowed func(ctx context.Context, permission string) bool
+}
+
+// Masking is a middleware that masks the values of sensitive columns on
+// reads, unless the context of the statement has the permission to read
+// them:
+//
+//	masking := ecql.NewMasking(ecql.MaskingConfig{
+//		Rules: []ecql.MaskRule{
+//			{Table: "users", Column: "email", Permission: "pii", Mask: ecql.Hash},
+//			{Table: "users", Column: "card", Permission: "billing", Mask: ecql.KeepLast(4)},
+//		},
+//	})
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(masking.Middleware()))
+//	// ...
+//	ctx = ecql.WithPermissions(ctx, "pii")
+//	err = sess.WithContext(ctx).Get(&user, id)
+//
+// The values are masked after they are read, so the conditions of the
+// statements use the real values. The tables of the rules match the tables
+// with or without keyspace, and the selected expressions that use a masked
+// column, like aliases or functions, are masked too.
+type Masking struct {
+	rules   map[string][]MaskRule
+	allowed func(ctx context.Context, permission string) bool
+}
+
+// NewMasking creates a Masking with the given configuration.
+func NewMasking(config MaskingConfig) *Masking {
+	m := &Masking{
+		rules:   make(map[string][]MaskRule),
+		allowed: config.Allowed,
+	}
+	for _, r := range config.Rules {
+		table := maskName(r.Table)
+		m.rules[table] = append(m.rules[table], r)
+	}
+	if m.allowed == nil {
+		m.allowed = HasPermission
+	}
+	return m
+}
+
+// Middleware returns the middleware that masks the columns.
+func (m *Masking) Middleware() Middleware {
+	return func(next Driver) Driver {
+		return &maskingDriver{Driver: next, masking: m}
+	}
+}
+
+// masks returns the masks of the columns that must be masked in the result
+// of the request, by the name of the column in the result. The selected
+// expressions that use a masked column, like aliases or functions, are masked
+// too. It returns ErrMaskedJSON on JSON selects with masked columns.
+func (m *Masking) masks(req *Request) (map[string]MaskFunc, error) {
+	if req.Command != SelectCmd {
+		return nil, nil
+	}
+	var columns map[string]MaskFunc
+	for _, r := range m.rules[maskName(req.Table)] {
+		if r.Permission != "" && m.allowed(contextOf(req.Context), r.Permission) {
+			continue
+		}
+		if columns == nil {
+			columns = make(map[string]MaskFunc)
+		}
+		columns[maskName(r.Column)] = r.Mask
+	}
+	if columns == nil {
+		return nil, nil
+	}
+
+	exprs, json := selectExprs(req.Statement)
+	if json {
+		return nil, ErrMaskedJSON
+	}
+	masks := make(map[string]MaskFunc, len(columns))
+	for col, mask := range columns {
+		masks[col] = mask
+	}
+	for name, expr := range exprs {
+		for _, id := range exprIdentRegexp.FindAllString(stringRegexp.ReplaceAllString(expr, ""), -1) {
+			if mask, ok := columns[maskName(id)]; ok {
+				masks[name] = mask
+				break
+			}
+		}
+	}
+	return masks, nil
+}
+
+var (
+	selectRegexp    = regexp.MustCompile(`(?is)^\s*SELECT\s+(?:(JSON)\s+)?(?:DISTINCT\s+)?(.*?)\s+FROM\s`)
+	aliasRegexp     = regexp.MustCompile(`(?is)^(.*)\s+AS\s+("(?:[^"]|"")+"|\w+)$`)
+	exprIdentRegexp = regexp.MustCompile(`"(?:[^"]|"")+"|[A-Za-z_]\w*`)
+	stringRegexp    = regexp.MustCompile(`'(?:[^']|'')*'`)
+)
+
+// selectExprs returns the expressions of a SELECT statement by the name of
+// their column in the result, and if it is a JSON select.
+func selectExprs(cql string) (map[string]string, bool) {
+	match := selectRegexp.FindStringSubmatch(cql)
+	if match == nil {
+		return nil, false
+	}
+	exprs := make(map[string]string)
+	for _, expr := range splitTopLevel(match[2]) {
+		expr = strings.TrimSpace(expr)
+		name := expr
+		if m := aliasRegexp.FindStringSubmatch(expr); m != nil {
+			expr, name = m[1], m[2]
+		}
+		exprs[maskName(name)] = expr
+	}
+	return exprs, match[1] != ""
+}
+
+// splitTopLevel splits s by the commas that are not inside parentheses or
+// literals.
+func splitTopLevel(s string) []string {
+	var parts []string
+	var depth, start int
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// maskName returns the name of a table or column as it is returned in the
+// metadata of the results: without the keyspace, unquoted, and lowercase if
+// it was not quoted.
+func maskName(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 && !isQuoted(name) {
+		name = name[i+1:]
+	}
+	if isQuoted(name) {
+		return unquote(name)
+	}
+	return strings.ToLower(name)
+}
+
+// maskingDriver is the Driver used by the Masking middleware.
+type maskingDriver struct {
+	Driver
+	masking *Masking
+}
+
+func (d *maskingDriver) Iter(req *Request) Rows {
+	masks, err := d.masking.masks(req)
+	if err != nil {
+		return errorRows{err: err}
+	}
+	rows := d.Driver.Iter(req)
+	if masks != nil {
+		return &maskRows{Rows: rows, masks: masks}
+	}
+	return rows
+}
+
+// maskRows masks the values of the columns after they are scanned.
+type maskRows struct {
+	Rows
+	masks map[string]MaskFunc
+}
+
+func (r *maskRows) Scan(dest ...interface{}) bool {
+	if !r.Rows.Scan(dest...) {
+		return false
+	}
+	for i, col := range r.Rows.Columns() {
+		if mask, ok := r.masks[col.Name]; ok && i < len(dest) {
+			maskDest(dest[i], mask)
+		}
+	}
+	return true
+}
+
+func (r *maskRows) MapScan(m map[string]interface{}) bool {
+	// The references in m are replaced by the values
+	refs := make(map[string]interface{}, len(r.masks))
+	for col := range r.masks {
+		if ref, ok := m[col]; ok {
+			refs[col] = ref
+		}
+	}
+	if !r.Rows.MapScan(m) {
+		return false
+	}
+	for col, mask := range r.masks {
+		if _, ok := m[col]; !ok {
+			continue
+		}
+		if ref, ok := refs[col]; ok {
+			if _, replace := maskDest(ref, mask); !replace {
+				m[col] = refValue(ref)
+				continue
+			}
+		}
+		if masked, replace := maskDest(m[col], mask); replace {
+			m[col] = masked
+		}
+	}
+	return true
+}
+
+// maskDest masks the value referenced by dest. If dest is not a reference it
+// returns the masked value and true.
+func maskDest(dest interface{}, mask MaskFunc) (interface{}, bool) {
+	switch d := dest.(type) {
+	case encryptedRef:
+		setMasked(d.v, mask)
+	case serializedRef:
+		setMasked(d.v, mask)
+	case enumRef:
+		setMasked(d.v, mask)
+	case nullRef:
+		setMasked(d.v.Field(0), mask)
+	default:
+		v := reflect.ValueOf(dest)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return mask(dest), true
+		}
+		setMasked(v.Elem(), mask)
+	}
+	return nil, false
+}
+
+// refValue returns the value referenced by a reference used by maskDest.
+func refValue(ref interface{}) interface{} {
+	switch r := ref.(type) {
+	case encryptedRef:
+		return r.v.Interface()
+	case serializedRef:
+		return r.v.Interface()
+	case enumRef:
+		return r.v.Interface()
+	case nullRef:
+		return r.v.Interface()
+	default:
+		return reflect.ValueOf(ref).Elem().Interface()
+	}
+}
+
+// setMasked sets the masked value of v in v.
+func setMasked(v reflect.Value, mask MaskFunc) {
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		setMasked(v.Elem(), mask)
+		return
+	}
+	masked := reflect.ValueOf(mask(v.Interface()))
+	switch {
+	case !masked.IsValid():
+		v.Set(reflect.Zero(v.Type()))
+	case masked.Type().AssignableTo(v.Type()):
+		v.Set(masked)
+	case masked.Kind() == v.Kind() && masked.Type().ConvertibleTo(v.Type()):
+		v.Set(masked.Convert(v.Type()))
+	default:
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
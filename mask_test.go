@@ -0,0 +1,134 @@
+package ecql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskFuncs(t *testing.T) {
+	assert.Equal(t, Redacted, Redact("secret"))
+	assert.Nil(t, Redact(42))
+	assert.Equal(t, "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", Hash("secret"))
+	assert.Nil(t, Hash(42))
+	assert.Equal(t, "************4242", KeepLast(4)("4242424242424242"))
+	assert.Equal(t, "42", KeepLast(4)("42"))
+	assert.Nil(t, KeepLast(4)(42))
+}
+
+func TestPermissions(t *testing.T) {
+	ctx := WithPermissions(context.Background(), "a")
+	ctx2 := WithPermissions(ctx, "b")
+	assert.True(t, HasPermission(ctx, "a"))
+	assert.False(t, HasPermission(ctx, "b"))
+	assert.True(t, HasPermission(ctx2, "a"))
+	assert.True(t, HasPermission(ctx2, "b"))
+	assert.False(t, HasPermission(nil, "a"))
+}
+
+func TestMasking(t *testing.T) {
+	DeleteRegistry()
+	masking := NewMasking(MaskingConfig{Rules: []MaskRule{
+		{Table: "mytable", Column: "f22", Permission: "pii", Mask: Redact},
+		{Table: "mytable", Column: "f4", Permission: "pii", Mask: Hash},
+		{Table: "mytable", Column: "f3", Mask: Redact},
+	}})
+	sess, d := newTestSession(WithMiddleware(masking.Middleware()))
+	columns := []string{"f1", "f22", "f3", "f4"}
+	row := func() []interface{} {
+		f4 := "secret"
+		return []interface{}{"a", 42, map[string]string{"k": "v"}, &f4}
+	}
+
+	// Structs
+	d.result(columns, row())
+	var ts testStruct
+	assert.NoError(t, sess.Get(&ts, "a"))
+	assert.Equal(t, "a", ts.F1)
+	assert.Equal(t, 0, ts.F2)
+	assert.Nil(t, ts.F3)
+	assert.Equal(t, Hash("secret"), *ts.F4)
+
+	// Maps and Scan
+	d.result(columns, row())
+	rows, err := sess.QueryRaw("SELECT * FROM mytable").MapRows()
+	assert.NoError(t, err)
+	assert.Equal(t, nil, rows[0]["f22"])
+	assert.Equal(t, "a", rows[0]["f1"])
+
+	d.result(columns, row())
+	var f1 string
+	var f22 int
+	assert.NoError(t, sess.QueryRaw("SELECT f1, f22 FROM mytable").Scan(&f1, &f22))
+	assert.Equal(t, "a", f1)
+	assert.Equal(t, 0, f22)
+
+	// With permission
+	ctx := WithPermissions(context.Background(), "pii")
+	d.result(columns, row())
+	ts = testStruct{}
+	assert.NoError(t, sess.WithContext(ctx).Get(&ts, "a"))
+	assert.Equal(t, ctx, d.last().Context)
+	assert.Equal(t, 42, ts.F2)
+	assert.Equal(t, "secret", *ts.F4)
+	assert.Nil(t, ts.F3)
+
+	d.result(columns, row())
+	ts = testStruct{}
+	assert.NoError(t, sess.Select(&ts).WithContext(ctx).TypeScan())
+	assert.Equal(t, 42, ts.F2)
+
+	// Other tables and writes are not masked
+	d.result([]string{"f22"}, []interface{}{42})
+	assert.NoError(t, sess.QueryRaw("SELECT f22 FROM other").Scan(&f22))
+	assert.Equal(t, 42, f22)
+}
+
+func TestMaskingNames(t *testing.T) {
+	DeleteRegistry()
+	masking := NewMasking(MaskingConfig{Rules: []MaskRule{
+		{Table: "ks.Users", Column: "Email", Permission: "pii", Mask: Redact},
+	}})
+	sess, d := newTestSession(WithMiddleware(masking.Middleware()))
+
+	// Keyspaces, aliases and functions
+	for _, cql := range []string{
+		"SELECT id, email FROM users",
+		"SELECT id, email FROM ks.users",
+		`SELECT id, "email" FROM "users"`,
+		"SELECT id, email AS e FROM users",
+		"SELECT id, upper(email) AS e FROM ks.users WHERE id = 'email'",
+		"SELECT id, blobAsText(textAsBlob(email)) FROM users",
+	} {
+		exprs, _ := selectExprs(cql)
+		var name string
+		for n := range exprs {
+			if n != "id" {
+				name = n
+			}
+		}
+		d.result([]string{"id", name}, []interface{}{"a", "me@example.com"})
+		rows, err := sess.QueryRaw(cql).MapRows()
+		assert.NoError(t, err, cql)
+		assert.Equal(t, Redacted, rows[0][name], cql)
+		assert.Equal(t, "a", rows[0]["id"], cql)
+	}
+
+	// Literals and quoted columns with other names
+	d.result([]string{"id", "Email"}, []interface{}{"a", "me@example.com"})
+	rows, err := sess.QueryRaw(`SELECT id, "Email" FROM users WHERE id = 'email'`).MapRows()
+	assert.NoError(t, err)
+	assert.Equal(t, "me@example.com", rows[0]["Email"])
+
+	// JSON
+	n := len(d.requests)
+	_, err = sess.QueryRaw("SELECT JSON * FROM ks.users").MapRows()
+	assert.Equal(t, ErrMaskedJSON, err)
+	assert.Len(t, d.requests, n)
+
+	d.result([]string{"[json]"}, []interface{}{`{"email": "me@example.com"}`})
+	ctx := WithPermissions(context.Background(), "pii")
+	_, err = sess.WithContext(ctx).QueryRaw("SELECT JSON * FROM users").MapRows()
+	assert.NoError(t, err)
+}
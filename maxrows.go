@@ -0,0 +1,85 @@
+package ecql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrTooManyRows is matched by the errors returned when a read into a slice
+// returns more rows than the maximum of the session.
+var ErrTooManyRows = errors.New("ecql: too many rows")
+
+// WithMaxRows sets the maximum number of rows that MultiGet, SelectRange,
+// SelectBuckets and RawQuery.SelectType append to a slice, protecting the
+// services from unbounded scans. The reads that exceed it stop reading rows
+// and return an error matching ErrTooManyRows, after appending the first n
+// rows. Session.MaxRows can be used to set a different limit on specific
+// reads.
+func WithMaxRows(n int) Option {
+	return func(s *SessionImpl) {
+		s.maxRows = n
+	}
+}
+
+// MaxRows returns a Session that appends at most n rows to the slices, see
+// WithMaxRows. If truncated is not nil, the reads that exceed the limit do
+// not fail, they keep the first n rows and set truncated to true:
+//
+//	var truncated bool
+//	err := sess.MaxRows(1000, &truncated).SelectRange(&events, from, to)
+//
+// The returned session shares the connections and configuration with s.
+func (s *SessionImpl) MaxRows(n int, truncated *bool) Session {
+	sess := *s
+	sess.maxRows, sess.truncated = n, truncated
+	return &sess
+}
+
+// tooManyRows returns true if n rows exceed the maximum of the session.
+func (s *SessionImpl) tooManyRows(n int) bool {
+	return s.maxRows > 0 && n > s.maxRows
+}
+
+// rowAppender appends the rows read to a slice up to the maximum of the
+// session.
+type rowAppender struct {
+	session  *SessionImpl
+	slice    reflect.Value
+	isPtr    bool
+	n        int
+	exceeded bool
+}
+
+func (s *SessionImpl) appender(slice reflect.Value, isPtr bool) *rowAppender {
+	return &rowAppender{session: s, slice: slice, isPtr: isPtr}
+}
+
+// append appends the struct pointed by v to the slice. It returns false if
+// the maximum number of rows has been reached.
+func (a *rowAppender) append(v reflect.Value) bool {
+	if a.session.tooManyRows(a.n + 1) {
+		a.exceeded = true
+		return false
+	}
+	if a.isPtr {
+		a.slice.Set(reflect.Append(a.slice, v))
+	} else {
+		a.slice.Set(reflect.Append(a.slice, v.Elem()))
+	}
+	a.n++
+	return true
+}
+
+// err returns the error of a read that exceeded the maximum number of rows,
+// or sets the truncated flag of the session.
+func (a *rowAppender) err() error {
+	if !a.exceeded {
+		return nil
+	}
+	if a.session.truncated != nil {
+		*a.session.truncated = true
+		return nil
+	}
+	return fmt.Errorf("%w: more than %d rows", ErrTooManyRows, a.session.maxRows)
+}
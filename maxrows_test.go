@@ -0,0 +1,55 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxRows(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithMaxRows(2))
+	cql := "SELECT f1, f22 FROM mytable"
+
+	d.result([]string{"f1", "f22"}, []interface{}{"a", 1}, []interface{}{"b", 2})
+	var values []testStruct
+	assert.NoError(t, sess.QueryRaw(cql).SelectType(&values))
+	assert.Len(t, values, 2)
+
+	d.result([]string{"f1", "f22"}, []interface{}{"a", 1}, []interface{}{"b", 2}, []interface{}{"c", 3})
+	values = nil
+	err := sess.QueryRaw(cql).SelectType(&values)
+	assert.True(t, errors.Is(err, ErrTooManyRows))
+	assert.Equal(t, "ecql: too many rows: more than 2 rows", err.Error())
+	assert.Equal(t, []testStruct{{F1: "a", F2: 1}, {F1: "b", F2: 2}}, values)
+
+	var truncated bool
+	values = nil
+	assert.NoError(t, sess.MaxRows(2, &truncated).QueryRaw(cql).SelectType(&values))
+	assert.True(t, truncated)
+	assert.Len(t, values, 2)
+
+	values = nil
+	assert.NoError(t, sess.MaxRows(0, nil).QueryRaw(cql).SelectType(&values))
+	assert.Len(t, values, 3)
+
+	// Each bucket returns one row
+	from := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	d.result([]string{"sensor", "day", "id", "value"}, []interface{}{"s1", "2024-06-01", gocql.UUIDFromTime(from), 1})
+	var readings []bucketStruct
+	err = sess.SelectBuckets(&readings, from, from.AddDate(0, 0, 2), Eq("sensor", "s1"))
+	assert.True(t, errors.Is(err, ErrTooManyRows))
+	assert.Len(t, readings, 2)
+	readings, truncated = nil, false
+	assert.NoError(t, sess.MaxRows(3, &truncated).SelectBuckets(&readings, from, from.AddDate(0, 0, 2), Eq("sensor", "s1")))
+	assert.False(t, truncated)
+	assert.Len(t, readings, 3)
+
+	d.result([]string{"f1", "f22"}, []interface{}{"a", 1})
+	values = nil
+	assert.True(t, errors.Is(sess.MultiGet(&values, "a", "b", "c"), ErrTooManyRows))
+	assert.Len(t, values, 2)
+}
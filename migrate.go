@@ -0,0 +1,208 @@
+package ecql
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+)
+
+// AutoMigrate creates the tables of the given types if they do not exist and
+// adds the columns of the fields that are not in the tables. AutoMigrate never
+// drops tables or columns, nor modifies the type of existing columns or the
+// primary key, use ValidateSchema to detect those differences.
+//
+// The CQL types are inferred from the Go types of the fields, sharded types
+// use the physical table of the given value.
+func (s *SessionImpl) AutoMigrate(types ...interface{}) error {
+	for _, i := range types {
+		_, table := s.getRegistry().MapTable(i)
+		cqlTypes, err := columnTypes(structOf(i).Type(), table)
+		if err != nil {
+			return err
+		}
+		if err := s.migrateTable(table, cqlTypes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AutoMigrateSnapshot is like AutoMigrate but using the tables of a registry
+// snapshot, so the schema can be migrated by tools that do not import the Go
+// types. All the columns must have a CQL type.
+func (s *SessionImpl) AutoMigrateSnapshot(tables ...TableSnapshot) error {
+	for _, t := range tables {
+		cqlTypes, err := t.columnTypes()
+		if err != nil {
+			return err
+		}
+		if err := s.migrateTable(t.Table(), cqlTypes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateTable creates the table or adds the missing columns.
+func (s *SessionImpl) migrateTable(table Table, cqlTypes map[string]string) error {
+	keyspace, name := s.splitTableName(table.Name)
+	if keyspace == "" {
+		return ErrNoKeyspace
+	}
+
+	live, err := s.DescribeTable(keyspace, name)
+	switch err {
+	case nil:
+		for _, c := range table.Columns {
+			if live.hasColumn(c.Name) {
+				continue
+			}
+			cql := fmt.Sprintf("ALTER TABLE %s ADD %s %s", table.quotedName(), table.quote(c.Name), cqlTypes[c.Name])
+			if err := s.execSchema(table.Name, cql); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ErrNotFound:
+		return s.execSchema(table.Name, createTableQuery(table, cqlTypes))
+	default:
+		return err
+	}
+}
+
+// execSchema executes a statement that modifies the schema.
+func (s *SessionImpl) execSchema(table, cql string) error {
+	return s.getDriver().Iter(s.request(SchemaCmd, table, cql, nil)).Close()
+}
+
+// createTableQuery returns the CREATE TABLE statement for table.
+func createTableQuery(table Table, cqlTypes map[string]string) string {
+	defs := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		defs[i] = table.quote(c.Name) + " " + cqlTypes[c.Name]
+	}
+
+	key := strings.Join(table.quoteAll(table.PartitionColumns), ", ")
+	if len(table.PartitionColumns) > 1 {
+		key = "(" + key + ")"
+	}
+	if len(table.ClusteringColumns) > 0 {
+		key += ", " + strings.Join(table.quoteAll(table.ClusteringColumns), ", ")
+	}
+
+	cql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (%s))", table.quotedName(), strings.Join(defs, ", "), key)
+	if len(table.ClusteringOrder) > 0 {
+		orders := make([]string, len(table.ClusteringOrder))
+		for i, o := range table.ClusteringOrder {
+			orders[i] = fmt.Sprintf("%s %s", table.quote(o.Column), o.OrderType)
+		}
+		cql += fmt.Sprintf(" WITH CLUSTERING ORDER BY (%s)", strings.Join(orders, ", "))
+	}
+	return cql
+}
+
+// columnTypes returns the CQL type of each column of the table of the struct
+// type t. The types set with the type option in the tags are used if they are
+// compatible with the type of the field. This allows, for example, to choose
+// between bigint, counter or timestamp for int64 fields.
+func columnTypes(t reflect.Type, table Table) (map[string]string, error) {
+	types := make(map[string]string, len(table.Columns))
+	for _, c := range table.Columns {
+		goType := t.FieldByIndex(c.Position).Type
+		if c.Encrypted {
+			types[c.Name] = "blob"
+			continue
+		}
+		if c.Serializer != "" {
+			if c.Type != "blob" && !isTextType(c.Type) {
+				return nil, ErrUnsupportedType
+			}
+			types[c.Name] = c.Type
+			continue
+		}
+		if c.Type != "" {
+			if !compatibleType(goType, c.Type) && !(c.Enum != nil && isTextType(c.Type)) {
+				return nil, ErrUnsupportedType
+			}
+			types[c.Name] = c.Type
+			continue
+		}
+		cqlType, ok := cqlTypeName(goType)
+		if !ok {
+			return nil, ErrUnsupportedType
+		}
+		types[c.Name] = cqlType
+	}
+	return types, nil
+}
+
+var (
+	uuidType    = reflect.TypeOf(gocql.UUID{})
+	timeType    = reflect.TypeOf(time.Time{})
+	ipType      = reflect.TypeOf(net.IP{})
+	cqlDurType  = reflect.TypeOf(gocql.Duration{})
+	decimalType = reflect.TypeOf(inf.Dec{})
+)
+
+// cqlTypeName returns the CQL type used to store values of type t.
+func cqlTypeName(t reflect.Type) (string, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isNullType(t) {
+		t = t.Field(0).Type
+	}
+
+	switch t {
+	case uuidType:
+		return "uuid", true
+	case timeType:
+		return "timestamp", true
+	case ipType:
+		return "inet", true
+	case durationType, cqlDurType:
+		return "duration", true
+	case bigIntType:
+		return "varint", true
+	case decimalType:
+		return "decimal", true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "text", true
+	case reflect.Bool:
+		return "boolean", true
+	case reflect.Int8:
+		return "tinyint", true
+	case reflect.Int16:
+		return "smallint", true
+	case reflect.Int, reflect.Int32:
+		return "int", true
+	case reflect.Int64:
+		return "bigint", true
+	case reflect.Float32:
+		return "float", true
+	case reflect.Float64:
+		return "double", true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "blob", true
+		}
+		if elem, ok := cqlTypeName(t.Elem()); ok {
+			return "list<" + elem + ">", true
+		}
+	case reflect.Map:
+		key, ok1 := cqlTypeName(t.Key())
+		elem, ok2 := cqlTypeName(t.Elem())
+		if ok1 && ok2 {
+			return "map<" + key + ", " + elem + ">", true
+		}
+	}
+	return "", false
+}
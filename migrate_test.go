@@ -0,0 +1,99 @@
+package ecql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type migrateStruct struct {
+	Tenant string            `cql:"tenant" cqltable:"ecql.events" cqlkey:"(tenant,day),time"`
+	Day    int               `cql:"day"`
+	Time   time.Time         `cql:"time"`
+	ID     gocql.UUID        `cql:"id"`
+	Tags   []string          `cql:"tags"`
+	Attrs  map[string]string `cql:"attrs"`
+	Body   []byte            `cql:"body"`
+}
+
+func TestAutoMigrate(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	// Create table
+	assert.NoError(t, sess.AutoMigrate(migrateStruct{}))
+	assert.Equal(t, SchemaCmd, d.last().Command)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS ecql.events (tenant text, day int, time timestamp, id uuid, tags list<text>, attrs map<text, text>, body blob, PRIMARY KEY ((tenant, day), time))", d.last().Statement)
+
+	// Alter table
+	d.result([]string{"column_name", "kind", "position", "clustering_order", "type"},
+		[]interface{}{"tenant", "partition_key", 0, "none", "text"},
+		[]interface{}{"day", "partition_key", 1, "none", "int"},
+		[]interface{}{"time", "clustering", 0, "asc", "timestamp"},
+		[]interface{}{"id", "regular", -1, "none", "uuid"},
+		[]interface{}{"body", "regular", -1, "none", "blob"},
+	)
+	n := len(d.requests)
+	assert.NoError(t, sess.AutoMigrate(&migrateStruct{}))
+	var stmts []string
+	for _, req := range d.requests[n+1:] {
+		stmts = append(stmts, req.Statement)
+	}
+	assert.Equal(t, []string{
+		"ALTER TABLE ecql.events ADD tags list<text>",
+		"ALTER TABLE ecql.events ADD attrs map<text, text>",
+	}, stmts)
+
+	assert.Equal(t, ErrNoKeyspace, sess.AutoMigrate(testStruct{}))
+	sess.keyspace = "ecql"
+	assert.Equal(t, ErrUnsupportedType, sess.AutoMigrate(struct {
+		ID string
+		Fn func()
+	}{}))
+}
+
+func TestAutoMigrateSnapshot(t *testing.T) {
+	sess, d := newTestSession()
+	r := NewRegistry()
+	r.Register(migrateStruct{})
+	table, ok := r.Snapshot().Table("ecql.events")
+	assert.True(t, ok)
+
+	cql, err := table.CreateTableCQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS ecql.events (tenant text, day int, time timestamp, id uuid, tags list<text>, attrs map<text, text>, body blob, PRIMARY KEY ((tenant, day), time))", cql)
+
+	assert.NoError(t, sess.AutoMigrateSnapshot(table))
+	assert.Equal(t, SchemaCmd, d.last().Command)
+	assert.Equal(t, cql, d.last().Statement)
+
+	table.Columns[0].Type = ""
+	_, err = table.CreateTableCQL()
+	assert.Equal(t, ErrUnsupportedType, err)
+	assert.Equal(t, ErrUnsupportedType, sess.AutoMigrateSnapshot(table))
+}
+
+func TestCQLTypeName(t *testing.T) {
+	var tests = []struct {
+		value interface{}
+		typ   string
+	}{
+		{"", "text"},
+		{int8(0), "tinyint"},
+		{int64(0), "bigint"},
+		{float32(0), "float"},
+		{true, "boolean"},
+		{new(string), "text"},
+		{map[gocql.UUID][]int{}, "map<uuid, list<int>>"},
+	}
+	for _, tc := range tests {
+		typ, ok := cqlTypeName(reflect.TypeOf(tc.value))
+		assert.True(t, ok)
+		assert.Equal(t, tc.typ, typ)
+	}
+	_, ok := cqlTypeName(reflect.TypeOf(struct{}{}))
+	assert.False(t, ok)
+}
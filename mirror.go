@@ -0,0 +1,315 @@
+package ecql
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MirrorConfig contains the configuration of a Mirror.
+type MirrorConfig struct {
+	// Target is the session where the requests are mirrored, it can be a
+	// session of the new cluster. It defaults to the mirrored session, to
+	// migrate tables in the same cluster.
+	Target Session
+	// Tables maps the names of the old tables to the new ones. If it is set
+	// only the requests on these tables are mirrored.
+	Tables map[string]string
+	// ShadowReads is the fraction of the reads, between 0 and 1, that are
+	// also executed in the target and compared.
+	ShadowReads float64
+	// OnMismatch is called with the shadow reads that return different rows.
+	OnMismatch func(m Mismatch)
+	// OnError is called with the errors of the mirrored requests.
+	OnError func(err error)
+}
+
+// Mismatch contains the rows returned by a read and its shadow read.
+type Mismatch struct {
+	Statement string
+	Values    []interface{}
+	Primary   []map[string]interface{}
+	Shadow    []map[string]interface{}
+}
+
+// MirrorStats contains the statistics of a Mirror.
+type MirrorStats struct {
+	Writes      int
+	WriteErrors int
+	ShadowReads int
+	ReadErrors  int
+	Mismatches  int
+}
+
+// Mirror is a middleware that mirrors the writes to a new table or cluster,
+// and compares a sample of the reads with the ones in the target, to support
+// zero downtime migrations:
+//
+//	mirror := ecql.NewMirror(ecql.MirrorConfig{
+//		Target:      newSess,
+//		ShadowReads: 0.01,
+//		OnMismatch:  func(m ecql.Mismatch) { log.Printf("mismatch: %s", m.Statement) },
+//	})
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(mirror.Middleware()))
+//
+// The writes are mirrored after they succeed in the primary, and the errors
+// of the mirrored writes are reported but not returned. The shadow reads are
+// executed in the background after the primary rows are closed, Wait waits
+// for them. Conditional batches are not mirrored.
+type Mirror struct {
+	config  MirrorConfig
+	mu      sync.Mutex
+	stats   MirrorStats
+	pending sync.WaitGroup
+}
+
+// NewMirror creates a Mirror with the given configuration.
+func NewMirror(config MirrorConfig) *Mirror {
+	return &Mirror{config: config}
+}
+
+// Middleware returns the middleware that mirrors the requests.
+func (m *Mirror) Middleware() Middleware {
+	return func(next Driver) Driver {
+		target := next
+		if s, ok := m.config.Target.(*SessionImpl); ok {
+			target = s.getDriver()
+		}
+		return &mirrorDriver{Driver: next, target: target, mirror: m}
+	}
+}
+
+// Stats returns the statistics of the mirror.
+func (m *Mirror) Stats() MirrorStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Wait waits for the shadow reads in flight.
+func (m *Mirror) Wait() {
+	m.pending.Wait()
+}
+
+// mirrored returns the request to mirror, or nil if the request is not
+// mirrored.
+func (m *Mirror) mirrored(req *Request) *Request {
+	if len(m.config.Tables) == 0 {
+		return req
+	}
+	table, ok := m.config.Tables[req.Table]
+	if !ok {
+		return nil
+	}
+	r := *req
+	r.Table = table
+	r.Statement = renameTable(req.Statement, req.Table, table)
+	return &r
+}
+
+// written records the result of a mirrored write.
+func (m *Mirror) written(err error) {
+	m.mu.Lock()
+	m.stats.Writes++
+	if err != nil {
+		m.stats.WriteErrors++
+	}
+	m.mu.Unlock()
+	if err != nil && m.config.OnError != nil {
+		m.config.OnError(err)
+	}
+}
+
+// shadow executes the request in the target and compares the rows. If
+// complete is false, the primary rows were closed before reading all of them
+// and only the same number of rows are compared.
+func (m *Mirror) shadow(target Driver, req *Request, primary []map[string]interface{}, complete bool) {
+	defer m.pending.Done()
+	rows := target.Iter(req)
+	var shadow []map[string]interface{}
+	for complete || len(shadow) < len(primary) {
+		row := make(map[string]interface{})
+		if !rows.MapScan(row) {
+			break
+		}
+		shadow = append(shadow, normalizeRow(row))
+	}
+	err := rows.Close()
+	mismatch := err == nil && !sameRows(primary, shadow)
+
+	m.mu.Lock()
+	m.stats.ShadowReads++
+	if err != nil {
+		m.stats.ReadErrors++
+	}
+	if mismatch {
+		m.stats.Mismatches++
+	}
+	m.mu.Unlock()
+
+	switch {
+	case err != nil && m.config.OnError != nil:
+		m.config.OnError(err)
+	case mismatch && m.config.OnMismatch != nil:
+		m.config.OnMismatch(Mismatch{
+			Statement: req.Statement,
+			Values:    req.Values,
+			Primary:   primary,
+			Shadow:    shadow,
+		})
+	}
+}
+
+// renameTable replaces the table of a statement.
+func renameTable(stmt, from, to string) string {
+	for _, idx := range rawTableRegexp.FindAllStringSubmatchIndex(stmt, -1) {
+		if stmt[idx[2]:idx[3]] == from {
+			return stmt[:idx[2]] + to + stmt[idx[3]:]
+		}
+	}
+	return stmt
+}
+
+// normalizeRow returns a copy of the row with the values instead of the
+// references to them.
+func normalizeRow(row map[string]interface{}) map[string]interface{} {
+	n := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		n[k] = deref(v)
+	}
+	return n
+}
+
+// sameRows compares the primary and shadow rows, only the columns in the
+// shadow rows are compared.
+func sameRows(primary, shadow []map[string]interface{}) bool {
+	if len(primary) != len(shadow) {
+		return false
+	}
+	for i := range shadow {
+		for k, v := range shadow[i] {
+			p := primary[i][k]
+			if !reflect.DeepEqual(p, v) && fmt.Sprint(p) != fmt.Sprint(v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// mirrorDriver is the Driver used by the Mirror middleware.
+type mirrorDriver struct {
+	Driver
+	target Driver
+	mirror *Mirror
+}
+
+func (d *mirrorDriver) Iter(req *Request) Rows {
+	rows := d.Driver.Iter(req)
+	mreq := d.mirror.mirrored(req)
+	if mreq == nil {
+		return rows
+	}
+	switch req.Command {
+	case InsertCmd, UpdateCmd, DeleteCmd:
+		return &mirrorRows{Rows: rows, driver: d, req: mreq, write: true}
+	case SelectCmd, CountCmd:
+		if rate := d.mirror.config.ShadowReads; rate >= 1 || (rate > 0 && rand.Float64() < rate) {
+			return &mirrorRows{Rows: rows, driver: d, req: mreq}
+		}
+	}
+	return rows
+}
+
+func (d *mirrorDriver) ExecBatch(b *BatchRequest) error {
+	if err := d.Driver.ExecBatch(b); err != nil {
+		return err
+	}
+	var entries []Request
+	for i := range b.Entries {
+		if req := d.mirror.mirrored(&b.Entries[i]); req != nil {
+			entries = append(entries, *req)
+		}
+	}
+	if len(entries) > 0 {
+		mb := *b
+		mb.Entries = entries
+		d.mirror.written(d.target.ExecBatch(&mb))
+	}
+	return nil
+}
+
+// mirrorRows mirrors a write when the rows are closed, or records the rows of
+// a read and compares them with a shadow read. The conditional writes are
+// only mirrored if the [applied] column is true.
+type mirrorRows struct {
+	Rows
+	driver     *mirrorDriver
+	req        *Request
+	write      bool
+	rows       []map[string]interface{}
+	complete   bool
+	closed     bool
+	scanned    bool
+	notApplied bool
+}
+
+func (r *mirrorRows) Scan(dest ...interface{}) bool {
+	if !r.Rows.Scan(dest...) {
+		r.complete = true
+		return false
+	}
+	r.scanned = true
+	if cols := r.Columns(); r.write && len(cols) > 0 && len(dest) > 0 && cols[0].Name == "[applied]" {
+		if applied, ok := dest[0].(*bool); ok {
+			r.notApplied = !*applied
+		}
+	}
+	if !r.write {
+		row := make(map[string]interface{}, len(dest))
+		for i, col := range r.Rows.Columns() {
+			if i < len(dest) {
+				row[col.Name] = deref(dest[i])
+			}
+		}
+		r.rows = append(r.rows, row)
+	}
+	return true
+}
+
+func (r *mirrorRows) MapScan(m map[string]interface{}) bool {
+	if !r.Rows.MapScan(m) {
+		r.complete = true
+		return false
+	}
+	r.scanned = true
+	if applied, ok := m["[applied]"].(bool); ok && r.write {
+		r.notApplied = !applied
+	}
+	if !r.write {
+		r.rows = append(r.rows, normalizeRow(m))
+	}
+	return true
+}
+
+func (r *mirrorRows) Close() error {
+	if r.write && !r.closed && !r.scanned && strings.Contains(r.req.Statement, " IF ") {
+		r.MapScan(make(map[string]interface{}))
+	}
+	err := r.Rows.Close()
+	if r.closed || err != nil {
+		return err
+	}
+	r.closed = true
+	switch {
+	case r.write && r.notApplied:
+	case r.write:
+		r.driver.mirror.written(r.driver.target.Iter(r.req).Close())
+	default:
+		r.driver.mirror.pending.Add(1)
+		go r.driver.mirror.shadow(r.driver.target, r.req, r.rows, r.complete)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirror(t *testing.T) {
+	DeleteRegistry()
+	target, td := newTestSession()
+	var errs []error
+	var mismatches []Mismatch
+	mirror := NewMirror(MirrorConfig{
+		Target:      target,
+		Tables:      map[string]string{"mytable": "newtable"},
+		ShadowReads: 1,
+		OnError:     func(err error) { errs = append(errs, err) },
+		OnMismatch:  func(m Mismatch) { mismatches = append(mismatches, m) },
+	})
+	sess, d := newTestSession(WithMiddleware(mirror.Middleware()))
+
+	// Writes
+	assert.NoError(t, sess.Set(testStruct{F1: "a", F2: 1}))
+	assert.Equal(t, "INSERT INTO newtable (f1,f22,f3,f4) VALUES (?,?,?,?)", td.last().Statement)
+	assert.Equal(t, "newtable", td.last().Table)
+	assert.Equal(t, d.last().Values, td.last().Values)
+	assert.Equal(t, "INSERT INTO mytable (f1,f22,f3,f4) VALUES (?,?,?,?)", d.last().Statement)
+
+	assert.NoError(t, sess.Batch().Add(sess.Delete(testStruct{F1: "a"})).Apply())
+	assert.Len(t, td.batches, 1)
+	assert.Equal(t, "DELETE FROM newtable WHERE f1 = ?", td.batches[0].Entries[0].Statement)
+
+	// Errors in the primary are not mirrored
+	d.err = errors.New("primary error")
+	assert.Error(t, sess.Set(testStruct{F1: "b"}))
+	assert.Len(t, td.requests, 1)
+	d.err = nil
+
+	// Errors in the target are reported
+	td.err = errors.New("target error")
+	assert.NoError(t, sess.Set(testStruct{F1: "b"}))
+	assert.Len(t, errs, 1)
+	td.err = nil
+
+	// Shadow reads
+	d.result([]string{"f1", "f22"}, []interface{}{"a", 1})
+	td.result([]string{"f1", "f22"}, []interface{}{"a", 1})
+	var ts testStruct
+	assert.NoError(t, sess.Get(&ts, "a"))
+	mirror.Wait()
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM newtable WHERE f1 = ?", td.last().Statement)
+	assert.Empty(t, mismatches)
+
+	td.result([]string{"f1", "f22"}, []interface{}{"a", 2})
+	rows, err := sess.Select(testStruct{}).MapRows()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	mirror.Wait()
+	if assert.Len(t, mismatches, 1) {
+		assert.Equal(t, []map[string]interface{}{{"f1": "a", "f22": 2}}, mismatches[0].Shadow)
+		assert.Equal(t, 1, mismatches[0].Primary[0]["f22"])
+	}
+
+	stats := mirror.Stats()
+	assert.Equal(t, MirrorStats{Writes: 3, WriteErrors: 1, ShadowReads: 2, Mismatches: 1}, stats)
+
+	// Other tables are not mirrored
+	assert.NoError(t, sess.QueryRaw("INSERT INTO other (id) VALUES (?)", 1).Exec())
+	assert.Equal(t, 3, mirror.Stats().Writes)
+
+	// Conditional writes are only mirrored if they are applied
+	n := len(td.requests)
+	d.result([]string{"[applied]", "f1"}, []interface{}{false, "c"})
+	assert.NoError(t, sess.Insert(testStruct{F1: "c"}).IfNotExists().Exec())
+	assert.Len(t, td.requests, n)
+	d.result([]string{"[applied]"}, []interface{}{true})
+	info, err := sess.Insert(testStruct{F1: "c"}).IfNotExists().ExecInfo()
+	assert.NoError(t, err)
+	assert.True(t, info.Applied)
+	assert.Len(t, td.requests, n+1)
+	assert.Equal(t, "INSERT INTO newtable (f1,f22,f3,f4) VALUES (?,?,?,?) IF NOT EXISTS", td.last().Statement)
+}
+
+func TestRenameTable(t *testing.T) {
+	assert.Equal(t, "SELECT mytable FROM new WHERE a = 1", renameTable("SELECT mytable FROM mytable WHERE a = 1", "mytable", "new"))
+	assert.Equal(t, "UPDATE new SET a = 1", renameTable("UPDATE mytable SET a = 1", "mytable", "new"))
+	assert.Equal(t, "INSERT INTO other (a) VALUES (1)", renameTable("INSERT INTO other (a) VALUES (1)", "mytable", "new"))
+}
@@ -0,0 +1,65 @@
+package ecql
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type nullStruct struct {
+	ID    string         `cql:"id,omitempty" cqltable:"nulls"`
+	Name  *string        `cql:"name"`
+	Email sql.NullString `cql:"email"`
+	Age   sql.NullInt64  `cql:"age"`
+	Bio   string         `cql:"bio,omitempty"`
+	Tags  []string       `cql:"tags,omitempty"`
+}
+
+func TestNullTypes(t *testing.T) {
+	DeleteRegistry()
+	textInfo := gocql.NewNativeType(4, gocql.TypeVarchar, "")
+	bigintInfo := gocql.NewNativeType(4, gocql.TypeBigInt, "")
+
+	v := nullStruct{ID: "a", Email: sql.NullString{String: "a@example.com", Valid: true}}
+	m := Map(&v)
+	assert.IsType(t, new(*string), m["name"])
+	assert.IsType(t, nullRef{}, m["email"])
+
+	data, err := m["email"].(gocql.Marshaler).MarshalCQL(textInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "a@example.com", string(data))
+	data, err = m["age"].(gocql.Marshaler).MarshalCQL(bigintInfo)
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+
+	assert.NoError(t, m["email"].(gocql.Unmarshaler).UnmarshalCQL(textInfo, nil))
+	assert.Equal(t, sql.NullString{}, v.Email)
+	data, _ = gocql.Marshal(bigintInfo, 42)
+	assert.NoError(t, m["age"].(gocql.Unmarshaler).UnmarshalCQL(bigintInfo, data))
+	assert.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, v.Age)
+
+	sess, d := newTestSession(WithKeyspace("ks"))
+	assert.NoError(t, sess.AutoMigrate(v))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS nulls (id text, name text, email text, age bigint, bio text, tags list<text>, PRIMARY KEY (id))", d.last().Statement)
+}
+
+func TestOmitEmpty(t *testing.T) {
+	DeleteRegistry()
+	table := GetTable(nullStruct{})
+	assert.False(t, table.Columns[0].OmitEmpty)
+	assert.True(t, table.Columns[4].OmitEmpty)
+
+	sess, d := newTestSession()
+	assert.NoError(t, sess.Insert(nullStruct{ID: "a", Tags: []string{}}).Exec())
+	values := d.last().Values
+	assert.Equal(t, "a", values[0])
+	assert.Equal(t, gocql.UnsetValue, values[4])
+	assert.Equal(t, []string{}, values[5])
+
+	assert.NoError(t, sess.Insert(nullStruct{ID: "a", Bio: "foo"}).Exec())
+	values = d.last().Values
+	assert.Equal(t, "foo", values[4])
+	assert.Equal(t, gocql.UnsetValue, values[5])
+}
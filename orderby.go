@@ -0,0 +1,105 @@
+package ecql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidOrder is the error returned when the ORDER BY of a statement
+// cannot be executed on its table.
+var ErrInvalidOrder = errors.New("ecql: invalid order")
+
+// OrderError is the error returned when an order of a statement is not
+// valid. Reason explains why it is not valid and Order is the valid
+// clustering order of the table.
+type OrderError struct {
+	Table  string
+	Column string
+	Reason string
+	Order  []OrderBy
+}
+
+// Error implements the error interface.
+func (e *OrderError) Error() string {
+	msg := fmt.Sprintf("%v by %s on table %s: %s", ErrInvalidOrder, e.Column, e.Table, e.Reason)
+	if len(e.Order) > 0 {
+		msg += ", the clustering order is " + formatOrders(e.Order)
+	}
+	return msg
+}
+
+// Is allows to check the error with errors.Is(err, ErrInvalidOrder).
+func (e *OrderError) Is(target error) bool {
+	return target == ErrInvalidOrder
+}
+
+// clusteringOrder returns the order of the clustering columns of the table,
+// the columns without an explicit order are ascending.
+func (t *Table) clusteringOrder() []OrderBy {
+	if len(t.ClusteringOrder) == len(t.ClusteringColumns) {
+		return t.ClusteringOrder
+	}
+	order := make([]OrderBy, len(t.ClusteringColumns))
+	for i, col := range t.ClusteringColumns {
+		order[i] = Asc(col)
+	}
+	return order
+}
+
+// checkOrder returns an OrderError if the orders cannot be used on the
+// table. Like in CQL, the columns must be clustering columns in the order of
+// the primary key, and all of them must follow the clustering order or the
+// reversed one. The columns are not checked if the key of the table is not
+// known, like on statements created with From.
+func (t *Table) checkOrder(orders []OrderBy) error {
+	for _, o := range orders {
+		if o.OrderType != AscOrder && o.OrderType != DescOrder {
+			return &OrderError{Table: t.Name, Column: o.Column, Reason: fmt.Sprintf("invalid direction %q", o.OrderType)}
+		}
+	}
+	if len(orders) == 0 || len(t.KeyColumns) == 0 {
+		return nil
+	}
+
+	clustering := t.clusteringOrder()
+	if len(clustering) == 0 {
+		return &OrderError{Table: t.Name, Column: orders[0].Column, Reason: "the table does not have clustering columns"}
+	}
+
+	var reversed bool
+	for i, o := range orders {
+		switch {
+		case i >= len(clustering) || !t.sameColumn(o.Column, clustering[i].Column):
+			reason := "not a clustering column"
+			for _, c := range clustering {
+				if t.sameColumn(o.Column, c.Column) {
+					reason = "the clustering columns must be in the order of the primary key"
+				}
+			}
+			return &OrderError{Table: t.Name, Column: o.Column, Reason: reason, Order: clustering}
+		case i == 0:
+			reversed = o.OrderType != clustering[i].OrderType
+		case reversed != (o.OrderType != clustering[i].OrderType):
+			return &OrderError{Table: t.Name, Column: o.Column, Reason: "mixed directions, use the clustering order or the reversed one", Order: clustering}
+		}
+	}
+	return nil
+}
+
+// sameColumn returns true if the column name used in a statement is the
+// given column of the table.
+func (t *Table) sameColumn(name, column string) bool {
+	if isQuoted(name) || t.CaseSensitive {
+		return unquote(name) == column
+	}
+	return strings.EqualFold(name, column)
+}
+
+func formatOrders(orders []OrderBy) string {
+	parts := make([]string, len(orders))
+	for i, o := range orders {
+		parts[i] = fmt.Sprintf("%s %s", o.Column, o.OrderType)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,71 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timelineStruct struct {
+	Stream string `cql:"stream" cqltable:"timelines" cqlkey:"stream, time desc, id"`
+	Time   int64  `cql:"time"`
+	ID     string `cql:"id"`
+	Text   string `cql:"text"`
+}
+
+func TestClusteringOrderTag(t *testing.T) {
+	DeleteRegistry()
+	table := GetTable(timelineStruct{})
+	assert.Equal(t, []string{"stream"}, table.PartitionColumns)
+	assert.Equal(t, []string{"time", "id"}, table.ClusteringColumns)
+	assert.Equal(t, []OrderBy{Desc("time"), Asc("id")}, table.ClusteringOrder)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS timelines (stream text, time bigint, id text, text text, PRIMARY KEY (stream, time, id)) WITH CLUSTERING ORDER BY (time DESC, id ASC)",
+		createTableQuery(table, map[string]string{"stream": "text", "time": "bigint", "id": "text", "text": "text"}))
+
+	// Without order the table is the same as before
+	table = GetTable(pageStruct{})
+	assert.Nil(t, table.ClusteringOrder)
+	assert.Equal(t, []OrderBy{Asc("seq"), Asc("id")}, table.clusteringOrder())
+}
+
+func TestCheckOrder(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	var valid = [][]OrderBy{
+		{Desc("time")},
+		{Asc("time")},
+		{Desc("time"), Asc("id")},
+		{Asc("time"), Desc("id")},
+		{Desc("TIME")},
+	}
+	for _, orders := range valid {
+		assert.NoError(t, sess.Select(timelineStruct{}).Where(Eq("stream", "a")).OrderBy(orders...).Exec(), "%v", orders)
+	}
+	assert.Equal(t, "SELECT stream,time,id,text FROM timelines WHERE stream = ? ORDER BY TIME DESC", d.last().Statement)
+
+	var invalid = []struct {
+		orders []OrderBy
+		err    string
+	}{
+		{[]OrderBy{Asc("text")}, "ecql: invalid order by text on table timelines: not a clustering column, the clustering order is (time DESC, id ASC)"},
+		{[]OrderBy{Asc("id")}, "ecql: invalid order by id on table timelines: the clustering columns must be in the order of the primary key, the clustering order is (time DESC, id ASC)"},
+		{[]OrderBy{Desc("time"), Desc("id")}, "ecql: invalid order by id on table timelines: mixed directions, use the clustering order or the reversed one, the clustering order is (time DESC, id ASC)"},
+		{[]OrderBy{{"time", "DESC; --"}}, `ecql: invalid order by time on table timelines: invalid direction "DESC; --"`},
+	}
+	n := len(d.requests)
+	for _, tc := range invalid {
+		err := sess.Select(timelineStruct{}).Where(Eq("stream", "a")).OrderBy(tc.orders...).Exec()
+		assert.True(t, errors.Is(err, ErrInvalidOrder))
+		assert.EqualError(t, err, tc.err)
+	}
+	assert.Len(t, d.requests, n)
+
+	// Tables without clustering columns
+	err := sess.Select(testStruct{}).Where(Eq("f1", "a")).OrderBy(Asc("f22")).Exec()
+	assert.EqualError(t, err, "ecql: invalid order by f22 on table mytable: the table does not have clustering columns")
+
+	// Statements without the key of the table are not checked
+	assert.NoError(t, NewStatement(sess).Do(SelectCmd).From("timelines").OrderBy(Asc("text")).Exec())
+}
@@ -0,0 +1,58 @@
+package ecql
+
+import "strings"
+
+// splitPartitionKey returns the columns of a composite partition key defined
+// using parenthesis in a cqlkey tag, "(partkey1,partkey2),time desc,id", and
+// the rest of the key. Keys without parenthesis are returned unchanged.
+func splitPartitionKey(key string) ([]string, string) {
+	key = strings.TrimSpace(key)
+	if !strings.HasPrefix(key, "(") {
+		return nil, key
+	}
+	i := strings.Index(key, ")")
+	if i < 0 {
+		return nil, key[1:]
+	}
+	rest := strings.TrimPrefix(strings.TrimSpace(key[i+1:]), ",")
+	return splitKey(key[1:i]), strings.TrimSpace(rest)
+}
+
+// KeyEq returns the equality conditions on the partition key columns of i,
+// using the values in i. It avoids listing the columns of composite
+// partition keys in every query:
+//
+//	iter := sess.Select(Event{}).Where(KeyEq(Event{UserID: id, Bucket: b}), Gt("created", t)).Iter()
+//
+// Like EqInt, it uses the default registry, and computed values like the
+// buckets must be already set in i. If the type has no partition key the
+// condition fails with ErrEmptyKey.
+func KeyEq(i interface{}) Condition {
+	m, table := MapTable(i)
+	where := partitionEq(m, table)
+	if len(where) == 0 {
+		return Condition{err: ErrEmptyKey}
+	}
+	return And(where[0], where[1:]...)
+}
+
+// PartitionOf returns the partition key columns of i and their values in the
+// order of the primary key.
+func PartitionOf(i interface{}) ([]string, []interface{}) {
+	m, table := MapTable(i)
+	values := make([]interface{}, len(table.PartitionColumns))
+	for i, col := range table.PartitionColumns {
+		values[i] = deref(m[col])
+	}
+	return append([]string(nil), table.PartitionColumns...), values
+}
+
+// partitionEq returns the equality conditions on the partition key columns
+// of table using the values in the mapping.
+func partitionEq(m map[string]interface{}, table Table) []Condition {
+	where := make([]Condition, len(table.PartitionColumns))
+	for i, col := range table.PartitionColumns {
+		where[i] = Eq(table.quote(col), m[col])
+	}
+	return where
+}
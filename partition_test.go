@@ -0,0 +1,89 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPartitionKey(t *testing.T) {
+	var tests = []struct {
+		key       string
+		partition []string
+		rest      string
+	}{
+		{"id,time", nil, "id,time"},
+		{"(id)", []string{"id"}, ""},
+		{"(id,bucket)", []string{"id", "bucket"}, ""},
+		{" (id, bucket), time", []string{"id", "bucket"}, "time"},
+		{"(id, bucket), time desc, seq", []string{"id", "bucket"}, "time desc, seq"},
+		{"(id,time", nil, "id,time"},
+	}
+
+	for _, tc := range tests {
+		partition, rest := splitPartitionKey(tc.key)
+		assert.Equal(t, tc.partition, partition, tc.key)
+		assert.Equal(t, tc.rest, rest, tc.key)
+	}
+}
+
+func TestRegisterCompositeKey(t *testing.T) {
+	type compositeStruct struct {
+		ID     string `cql:"id" cqltable:"events" cqlkey:"(id,bucket),time"`
+		Bucket int    `cql:"bucket"`
+		Time   int64  `cql:"time"`
+	}
+
+	DeleteRegistry()
+	table := GetTable(compositeStruct{})
+	assert.Equal(t, []string{"id", "bucket", "time"}, table.KeyColumns)
+	assert.Equal(t, []string{"id", "bucket"}, table.PartitionColumns)
+	assert.Equal(t, []string{"time"}, table.ClusteringColumns)
+
+	// Without parenthesis
+	table = GetTable(MockModel{})
+	assert.Equal(t, []string{"key1"}, table.PartitionColumns)
+	assert.Equal(t, []string{"key2"}, table.ClusteringColumns)
+}
+
+type sensorStruct struct {
+	Sensor string `cql:"sensor" cqltable:"sensors" cqlkey:"(sensor,Day),id"`
+	Day    string `cql:"Day"`
+	ID     int    `cql:"id"`
+	Value  int    `cql:"value"`
+}
+
+type noKeyStruct struct {
+	A string `cql:"-" cqltable:"nokey"`
+}
+
+func TestKeyEq(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	s := sensorStruct{Sensor: "a", Day: "2024-06-01", ID: 3}
+	cond := KeyEq(s)
+	assert.Equal(t, "sensor = ? AND Day = ?", cond.CQLFragment)
+
+	assert.NoError(t, sess.Select(sensorStruct{}).Where(KeyEq(s), Gt("id", 1)).Exec())
+	assert.Equal(t, "SELECT sensor,Day,id,value FROM sensors WHERE sensor = ? AND Day = ? AND id > ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"a", "2024-06-01", 1}, derefValues(d.last()))
+
+	// Types without partition key
+	n := len(d.requests)
+	err := sess.Select(testStruct{}).Where(KeyEq(noKeyStruct{A: "a"})).Exec()
+	assert.True(t, errors.Is(err, ErrEmptyKey))
+	assert.Len(t, d.requests, n)
+}
+
+func TestPartitionOf(t *testing.T) {
+	DeleteRegistry()
+	cols, values := PartitionOf(&sensorStruct{Sensor: "a", Day: "2024-06-01", ID: 3})
+	assert.Equal(t, []string{"sensor", "Day"}, cols)
+	assert.Equal(t, []interface{}{"a", "2024-06-01"}, values)
+
+	cols, values = PartitionOf(testStruct{F1: "b"})
+	assert.Equal(t, []string{"f1"}, cols)
+	assert.Equal(t, []interface{}{"b"}, values)
+}
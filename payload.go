@@ -0,0 +1,40 @@
+package ecql
+
+// WithPayload adds a value to the custom payload sent with all the
+// statements and batches of the session. The custom payloads are not used by
+// Cassandra, but they can be read by custom query handlers or by proxies and
+// sidecars, for example for routing hints or audit metadata.
+func WithPayload(key string, value []byte) Option {
+	return func(s *SessionImpl) {
+		s.payload = withPayload(s.payload, key, value)
+	}
+}
+
+// Payload adds a value to the custom payload of the statement, the values
+// override the ones with the same key in the payload of the session:
+//
+//	err := sess.Insert(&order).Payload("trace-id", []byte(traceID)).Exec()
+func (s *StatementImpl) Payload(key string, value []byte) Statement {
+	s.payload = withPayload(s.payload, key, value)
+	return s
+}
+
+// withPayload returns a copy of the payload p with the key set to value.
+func withPayload(p map[string][]byte, key string, value []byte) map[string][]byte {
+	return mergePayload(p, map[string][]byte{key: value})
+}
+
+// mergePayload returns a new payload with the values of all the payloads, or
+// nil if all of them are empty. The last values of a key are used.
+func mergePayload(payloads ...map[string][]byte) map[string][]byte {
+	var merged map[string][]byte
+	for _, p := range payloads {
+		for k, v := range p {
+			if merged == nil {
+				merged = make(map[string][]byte)
+			}
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,26 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayload(t *testing.T) {
+	sess, d := newTestSession(WithPayload("app", []byte("api")), WithPayload("route", []byte("a")))
+
+	assert.NoError(t, sess.Set(testStruct{F1: "a"}))
+	assert.Equal(t, map[string][]byte{"app": []byte("api"), "route": []byte("a")}, d.last().Payload)
+
+	stmt := sess.Select(testStruct{}).Payload("route", []byte("b")).Payload("trace", []byte("1"))
+	assert.NoError(t, stmt.Exec())
+	assert.Equal(t, map[string][]byte{"app": []byte("api"), "route": []byte("b"), "trace": []byte("1")}, d.last().Payload)
+	assert.Equal(t, []byte("a"), sess.payload["route"])
+
+	assert.NoError(t, sess.Batch().Add(sess.Insert(testStruct{F1: "a"}).Payload("trace", []byte("2"))).Apply())
+	assert.Equal(t, map[string][]byte{"app": []byte("api"), "route": []byte("a"), "trace": []byte("2")}, d.batches[0].Payload)
+
+	sess, d = newTestSession()
+	assert.NoError(t, sess.Set(testStruct{F1: "a"}))
+	assert.Nil(t, d.last().Payload)
+}
@@ -0,0 +1,75 @@
+package ecql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultPingTimeout is the maximum time waited by Ping.
+const DefaultPingTimeout = 2 * time.Second
+
+// pingQuery is the statement executed by Ping, it is answered by the
+// coordinator without reading any user table.
+const pingQuery = "SELECT release_version FROM system.local"
+
+// Ping executes a trivial query on the system.local table to check that the
+// cluster is reachable. The query is not retried and it times out after
+// DefaultPingTimeout, or the timeout of the session if it is shorter, so it
+// can be used in readiness and liveness probes. It does not go through the
+// middlewares, the policies nor the limits of the session:
+//
+//	if err := sess.Ping(r.Context()); err != nil {
+//		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//	}
+func (s *SessionImpl) Ping(ctx context.Context) error {
+	req := s.request(SelectCmd, "system.local", pingQuery, nil)
+	if ctx != nil {
+		req.Context = ctx
+	}
+	if req.Timeout <= 0 || req.Timeout > DefaultPingTimeout {
+		req.Timeout = DefaultPingTimeout
+	}
+	req.Retry = NoRetry
+	return timeoutDriver{errorsDriver{s.baseDriver()}}.Iter(req).Close()
+}
+
+// WaitUntilReady pings the cluster until it answers or the context is done,
+// it is used at startup to wait for the database. The time between attempts
+// follows the backoff of the given policy, and the number of attempts is
+// limited by its MaxRetries if it is set. The default backoff is used if
+// backoff is nil:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//	if err := sess.WaitUntilReady(ctx, &ecql.RetryPolicy{MaxBackoff: 5 * time.Second}); err != nil {
+//		log.Fatal(err)
+//	}
+func (s *SessionImpl) WaitUntilReady(ctx context.Context, backoff *RetryPolicy) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if backoff == nil {
+		backoff = &RetryPolicy{}
+	}
+	for attempt := 1; ; attempt++ {
+		err := s.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		if backoff.MaxRetries > 0 && attempt > backoff.MaxRetries {
+			return fmt.Errorf("ecql: session not ready after %d attempts: %w", attempt, err)
+		}
+		e := RetryEvent{
+			Command:   SelectCmd,
+			Table:     "system.local",
+			Statement: pingQuery,
+			Attempt:   attempt,
+			Err:       err,
+			Backoff:   backoff.backoff(attempt),
+		}
+		if !backoff.wait(ctx, e) {
+			return fmt.Errorf("ecql: session not ready: %w", err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	sess, d := newTestSession(WithRetry(&RetryPolicy{MaxRetries: 3}), WithTimeout(time.Minute))
+	assert.NoError(t, sess.Ping(context.Background()))
+	req := d.last()
+	assert.Equal(t, "SELECT release_version FROM system.local", req.Statement)
+	assert.Equal(t, "system.local", req.Table)
+	assert.Equal(t, DefaultPingTimeout, req.Timeout)
+	assert.Equal(t, NoRetry, req.Retry)
+
+	// Errors are not retried
+	d.err = testRequestError{gocql.ErrCodeUnavailable}
+	n := len(d.requests)
+	err := sess.Ping(context.Background())
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.Len(t, d.requests, n+1)
+
+	// Shorter timeouts of the session are kept
+	sess, d = newTestSession(WithTimeout(time.Second))
+	assert.NoError(t, sess.Ping(nil))
+	assert.Equal(t, time.Second, d.last().Timeout)
+
+	// Middlewares are not used
+	var calls int
+	middleware := func(next Driver) Driver {
+		return &middlewareDriver{Driver: next, fn: func(*Request) { calls++ }}
+	}
+	sess, d = newTestSession(WithMiddleware(middleware))
+	assert.NoError(t, sess.Ping(context.Background()))
+	assert.Equal(t, pingQuery, d.last().Statement)
+	assert.Equal(t, 0, calls)
+}
+
+func TestWaitUntilReady(t *testing.T) {
+	d := &testDriver{}
+	flaky := &flakyDriver{testDriver: d, n: 2, err: testRequestError{gocql.ErrCodeUnavailable}}
+	sess := NewWithDriver(flaky)
+
+	var events []RetryEvent
+	backoff := &RetryPolicy{MinBackoff: time.Millisecond, OnRetry: func(e RetryEvent) {
+		events = append(events, e)
+	}}
+	assert.NoError(t, sess.WaitUntilReady(context.Background(), backoff))
+	assert.Len(t, d.requests, 3)
+	assert.Len(t, events, 2)
+	assert.Equal(t, 2, events[1].Attempt)
+
+	// Limited attempts
+	flaky.n = 5
+	err := sess.WaitUntilReady(context.Background(), &RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond})
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.Contains(t, err.Error(), "ecql: session not ready after 3 attempts")
+
+	// Context done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = sess.WaitUntilReady(ctx, nil)
+	assert.True(t, errors.Is(err, ErrUnavailable))
+}
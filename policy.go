@@ -0,0 +1,171 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrAccessDenied is the error that policies should return when a request is
+// not authorized.
+var ErrAccessDenied = errors.New("ecql: access denied")
+
+// ErrInvalidRewrite is returned by Request.AndWhere on requests that cannot
+// be filtered, like inserts.
+var ErrInvalidRewrite = errors.New("ecql: conditions cannot be added to the statement")
+
+// Policy authorizes the requests executed by a session. Authorize is called
+// before a request is executed, it can reject it returning an error, or
+// rewrite it, for example to restrict the rows that can be read or modified
+// by the principal of the context.
+type Policy interface {
+	Authorize(ctx context.Context, req *Request) error
+}
+
+// PolicyFunc is an adapter to use ordinary functions as a Policy.
+type PolicyFunc func(ctx context.Context, req *Request) error
+
+// Authorize implements Policy.
+func (f PolicyFunc) Authorize(ctx context.Context, req *Request) error {
+	return f(ctx, req)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx with the principal used by the
+// policies.
+func WithPrincipal(ctx context.Context, principal interface{}) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalOf returns the principal set in the context with WithPrincipal, or
+// nil if it is not set.
+func PrincipalOf(ctx context.Context) interface{} {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Value(principalKey{})
+}
+
+// WithPolicy sets the policy that authorizes all the requests executed by the
+// session, including the statements of the batches. The policy is evaluated
+// before the middlewares. Cassandra only accepts key columns in the WHERE
+// clause of UPDATE and DELETE statements, so in this example owner_id is
+// part of the primary key of documents:
+//
+//	policy := ecql.PolicyFunc(func(ctx context.Context, req *ecql.Request) error {
+//		user, ok := ecql.PrincipalOf(ctx).(*User)
+//		switch {
+//		case !ok:
+//			return ecql.ErrAccessDenied
+//		case user.Admin || req.Table != "documents":
+//			return nil
+//		case req.Command == ecql.InsertCmd:
+//			if req.Row["owner_id"] != user.ID {
+//				return ecql.ErrAccessDenied
+//			}
+//			return nil
+//		default:
+//			return req.AndWhere(ecql.Eq("owner_id", user.ID))
+//		}
+//	})
+//	sess, err := ecql.NewSession(cfg, ecql.WithPolicy(policy))
+func WithPolicy(p Policy) Option {
+	return func(s *SessionImpl) {
+		s.policy = p
+	}
+}
+
+// policyDriver is the Driver that evaluates the policy of a session.
+type policyDriver struct {
+	Driver
+	policy Policy
+}
+
+func (d policyDriver) Iter(req *Request) Rows {
+	if err := d.policy.Authorize(contextOf(req.Context), req); err != nil {
+		return errorRows{err: err}
+	}
+	return d.Driver.Iter(req)
+}
+
+func (d policyDriver) authorize(b *BatchRequest) error {
+	for i := range b.Entries {
+		e := &b.Entries[i]
+		if e.Context == nil {
+			e.Context = b.Context
+		}
+		if err := d.policy.Authorize(contextOf(e.Context), e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d policyDriver) ExecBatch(b *BatchRequest) error {
+	if err := d.authorize(b); err != nil {
+		return err
+	}
+	return d.Driver.ExecBatch(b)
+}
+
+func (d policyDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	if err := d.authorize(b); err != nil {
+		return false, err
+	}
+	return d.Driver.ExecBatchCAS(b, dest)
+}
+
+// whereEnd are the clauses that can follow the WHERE clause.
+var whereEnd = []string{" GROUP BY ", " ORDER BY ", " PER PARTITION LIMIT ", " LIMIT ", " ALLOW FILTERING", " IF "}
+
+// AndWhere adds the conditions to the WHERE clause of a SELECT, UPDATE or
+// DELETE statement. It returns ErrInvalidRewrite on other statements.
+func (r *Request) AndWhere(cond ...Condition) error {
+	if len(cond) == 0 {
+		return nil
+	}
+	switch r.Command {
+	case SelectCmd, CountCmd, UpdateCmd, DeleteCmd:
+	default:
+		return ErrInvalidRewrite
+	}
+
+	and := And(cond[0], cond[1:]...)
+	if and.err != nil {
+		return and.err
+	}
+	stmt := r.Statement
+	start := strings.Index(stmt, " WHERE ")
+	if start < 0 && r.Command != SelectCmd && r.Command != CountCmd {
+		return ErrInvalidRewrite
+	}
+
+	// Find the end of the WHERE clause, or where it should be
+	end := len(stmt)
+	for _, clause := range whereEnd {
+		if i := strings.Index(stmt[start+1:], clause); i >= 0 && start+1+i < end {
+			end = start + 1 + i
+		}
+	}
+	fragment := " AND " + and.CQLFragment
+	if start < 0 {
+		fragment = " WHERE " + and.CQLFragment
+	}
+	pos := strings.Count(stmt[:end], "?")
+	if pos > len(r.Values) {
+		return ErrInvalidRewrite
+	}
+
+	values := make([]interface{}, 0, len(r.Values)+len(and.Values))
+	values = append(values, r.Values[:pos]...)
+	values = append(values, and.Values...)
+	r.Values = append(values, r.Values[pos:]...)
+	r.Statement = stmt[:end] + fragment + stmt[end:]
+	if r.Row != nil {
+		for col, v := range and.equalities() {
+			r.Row[col] = v
+		}
+	}
+	return nil
+}
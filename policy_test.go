@@ -0,0 +1,82 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestAndWhere(t *testing.T) {
+	tests := []struct {
+		cmd       Command
+		stmt      string
+		values    []interface{}
+		want      string
+		wantValue []interface{}
+		err       error
+	}{
+		{SelectCmd, "SELECT * FROM t", nil, "SELECT * FROM t WHERE owner = ?", []interface{}{"u"}, nil},
+		{SelectCmd, "SELECT * FROM t WHERE a = ? LIMIT 10", []interface{}{1}, "SELECT * FROM t WHERE a = ? AND owner = ? LIMIT 10", []interface{}{1, "u"}, nil},
+		{CountCmd, "SELECT COUNT(1) FROM t ALLOW FILTERING", nil, "SELECT COUNT(1) FROM t WHERE owner = ? ALLOW FILTERING", []interface{}{"u"}, nil},
+		{SelectCmd, "SELECT * FROM t WHERE a = ? ORDER BY b DESC", []interface{}{1}, "SELECT * FROM t WHERE a = ? AND owner = ? ORDER BY b DESC", []interface{}{1, "u"}, nil},
+		{UpdateCmd, "UPDATE t SET b = ? WHERE a = ? IF c = ?", []interface{}{1, 2, 3}, "UPDATE t SET b = ? WHERE a = ? AND owner = ? IF c = ?", []interface{}{1, 2, "u", 3}, nil},
+		{DeleteCmd, "DELETE FROM t WHERE a = ? IF EXISTS", []interface{}{1}, "DELETE FROM t WHERE a = ? AND owner = ? IF EXISTS", []interface{}{1, "u"}, nil},
+		{InsertCmd, "INSERT INTO t (a) VALUES (?)", []interface{}{1}, "INSERT INTO t (a) VALUES (?)", []interface{}{1}, ErrInvalidRewrite},
+		{DeleteCmd, "DELETE FROM t", nil, "DELETE FROM t", nil, ErrInvalidRewrite},
+	}
+	for _, tt := range tests {
+		req := &Request{Command: tt.cmd, Statement: tt.stmt, Values: tt.values}
+		assert.Equal(t, tt.err, req.AndWhere(Eq("owner", "u")), tt.stmt)
+		assert.Equal(t, tt.want, req.Statement)
+		assert.Equal(t, tt.wantValue, req.Values)
+	}
+
+	// Invalid identifiers are not added to the statement
+	req := &Request{Command: SelectCmd, Statement: "SELECT * FROM t"}
+	assert.True(t, errors.Is(req.AndWhere(Eq("owner", "u"), Eq("a; DROP TABLE t", 1)), ErrInvalidIdentifier))
+	assert.Equal(t, "SELECT * FROM t", req.Statement)
+	assert.Nil(t, req.Values)
+}
+
+func TestPolicy(t *testing.T) {
+	DeleteRegistry()
+	policy := PolicyFunc(func(ctx context.Context, req *Request) error {
+		user, ok := PrincipalOf(ctx).(string)
+		switch {
+		case !ok:
+			return ErrAccessDenied
+		case user == "admin":
+			return nil
+		case req.Command == InsertCmd:
+			if req.Row["f1"] != user {
+				return ErrAccessDenied
+			}
+			return nil
+		default:
+			return req.AndWhere(Eq("f1", user))
+		}
+	})
+	sess, d := newTestSession(WithPolicy(policy))
+	d.result([]string{"f1"}, []interface{}{"bob"})
+
+	var ts testStruct
+	assert.Equal(t, ErrAccessDenied, sess.Get(&ts, "a"))
+	assert.Len(t, d.requests, 0)
+
+	bob := sess.WithContext(WithPrincipal(context.Background(), "bob"))
+	assert.NoError(t, bob.Select(&ts).Where(Eq("f22", 1)).AllowFiltering().TypeScan())
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f22 = ? AND f1 = ? ALLOW FILTERING", d.last().Statement)
+	assert.Equal(t, []interface{}{1, "bob"}, d.last().Values)
+
+	assert.NoError(t, bob.Set(testStruct{F1: "bob"}))
+	assert.Equal(t, ErrAccessDenied, bob.Set(testStruct{F1: "alice"}))
+	assert.Equal(t, ErrAccessDenied, bob.Batch().Add(bob.Insert(&testStruct{F1: "alice"})).Apply())
+	assert.Len(t, d.batches, 0)
+
+	admin := sess.WithContext(WithPrincipal(context.Background(), "admin"))
+	assert.NoError(t, admin.Batch().Add(admin.Insert(&testStruct{F1: "alice"})).Apply())
+	assert.Len(t, d.batches, 1)
+	assert.Nil(t, PrincipalOf(nil))
+}
@@ -0,0 +1,49 @@
+package ecql
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Pool is a pool of structs of the same type backed by a sync.Pool. It
+// reduces the allocations of the services that read large number of rows,
+// the structs are leased from the pool by Iter.Lease and returned with Put
+// when they are no longer used:
+//
+//	pool := ecql.NewPool(Tweet{})
+//	iter := sess.Select(Tweet{}).Iter()
+//	for v, ok := iter.Lease(pool); ok; v, ok = iter.Lease(pool) {
+//		process(v.(*Tweet))
+//		pool.Put(v)
+//	}
+//	err := iter.Close()
+type Pool struct {
+	typ  reflect.Type
+	pool sync.Pool
+}
+
+// NewPool returns a Pool of structs of the type of i.
+func NewPool(i interface{}) *Pool {
+	typ := structOf(i).Type()
+	p := &Pool{typ: typ}
+	p.pool.New = func() interface{} {
+		return reflect.New(typ).Interface()
+	}
+	return p
+}
+
+// Get returns a pointer to a zero struct from the pool.
+func (p *Pool) Get() interface{} {
+	return p.pool.Get()
+}
+
+// Put resets the struct pointed by v and returns it to the pool. Values of
+// other types are ignored.
+func (p *Pool) Put(v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Type() != p.typ {
+		return
+	}
+	rv.Elem().Set(reflect.Zero(p.typ))
+	p.pool.Put(v)
+}
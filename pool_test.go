@@ -0,0 +1,93 @@
+package ecql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	pool := NewPool(&testStruct{})
+	v := pool.Get().(*testStruct)
+	v.F1, v.F2 = "foo", 1
+	pool.Put(v)
+	pool.Put(testStruct{})
+	pool.Put((*testStruct)(nil))
+	pool.Put(&struct{}{})
+	assert.Equal(t, testStruct{}, *v)
+	assert.IsType(t, &testStruct{}, pool.Get())
+}
+
+func TestIterLease(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 1}, []interface{}{"bar", 2})
+
+	pool := NewPool(testStruct{})
+	var names []string
+	iter := sess.Select(testStruct{}).Iter()
+	for v, ok := iter.Lease(pool); ok; v, ok = iter.Lease(pool) {
+		ts := v.(*testStruct)
+		names = append(names, ts.F1)
+		pool.Put(ts)
+	}
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, []string{"foo", "bar"}, names)
+
+	names = nil
+	iter = sess.Select(testStruct{}).Iter()
+	for v := range iter.Chan(context.Background(), pool) {
+		names = append(names, v.(*testStruct).F1)
+		pool.Put(v)
+	}
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, []string{"foo", "bar"}, names)
+}
+
+// benchmarkRows returns a session that returns n rows of testStruct.
+func benchmarkRows(n int) *SessionImpl {
+	rows := make([][]interface{}, n)
+	for i := range rows {
+		rows[i] = []interface{}{"foo", i}
+	}
+	sess, d := newTestSession()
+	d.result([]string{"f1", "f22"}, rows...)
+	return sess
+}
+
+func BenchmarkIterTypeScan(b *testing.B) {
+	DeleteRegistry()
+	sess := benchmarkRows(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := sess.Select(testStruct{}).Iter()
+		for {
+			v := new(testStruct)
+			if !iter.TypeScan(v) {
+				break
+			}
+		}
+		if err := iter.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIterLease(b *testing.B) {
+	DeleteRegistry()
+	sess := benchmarkRows(1000)
+	pool := NewPool(testStruct{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := sess.Select(testStruct{}).Iter()
+		for v, ok := iter.Lease(pool); ok; v, ok = iter.Lease(pool) {
+			pool.Put(v)
+		}
+		if err := iter.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
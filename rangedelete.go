@@ -0,0 +1,47 @@
+package ecql
+
+import "errors"
+
+// ErrEmptyRange is returned by DeleteRange if there are no conditions on the
+// clustering columns.
+var ErrEmptyRange = errors.New("ecql: range delete without conditions")
+
+// DeleteRange initializes a DELETE statement of the rows of the partition of
+// i matching the given conditions on the clustering columns. The values of
+// the partition key are taken from i, for example to delete the events of a
+// user older than a month:
+//
+//	err := sess.DeleteRange(Event{UserID: id}, Lt("created", time.Now().AddDate(0, -1, 0))).Exec()
+//
+// The statement returns a *KeyError, matching ErrEmptyKey, if a column of the
+// partition key of i is empty, ErrNoClusteringColumns if the table does not
+// have clustering columns, and ErrEmptyRange without conditions. Like in
+// CQL, the conditions must be on the clustering columns, and the rows deleted
+// are not removed from the session cache.
+func (s *SessionImpl) DeleteRange(i interface{}, cond ...Condition) Statement {
+	stmt := &StatementImpl{session: s}
+	i, err := s.scope(i)
+	if err != nil {
+		stmt.err = err
+		return stmt
+	}
+	i = s.setBucket(i)
+	m, table := s.getRegistry().MapTable(i)
+	switch {
+	case len(table.ClusteringColumns) == 0:
+		stmt.err = ErrNoClusteringColumns
+		return stmt
+	case len(cond) == 0:
+		stmt.err = ErrEmptyRange
+		return stmt
+	}
+	if err := table.checkColumns(structOf(i), table.PartitionColumns); err != nil {
+		stmt.err = err
+		return stmt
+	}
+
+	where := append(partitionEq(m, table), cond...)
+	stmt.Do(DeleteCmd).From(table.Name).Where(where...)
+	stmt.Table = table
+	return stmt
+}
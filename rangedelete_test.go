@@ -0,0 +1,29 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteRange(t *testing.T) {
+	sess, d := newTestSession()
+
+	assert.NoError(t, sess.DeleteRange(pageStruct{Stream: "s"}, Lt("seq", 10)).Exec())
+	assert.Equal(t, "DELETE FROM pages WHERE stream = ? AND seq < ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"s", 10}, derefValues(d.last()))
+	assert.Equal(t, []interface{}{"s"}, d.last().PartitionKey)
+
+	assert.NoError(t, sess.DeleteRange(&pageStruct{Stream: "s"}, Ge("seq", 1), Lt("seq", 10)).Exec())
+	assert.Equal(t, "DELETE FROM pages WHERE stream = ? AND seq >= ? AND seq < ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"s", 1, 10}, derefValues(d.last()))
+
+	n := len(d.requests)
+	err := sess.DeleteRange(pageStruct{}, Lt("seq", 10)).Exec()
+	assert.True(t, errors.Is(err, ErrEmptyKey))
+	assert.Equal(t, &KeyError{Table: "pages", Column: "stream"}, err)
+	assert.Equal(t, ErrEmptyRange, sess.DeleteRange(pageStruct{Stream: "s"}).Exec())
+	assert.Equal(t, ErrNoClusteringColumns, sess.DeleteRange(testStruct{F1: "a"}, Lt("f22", 1)).Exec())
+	assert.Len(t, d.requests, n)
+}
@@ -0,0 +1,205 @@
+package ecql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit is the configuration of a token bucket, Rate is the number of
+// requests per second and Burst the maximum number of requests executed at
+// once. A zero Rate disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimiterConfig contains the configuration of a RateLimiter. Global
+// limits all the requests of the session, and Tables limits the requests on
+// each table. A request waits for both limits. Clock is the source of the
+// current time, it defaults to SystemClock.
+type RateLimiterConfig struct {
+	Global RateLimit
+	Tables map[string]RateLimit
+	Clock  Clock
+}
+
+// RateLimiter is a middleware that throttles the requests of a session using
+// token buckets. The requests wait until they are allowed or their context
+// is done, so a batch job sharing the cluster can be limited without
+// failing its requests:
+//
+//	limiter := ecql.NewRateLimiter(ecql.RateLimiterConfig{
+//		Global: ecql.RateLimit{Rate: 500, Burst: 50},
+//		Tables: map[string]ecql.RateLimit{"events": {Rate: 100, Burst: 10}},
+//	})
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(limiter.Middleware()))
+//
+// Each statement of a batch counts as a request.
+type RateLimiter struct {
+	global *tokenBucket
+	tables map[string]*tokenBucket
+}
+
+// NewRateLimiter creates a RateLimiter with the given configuration.
+func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
+	if config.Clock == nil {
+		config.Clock = SystemClock
+	}
+	l := &RateLimiter{
+		global: newTokenBucket(config.Global, config.Clock),
+		tables: make(map[string]*tokenBucket),
+	}
+	for name, limit := range config.Tables {
+		if b := newTokenBucket(limit, config.Clock); b != nil {
+			l.tables[name] = b
+		}
+	}
+	return l
+}
+
+// Middleware returns the middleware that applies the limits.
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next Driver) Driver {
+		return &rateLimitDriver{Driver: next, limiter: l}
+	}
+}
+
+// Wait blocks until a request on the given table is allowed or the context
+// is done.
+func (l *RateLimiter) Wait(ctx context.Context, table string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := l.global.wait(ctx); err != nil {
+		return err
+	}
+	if err := l.tables[table].wait(ctx); err != nil {
+		l.global.cancel()
+		return err
+	}
+	return nil
+}
+
+// cancel returns the tokens taken by Wait for a request on the given table.
+func (l *RateLimiter) cancel(table string) {
+	l.global.cancel()
+	l.tables[table].cancel()
+}
+
+// waitBatch waits for each entry of the batch, if one of them fails the
+// tokens taken for the previous entries are returned.
+func (l *RateLimiter) waitBatch(b *BatchRequest) error {
+	for i := range b.Entries {
+		if err := l.Wait(b.Context, b.Entries[i].Table); err != nil {
+			for j := 0; j < i; j++ {
+				l.cancel(b.Entries[j].Table)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// tokenBucket is a token bucket that allows negative balances, the requests
+// reserve a token and wait until the balance would have been positive.
+type tokenBucket struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, clock Clock) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		clock:  clock,
+		rate:   limit.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   clock.Now(),
+	}
+}
+
+// reserve takes a token and returns the time to wait before using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token. A nil bucket does nothing.
+func (b *tokenBucket) cancel() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.tokens++
+	b.mu.Unlock()
+}
+
+// wait takes a token and waits until it can be used. A nil bucket does not
+// wait.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	d := b.reserve()
+	if d == 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// rateLimitDriver is the Driver used by the RateLimiter middleware.
+type rateLimitDriver struct {
+	Driver
+	limiter *RateLimiter
+}
+
+func (d *rateLimitDriver) Iter(req *Request) Rows {
+	if err := d.limiter.Wait(req.Context, req.Table); err != nil {
+		return errorRows{err: wrapError(err, req)}
+	}
+	return d.Driver.Iter(req)
+}
+
+func (d *rateLimitDriver) ExecBatch(b *BatchRequest) error {
+	if err := d.limiter.waitBatch(b); err != nil {
+		return wrapBatchError(err, b)
+	}
+	return d.Driver.ExecBatch(b)
+}
+
+func (d *rateLimitDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	if err := d.limiter.waitBatch(b); err != nil {
+		return false, wrapBatchError(err, b)
+	}
+	return d.Driver.ExecBatchCAS(b, dest)
+}
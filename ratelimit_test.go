@@ -0,0 +1,80 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	clock := ClockFunc(func() time.Time { return now })
+
+	assert.Nil(t, newTokenBucket(RateLimit{}, clock))
+	b := newTokenBucket(RateLimit{Rate: 10, Burst: 2}, clock)
+	assert.Equal(t, time.Duration(0), b.reserve())
+	assert.Equal(t, time.Duration(0), b.reserve())
+	assert.Equal(t, 100*time.Millisecond, b.reserve())
+	assert.Equal(t, 200*time.Millisecond, b.reserve())
+	b.cancel()
+
+	// The tokens are refilled up to the burst
+	now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), b.reserve())
+	assert.Equal(t, time.Duration(0), b.reserve())
+	assert.Equal(t, 100*time.Millisecond, b.reserve())
+}
+
+func TestRateLimiter(t *testing.T) {
+	DeleteRegistry()
+	limiter := NewRateLimiter(RateLimiterConfig{
+		Global: RateLimit{Rate: 1000, Burst: 100},
+		Tables: map[string]RateLimit{"mytable": {Rate: 0.001, Burst: 1}},
+	})
+	sess, d := newTestSession(WithMiddleware(limiter.Middleware()))
+
+	assert.NoError(t, sess.QueryRaw("SELECT * FROM other").Exec())
+	assert.NoError(t, sess.Set(testStruct{F1: "foo"}))
+	assert.Len(t, d.requests, 2)
+
+	// The next request on mytable waits until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := limiter.Wait(ctx, "mytable")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.NoError(t, limiter.Wait(ctx, "other"))
+
+	// The requests are not executed if the context is done
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	rows := limiter.Middleware()(d).Iter(&Request{Context: ctx, Table: "mytable", Statement: "SELECT * FROM mytable"})
+	assert.True(t, errors.Is(rows.Close(), context.Canceled))
+	assert.True(t, errors.Is(limiter.Middleware()(d).ExecBatch(&BatchRequest{Context: ctx, Entries: []Request{{Table: "mytable"}}}), context.Canceled))
+	assert.Len(t, d.requests, 2)
+	assert.Len(t, d.batches, 0)
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(RateLimiterConfig{
+		Global: RateLimit{Rate: 1, Burst: 2},
+		Tables: map[string]RateLimit{"mytable": {Rate: 1, Burst: 1}},
+		Clock:  ClockFunc(func() time.Time { return now }),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The global token is returned if the table wait fails
+	assert.NoError(t, limiter.Wait(ctx, "mytable"))
+	assert.Equal(t, context.Canceled, limiter.Wait(ctx, "mytable"))
+	assert.Equal(t, float64(1), limiter.global.tokens)
+
+	// The tokens of the previous entries of a batch are returned
+	err := limiter.waitBatch(&BatchRequest{Context: ctx, Entries: []Request{{Table: "other"}, {Table: "mytable"}}})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, float64(1), limiter.global.tokens)
+	assert.Equal(t, float64(0), limiter.tables["mytable"].tokens)
+}
@@ -0,0 +1,179 @@
+package ecql
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+// RawQuery is an arbitrary CQL statement executed by the session driver. It
+// is the escape hatch for the statements that cannot be built with the
+// Statement API, but the results can still be mapped into the registered
+// structs:
+//
+//	var tweets []Tweet
+//	err := sess.QueryRaw("SELECT * FROM tweet WHERE timeline = ? LIMIT 10", "me").SelectType(&tweets)
+type RawQuery interface {
+	ScanType(i interface{}) error
+	SelectType(dest interface{}) error
+	Scan(dest ...interface{}) error
+	MapRows() ([]map[string]interface{}, error)
+	Exec() error
+	Iter() Iter
+	Consistency(c gocql.Consistency) RawQuery
+	Retry(p *RetryPolicy) RawQuery
+	Idempotent() RawQuery
+}
+
+type RawQueryImpl struct {
+	statement *StatementImpl
+}
+
+// QueryRaw returns a RawQuery that executes the given CQL with the given
+// values. Unlike Query, it uses the session driver and middlewares, and it is
+// also available on sessions created with NewWithDriver.
+func (s *SessionImpl) QueryRaw(cql string, values ...interface{}) RawQuery {
+	cmd, table := parseCommand(cql)
+	stmt := &StatementImpl{
+		session: s,
+		Command: cmd,
+		Table:   Table{Name: table},
+		rawCQL:  cql,
+		values:  values,
+	}
+	return &RawQueryImpl{statement: stmt}
+}
+
+// ScanType sets the values of the first row in the struct i. It returns
+// ErrNotFound if there are no rows.
+func (q *RawQueryImpl) ScanType(i interface{}) error {
+	m, table := q.statement.session.getRegistry().MapTable(i)
+	req, err := q.statement.request()
+	if err != nil {
+		return err
+	}
+	if err := mapScanRow(q.statement.session.getDriver().Iter(req), m); err != nil {
+		return err
+	}
+	table.scanned(structOf(i), m)
+	return afterScan(contextOf(req.Context), structOf(i))
+}
+
+// SelectType appends all the rows to dest, that must be a pointer to a slice
+// of structs or pointers to structs.
+func (q *RawQueryImpl) SelectType(dest interface{}) error {
+	slice, elemType, isPtr, err := structSlice(dest)
+	if err != nil {
+		return err
+	}
+
+	iter := q.Iter()
+	rows := q.statement.session.appender(slice, isPtr)
+	v := reflect.New(elemType)
+	for iter.TypeScan(v.Interface()) && rows.append(v) {
+		v = reflect.New(elemType)
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	return rows.err()
+}
+
+// Scan copies the columns of the first row into the values pointed by dest.
+// It returns ErrNotFound if there are no rows.
+func (q *RawQueryImpl) Scan(dest ...interface{}) error {
+	return q.statement.Scan(dest...)
+}
+
+// MapRows returns all the rows as maps from the column name to the value.
+func (q *RawQueryImpl) MapRows() ([]map[string]interface{}, error) {
+	return q.statement.MapRows()
+}
+
+// Exec executes the query.
+func (q *RawQueryImpl) Exec() error {
+	req, err := q.statement.request()
+	if err != nil {
+		return err
+	}
+	defer q.statement.invalidateCache()
+	return q.statement.session.getDriver().Iter(req).Close()
+}
+
+// Iter returns an iterator over the rows of the query.
+func (q *RawQueryImpl) Iter() Iter {
+	return q.statement.Iter()
+}
+
+// Consistency sets the consistency level of the query.
+func (q *RawQueryImpl) Consistency(c gocql.Consistency) RawQuery {
+	q.statement.Consistency(c)
+	return q
+}
+
+// Retry sets the retry policy of the query, overriding the one of the
+// session.
+func (q *RawQueryImpl) Retry(p *RetryPolicy) RawQuery {
+	q.statement.Retry(p)
+	return q
+}
+
+// Idempotent marks the query as safe to retry. Only raw SELECT queries are
+// considered idempotent by default.
+func (q *RawQueryImpl) Idempotent() RawQuery {
+	q.statement.Idempotent()
+	return q
+}
+
+var rawTableRegexp = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE|TRUNCATE(?:\s+TABLE)?)\s+([\w."]+)`)
+
+// parseCommand returns the command and the table of a CQL statement. The
+// statements that are not SELECT, INSERT, UPDATE, DELETE or TRUNCATE use
+// SchemaCmd.
+func parseCommand(cql string) (Command, string) {
+	var table string
+	if m := rawTableRegexp.FindStringSubmatch(cql); m != nil {
+		table = m[1]
+	}
+
+	fields := strings.Fields(cql)
+	if len(fields) == 0 {
+		return SchemaCmd, table
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT":
+		return SelectCmd, table
+	case "INSERT":
+		return InsertCmd, table
+	case "UPDATE":
+		return UpdateCmd, table
+	case "DELETE":
+		return DeleteCmd, table
+	case "TRUNCATE":
+		return TruncateCmd, table
+	default:
+		return SchemaCmd, table
+	}
+}
+
+// structSlice returns the slice pointed by dest and the type of its elements.
+// isPtr is true if the elements are pointers to structs.
+func structSlice(dest interface{}) (slice reflect.Value, elemType reflect.Type, isPtr bool, err error) {
+	slice = reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return slice, nil, false, ErrInvalidDestination
+	}
+	slice = slice.Elem()
+
+	elemType = slice.Type().Elem()
+	isPtr = elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return slice, nil, false, ErrInvalidDestination
+	}
+	return slice, elemType, isPtr, nil
+}
@@ -0,0 +1,61 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommand(t *testing.T) {
+	var tests = []struct {
+		cql   string
+		cmd   Command
+		table string
+	}{
+		{"SELECT * FROM tweet WHERE id = ?", SelectCmd, "tweet"},
+		{"select count(*) from ks.tweet", SelectCmd, "ks.tweet"},
+		{"INSERT INTO users (id) VALUES (?)", InsertCmd, "users"},
+		{"UPDATE views SET counter = counter + 1 WHERE id = ?", UpdateCmd, "views"},
+		{"DELETE FROM timeline WHERE id = ?", DeleteCmd, "timeline"},
+		{"TRUNCATE TABLE users", TruncateCmd, "users"},
+		{"CREATE INDEX ON users (following)", SchemaCmd, ""},
+	}
+	for _, tc := range tests {
+		cmd, table := parseCommand(tc.cql)
+		assert.Equal(t, tc.cmd, cmd, tc.cql)
+		assert.Equal(t, tc.table, table, tc.cql)
+	}
+}
+
+func TestQueryRaw(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithConsistency(LocalOnePreset))
+	cql := "SELECT f1, f22 FROM mytable WHERE token(f1) > token(?)"
+
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 1}, []interface{}{"bar", 2})
+	var ts testStruct
+	assert.NoError(t, sess.QueryRaw(cql, "a").ScanType(&ts))
+	assert.Equal(t, testStruct{F1: "foo", F2: 1}, ts)
+	req := d.last()
+	assert.Equal(t, SelectCmd, req.Command)
+	assert.Equal(t, "mytable", req.Table)
+	assert.Equal(t, cql, req.Statement)
+	assert.Equal(t, []interface{}{"a"}, req.Values)
+	assert.Equal(t, gocql.LocalOne, *req.Consistency)
+
+	var values []testStruct
+	assert.NoError(t, sess.QueryRaw(cql, "a").SelectType(&values))
+	assert.Equal(t, []testStruct{{F1: "foo", F2: 1}, {F1: "bar", F2: 2}}, values)
+	var pointers []*testStruct
+	assert.NoError(t, sess.QueryRaw(cql, "a").SelectType(&pointers))
+	assert.Equal(t, []*testStruct{{F1: "foo", F2: 1}, {F1: "bar", F2: 2}}, pointers)
+	assert.Equal(t, ErrInvalidDestination, sess.QueryRaw(cql).SelectType(values))
+
+	assert.NoError(t, sess.QueryRaw("UPDATE views SET counter = counter + 1 WHERE id = ?", 1).Consistency(gocql.All).Exec())
+	assert.Equal(t, UpdateCmd, d.last().Command)
+	assert.Equal(t, gocql.All, *d.last().Consistency)
+
+	d.result(nil)
+	assert.Equal(t, ErrNotFound, sess.QueryRaw(cql, "a").ScanType(&ts))
+}
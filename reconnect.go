@@ -0,0 +1,118 @@
+package ecql
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// StartupMode defines what NewSession does if the cluster is not available.
+type StartupMode int
+
+const (
+	// StartFailFast returns the connection error once the retries set with
+	// WithConnectRetry are exhausted. It is the default mode.
+	StartFailFast StartupMode = iota
+	// StartWait retries the connection until the cluster is available, it
+	// ignores the MaxRetries of the policy set with WithConnectRetry. Use
+	// WithStartupContext to stop waiting.
+	StartWait
+)
+
+// newGocqlSession creates the gocql sessions, it is replaced in the tests.
+var newGocqlSession = gocql.NewSession
+
+// WithReconnectInterval sets the interval used to try to reconnect to the
+// hosts that are down. Gocql uses 60 seconds by default.
+//
+// It only has effect on sessions created with NewSession.
+func WithReconnectInterval(d time.Duration) Option {
+	return func(s *SessionImpl) {
+		if s.cluster != nil {
+			s.cluster.ReconnectInterval = d
+		}
+	}
+}
+
+// WithReconnectBackoff sets the exponential backoff used to reconnect the
+// connections to a host before marking it as down, from min up to max and
+// at most maxRetries times.
+//
+// It only has effect on sessions created with NewSession.
+func WithReconnectBackoff(maxRetries int, min, max time.Duration) Option {
+	return func(s *SessionImpl) {
+		if s.cluster != nil {
+			s.cluster.ReconnectionPolicy = &gocql.ExponentialReconnectionPolicy{
+				MaxRetries:      maxRetries,
+				InitialInterval: min,
+				MaxInterval:     max,
+			}
+		}
+	}
+}
+
+// WithConnectRetry retries the initial connection of NewSession with the
+// backoff and the number of retries of the given policy. The OnRetry hook of
+// the policy can be used to log the failed attempts:
+//
+//	sess, err := ecql.NewSession(cfg, ecql.WithConnectRetry(&ecql.RetryPolicy{
+//		MaxRetries: 5,
+//		MaxBackoff: 10 * time.Second,
+//		OnRetry: func(e ecql.RetryEvent) {
+//			log.Printf("cassandra not available: %v, retrying in %s", e.Err, e.Backoff)
+//		},
+//	}))
+func WithConnectRetry(p *RetryPolicy) Option {
+	return func(s *SessionImpl) {
+		s.connRetry = p
+	}
+}
+
+// WithStartupMode sets what NewSession does if the cluster is not available,
+// see StartFailFast and StartWait.
+func WithStartupMode(m StartupMode) Option {
+	return func(s *SessionImpl) {
+		s.startup = m
+	}
+}
+
+// WithStartupContext sets a context that stops the retries of the initial
+// connection of NewSession when it is done, NewSession then returns the last
+// connection error. It can be used to wait at most some time with StartWait:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+//	defer cancel()
+//	sess, err := ecql.NewSession(cfg, ecql.WithStartupMode(ecql.StartWait), ecql.WithStartupContext(ctx))
+func WithStartupContext(ctx context.Context) Option {
+	return func(s *SessionImpl) {
+		s.startCtx = ctx
+	}
+}
+
+// connect creates the gocql session retrying with the connection policy.
+func (s *SessionImpl) connect(cfg gocql.ClusterConfig) (*gocql.Session, error) {
+	p := s.connRetry
+	if p == nil {
+		if s.startup != StartWait {
+			return newGocqlSession(cfg)
+		}
+		p = &RetryPolicy{}
+	}
+	for attempt := 1; ; attempt++ {
+		session, err := newGocqlSession(cfg)
+		if err == nil {
+			return session, nil
+		}
+		if s.startup != StartWait && attempt > p.MaxRetries {
+			return nil, err
+		}
+		if !p.wait(s.startCtx, RetryEvent{
+			Attempt: attempt,
+			Err:     err,
+			Backoff: p.backoff(attempt),
+		}) {
+			return nil, err
+		}
+	}
+}
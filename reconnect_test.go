@@ -0,0 +1,72 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectOptions(t *testing.T) {
+	cfg := gocql.NewCluster("127.0.0.1")
+	newSessionImpl(cfg, []Option{
+		WithReconnectInterval(10 * time.Second),
+		WithReconnectBackoff(5, time.Second, time.Minute),
+	})
+	assert.Equal(t, 10*time.Second, cfg.ReconnectInterval)
+	assert.Equal(t, &gocql.ExponentialReconnectionPolicy{MaxRetries: 5, InitialInterval: time.Second, MaxInterval: time.Minute}, cfg.ReconnectionPolicy)
+}
+
+func TestConnectRetry(t *testing.T) {
+	errConnect := errors.New("no connections were made")
+	var attempts int
+	newGocqlSession = func(cfg gocql.ClusterConfig) (*gocql.Session, error) {
+		attempts++
+		if attempts < 4 {
+			return nil, errConnect
+		}
+		return &gocql.Session{}, nil
+	}
+	defer func() { newGocqlSession = gocql.NewSession }()
+
+	cfg := *gocql.NewCluster("127.0.0.1")
+
+	// Fail fast without retries
+	_, err := NewSession(cfg)
+	assert.Equal(t, errConnect, err)
+	assert.Equal(t, 1, attempts)
+
+	// Not enough retries
+	var events []RetryEvent
+	p := &RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond, OnRetry: func(e RetryEvent) {
+		events = append(events, e)
+	}}
+	attempts = 0
+	_, err = NewSession(cfg, WithConnectRetry(p))
+	assert.Equal(t, errConnect, err)
+	assert.Equal(t, 2, attempts)
+	assert.Len(t, events, 1)
+	assert.Equal(t, errConnect, events[0].Err)
+
+	// Wait ignores the maximum number of retries
+	attempts = 0
+	sess, err := NewSession(cfg, WithConnectRetry(p), WithStartupMode(StartWait))
+	assert.NoError(t, err)
+	assert.NotNil(t, sess)
+	assert.Equal(t, 4, attempts)
+
+	// Wait until the startup context is done
+	attempts = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	p = &RetryPolicy{MinBackoff: time.Millisecond, OnRetry: func(e RetryEvent) {
+		if e.Attempt == 2 {
+			cancel()
+		}
+	}}
+	_, err = NewSession(cfg, WithConnectRetry(p), WithStartupMode(StartWait), WithStartupContext(ctx))
+	assert.Equal(t, errConnect, err)
+	assert.Equal(t, 2, attempts)
+}
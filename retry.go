@@ -0,0 +1,233 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultRetryMinBackoff is the default backoff before the first retry.
+	DefaultRetryMinBackoff = 100 * time.Millisecond
+
+	// DefaultRetryMaxBackoff is the default maximum backoff between retries.
+	DefaultRetryMaxBackoff = 10 * time.Second
+)
+
+// RetryPolicy defines how the requests that fail with ErrTimeout or
+// ErrUnavailable are retried. The backoff doubles after each attempt, from
+// MinBackoff up to MaxBackoff, and a random jitter of up to half of the
+// backoff is subtracted to spread the retries of concurrent requests.
+//
+// Only idempotent requests are retried: SELECT statements, and INSERT,
+// UPDATE and DELETE statements without conditions (IF EXISTS, IF NOT EXISTS)
+// or counter updates. Raw writes are not considered idempotent, use
+// Idempotent to mark them as safe to retry.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries, the request is executed
+	// at most MaxRetries+1 times.
+	MaxRetries int
+	// MinBackoff is the backoff before the first retry, it defaults to
+	// DefaultRetryMinBackoff.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum backoff, it defaults to
+	// DefaultRetryMaxBackoff.
+	MaxBackoff time.Duration
+	// OnRetry is called before each retry, it can be used to collect retry
+	// metrics.
+	OnRetry func(e RetryEvent)
+}
+
+// NoRetry is the RetryPolicy that never retries, it can be used to disable
+// the retries of the session on a statement.
+var NoRetry = &RetryPolicy{}
+
+// RetryEvent contains the information of a retry passed to the OnRetry hook.
+// Command and Table are not set on batches and on the retries of the initial
+// connection.
+type RetryEvent struct {
+	Command   Command
+	Table     string
+	Statement string
+	// Attempt is the number of the retry, starting at 1.
+	Attempt int
+	// Err is the error of the previous attempt.
+	Err error
+	// Backoff is the time waited before the retry.
+	Backoff time.Duration
+}
+
+// WithRetry enables the retries with the given policy on all the idempotent
+// statements of the session. Statement.Retry can be used to override the
+// policy on a specific statement.
+func WithRetry(p *RetryPolicy) Option {
+	return func(s *SessionImpl) {
+		s.retry = p
+	}
+}
+
+// backoff returns the time to wait before the given retry.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	min, max := p.MinBackoff, p.MaxBackoff
+	if min <= 0 {
+		min = DefaultRetryMinBackoff
+	}
+	if max <= 0 {
+		max = DefaultRetryMaxBackoff
+	}
+	d := min
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d - time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// wait sleeps before the given retry, it returns false if the context is
+// done before.
+func (p *RetryPolicy) wait(ctx context.Context, e RetryEvent) bool {
+	if p.OnRetry != nil {
+		p.OnRetry(e)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	t := time.NewTimer(e.Backoff)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// isRetriable returns if a request that failed with err can be retried.
+func isRetriable(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrUnavailable)
+}
+
+// retryDriver retries the requests that fail with a retriable error.
+type retryDriver struct {
+	Driver
+	policy *RetryPolicy
+}
+
+// policyOf returns the policy used on a request.
+func (d retryDriver) policyOf(req *Request) *RetryPolicy {
+	if req.Retry != nil {
+		return req.Retry
+	}
+	return d.policy
+}
+
+// batchPolicyOf returns the policy used on a batch, the policy of the first
+// statement that overrides the one of the session.
+func (d retryDriver) batchPolicyOf(b *BatchRequest) *RetryPolicy {
+	for i := range b.Entries {
+		if b.Entries[i].Retry != nil {
+			return b.Entries[i].Retry
+		}
+	}
+	return d.policy
+}
+
+func (d retryDriver) Iter(req *Request) Rows {
+	rows := d.Driver.Iter(req)
+	if p := d.policyOf(req); p != nil && p.MaxRetries > 0 && req.Idempotent {
+		return &retryRows{Rows: rows, driver: d.Driver, req: req, policy: p}
+	}
+	return rows
+}
+
+func (d retryDriver) ExecBatch(b *BatchRequest) error {
+	err := d.Driver.ExecBatch(b)
+	p := d.batchPolicyOf(b)
+	if p == nil || !b.idempotent() {
+		return err
+	}
+	for attempt := 1; attempt <= p.MaxRetries && isRetriable(err); attempt++ {
+		e := RetryEvent{Statement: b.cql(), Attempt: attempt, Err: err, Backoff: p.backoff(attempt)}
+		if !p.wait(b.Context, e) {
+			return err
+		}
+		err = d.Driver.ExecBatch(b)
+	}
+	return err
+}
+
+// retryRows executes again the request if it fails before any row is
+// returned.
+type retryRows struct {
+	Rows
+	driver  Driver
+	req     *Request
+	policy  *RetryPolicy
+	attempt int
+	scanned bool
+	closed  bool
+	err     error
+}
+
+// retry closes the current rows and executes the request again if the error
+// is retriable. It returns the error of the rows if it cannot be retried.
+func (r *retryRows) retry() (bool, error) {
+	if r.closed {
+		return false, r.err
+	}
+	r.closed, r.err = true, r.Rows.Close()
+	err := r.err
+	if r.scanned || r.attempt >= r.policy.MaxRetries || !isRetriable(err) {
+		return false, err
+	}
+	r.attempt++
+	e := RetryEvent{
+		Command:   r.req.Command,
+		Table:     r.req.Table,
+		Statement: r.req.Statement,
+		Attempt:   r.attempt,
+		Err:       err,
+		Backoff:   r.policy.backoff(r.attempt),
+	}
+	if !r.policy.wait(r.req.Context, e) {
+		return false, err
+	}
+	r.Rows, r.closed = r.driver.Iter(r.req), false
+	return true, nil
+}
+
+func (r *retryRows) Scan(dest ...interface{}) bool {
+	for {
+		if r.Rows.Scan(dest...) {
+			r.scanned = true
+			return true
+		}
+		if ok, _ := r.retry(); !ok {
+			return false
+		}
+	}
+}
+
+func (r *retryRows) MapScan(m map[string]interface{}) bool {
+	for {
+		if r.Rows.MapScan(m) {
+			r.scanned = true
+			return true
+		}
+		if ok, _ := r.retry(); !ok {
+			return false
+		}
+	}
+}
+
+func (r *retryRows) Close() error {
+	for {
+		ok, err := r.retry()
+		if !ok {
+			return err
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyDriver fails the first n requests with err.
+type flakyDriver struct {
+	*testDriver
+	n   int
+	err error
+}
+
+func (d *flakyDriver) Iter(req *Request) Rows {
+	rows := d.testDriver.Iter(req)
+	if d.n > 0 {
+		d.n--
+		return &testRows{err: d.err}
+	}
+	return rows
+}
+
+func (d *flakyDriver) ExecBatch(b *BatchRequest) error {
+	d.testDriver.ExecBatch(b)
+	if d.n > 0 {
+		d.n--
+		return d.err
+	}
+	return nil
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := &RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		d := p.backoff(attempt + 1)
+		max *= time.Millisecond
+		assert.True(t, d >= max/2 && d <= max, "attempt %d: %s", attempt+1, d)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	DeleteRegistry()
+	timeout := testRequestError{gocql.ErrCodeWriteTimeout}
+
+	var events []RetryEvent
+	policy := &RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond, OnRetry: func(e RetryEvent) {
+		events = append(events, e)
+	}}
+	d := &flakyDriver{testDriver: &testDriver{}, n: 2, err: timeout}
+	sess := NewWithDriver(d, WithRetry(policy)).(*SessionImpl)
+
+	// Retried until success
+	assert.NoError(t, sess.Set(testStruct{F1: "foo"}))
+	assert.Len(t, d.requests, 3)
+	assert.Len(t, events, 2)
+	assert.Equal(t, 2, events[1].Attempt)
+	assert.Equal(t, InsertCmd, events[1].Command)
+	assert.Equal(t, "mytable", events[1].Table)
+	assert.True(t, errors.Is(events[1].Err, ErrTimeout))
+
+	// Retries exhausted
+	d.n, d.requests, events = 3, nil, nil
+	err := sess.Insert(testStruct{F1: "foo"}).Exec()
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Len(t, d.requests, 3)
+
+	// Reads
+	d.n, d.requests = 1, nil
+	d.result([]string{"f1", "f22"}, []interface{}{"foo", 1})
+	var ts testStruct
+	assert.NoError(t, sess.Select(&ts).Where(Eq("f1", "foo")).TypeScan())
+	assert.Equal(t, 1, ts.F2)
+	assert.Len(t, d.requests, 2)
+
+	// Not idempotent
+	for _, stmt := range []Statement{
+		sess.Update(testStruct{F1: "foo"}).Set("f22", Inc(1)),
+		sess.Insert(testStruct{F1: "foo"}).IfNotExists(),
+		sess.QueryRaw("UPDATE mytable SET f22 = ? WHERE f1 = ?", 1, "foo").(*RawQueryImpl).statement,
+		sess.Insert(testStruct{F1: "foo"}).Retry(NoRetry),
+	} {
+		d.n, d.requests = 1, nil
+		assert.Error(t, stmt.Exec())
+		assert.Len(t, d.requests, 1)
+	}
+	d.n, d.requests = 1, nil
+	assert.NoError(t, sess.QueryRaw("UPDATE mytable SET f22 = ? WHERE f1 = ?", 1, "foo").Idempotent().Exec())
+	assert.Len(t, d.requests, 2)
+
+	// Not retriable
+	d.n, d.requests, d.err = 1, nil, testRequestError{gocql.ErrCodeInvalid}
+	assert.Error(t, sess.Set(testStruct{F1: "foo"}))
+	assert.Len(t, d.requests, 1)
+
+	// Batches
+	d.n, d.err = 1, timeout
+	assert.NoError(t, sess.Batch().Add(sess.Insert(testStruct{F1: "foo"})).Apply())
+	assert.Len(t, d.batches, 2)
+	d.n, d.batches = 1, nil
+	assert.Error(t, sess.Batch().Add(sess.Insert(testStruct{F1: "foo"}).Retry(NoRetry)).Apply())
+	assert.Len(t, d.batches, 1)
+
+	// Rows are closed once
+	rows := &closeCountRows{Rows: &testRows{err: timeout}}
+	r := &retryRows{Rows: rows, req: &Request{}, policy: NoRetry}
+	assert.False(t, r.Scan())
+	assert.Error(t, r.Close())
+	assert.Equal(t, 1, rows.closed)
+
+	// Per statement policy
+	d = &flakyDriver{testDriver: &testDriver{}, n: 1, err: timeout}
+	sess = NewWithDriver(d).(*SessionImpl)
+	assert.Error(t, sess.Insert(testStruct{F1: "foo"}).Exec())
+	d.n, d.requests = 1, nil
+	assert.NoError(t, sess.Insert(testStruct{F1: "foo"}).Retry(policy).Exec())
+	assert.Len(t, d.requests, 2)
+	d.n, d.batches = 1, nil
+	assert.NoError(t, sess.Batch().Add(sess.Insert(testStruct{F1: "foo"}).Retry(policy)).Apply())
+	assert.Len(t, d.batches, 2)
+}
+
+// closeCountRows counts the calls to Close.
+type closeCountRows struct {
+	Rows
+	closed int
+}
+
+func (r *closeCountRows) Close() error {
+	r.closed++
+	return r.Rows.Close()
+}
@@ -0,0 +1,130 @@
+package ecql
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrUnknownCluster is returned by the statements executed on a name that is
+// not registered in the Router.
+var ErrUnknownCluster = errors.New("unknown cluster")
+
+// ErrUnsupportedSession is returned when a session that is not created by
+// ecql is registered in the Router.
+var ErrUnsupportedSession = errors.New("unsupported session, a *SessionImpl is required")
+
+// Router is a middleware that routes the statements to other sessions
+// registered with a name, for example to run the reporting queries against a
+// separate datacenter or cluster:
+//
+//	router := ecql.NewRouter()
+//	if err := router.Register("analytics", analyticsSess); err != nil {
+//		return err
+//	}
+//	router.Route("events_daily", "analytics")
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(router.Middleware()))
+//	// ...
+//	err = sess.Select(Event{}).On("analytics").Iter().ForEach(&e, fn)
+//
+// The statements are executed on the session set with Statement.On, or on
+// the session routed for its table, or on the session of the middleware. The
+// batches are routed by their first statement.
+type Router struct {
+	mu       sync.RWMutex
+	sessions map[string]Driver
+	tables   map[string]string
+}
+
+// NewRouter creates an empty Router.
+func NewRouter() *Router {
+	return &Router{
+		sessions: make(map[string]Driver),
+		tables:   make(map[string]string),
+	}
+}
+
+// Register registers the session s with the given name. It returns
+// ErrUnsupportedSession if s is not a *SessionImpl, like the mocks.
+func (r *Router) Register(name string, s Session) error {
+	sess, ok := s.(*SessionImpl)
+	if !ok {
+		return ErrUnsupportedSession
+	}
+	r.mu.Lock()
+	r.sessions[name] = sess.getDriver()
+	r.mu.Unlock()
+	return nil
+}
+
+// Route routes the statements on the table to the session with the given
+// name.
+func (r *Router) Route(table, name string) {
+	r.mu.Lock()
+	r.tables[table] = name
+	r.mu.Unlock()
+}
+
+// Middleware returns the middleware that routes the requests.
+func (r *Router) Middleware() Middleware {
+	return func(next Driver) Driver {
+		return &routerDriver{Driver: next, router: r}
+	}
+}
+
+// driver returns the driver of the request, or next if it is not routed.
+func (r *Router) driver(req *Request, next Driver) (Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name := req.Cluster
+	if name == "" {
+		if name = r.tables[req.Table]; name == "" {
+			return next, nil
+		}
+	}
+	d, ok := r.sessions[name]
+	if !ok {
+		return nil, ErrUnknownCluster
+	}
+	return d, nil
+}
+
+// routerDriver is the Driver used by the Router middleware.
+type routerDriver struct {
+	Driver
+	router *Router
+}
+
+func (d *routerDriver) Iter(req *Request) Rows {
+	driver, err := d.router.driver(req, d.Driver)
+	if err != nil {
+		return errorRows{err: newQueryError(req, err)}
+	}
+	return driver.Iter(req)
+}
+
+func (d *routerDriver) batchDriver(b *BatchRequest) (Driver, error) {
+	if len(b.Entries) == 0 {
+		return d.Driver, nil
+	}
+	driver, err := d.router.driver(&b.Entries[0], d.Driver)
+	if err != nil {
+		return nil, newBatchError(b, err)
+	}
+	return driver, nil
+}
+
+func (d *routerDriver) ExecBatch(b *BatchRequest) error {
+	driver, err := d.batchDriver(b)
+	if err != nil {
+		return err
+	}
+	return driver.ExecBatch(b)
+}
+
+func (d *routerDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	driver, err := d.batchDriver(b)
+	if err != nil {
+		return false, err
+	}
+	return driver.ExecBatchCAS(b, dest)
+}
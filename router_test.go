@@ -0,0 +1,46 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	DeleteRegistry()
+	analytics, ad := newTestSession()
+	router := NewRouter()
+	assert.NoError(t, router.Register("analytics", analytics))
+	assert.Equal(t, ErrUnsupportedSession, router.Register("other", struct{ Session }{analytics}))
+	sess, d := newTestSession(WithMiddleware(router.Middleware()))
+
+	// Default session
+	assert.NoError(t, sess.Set(testStruct{F1: "a"}))
+	assert.Len(t, d.requests, 1)
+	assert.Empty(t, ad.requests)
+
+	// Explicit session
+	d.result([]string{"f1"}, []interface{}{"a"})
+	ad.result([]string{"f1"}, []interface{}{"b"})
+	var ts testStruct
+	assert.NoError(t, sess.Select(&ts).On("analytics").TypeScan())
+	assert.Equal(t, "b", ts.F1)
+	assert.Equal(t, "analytics", ad.last().Cluster)
+	assert.Len(t, d.requests, 1)
+
+	err := sess.Select(&ts).On("other").TypeScan()
+	assert.True(t, errors.Is(err, ErrUnknownCluster))
+
+	// By table
+	router.Route("mytable", "analytics")
+	assert.NoError(t, sess.Get(&ts, "b"))
+	assert.Len(t, ad.requests, 2)
+	assert.NoError(t, sess.Batch().Add(sess.Insert(testStruct{F1: "a"})).Apply())
+	assert.Len(t, ad.batches, 1)
+	assert.Empty(t, d.batches)
+
+	router.Route("mytable", "other")
+	err = sess.Batch().Add(sess.Insert(testStruct{F1: "a"})).Apply()
+	assert.True(t, errors.Is(err, ErrUnknownCluster))
+}
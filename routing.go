@@ -0,0 +1,182 @@
+package ecql
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// RoutingKey returns the routing key of i, the serialized values of its
+// partition key columns. gocql uses the routing key to send the statements
+// directly to the replicas of the partition when a token aware host policy is
+// used.
+func RoutingKey(i interface{}) ([]byte, error) {
+	_, mapping, table := BindTable(i)
+	values := make([]interface{}, len(table.PartitionColumns))
+	for i, col := range table.PartitionColumns {
+		values[i] = mapping[col]
+	}
+	return routingKey(values)
+}
+
+// routingKey serializes the given partition key values. Single column keys
+// are just the serialized value, composite keys use the format:
+// <len><value>0x00 for each component.
+func routingKey(values []interface{}) ([]byte, error) {
+	if len(values) == 1 {
+		return marshalKey(values[0])
+	}
+
+	var key []byte
+	for _, v := range values {
+		b, err := marshalKey(v)
+		if err != nil {
+			return nil, err
+		}
+		var size [2]byte
+		binary.BigEndian.PutUint16(size[:], uint16(len(b)))
+		key = append(key, size[:]...)
+		key = append(key, b...)
+		key = append(key, 0)
+	}
+	return key, nil
+}
+
+// marshalKey serializes a partition key value with the CQL type that
+// corresponds to its Go type.
+func marshalKey(v interface{}) ([]byte, error) {
+	v = deref(v)
+	typ, ok := cqlTypeOf(v)
+	if !ok {
+		return nil, fmt.Errorf("ecql: cannot compute routing key of type %T", v)
+	}
+	return gocql.Marshal(gocql.NewNativeType(4, typ, ""), v)
+}
+
+// cqlTypeOf returns the default CQL type used for a Go value.
+func cqlTypeOf(v interface{}) (gocql.Type, bool) {
+	switch v.(type) {
+	case string:
+		return gocql.TypeVarchar, true
+	case []byte:
+		return gocql.TypeBlob, true
+	case bool:
+		return gocql.TypeBoolean, true
+	case int8:
+		return gocql.TypeTinyInt, true
+	case int16:
+		return gocql.TypeSmallInt, true
+	case int, int32:
+		return gocql.TypeInt, true
+	case int64:
+		return gocql.TypeBigInt, true
+	case float32:
+		return gocql.TypeFloat, true
+	case float64:
+		return gocql.TypeDouble, true
+	case gocql.UUID:
+		return gocql.TypeUUID, true
+	case time.Time:
+		return gocql.TypeTimestamp, true
+	default:
+		return gocql.TypeCustom, false
+	}
+}
+
+// tokenAwarePolicy marks a host selection policy as token aware.
+type tokenAwarePolicy struct {
+	gocql.HostSelectionPolicy
+}
+
+func newTokenAwarePolicy(fallback gocql.HostSelectionPolicy) gocql.HostSelectionPolicy {
+	return tokenAwarePolicy{gocql.TokenAwareHostPolicy(fallback)}
+}
+
+type dcContextKey struct{}
+
+// hostPolicy wraps the host selection policy of a session to restrict the
+// hosts used by statements pinned to a datacenter with Statement.InDC, and to
+// keep track of the state of the hosts.
+type hostPolicy struct {
+	gocql.HostSelectionPolicy
+	monitor *hostMonitor
+}
+
+// newHostPolicy returns the hostPolicy wrapping the given policy. The
+// optional interfaces of the policy used by gocql, gocql.ReadyPolicy and the
+// AddHosts method, are also implemented by the wrapper.
+func newHostPolicy(policy gocql.HostSelectionPolicy, monitor *hostMonitor) gocql.HostSelectionPolicy {
+	p := &hostPolicy{HostSelectionPolicy: policy, monitor: monitor}
+	if ready, ok := policy.(gocql.ReadyPolicy); ok {
+		return &readyHostPolicy{hostPolicy: p, ready: ready}
+	}
+	return p
+}
+
+// readyHostPolicy is the hostPolicy of the policies that implement
+// gocql.ReadyPolicy.
+type readyHostPolicy struct {
+	*hostPolicy
+	ready gocql.ReadyPolicy
+}
+
+func (p *readyHostPolicy) Ready() bool {
+	return p.ready.Ready()
+}
+
+func (p *hostPolicy) AddHosts(hosts []*gocql.HostInfo) {
+	for _, host := range hosts {
+		p.monitor.add(host)
+	}
+	if bulk, ok := p.HostSelectionPolicy.(interface{ AddHosts([]*gocql.HostInfo) }); ok {
+		bulk.AddHosts(hosts)
+		return
+	}
+	for _, host := range hosts {
+		p.HostSelectionPolicy.AddHost(host)
+	}
+}
+
+func (p *hostPolicy) AddHost(host *gocql.HostInfo) {
+	p.monitor.add(host)
+	p.HostSelectionPolicy.AddHost(host)
+}
+
+func (p *hostPolicy) RemoveHost(host *gocql.HostInfo) {
+	p.monitor.remove(host)
+	p.HostSelectionPolicy.RemoveHost(host)
+}
+
+func (p *hostPolicy) HostUp(host *gocql.HostInfo) {
+	p.monitor.setUp(host, true)
+	p.HostSelectionPolicy.HostUp(host)
+}
+
+func (p *hostPolicy) HostDown(host *gocql.HostInfo) {
+	p.monitor.setUp(host, false)
+	p.HostSelectionPolicy.HostDown(host)
+}
+
+func (p *hostPolicy) Pick(q gocql.ExecutableQuery) gocql.NextHost {
+	next := p.HostSelectionPolicy.Pick(q)
+
+	var dc string
+	if cq, ok := q.(interface{ Context() context.Context }); ok {
+		dc, _ = cq.Context().Value(dcContextKey{}).(string)
+	}
+	if dc == "" {
+		return next
+	}
+
+	return func() gocql.SelectedHost {
+		for {
+			host := next()
+			if host == nil || host.Info().DataCenter() == dc {
+				return host
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutingKey(t *testing.T) {
+	DeleteRegistry()
+
+	key, err := RoutingKey(testStruct{F1: "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo"), key)
+
+	type composite struct {
+		ID     string `cql:"id" cqlkey:"(id,bucket),time"`
+		Bucket int32  `cql:"bucket"`
+		Time   int64  `cql:"time"`
+	}
+	key, err = RoutingKey(&composite{ID: "ab", Bucket: 1, Time: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 2, 'a', 'b', 0, 0, 4, 0, 0, 0, 1, 0}, key)
+
+	uuid := MustUUID("a5450908-17d7-11e6-b9ec-542696d5770f")
+	key, err = routingKey([]interface{}{uuid})
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Bytes(), key)
+
+	_, err = routingKey([]interface{}{struct{}{}})
+	assert.Error(t, err)
+}
+
+func TestStatementRoutingKey(t *testing.T) {
+	s := NewStatement(&SessionImpl{}).RoutingKey("foo").InDC("dc1").(*StatementImpl)
+	assert.NoError(t, s.err)
+	assert.Equal(t, []byte("foo"), s.RoutingKeyValue)
+	assert.Equal(t, "dc1", s.DCValue)
+
+	s = NewStatement(&SessionImpl{}).RoutingKey(gocql.Consistency(1), "foo").(*StatementImpl)
+	assert.Error(t, s.err)
+	_, err := s.request()
+	assert.Error(t, err)
+}
+
+func TestStatementReadFrom(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithConsistency(LocalQuorumPreset))
+
+	var ts testStruct
+	assert.NoError(t, sess.Select(&ts).ReadFrom("analytics", gocql.LocalOne).Exec())
+	req := d.last()
+	assert.Equal(t, "analytics", req.DC)
+	assert.Equal(t, gocql.LocalOne, *req.Consistency)
+
+	// Writes ignore it
+	assert.NoError(t, sess.Insert(testStruct{F1: "a"}).ReadFrom("analytics", gocql.LocalOne).Exec())
+	req = d.last()
+	assert.Equal(t, "", req.DC)
+	assert.Equal(t, gocql.LocalQuorum, *req.Consistency)
+
+	// The session default is not modified
+	assert.NoError(t, sess.Select(&ts).Exec())
+	assert.Equal(t, gocql.LocalQuorum, *d.last().Consistency)
+}
+
+type readyPolicy struct {
+	gocql.HostSelectionPolicy
+	ready bool
+	added []*gocql.HostInfo
+}
+
+func (p *readyPolicy) Ready() bool {
+	return p.ready
+}
+
+func (p *readyPolicy) AddHosts(hosts []*gocql.HostInfo) {
+	p.added = append(p.added, hosts...)
+}
+
+func TestHostPolicy(t *testing.T) {
+	// Optional interfaces
+	inner := &readyPolicy{HostSelectionPolicy: gocql.RoundRobinHostPolicy(), ready: true}
+	monitor := newHostMonitor()
+	policy := newHostPolicy(inner, monitor)
+	ready, ok := policy.(gocql.ReadyPolicy)
+	if assert.True(t, ok) {
+		assert.True(t, ready.Ready())
+		inner.ready = false
+		assert.False(t, ready.Ready())
+	}
+
+	h1, h2 := &gocql.HostInfo{}, &gocql.HostInfo{}
+	h1.SetHostID("host-1")
+	h2.SetHostID("host-2")
+	hosts := []*gocql.HostInfo{h1, h2}
+	policy.(interface{ AddHosts([]*gocql.HostInfo) }).AddHosts(hosts)
+	assert.Equal(t, hosts, inner.added)
+	assert.Len(t, monitor.status().Hosts, 2)
+
+	// Policies without them
+	other := &hostsPolicy{HostSelectionPolicy: gocql.RoundRobinHostPolicy()}
+	policy = newHostPolicy(other, newHostMonitor())
+	_, ok = policy.(gocql.ReadyPolicy)
+	assert.False(t, ok)
+	policy.(interface{ AddHosts([]*gocql.HostInfo) }).AddHosts(hosts)
+	assert.Equal(t, hosts, other.added)
+}
+
+type hostsPolicy struct {
+	gocql.HostSelectionPolicy
+	added []*gocql.HostInfo
+}
+
+func (p *hostsPolicy) AddHost(host *gocql.HostInfo) {
+	p.added = append(p.added, host)
+}
@@ -0,0 +1,98 @@
+package ecql
+
+import (
+	"reflect"
+	"time"
+)
+
+// SaveIfChanged writes i only if its columns differ from the ones in the
+// database, and returns true if it has been written. If the type embeds
+// Tracked and i was loaded from the database, the changes are computed with
+// the tracked values, if not, the current row is read first. New rows are
+// inserted with Set, and existing rows are updated setting only the columns
+// that have changed:
+//
+//	written, err := sess.SaveIfChanged(&user)
+//
+// It avoids the mutations and tombstones of rewriting rows that are saved
+// often but rarely change.
+func (s *SessionImpl) SaveIfChanged(i interface{}) (bool, error) {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		i, v = p.Interface(), p
+	}
+	i, err := s.scope(i)
+	if err != nil {
+		return false, err
+	}
+	i = s.setBucket(i)
+	m, table := s.getRegistry().MapTable(i)
+	sv := structOf(i)
+
+	var changed []string
+	if tr := table.trackedOf(sv); tr != nil && tr.Loaded() {
+		changed = tr.changed(sv, table)
+	} else {
+		current := reflect.New(sv.Type())
+		err := NewStatement(s).Do(SelectCmd).Map(current.Interface()).Where(eqKey(m, table)).TypeScan()
+		switch err {
+		case nil:
+			changed = changedColumns(table, current.Elem(), sv)
+		case ErrNotFound:
+			return true, s.Set(i)
+		default:
+			return false, err
+		}
+	}
+
+	if len(changed) == 0 {
+		return false, nil
+	}
+	stmt := s.Update(i)
+	if st, ok := stmt.(*StatementImpl); ok && st.tracked == nil {
+		stmt.Columns(changed...)
+	}
+	return true, stmt.Exec()
+}
+
+// changedColumns returns the columns not in the primary key with different
+// values in the structs a and b.
+func changedColumns(table Table, a, b reflect.Value) []string {
+	var columns []string
+	for _, col := range table.Columns {
+		if table.isKey(col.Name) || col.Position == nil {
+			continue
+		}
+		fa, _ := table.field(col.Name, a)
+		fb, _ := table.field(col.Name, b)
+		if !sameField(fa, fb) {
+			columns = append(columns, col.Name)
+		}
+	}
+	return columns
+}
+
+// sameField returns true if the fields have the same value once stored. The
+// times are compared with millisecond precision, and nil and empty
+// collections are equal, like in the database.
+func sameField(a, b reflect.Value) bool {
+	for a.Kind() == reflect.Ptr && b.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	if ta, ok := a.Interface().(time.Time); ok {
+		tb, ok := b.Interface().(time.Time)
+		return ok && ta.Truncate(time.Millisecond).Equal(tb.Truncate(time.Millisecond))
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,94 @@
+package ecql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveIfChanged(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	columns := []string{"f1", "f22", "f3", "f4"}
+
+	// New row
+	written, err := sess.SaveIfChanged(testStruct{F1: "a", F2: 1})
+	assert.NoError(t, err)
+	assert.True(t, written)
+	assert.Len(t, d.requests, 2)
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ?", d.requests[0].Statement)
+	assert.Equal(t, "INSERT INTO mytable (f1,f22,f3,f4) VALUES (?,?,?,?)", d.last().Statement)
+
+	// Same values, nil and empty collections are equal
+	d.requests = nil
+	d.result(columns, []interface{}{"a", 1, map[string]string{}, nil})
+	written, err = sess.SaveIfChanged(&testStruct{F1: "a", F2: 1})
+	assert.NoError(t, err)
+	assert.False(t, written)
+	assert.Len(t, d.requests, 1)
+
+	// Only the changed columns are updated
+	d.requests = nil
+	f4 := "foo"
+	written, err = sess.SaveIfChanged(&testStruct{F1: "a", F2: 1, F4: &f4})
+	assert.NoError(t, err)
+	assert.True(t, written)
+	assert.Len(t, d.requests, 2)
+	assert.Equal(t, "UPDATE mytable SET f4 = ? WHERE f1 = ?", d.last().Statement)
+
+	// Tracked structs are not read again
+	d.result([]string{"id", "name", "email", "tags"}, []interface{}{"a", "foo", "foo@example.com", []string{"x"}})
+	var u trackedStruct
+	assert.NoError(t, sess.Get(&u, "a"))
+	d.requests = nil
+	written, err = sess.SaveIfChanged(&u)
+	assert.NoError(t, err)
+	assert.False(t, written)
+	assert.Len(t, d.requests, 0)
+	u.Email = "bar@example.com"
+	written, err = sess.SaveIfChanged(&u)
+	assert.NoError(t, err)
+	assert.True(t, written)
+	assert.Equal(t, "UPDATE tracked SET email = ? WHERE id = ?", d.last().Statement)
+}
+
+type SavedEmbedded struct {
+	Name string `cql:"name"`
+}
+
+type savedEmbeddedStruct struct {
+	ID             string `cql:"id" cqltable:"saved" cqlkey:"id"`
+	*SavedEmbedded `cql:"-"`
+}
+
+func TestChangedColumnsEmbedded(t *testing.T) {
+	r := NewRegistry()
+	table := r.GetTable(&savedEmbeddedStruct{SavedEmbedded: &SavedEmbedded{}})
+
+	a := structOf(savedEmbeddedStruct{ID: "a", SavedEmbedded: &SavedEmbedded{Name: "foo"}})
+	assert.Equal(t, []string{"name"}, changedColumns(table, a, structOf(savedEmbeddedStruct{ID: "a"})))
+	assert.Nil(t, changedColumns(table, structOf(savedEmbeddedStruct{ID: "a"}), structOf(savedEmbeddedStruct{ID: "b"})))
+}
+
+func TestSameField(t *testing.T) {
+	now := time.Now()
+	a, b := "a", "a"
+	for _, tc := range []struct {
+		a, b interface{}
+		same bool
+	}{
+		{1, 1, true},
+		{1, 2, false},
+		{&a, &b, true},
+		{&a, (*string)(nil), false},
+		{(*string)(nil), (*string)(nil), true},
+		{[]string(nil), []string{}, true},
+		{[]string{"a"}, []string{}, false},
+		{now, now.UTC().Truncate(time.Millisecond), true},
+		{now, now.Add(time.Second), false},
+	} {
+		assert.Equal(t, tc.same, sameField(reflect.ValueOf(tc.a), reflect.ValueOf(tc.b)), "%v %v", tc.a, tc.b)
+	}
+}
@@ -0,0 +1,194 @@
+package ecql
+
+import (
+	"context"
+	"sync"
+)
+
+// Priority is the priority class of a request.
+type Priority int
+
+const (
+	// InteractivePriority is the default priority, it is meant for the
+	// latency sensitive requests.
+	InteractivePriority Priority = iota
+	// BatchPriority is the priority of background requests, like scans or
+	// bulk loads, they only start if there are no interactive requests
+	// waiting.
+	BatchPriority
+)
+
+// WithPriority sets the default priority of the requests of the session. It
+// defaults to InteractivePriority.
+func WithPriority(p Priority) Option {
+	return func(s *SessionImpl) {
+		s.priority = p
+	}
+}
+
+// SchedulerConfig contains the maximum number of requests of each priority
+// class executed at the same time. A value of 0 does not limit them.
+type SchedulerConfig struct {
+	MaxInteractive int
+	MaxBatch       int
+}
+
+// Scheduler is a middleware that limits the number of requests in flight of
+// each priority class. The batch requests wait while there are interactive
+// requests waiting, so background scans yield to the foreground requests
+// under load:
+//
+//	sched := ecql.NewScheduler(ecql.SchedulerConfig{MaxInteractive: 200, MaxBatch: 20})
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(sched.Middleware()))
+//	// ...
+//	iter := sess.Select(Event{}).Priority(ecql.BatchPriority).Iter()
+//
+// A request is in flight until its rows are closed, so the iterators must
+// always be closed.
+type Scheduler struct {
+	mu       sync.Mutex
+	limits   [2]int
+	inflight [2]int
+	waiting  [2]int
+	changed  chan struct{}
+}
+
+// NewScheduler creates a Scheduler with the given configuration.
+func NewScheduler(config SchedulerConfig) *Scheduler {
+	return &Scheduler{
+		limits:  [2]int{config.MaxInteractive, config.MaxBatch},
+		changed: make(chan struct{}),
+	}
+}
+
+// Middleware returns the middleware that schedules the requests.
+func (s *Scheduler) Middleware() Middleware {
+	return func(next Driver) Driver {
+		return &schedulerDriver{Driver: next, sched: s}
+	}
+}
+
+// InFlight returns the number of requests in flight of the priority class.
+func (s *Scheduler) InFlight(p Priority) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inflight[class(p)]
+}
+
+// class returns the index of the priority class of p.
+func class(p Priority) int {
+	if p == BatchPriority {
+		return 1
+	}
+	return 0
+}
+
+// canRun returns true if a request of class c can start.
+func (s *Scheduler) canRun(c int) bool {
+	if s.limits[c] > 0 && s.inflight[c] >= s.limits[c] {
+		return false
+	}
+	return c == 0 || s.waiting[0] == 0
+}
+
+// broadcast wakes up the waiting requests.
+func (s *Scheduler) broadcast() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// acquire waits until a request with the priority p can start or the context
+// is done.
+func (s *Scheduler) acquire(ctx context.Context, p Priority) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c := class(p)
+
+	s.mu.Lock()
+	s.waiting[c]++
+	for !s.canRun(c) {
+		ch := s.changed
+		s.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.waiting[c]--
+			s.broadcast()
+			s.mu.Unlock()
+			return ctx.Err()
+		}
+		s.mu.Lock()
+	}
+	s.waiting[c]--
+	s.inflight[c]++
+	s.broadcast()
+	s.mu.Unlock()
+	return nil
+}
+
+// release marks a request with the priority p as done.
+func (s *Scheduler) release(p Priority) {
+	s.mu.Lock()
+	s.inflight[class(p)]--
+	s.broadcast()
+	s.mu.Unlock()
+}
+
+// schedulerDriver is the Driver used by the Scheduler middleware.
+type schedulerDriver struct {
+	Driver
+	sched *Scheduler
+}
+
+func (d *schedulerDriver) Iter(req *Request) Rows {
+	if err := d.sched.acquire(req.Context, req.Priority); err != nil {
+		return errorRows{err: wrapError(err, req)}
+	}
+	return &schedulerRows{Rows: d.Driver.Iter(req), sched: d.sched, priority: req.Priority}
+}
+
+func (d *schedulerDriver) ExecBatch(b *BatchRequest) error {
+	p := b.priority()
+	if err := d.sched.acquire(b.Context, p); err != nil {
+		return wrapBatchError(err, b)
+	}
+	defer d.sched.release(p)
+	return d.Driver.ExecBatch(b)
+}
+
+func (d *schedulerDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	p := b.priority()
+	if err := d.sched.acquire(b.Context, p); err != nil {
+		return false, wrapBatchError(err, b)
+	}
+	defer d.sched.release(p)
+	return d.Driver.ExecBatchCAS(b, dest)
+}
+
+// priority returns the priority of the batch, the one of its first
+// statement.
+func (b *BatchRequest) priority() Priority {
+	if len(b.Entries) == 0 {
+		return InteractivePriority
+	}
+	return b.Entries[0].Priority
+}
+
+// schedulerRows releases the request when the rows are closed.
+type schedulerRows struct {
+	Rows
+	sched    *Scheduler
+	priority Priority
+	closed   bool
+}
+
+func (r *schedulerRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.sched.release(r.priority)
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	DeleteRegistry()
+	sched := NewScheduler(SchedulerConfig{MaxInteractive: 1, MaxBatch: 1})
+	sess, d := newTestSession(WithMiddleware(sched.Middleware()))
+	d.result([]string{"f1"}, []interface{}{"foo"})
+
+	// A request is in flight until its rows are closed
+	var ts testStruct
+	iter := sess.Select(&ts).Priority(BatchPriority).Iter()
+	assert.True(t, iter.TypeScan(&ts))
+	assert.Equal(t, 1, sched.InFlight(BatchPriority))
+	assert.Equal(t, 0, sched.InFlight(InteractivePriority))
+	assert.Equal(t, BatchPriority, d.last().Priority)
+
+	// The batch class is full
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, sched.acquire(ctx, BatchPriority))
+	assert.NoError(t, sched.acquire(context.Background(), InteractivePriority))
+	sched.release(InteractivePriority)
+	assert.NoError(t, iter.Close())
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, 0, sched.InFlight(BatchPriority))
+
+	// Batch requests wait while interactive requests are waiting
+	assert.NoError(t, sched.acquire(context.Background(), InteractivePriority))
+	done := make(chan error)
+	go func() {
+		done <- sched.acquire(context.Background(), InteractivePriority)
+	}()
+	for {
+		sched.mu.Lock()
+		waiting := sched.waiting[0]
+		sched.mu.Unlock()
+		if waiting == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, sched.acquire(ctx, BatchPriority))
+	sched.release(InteractivePriority)
+	assert.NoError(t, <-done)
+	assert.NoError(t, sched.acquire(context.Background(), BatchPriority))
+	sched.release(BatchPriority)
+	sched.release(InteractivePriority)
+
+	// Session default and batches
+	sess, d = newTestSession(WithPriority(BatchPriority), WithMiddleware(sched.Middleware()))
+	assert.NoError(t, sess.Set(testStruct{F1: "foo"}))
+	assert.Equal(t, BatchPriority, d.last().Priority)
+	assert.NoError(t, sess.Batch().Add(sess.Insert(testStruct{F1: "foo"})).Apply())
+	assert.Equal(t, 0, sched.InFlight(BatchPriority))
+
+	// Canceled context
+	assert.NoError(t, sched.acquire(context.Background(), InteractivePriority))
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	rows := sched.Middleware()(d).Iter(&Request{Context: ctx, Statement: "SELECT * FROM mytable"})
+	assert.True(t, errors.Is(rows.Close(), context.Canceled))
+	sched.release(InteractivePriority)
+	assert.Equal(t, 0, sched.InFlight(InteractivePriority))
+}
@@ -0,0 +1,288 @@
+package ecql
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+// schemaColumn is a row of system_schema.columns.
+type schemaColumn struct {
+	Name            string
+	Kind            string
+	Position        int
+	ClusteringOrder string
+	Type            string
+}
+
+// kindOrder is the order of the columns in a table by kind.
+var kindOrder = map[string]int{
+	"partition_key": 0,
+	"clustering":    1,
+	"static":        2,
+	"regular":       3,
+}
+
+// DescribeTable returns the Table with the columns, types and keys of the
+// given table read from system_schema. The columns returned are not mapped to
+// any field, but the table can be used to validate that a registered type
+// matches the schema in the database. It returns ErrNotFound if the table does
+// not exist.
+func (s *SessionImpl) DescribeTable(keyspace, table string) (Table, error) {
+	iter := NewStatement(s).Do(SelectCmd).From("system_schema.columns").
+		Columns("column_name", "kind", "position", "clustering_order", "type").
+		Where(Eq("keyspace_name", keyspace), Eq("table_name", table)).Iter()
+
+	var columns []schemaColumn
+	var c schemaColumn
+	for iter.Scan(&c.Name, &c.Kind, &c.Position, &c.ClusteringOrder, &c.Type) {
+		columns = append(columns, c)
+		c = schemaColumn{}
+	}
+	if err := iter.Close(); err != nil {
+		return Table{}, err
+	}
+	if len(columns) == 0 {
+		return Table{}, ErrNotFound
+	}
+
+	sort.SliceStable(columns, func(i, j int) bool {
+		ki, kj := kindOrder[columns[i].Kind], kindOrder[columns[j].Kind]
+		if ki != kj {
+			return ki < kj
+		}
+		if ki < 2 {
+			return columns[i].Position < columns[j].Position
+		}
+		return columns[i].Name < columns[j].Name
+	})
+
+	t := Table{Name: table}
+	for _, c := range columns {
+		switch c.Kind {
+		case "partition_key":
+			t.PartitionColumns = append(t.PartitionColumns, c.Name)
+			t.KeyColumns = append(t.KeyColumns, c.Name)
+		case "clustering":
+			t.ClusteringColumns = append(t.ClusteringColumns, c.Name)
+			t.KeyColumns = append(t.KeyColumns, c.Name)
+			if strings.ToLower(c.ClusteringOrder) == "desc" {
+				t.ClusteringOrder = append(t.ClusteringOrder, Desc(c.Name))
+			} else {
+				t.ClusteringOrder = append(t.ClusteringOrder, Asc(c.Name))
+			}
+		}
+		t.Columns = append(t.Columns, Column{Name: c.Name, Type: c.Type})
+	}
+	return t, nil
+}
+
+// SchemaDiff contains the differences between a registered type and the
+// table in the database. Missing contains the columns of the type that are
+// not in the table, Unmapped the columns of the table that are not mapped to
+// a field, Types the columns with a Go type that cannot be used with the CQL
+// type, and PartitionColumns and ClusteringColumns are set to the keys in the
+// database if they differ from the ones in the type.
+type SchemaDiff struct {
+	Table             string
+	NotFound          bool
+	Missing           []string
+	Unmapped          []string
+	Types             []TypeMismatch
+	PartitionColumns  []string
+	ClusteringColumns []string
+}
+
+// TypeMismatch contains a column with a Go type not compatible with the CQL
+// type.
+type TypeMismatch struct {
+	Column  string
+	GoType  string
+	CQLType string
+}
+
+// Empty returns true if there are no differences.
+func (d SchemaDiff) Empty() bool {
+	return !d.NotFound && len(d.Missing) == 0 && len(d.Unmapped) == 0 && len(d.Types) == 0 &&
+		d.PartitionColumns == nil && d.ClusteringColumns == nil
+}
+
+// ValidateSchema compares the given types with the tables in the database
+// and returns the differences found, it returns an empty list if all the
+// types match the schema. Table names without a keyspace use the keyspace of
+// the session. Sharded types are validated against their base table.
+//
+// ValidateSchema can be used on startup to detect schema drifts:
+//
+//	diffs, err := sess.ValidateSchema(Tweet{}, User{})
+func (s *SessionImpl) ValidateSchema(types ...interface{}) ([]SchemaDiff, error) {
+	var diffs []SchemaDiff
+	for _, i := range types {
+		registry := s.getRegistry()
+		registry.GetTable(i)
+		table, _ := registry.get(structOf(i).Type())
+
+		keyspace, name := s.splitTableName(table.Name)
+		if keyspace == "" {
+			return nil, ErrNoKeyspace
+		}
+
+		diff := SchemaDiff{Table: table.Name}
+		live, err := s.DescribeTable(keyspace, name)
+		switch err {
+		case nil:
+			diff.compare(structOf(i).Type(), table, live)
+		case ErrNotFound:
+			diff.NotFound = true
+		default:
+			return nil, err
+		}
+
+		if !diff.Empty() {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, nil
+}
+
+// splitTableName returns the keyspace and name of a table, the keyspace
+// defaults to the one of the session.
+func (s *SessionImpl) splitTableName(name string) (string, string) {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return s.keyspace, name
+}
+
+// compare sets in d the differences between the table of the type t and the
+// live table.
+func (d *SchemaDiff) compare(t reflect.Type, table, live Table) {
+	types := make(map[string]string, len(live.Columns))
+	for _, c := range live.Columns {
+		types[c.Name] = c.Type
+	}
+
+	for _, c := range table.Columns {
+		cqlType, ok := types[c.Name]
+		if !ok {
+			d.Missing = append(d.Missing, c.Name)
+			continue
+		}
+		goType := t.FieldByIndex(c.Position).Type
+		compatible := compatibleType(goType, cqlType) || (c.Enum != nil && isTextType(cqlType))
+		if c.Encrypted {
+			compatible = strings.ToLower(cqlType) == "blob"
+		}
+		if c.Serializer != "" {
+			compatible = strings.ToLower(cqlType) == "blob" || isTextType(cqlType)
+		}
+		if !compatible {
+			d.Types = append(d.Types, TypeMismatch{Column: c.Name, GoType: goType.String(), CQLType: cqlType})
+		}
+	}
+
+	if table.Remaining == nil {
+		for _, c := range live.Columns {
+			if !table.hasColumn(c.Name) {
+				d.Unmapped = append(d.Unmapped, c.Name)
+			}
+		}
+	}
+
+	if !equalStrings(table.PartitionColumns, live.PartitionColumns) {
+		d.PartitionColumns = live.PartitionColumns
+	}
+	if !equalStrings(table.ClusteringColumns, live.ClusteringColumns) {
+		d.ClusteringColumns = live.ClusteringColumns
+		if d.ClusteringColumns == nil {
+			d.ClusteringColumns = []string{}
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compatibleType returns false if a value of type t cannot be used in a
+// column of the given CQL type. Unknown types like user defined types or
+// tuples are always compatible.
+func compatibleType(t reflect.Type, cqlType string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isNullType(t) {
+		t = t.Field(0).Type
+	}
+
+	cqlType = strings.ToLower(strings.TrimSpace(cqlType))
+	if strings.HasPrefix(cqlType, "frozen<") {
+		cqlType = strings.TrimSuffix(strings.TrimPrefix(cqlType, "frozen<"), ">")
+	}
+	if i := strings.IndexByte(cqlType, '<'); i >= 0 {
+		switch cqlType[:i] {
+		case "list":
+			return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+		case "set":
+			return t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map
+		case "map":
+			return t.Kind() == reflect.Map
+		case "vector":
+			return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Float32
+		default:
+			return true
+		}
+	}
+
+	switch cqlType {
+	case "text", "varchar", "ascii":
+		return t.Kind() == reflect.String
+	case "boolean":
+		return t.Kind() == reflect.Bool
+	case "tinyint", "smallint", "int", "bigint", "counter":
+		return isInteger(t)
+	case "varint":
+		return isInteger(t) || t == bigIntType
+	case "float", "double":
+		return t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+	case "decimal":
+		return t == decimalType || t.Kind() == reflect.Float64
+	case "duration":
+		return t == durationType || t == cqlDurType || t.Kind() == reflect.Int64 || t.Kind() == reflect.String
+	case "time":
+		return t == durationType || t.Kind() == reflect.Int64
+	case "uuid", "timeuuid":
+		return t == reflect.TypeOf(gocql.UUID{}) || t.Kind() == reflect.String ||
+			(t.Kind() == reflect.Array && t.Len() == 16) || t.Kind() == reflect.Slice
+	case "timestamp":
+		return t == timeType || isInteger(t)
+	case "date":
+		return t == timeType || t.Kind() == reflect.String
+	case "inet":
+		return t == ipType || t.Kind() == reflect.String
+	case "blob":
+		return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+	default:
+		return true
+	}
+}
+
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
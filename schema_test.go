@@ -0,0 +1,118 @@
+package ecql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeTable(t *testing.T) {
+	sess, d := newTestSession()
+	d.result([]string{"column_name", "kind", "position", "clustering_order", "type"},
+		[]interface{}{"text", "regular", -1, "none", "text"},
+		[]interface{}{"time", "clustering", 0, "desc", "timeuuid"},
+		[]interface{}{"author", "regular", -1, "none", "text"},
+		[]interface{}{"id", "partition_key", 0, "none", "uuid"},
+	)
+
+	table, err := sess.DescribeTable("ecql", "timeline")
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT column_name, kind, position, clustering_order, type FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"ecql", "timeline"}, d.last().Values)
+	assert.Equal(t, Table{
+		Name:              "timeline",
+		KeyColumns:        []string{"id", "time"},
+		PartitionColumns:  []string{"id"},
+		ClusteringColumns: []string{"time"},
+		ClusteringOrder:   []OrderBy{Desc("time")},
+		Columns: []Column{
+			{Name: "id", Type: "uuid"},
+			{Name: "time", Type: "timeuuid"},
+			{Name: "author", Type: "text"},
+			{Name: "text", Type: "text"},
+		},
+	}, table)
+
+	d.result(nil)
+	_, err = sess.DescribeTable("ecql", "missing")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+type schemaStruct struct {
+	ID      string  `cql:"id" cqltable:"ecql.items" cqlkey:"id,version"`
+	Version int     `cql:"version"`
+	Price   float64 `cql:"price"`
+	Tags    []string
+	Deleted bool
+}
+
+func TestValidateSchema(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	d.result([]string{"column_name", "kind", "position", "clustering_order", "type"},
+		[]interface{}{"id", "partition_key", 0, "none", "text"},
+		[]interface{}{"version", "clustering", 0, "asc", "int"},
+		[]interface{}{"price", "regular", -1, "none", "decimal"},
+		[]interface{}{"tags", "regular", -1, "none", "text"},
+		[]interface{}{"owner", "regular", -1, "none", "text"},
+	)
+
+	diffs, err := sess.ValidateSchema(schemaStruct{})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"ecql", "items"}, d.last().Values)
+	assert.Equal(t, []SchemaDiff{{
+		Table:    "ecql.items",
+		Missing:  []string{"deleted"},
+		Unmapped: []string{"owner"},
+		Types:    []TypeMismatch{{Column: "tags", GoType: "[]string", CQLType: "text"}},
+	}}, diffs)
+
+	// Key differences
+	d.result([]string{"column_name", "kind", "position", "clustering_order", "type"},
+		[]interface{}{"f1", "partition_key", 0, "none", "text"},
+		[]interface{}{"f22", "partition_key", 1, "none", "int"},
+		[]interface{}{"f3", "regular", -1, "none", "map<text, text>"},
+		[]interface{}{"f4", "regular", -1, "none", "text"},
+	)
+	diffs, err = sess.ValidateSchema(testStruct{})
+	assert.Equal(t, ErrNoKeyspace, err)
+	sess.keyspace = "ecql"
+	diffs, err = sess.ValidateSchema(testStruct{})
+	assert.NoError(t, err)
+	assert.Equal(t, []SchemaDiff{{Table: "mytable", PartitionColumns: []string{"f1", "f22"}}}, diffs)
+
+	// Not found
+	d.result(nil)
+	diffs, err = sess.ValidateSchema(testStruct{})
+	assert.NoError(t, err)
+	assert.Equal(t, []SchemaDiff{{Table: "mytable", NotFound: true}}, diffs)
+}
+
+func TestCompatibleType(t *testing.T) {
+	var tests = []struct {
+		value interface{}
+		typ   string
+		ok    bool
+	}{
+		{"", "varchar", true},
+		{"", "int", false},
+		{int64(0), "bigint", true},
+		{0, "counter", true},
+		{0.0, "int", false},
+		{gocql.UUID{}, "timeuuid", true},
+		{time.Time{}, "timestamp", true},
+		{[]byte{}, "blob", true},
+		{"", "blob", false},
+		{map[string]bool{}, "set<text>", true},
+		{[]int{}, "frozen<list<int>>", true},
+		{map[string]string{}, "list<text>", false},
+		{struct{}{}, "frozen<address>", true},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.ok, compatibleType(reflect.TypeOf(tc.value), tc.typ), "%T %s", tc.value, tc.typ)
+	}
+}
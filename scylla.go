@@ -0,0 +1,51 @@
+package ecql
+
+import (
+	"reflect"
+
+	"github.com/gocql/gocql"
+)
+
+// WithScylla configures a session for a Scylla cluster. Scylla shard-aware
+// routing requires the scylladb fork of gocql, that uses the same import path
+// than gocql, and can be used without changes in ecql adding a replace
+// directive in the go.mod of the application:
+//
+//	replace github.com/gocql/gocql => github.com/scylladb/gocql v1.14.0
+//
+// With the fork, connections are established to every shard of a host, and
+// statements are sent directly to the shard that owns the partition. For that
+// to happen the statements must be routed by token, so WithScylla wraps the
+// configured host selection policy in a token aware one, or uses a token
+// aware round robin policy if none is set. Policies that are already token
+// aware are not changed. WithLocalDC also sets a token aware policy, so both
+// options can be used in any order.
+//
+// It only has effect on sessions created with NewSession.
+func WithScylla() Option {
+	return func(s *SessionImpl) {
+		if s.cluster == nil {
+			return
+		}
+		policy := s.cluster.PoolConfig.HostSelectionPolicy
+		if isTokenAware(policy) {
+			return
+		}
+		if policy == nil {
+			policy = gocql.RoundRobinHostPolicy()
+		}
+		s.cluster.PoolConfig.HostSelectionPolicy = newTokenAwarePolicy(policy)
+	}
+}
+
+// gocqlTokenAwareType is the type of the policies created with
+// gocql.TokenAwareHostPolicy.
+var gocqlTokenAwareType = reflect.TypeOf(gocql.TokenAwareHostPolicy(nil))
+
+// isTokenAware returns true if the policy routes the statements by token.
+func isTokenAware(policy gocql.HostSelectionPolicy) bool {
+	if _, ok := policy.(tokenAwarePolicy); ok {
+		return true
+	}
+	return policy != nil && reflect.TypeOf(policy) == gocqlTokenAwareType
+}
@@ -0,0 +1,196 @@
+package ecql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SecretsManagerCredentials is a CredentialsProvider that reads the
+// credentials from a secret of AWS Secrets Manager. The secret is a JSON
+// object with the username and the password, like the secrets rotated by
+// the Secrets Manager rotation functions:
+//
+//	{"username": "app", "password": "..."}
+//
+// Secrets Manager is called every time the credentials are requested, it is
+// usually combined with CachedCredentials:
+//
+//	sm := ecql.NewSecretsManagerCredentialsFromEnv("prod/cassandra/app")
+//	sess, err := ecql.NewSession(cfg, ecql.WithCredentialsProvider(ecql.NewCachedCredentials(sm, 15*time.Minute)))
+//
+// UsernameKey and PasswordKey are the keys of the secret with the username
+// and the password, they default to "username" and "password". Endpoint
+// defaults to the regional endpoint of Secrets Manager, and Clock to
+// SystemClock.
+type SecretsManagerCredentials struct {
+	SecretID        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Endpoint        string
+	UsernameKey     string
+	PasswordKey     string
+	Client          *http.Client
+	Clock           Clock
+}
+
+// NewSecretsManagerCredentialsFromEnv creates a SecretsManagerCredentials for
+// the given secret using the standard AWS environment variables:
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, and AWS_REGION
+// or AWS_DEFAULT_REGION.
+func NewSecretsManagerCredentialsFromEnv(secretID string) *SecretsManagerCredentials {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &SecretsManagerCredentials{
+		SecretID:        secretID,
+		Region:          region,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Credentials implements the CredentialsProvider interface.
+func (c *SecretsManagerCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": c.SecretID})
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", c.Region)
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return Credentials{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	c.sign(req, body, "secretsmanager", nowOf(c.Clock).UTC())
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		if e.Type != "" {
+			return Credentials{}, fmt.Errorf("ecql: error reading secret %s: %s: %s %s", c.SecretID, resp.Status, e.Type, e.Message)
+		}
+		return Credentials{}, fmt.Errorf("ecql: error reading secret %s: %s", c.SecretID, resp.Status)
+	}
+
+	var value struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&value); err != nil {
+		return Credentials{}, err
+	}
+	var secret map[string]interface{}
+	if err := json.Unmarshal([]byte(value.SecretString), &secret); err != nil {
+		return Credentials{}, fmt.Errorf("ecql: secret %s is not a JSON object", c.SecretID)
+	}
+
+	usernameKey, passwordKey := c.UsernameKey, c.PasswordKey
+	if usernameKey == "" {
+		usernameKey = "username"
+	}
+	if passwordKey == "" {
+		passwordKey = "password"
+	}
+	username, _ := secret[usernameKey].(string)
+	password, _ := secret[passwordKey].(string)
+	if username == "" || password == "" {
+		return Credentials{}, fmt.Errorf("ecql: secret %s does not have %s and %s", c.SecretID, usernameKey, passwordKey)
+	}
+	return Credentials{Username: username, Password: password}, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request.
+func (c *SecretsManagerCredentials) sign(req *http.Request, body []byte, service string, t time.Time) {
+	amzdate := t.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzdate)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	scope := sigV4Scope(t, c.Region, service)
+	signature := sigV4Signature(c.SecretAccessKey, t, c.Region, service, amzdate, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, hex.EncodeToString(signature)))
+}
+
+// canonicalQuery returns the query sorted and encoded as required by SigV4.
+func canonicalQuery(q url.Values) string {
+	var params []string
+	for k, values := range q {
+		for _, v := range values {
+			params = append(params, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
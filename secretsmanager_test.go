@@ -0,0 +1,84 @@
+package ecql
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsManagerCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "20200609T224151Z", r.Header.Get("X-Amz-Date"))
+		assert.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKID/20200609/us-west-2/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature="))
+
+		var req struct{ SecretId string }
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(b, &req))
+		switch req.SecretId {
+		case "prod/cassandra":
+			json.NewEncoder(w).Encode(map[string]string{
+				"Name":         "prod/cassandra",
+				"SecretString": `{"username":"app","password":"secret","engine":"cassandra"}`,
+			})
+		case "prod/plain":
+			w.Write([]byte(`{"SecretString":"secret"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := &SecretsManagerCredentials{
+		SecretID:        "prod/cassandra",
+		Region:          "us-west-2",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+		Endpoint:        srv.URL,
+		Clock:           ClockFunc(func() time.Time { return time.Date(2020, 6, 9, 22, 41, 51, 0, time.UTC) }),
+	}
+	creds, err := c.Credentials(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "app", Password: "secret"}, creds)
+
+	c.UsernameKey = "user"
+	_, err = c.Credentials(context.Background())
+	assert.EqualError(t, err, "ecql: secret prod/cassandra does not have user and password")
+
+	c.SecretID = "prod/plain"
+	_, err = c.Credentials(context.Background())
+	assert.EqualError(t, err, "ecql: secret prod/plain is not a JSON object")
+
+	c.SecretID = "prod/missing"
+	_, err = c.Credentials(context.Background())
+	assert.EqualError(t, err, "ecql: error reading secret prod/missing: 400 Bad Request: ResourceNotFoundException Secrets Manager can't find the specified secret.")
+}
+
+func TestSecretsManagerSign(t *testing.T) {
+	// Example of the AWS Signature Version 4 documentation.
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	c := &SecretsManagerCredentials{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	c.sign(req, nil, "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		req.Header.Get("Authorization"))
+}
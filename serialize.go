@@ -0,0 +1,155 @@
+package ecql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+// Serializer encodes the values of the fields stored in a single column with
+// the serializer name as a tag option, like `cql:"data,json"`.
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var serializers = struct {
+	sync.RWMutex
+	m map[string]Serializer
+}{m: map[string]Serializer{
+	"json": jsonSerializer{},
+	"gob":  gobSerializer{},
+}}
+
+// RegisterSerializer registers a Serializer with the given name, so it can be
+// used as a tag option. The json and gob serializers are registered by
+// default. Other formats can be added with a few lines, for example
+// MessagePack using github.com/vmihailenco/msgpack:
+//
+//	type msgpackSerializer struct{}
+//
+//	func (msgpackSerializer) Marshal(v interface{}) ([]byte, error) { return msgpack.Marshal(v) }
+//	func (msgpackSerializer) Unmarshal(b []byte, v interface{}) error { return msgpack.Unmarshal(b, v) }
+//
+//	func init() {
+//		ecql.RegisterSerializer("msgpack", msgpackSerializer{})
+//	}
+//
+// The serializers must be registered before the types that use them.
+func RegisterSerializer(name string, s Serializer) {
+	serializers.Lock()
+	serializers.m[name] = s
+	serializers.Unlock()
+}
+
+// serializerOf returns the serializer with the given name.
+func serializerOf(name string) (Serializer, bool) {
+	serializers.RLock()
+	defer serializers.RUnlock()
+	s, ok := serializers.m[name]
+	return s, ok
+}
+
+// serializerOption returns the name of the serializer in the tag options.
+func serializerOption(opts tagOptions) string {
+	for _, opt := range opts {
+		if _, ok := serializerOf(opt); ok {
+			return opt
+		}
+	}
+	return ""
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonSerializer) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type gobSerializer struct{}
+
+func (gobSerializer) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobSerializer) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// serializedRef marshals and unmarshals the values of the fields stored
+// with a serializer. The values are stored in blob columns, or text columns
+// with the option type=text, and compressed if a blob column has the gzip
+// option.
+type serializedRef struct {
+	column Column
+	v      reflect.Value
+}
+
+// MarshalCQL implements gocql.Marshaler.
+func (s serializedRef) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	switch s.v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if s.v.IsNil() {
+			return nil, nil
+		}
+	}
+	serializer, _ := serializerOf(s.column.Serializer)
+	data, err := serializer.Marshal(s.v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	if s.column.Compressed {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	}
+	if info == nil {
+		return data, nil
+	}
+	return gocql.Marshal(info, data)
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler.
+func (s serializedRef) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if data == nil {
+		s.v.Set(reflect.Zero(s.v.Type()))
+		return nil
+	}
+	if info != nil {
+		var b []byte
+		if err := gocql.Unmarshal(info, data, &b); err != nil {
+			return err
+		}
+		data = b
+	}
+	if s.column.Compressed {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if data, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	serializer, _ := serializerOf(s.column.Serializer)
+	v := reflect.New(s.v.Type())
+	if err := serializer.Unmarshal(data, v.Interface()); err != nil {
+		return err
+	}
+	s.v.Set(v.Elem())
+	return nil
+}
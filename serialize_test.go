@@ -0,0 +1,124 @@
+package ecql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	Name string
+	Tags []string
+	Meta map[string]int
+}
+
+type upperSerializer struct{}
+
+func (upperSerializer) Marshal(v interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(v.(string))), nil
+}
+
+func (upperSerializer) Unmarshal(data []byte, v interface{}) error {
+	*v.(*string) = strings.ToLower(string(data))
+	return nil
+}
+
+func init() {
+	RegisterSerializer("upper", upperSerializer{})
+}
+
+type serializedStruct struct {
+	ID     string   `cql:"id" cqltable:"documents" cqlkey:"id"`
+	JSON   payload  `cql:"json,json,type=text"`
+	Gob    *payload `cql:"gob,gob"`
+	Zipped []string `cql:"zipped,json,gzip"`
+	Custom string   `cql:"custom,upper"`
+}
+
+func TestSerializedColumns(t *testing.T) {
+	r := NewRegistry()
+	table := r.GetTable(serializedStruct{})
+	assert.Equal(t, "json", table.Columns[1].Serializer)
+	assert.Equal(t, "text", table.Columns[1].Type)
+	assert.Equal(t, "gob", table.Columns[2].Serializer)
+	assert.Equal(t, "blob", table.Columns[2].Type)
+	assert.True(t, table.Columns[3].Compressed)
+	assert.Equal(t, "upper", table.Columns[4].Serializer)
+
+	src := serializedStruct{
+		ID:     "a",
+		JSON:   payload{Name: "foo", Tags: []string{"x"}, Meta: map[string]int{"n": 1}},
+		Gob:    &payload{Name: "bar"},
+		Zipped: []string{"a", "b", "c"},
+		Custom: "hello",
+	}
+	values := r.Bind(src)
+	marshal := func(i int, info gocql.TypeInfo) []byte {
+		b, err := gocql.Marshal(info, values[i])
+		assert.NoError(t, err)
+		return b
+	}
+	text := gocql.NewNativeType(4, gocql.TypeText, "")
+	blob := gocql.NewNativeType(4, gocql.TypeBlob, "")
+	jsonData := marshal(1, text)
+	assert.Equal(t, `{"Name":"foo","Tags":["x"],"Meta":{"n":1}}`, string(jsonData))
+	gobData := marshal(2, blob)
+	zipped := marshal(3, blob)
+	assert.Equal(t, []byte{0x1f, 0x8b}, zipped[:2])
+	assert.Equal(t, "HELLO", string(marshal(4, blob)))
+
+	var dst serializedStruct
+	m, _ := r.MapTable(&dst)
+	assert.NoError(t, gocql.Unmarshal(text, jsonData, m["json"]))
+	assert.NoError(t, gocql.Unmarshal(blob, gobData, m["gob"]))
+	assert.NoError(t, gocql.Unmarshal(blob, zipped, m["zipped"]))
+	assert.NoError(t, gocql.Unmarshal(blob, []byte("HELLO"), m["custom"]))
+	dst.ID = "a"
+	assert.Equal(t, src, dst)
+
+	// Nil values
+	src.Gob = nil
+	values = r.Bind(src)
+	assert.Nil(t, marshal(2, blob))
+	assert.NoError(t, gocql.Unmarshal(blob, nil, m["gob"]))
+	assert.Nil(t, dst.Gob)
+
+	// Invalid values
+	assert.Error(t, gocql.Unmarshal(blob, []byte("{"), m["zipped"]))
+	assert.Error(t, gocql.Unmarshal(text, []byte("{"), m["json"]))
+}
+
+func TestSerializedColumnsSchema(t *testing.T) {
+	r := NewRegistry()
+	typ := reflect.TypeOf(serializedStruct{})
+	table := r.GetTable(serializedStruct{})
+	cqlTypes, err := columnTypes(typ, table)
+	assert.NoError(t, err)
+	assert.Equal(t, "text", cqlTypes["json"])
+	assert.Equal(t, "blob", cqlTypes["gob"])
+
+	var d SchemaDiff
+	d.compare(typ, table, Table{Columns: []Column{
+		{Name: "id", Type: "text"}, {Name: "json", Type: "varchar"}, {Name: "gob", Type: "blob"},
+		{Name: "zipped", Type: "list<text>"}, {Name: "custom", Type: "blob"},
+	}})
+	assert.Equal(t, []TypeMismatch{{Column: "zipped", GoType: "[]string", CQLType: "list<text>"}}, d.Types)
+
+	snapshot := newColumnSnapshot(typ, table.Columns[3])
+	assert.Equal(t, "json", snapshot.Serializer)
+	assert.True(t, snapshot.Compressed)
+	assert.Equal(t, table.Columns[3].Serializer, snapshot.column().Serializer)
+}
+
+func TestSerializedGzipText(t *testing.T) {
+	type zippedText struct {
+		ID   string   `cql:"id" cqltable:"documents"`
+		Tags []string `cql:"tags,json,gzip,type=text"`
+	}
+	assert.PanicsWithValue(t, "gzip column tags is not a blob", func() {
+		NewRegistry().Register(zippedText{})
+	})
+}
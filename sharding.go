@@ -0,0 +1,124 @@
+package ecql
+
+import (
+	"reflect"
+	"time"
+)
+
+// Sharding defines a table split in multiple physical tables, for example one
+// table per month or per tenant. Name returns the physical table for a row of
+// the table base, using the values in the mapping of the row. Range returns
+// the physical tables with the rows between from and to, it is only required
+// by SelectRange.
+type Sharding struct {
+	Name  func(base string, mapping map[string]interface{}) string
+	Range func(base string, from, to time.Time) []string
+}
+
+// MonthlySharding returns a Sharding with one table per month using the
+// time.Time value in column, the physical tables are named like
+// events_2024_06.
+func MonthlySharding(column string) *Sharding {
+	return &Sharding{
+		Name: func(base string, mapping map[string]interface{}) string {
+			t, _ := deref(mapping[column]).(time.Time)
+			return monthlyTable(base, t)
+		},
+		Range: func(base string, from, to time.Time) []string {
+			var names []string
+			from = from.UTC()
+			from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+			for t := from; !t.After(to); t = t.AddDate(0, 1, 0) {
+				names = append(names, monthlyTable(base, t))
+			}
+			return names
+		},
+	}
+}
+
+func monthlyTable(base string, t time.Time) string {
+	return base + "_" + t.UTC().Format("2006_01")
+}
+
+// RegisterSharded adds the passed struct to the registry, the statements
+// built from values of this type use the physical table returned by the
+// sharding.
+func RegisterSharded(i interface{}, sharding *Sharding) {
+	DefaultRegistry.RegisterSharded(i, sharding)
+}
+
+// RegisterSharded is like the package function RegisterSharded but using the
+// registry r.
+func (r *Registry) RegisterSharded(i interface{}, sharding *Sharding) {
+	table := r.register(i)
+	table.Sharding = sharding
+	r.set(structOf(i).Type(), table)
+}
+
+// shard returns the table with the name of the physical table of the row
+// with the given mapping.
+func (t Table) shard(mapping map[string]interface{}) Table {
+	if t.Sharding != nil && t.Sharding.Name != nil {
+		t.Name = t.Sharding.Name(t.Name, mapping)
+	}
+	return t
+}
+
+// SelectRange executes a SELECT statement with the given conditions on all
+// the physical tables of the type of the elements of dest with rows between
+// from and to, and appends the rows to dest. The type must be registered with
+// RegisterSharded and the results are in the order of the tables returned
+// by the sharding.
+//
+//	var events []Event
+//	err := sess.SelectRange(&events, from, to, Eq("tenant", id), Ge("time", from), Le("time", to))
+func (s *SessionImpl) SelectRange(dest interface{}, from, to time.Time, cond ...Condition) error {
+	slice, elemType, isPtr, err := structSlice(dest)
+	if err != nil {
+		return err
+	}
+
+	// Register the type if necessary and get the base table
+	registry := s.getRegistry()
+	registry.GetTable(reflect.New(elemType).Interface())
+	table, _ := registry.get(elemType)
+	if table.Sharding == nil || table.Sharding.Range == nil {
+		return ErrNotSharded
+	}
+
+	names := table.Sharding.Range(table.Name, from, to)
+	results := make([][]reflect.Value, len(names))
+	futures := make([]*Future, len(names))
+	for i := range names {
+		i := i
+		futures[i] = s.async(func(sess *SessionImpl) error {
+			v := reflect.New(elemType)
+			stmt := NewStatement(sess).Do(SelectCmd).Map(v.Interface()).IntoTable(names[i])
+			if len(cond) > 0 {
+				stmt.Where(cond...)
+			}
+			iter := stmt.Iter()
+			for iter.TypeScan(v.Interface()) {
+				results[i] = append(results[i], v)
+				if s.tooManyRows(len(results[i])) {
+					break
+				}
+				v = reflect.New(elemType)
+			}
+			return iter.Close()
+		})
+	}
+	if err := WaitAll(futures...); err != nil {
+		return err
+	}
+
+	rows := s.appender(slice, isPtr)
+	for _, values := range results {
+		for _, v := range values {
+			if !rows.append(v) {
+				return rows.err()
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package ecql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type shardedEvent struct {
+	ID   string    `cql:"id" cqltable:"events" cqlkey:"id,time"`
+	Time time.Time `cql:"time"`
+	Name string    `cql:"name"`
+}
+
+func TestMonthlySharding(t *testing.T) {
+	s := MonthlySharding("time")
+	from := time.Date(2024, 11, 15, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "events_2024_11", s.Name("events", map[string]interface{}{"time": &from}))
+	assert.Equal(t, []string{"events_2024_11", "events_2024_12", "events_2025_01", "events_2025_02"}, s.Range("events", from, to))
+
+	// Other locations use the month in UTC
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	from = time.Date(2024, 12, 1, 1, 0, 0, 0, loc)
+	to = time.Date(2025, 1, 1, 1, 0, 0, 0, loc)
+	assert.Equal(t, "events_2024_11", s.Name("events", map[string]interface{}{"time": from}))
+	assert.Equal(t, []string{"events_2024_11", "events_2024_12"}, s.Range("events", from, to))
+}
+
+func TestSharding(t *testing.T) {
+	DeleteRegistry()
+	RegisterSharded(shardedEvent{}, MonthlySharding("time"))
+	sess, d := newTestSession()
+
+	e := shardedEvent{ID: "a", Time: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)}
+	assert.NoError(t, sess.Set(&e))
+	assert.Equal(t, "INSERT INTO events_2024_06 (id,time,name) VALUES (?,?,?)", d.last().Statement)
+	assert.NoError(t, sess.Insert(e).Exec())
+	assert.Equal(t, "INSERT INTO events_2024_06 (id,time,name) VALUES (?,?,?)", d.last().Statement)
+	assert.NoError(t, sess.Delete(e).Exec())
+	assert.Equal(t, "DELETE FROM events_2024_06 WHERE id = ? AND time = ?", d.last().Statement)
+	assert.NoError(t, sess.Count(e).Exec())
+	assert.Equal(t, "SELECT COUNT(1) FROM events_2024_06", d.last().Statement)
+
+	d.result([]string{"id", "name"}, []interface{}{"a", "foo"})
+	var events []shardedEvent
+	from := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, sess.SelectRange(&events, from, to, Eq("id", "a")))
+	assert.Equal(t, []shardedEvent{{ID: "a", Name: "foo"}, {ID: "a", Name: "foo"}}, events)
+
+	var stmts []string
+	for _, req := range d.requests[len(d.requests)-2:] {
+		stmts = append(stmts, req.Statement)
+	}
+	assert.ElementsMatch(t, []string{
+		"SELECT id,time,name FROM events_2024_05 WHERE id = ?",
+		"SELECT id,time,name FROM events_2024_06 WHERE id = ?",
+	}, stmts)
+
+	assert.Equal(t, ErrNotSharded, sess.SelectRange(&[]testStruct{}, from, to))
+	assert.Equal(t, ErrInvalidDestination, sess.SelectRange(events, from, to))
+}
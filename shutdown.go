@@ -0,0 +1,168 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSessionClosed is the error returned by the statements executed after
+// Shutdown is called.
+var ErrSessionClosed = errors.New("ecql: session closed")
+
+// Shutdown stops accepting new statements, waits for the statements in
+// flight, including the ones executed asynchronously and the batches of the
+// bulk writers, and then closes the driver of the session. If the context is done
+// before the statements finish, the session is closed anyway and an error
+// matching the context error is returned:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := sess.Shutdown(ctx); err != nil {
+//		log.Println(err)
+//	}
+//
+// The statements executed after Shutdown return ErrSessionClosed. The rows
+// buffered by a BulkWriter are not flushed, call Close on the writers before.
+func (s *SessionImpl) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var err error
+	select {
+	case <-s.drain.close():
+	case <-ctx.Done():
+		err = fmt.Errorf("ecql: shutdown with %d statements in flight: %w", s.drain.inFlight(), ctx.Err())
+	}
+	if s.base != nil || s.Session != nil {
+		s.baseDriver().Close()
+	}
+	return err
+}
+
+// drainer keeps track of the statements in flight of a session.
+type drainer struct {
+	mu     sync.Mutex
+	closed bool
+	active int
+	done   chan struct{}
+}
+
+func newDrainer() *drainer {
+	return &drainer{}
+}
+
+// acquire registers a new statement, it returns false if the session is
+// closed.
+func (d *drainer) acquire() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// release unregisters a statement registered with acquire.
+func (d *drainer) release() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.closed && d.active == 0 {
+		close(d.done)
+	}
+}
+
+// close stops accepting new statements and returns a channel that is closed
+// when there are no statements in flight.
+func (d *drainer) close() <-chan struct{} {
+	if d == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.closed = true
+		d.done = make(chan struct{})
+		if d.active == 0 {
+			close(d.done)
+		}
+	}
+	return d.done
+}
+
+// inFlight returns the number of statements in flight.
+func (d *drainer) inFlight() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.active
+}
+
+// drainerOf returns the drainer of the session if it is a *SessionImpl.
+func drainerOf(s Session) *drainer {
+	if sess, ok := s.(*SessionImpl); ok {
+		return sess.drain
+	}
+	return nil
+}
+
+// shutdownDriver rejects the requests after Shutdown and keeps track of the
+// ones in flight.
+type shutdownDriver struct {
+	Driver
+	drain *drainer
+}
+
+func (d shutdownDriver) Iter(req *Request) Rows {
+	// The statements executed asynchronously are already counted
+	if req.drainHeld {
+		return d.Driver.Iter(req)
+	}
+	if !d.drain.acquire() {
+		return errorRows{err: ErrSessionClosed}
+	}
+	return &drainRows{Rows: d.Driver.Iter(req), drain: d.drain}
+}
+
+func (d shutdownDriver) ExecBatch(b *BatchRequest) error {
+	if !d.drain.acquire() {
+		return ErrSessionClosed
+	}
+	defer d.drain.release()
+	return d.Driver.ExecBatch(b)
+}
+
+func (d shutdownDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	if !d.drain.acquire() {
+		return false, ErrSessionClosed
+	}
+	defer d.drain.release()
+	return d.Driver.ExecBatchCAS(b, dest)
+}
+
+// drainRows releases the request when the rows are closed.
+type drainRows struct {
+	Rows
+	drain *drainer
+	once  sync.Once
+}
+
+func (r *drainRows) Close() error {
+	err := r.Rows.Close()
+	r.once.Do(r.drain.release)
+	return err
+}
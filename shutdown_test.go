@@ -0,0 +1,93 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingDriver blocks the requests until release is closed.
+type blockingDriver struct {
+	*testDriver
+	started chan struct{}
+	release chan struct{}
+	closed  int
+}
+
+func (d *blockingDriver) Close() {
+	d.closed++
+}
+
+func (d *blockingDriver) Iter(req *Request) Rows {
+	d.started <- struct{}{}
+	<-d.release
+	return d.testDriver.Iter(req)
+}
+
+func TestShutdown(t *testing.T) {
+	DeleteRegistry()
+	d := &blockingDriver{testDriver: &testDriver{}, started: make(chan struct{}, 1), release: make(chan struct{})}
+	sess := NewWithDriver(d).(*SessionImpl)
+
+	f := sess.Insert(testStruct{F1: "a"}).ExecAsync()
+	<-d.started
+
+	done := make(chan error)
+	go func() {
+		done <- sess.Shutdown(context.Background())
+	}()
+
+	// New statements are rejected while draining
+	for closed := false; !closed; time.Sleep(time.Millisecond) {
+		sess.drain.mu.Lock()
+		closed = sess.drain.closed
+		sess.drain.mu.Unlock()
+	}
+	assert.Equal(t, ErrSessionClosed, sess.Set(testStruct{F1: "b"}))
+	assert.Equal(t, ErrSessionClosed, sess.Insert(testStruct{F1: "c"}).ExecAsync().Wait())
+	assert.Equal(t, ErrSessionClosed, sess.Batch().Add(sess.Insert(testStruct{F1: "c"})).Apply())
+	select {
+	case <-done:
+		t.Fatal("shutdown returned with statements in flight")
+	default:
+	}
+
+	close(d.release)
+	assert.NoError(t, f.Wait())
+	assert.NoError(t, <-done)
+	assert.Len(t, d.requests, 1)
+	assert.Equal(t, 1, d.closed)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	DeleteRegistry()
+	d := &blockingDriver{testDriver: &testDriver{}, started: make(chan struct{}, 1), release: make(chan struct{})}
+	sess := NewWithDriver(d).(*SessionImpl)
+	defer close(d.release)
+
+	sess.Insert(testStruct{F1: "a"}).ExecAsync()
+	<-d.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := sess.Shutdown(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.EqualError(t, err, "ecql: shutdown with 1 statements in flight: context deadline exceeded")
+}
+
+func TestShutdownBulkWriter(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	w := NewBulkWriter(sess, BulkWriterConfig{BatchSize: 1})
+	assert.NoError(t, w.Write(testStruct{F1: "a"}))
+	assert.NoError(t, w.Flush())
+	assert.NoError(t, sess.Shutdown(context.Background()))
+	assert.Len(t, d.batches, 1)
+
+	assert.NoError(t, w.Write(testStruct{F1: "b"}))
+	assert.Equal(t, ErrSessionClosed, w.Close())
+	assert.Equal(t, 0, sess.drain.inFlight())
+}
@@ -0,0 +1,210 @@
+package ecql
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RegistrySnapshot contains the Table information of the types in a
+// registry. It can be exported as JSON, or encoded with gob, so tools like
+// schema documentation generators can use the mapping of an application
+// without importing its types.
+type RegistrySnapshot struct {
+	Tables []TableSnapshot `json:"tables"`
+}
+
+// TableSnapshot contains the Table information of a registered type. Type is
+// the Go type with its package path, like "github.com/user/app.Event".
+type TableSnapshot struct {
+	Type              string           `json:"type"`
+	Name              string           `json:"name"`
+	PartitionColumns  []string         `json:"partitionColumns"`
+	ClusteringColumns []string         `json:"clusteringColumns,omitempty"`
+	Columns           []ColumnSnapshot `json:"columns"`
+	TTLColumns        []ColumnSnapshot `json:"ttlColumns,omitempty"`
+	Sharded           bool             `json:"sharded,omitempty"`
+	TenantColumn      string           `json:"tenantColumn,omitempty"`
+	Bucket            *TimeBucket      `json:"bucket,omitempty"`
+	TTLFrom           string           `json:"ttlFrom,omitempty"`
+	CaseSensitive     bool             `json:"caseSensitive,omitempty"`
+}
+
+// ColumnSnapshot contains the information of a column. Field is the path of
+// the struct field, like "Meta.Created" on embedded structs, and Type is the
+// CQL type of the column if it is known.
+type ColumnSnapshot struct {
+	Name       string   `json:"name"`
+	Field      string   `json:"field"`
+	GoType     string   `json:"goType"`
+	Type       string   `json:"type,omitempty"`
+	Enum       []string `json:"enum,omitempty"`
+	OmitEmpty  bool     `json:"omitEmpty,omitempty"`
+	Encrypted  bool     `json:"encrypted,omitempty"`
+	Serializer string   `json:"serializer,omitempty"`
+	Compressed bool     `json:"compressed,omitempty"`
+	Auto       bool     `json:"auto,omitempty"`
+}
+
+// Snapshot returns the Table information of the types in the registry,
+// sorted by table name.
+func (r *Registry) Snapshot() RegistrySnapshot {
+	types := make(map[reflect.Type]Table)
+	r.collect(types)
+
+	var snapshot RegistrySnapshot
+	for t, table := range types {
+		snapshot.Tables = append(snapshot.Tables, newTableSnapshot(t, table))
+	}
+	sort.Slice(snapshot.Tables, func(i, j int) bool {
+		a, b := snapshot.Tables[i], snapshot.Tables[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Type < b.Type
+	})
+	return snapshot
+}
+
+// collect adds to types the tables in the registry and its parents.
+func (r *Registry) collect(types map[reflect.Type]Table) {
+	if r.parent != nil {
+		r.parent.collect(types)
+	}
+	r.RLock()
+	for t, table := range r.data {
+		types[t] = table
+	}
+	r.RUnlock()
+}
+
+// ExportRegistry writes the snapshot of the DefaultRegistry as JSON.
+func ExportRegistry(w io.Writer) error {
+	return DefaultRegistry.Export(w)
+}
+
+// Export writes the snapshot of the registry as JSON.
+func (r *Registry) Export(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Snapshot())
+}
+
+// ImportRegistry reads a snapshot written by Export.
+func ImportRegistry(r io.Reader) (RegistrySnapshot, error) {
+	var snapshot RegistrySnapshot
+	err := json.NewDecoder(r).Decode(&snapshot)
+	return snapshot, err
+}
+
+// Table returns the snapshot of the table with the given name.
+func (s RegistrySnapshot) Table(name string) (TableSnapshot, bool) {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return TableSnapshot{}, false
+}
+
+// Table returns the Table described by the snapshot. The positions of the
+// columns are not set, they are only known with the Go type.
+func (t TableSnapshot) Table() Table {
+	table := Table{
+		Name:              t.Name,
+		PartitionColumns:  t.PartitionColumns,
+		ClusteringColumns: t.ClusteringColumns,
+		KeyColumns:        append(append([]string{}, t.PartitionColumns...), t.ClusteringColumns...),
+		TenantColumn:      t.TenantColumn,
+		Bucket:            t.Bucket,
+		TTLFrom:           t.TTLFrom,
+		CaseSensitive:     t.CaseSensitive,
+	}
+	for _, c := range t.Columns {
+		table.Columns = append(table.Columns, c.column())
+	}
+	for _, c := range t.TTLColumns {
+		table.TTLColumns = append(table.TTLColumns, c.column())
+	}
+	return table
+}
+
+// CreateTableCQL returns the CREATE TABLE statement of the table. All the
+// columns must have a CQL type.
+func (t TableSnapshot) CreateTableCQL() (string, error) {
+	cqlTypes, err := t.columnTypes()
+	if err != nil {
+		return "", err
+	}
+	return createTableQuery(t.Table(), cqlTypes), nil
+}
+
+// columnTypes returns the CQL type of each column, or ErrUnsupportedType if
+// a column does not have one.
+func (t TableSnapshot) columnTypes() (map[string]string, error) {
+	types := make(map[string]string, len(t.Columns))
+	for _, c := range t.Columns {
+		if c.Type == "" {
+			return nil, ErrUnsupportedType
+		}
+		types[c.Name] = c.Type
+	}
+	return types, nil
+}
+
+func (c ColumnSnapshot) column() Column {
+	return Column{
+		Name: c.Name, Type: c.Type, Enum: c.Enum, OmitEmpty: c.OmitEmpty, Encrypted: c.Encrypted,
+		Serializer: c.Serializer, Compressed: c.Compressed, Auto: c.Auto,
+	}
+}
+
+func newTableSnapshot(t reflect.Type, table Table) TableSnapshot {
+	s := TableSnapshot{
+		Type:              t.PkgPath() + "." + t.Name(),
+		Name:              table.Name,
+		PartitionColumns:  table.PartitionColumns,
+		ClusteringColumns: table.ClusteringColumns,
+		Sharded:           table.Sharding != nil,
+		TenantColumn:      table.TenantColumn,
+		Bucket:            table.Bucket,
+		TTLFrom:           table.TTLFrom,
+		CaseSensitive:     table.CaseSensitive,
+	}
+	for _, c := range table.Columns {
+		s.Columns = append(s.Columns, newColumnSnapshot(t, c))
+	}
+	for _, c := range table.TTLColumns {
+		s.TTLColumns = append(s.TTLColumns, newColumnSnapshot(t, c))
+	}
+	return s
+}
+
+func newColumnSnapshot(t reflect.Type, c Column) ColumnSnapshot {
+	names := make([]string, len(c.Position))
+	for i, n := range c.Position {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		f := t.Field(n)
+		names[i], t = f.Name, f.Type
+	}
+	s := ColumnSnapshot{
+		Name:       c.Name,
+		Field:      strings.Join(names, "."),
+		GoType:     t.String(),
+		Type:       c.Type,
+		Enum:       c.Enum,
+		OmitEmpty:  c.OmitEmpty,
+		Encrypted:  c.Encrypted,
+		Serializer: c.Serializer,
+		Compressed: c.Compressed,
+		Auto:       c.Auto,
+	}
+	if s.Type == "" {
+		s.Type, _ = cqlTypeName(t)
+	}
+	return s
+}
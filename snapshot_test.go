@@ -0,0 +1,73 @@
+package ecql
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type SnapshotMeta struct {
+	Created time.Time `cql:"created"`
+}
+
+type snapshotStruct struct {
+	SnapshotMeta `cql:"-"`
+	ID           string `cql:"id" cqltable:"snapshots" cqlkey:"(id),seq"`
+	Seq          int    `cql:"seq"`
+	Status       string `cql:"status,type=ascii,omitempty"`
+}
+
+func TestRegistrySnapshot(t *testing.T) {
+	r := NewRegistry()
+	r.Register(snapshotStruct{})
+	o := r.overlay()
+	o.Register(testStruct{})
+
+	// The embedded structs are also registered
+	snapshot := o.Snapshot()
+	assert.Len(t, snapshot.Tables, 3)
+	assert.Equal(t, "SnapshotMeta", snapshot.Tables[0].Name)
+	assert.Equal(t, "mytable", snapshot.Tables[1].Name)
+	assert.Equal(t, "github.com/maraino/ecql.testStruct", snapshot.Tables[1].Type)
+
+	s, ok := snapshot.Table("snapshots")
+	assert.True(t, ok)
+	assert.Equal(t, TableSnapshot{
+		Type:              "github.com/maraino/ecql.snapshotStruct",
+		Name:              "snapshots",
+		PartitionColumns:  []string{"id"},
+		ClusteringColumns: []string{"seq"},
+		Columns: []ColumnSnapshot{
+			{Name: "created", Field: "SnapshotMeta.Created", GoType: "time.Time", Type: "timestamp"},
+			{Name: "id", Field: "ID", GoType: "string", Type: "text"},
+			{Name: "seq", Field: "Seq", GoType: "int", Type: "int"},
+			{Name: "status", Field: "Status", GoType: "string", Type: "ascii", OmitEmpty: true},
+		},
+	}, s)
+	_, ok = snapshot.Table("other")
+	assert.False(t, ok)
+
+	table := s.Table()
+	assert.Equal(t, []string{"id", "seq"}, table.KeyColumns)
+	assert.Equal(t, []string{"created", "id", "seq", "status"}, table.columnNames())
+
+	// JSON
+	var buf bytes.Buffer
+	assert.NoError(t, o.Export(&buf))
+	imported, err := ImportRegistry(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, imported)
+
+	_, err = ImportRegistry(bytes.NewBufferString("{"))
+	assert.Error(t, err)
+
+	// Gob
+	buf.Reset()
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(snapshot))
+	imported = RegistrySnapshot{}
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&imported))
+	assert.Equal(t, snapshot, imported)
+}
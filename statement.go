@@ -1,9 +1,13 @@
 package ecql
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/gocql/gocql"
 )
@@ -16,29 +20,84 @@ const (
 	DeleteCmd
 	UpdateCmd
 	CountCmd
+	TruncateCmd
+	DropTableCmd
+	// SchemaCmd is used in the requests that modify the schema, like the
+	// ones sent by AutoMigrate, it cannot be used to build statements.
+	SchemaCmd
 )
 
+var commandStrings = map[Command]string{
+	SelectCmd:    "SELECT",
+	InsertCmd:    "INSERT",
+	DeleteCmd:    "DELETE",
+	UpdateCmd:    "UPDATE",
+	CountCmd:     "COUNT",
+	TruncateCmd:  "TRUNCATE",
+	DropTableCmd: "DROP TABLE",
+	SchemaCmd:    "SCHEMA",
+}
+
+// String returns the name of the command.
+func (c Command) String() string {
+	if s, ok := commandStrings[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("Command(%d)", int(c))
+}
+
+// Statement is a CQL statement built with the query builder. The builder
+// methods modify and return the same statement, so a Statement is not safe
+// for concurrent use. Use Clone to get an independent copy, or Immutable to
+// get a statement that can be shared by multiple goroutines.
 type Statement interface {
 	TypeScan() error
 	Scan(i ...interface{}) error
+	MapRows() ([]map[string]interface{}, error)
+	Page(dest interface{}, cursor string, n int) (string, error)
+	Count() (int64, error)
+	Exists() (bool, error)
+	MinOf(col string) (interface{}, error)
+	MaxOf(col string) (interface{}, error)
+	SumOf(col string) (interface{}, error)
+	AvgOf(col string) (interface{}, error)
 	Exec() error
+	ExecInfo() (QueryInfo, error)
+	ExecAsync() *Future
 	Iter() Iter
 	BuildQuery() (string, []interface{})
+	Template() (*StatementTemplate, error)
 	Do(cmd Command) Statement
 	From(table string) Statement
+	IntoTable(name string) Statement
 	FromType(i interface{}) Statement
 	Columns(columns ...string) Statement
 	Set(column string, value interface{}) Statement
 	Where(cond ...Condition) Statement
+	AndWhere(cond ...Condition) Statement
 	OrderBy(order ...OrderBy) Statement
+	OrderByAnn(column string, vector []float32) Statement
 	AllowFiltering() Statement
 	IfExists() Statement
 	IfNotExists() Statement
+	UnsetEmpty() Statement
 	Bind(i interface{}) Statement
 	Map(i interface{}) Statement
 	Limit(n int) Statement
 	TTL(seconds int) Statement
 	Timestamp(microseconds int64) Statement
+	RoutingKey(values ...interface{}) Statement
+	InDC(dc string) Statement
+	ReadFrom(dc string, c gocql.Consistency) Statement
+	Consistency(c gocql.Consistency) Statement
+	Retry(p *RetryPolicy) Statement
+	Idempotent() Statement
+	Priority(p Priority) Statement
+	On(cluster string) Statement
+	WithContext(ctx context.Context) Statement
+	Timeout(d time.Duration) Statement
+	Payload(key string, value []byte) Statement
+	Clone() Statement
 }
 
 type StatementImpl struct {
@@ -48,6 +107,8 @@ type StatementImpl struct {
 	ColumnNames         []string
 	Conditions          *Condition
 	Orders              []OrderBy
+	AnnColumn           string
+	AnnVector           Vector
 	Assignments         map[string]interface{}
 	LimitValue          int
 	TTLValue            int
@@ -55,8 +116,26 @@ type StatementImpl struct {
 	AllowFilteringValue bool
 	IfExistsValue       bool
 	IfNotExistsValue    bool
+	RoutingKeyValue     []byte
+	DCValue             string
+	ConsistencyValue    *gocql.Consistency
 	mapping             map[string]interface{}
+	bound               interface{}
+	dest                reflect.Value
+	tracked             *Tracked
+	unsetEmpty          bool
+	rawCQL              string
+	retry               *RetryPolicy
+	idempotent          bool
+	priority            *Priority
+	cluster             string
+	readDC              string
+	readConsistency     *gocql.Consistency
+	ctx                 context.Context
+	timeout             time.Duration
+	payload             map[string][]byte
 	values              []interface{}
+	err                 error
 }
 
 func NewStatement(sess *SessionImpl) Statement {
@@ -64,40 +143,156 @@ func NewStatement(sess *SessionImpl) Statement {
 }
 
 func (s *StatementImpl) TypeScan() error {
-	if query, err := s.query(); err != nil {
+	if req, err := s.request(); err != nil {
 		return err
 	} else {
-		return query.MapScan(s.mapping)
+		if err := mapScanRow(s.session.getDriver().Iter(req), s.mapping); err != nil {
+			return err
+		}
+		s.Table.scanned(s.dest, s.mapping)
+		return afterScan(contextOf(req.Context), s.dest)
 	}
 }
 
 func (s *StatementImpl) Scan(i ...interface{}) error {
-	if query, err := s.query(); err != nil {
+	if req, err := s.request(); err != nil {
 		return err
 	} else {
-		return query.Scan(i...)
+		return scanRow(s.session.getDriver().Iter(req), i...)
 	}
 }
 
+// MapRows executes the statement and returns all the rows as maps from the
+// column name to the value. It can be used to run ad-hoc queries on tables
+// without a registered type:
+//
+//	rows, err := NewStatement(sess).Do(SelectCmd).From("tweet").MapRows()
+func (s *StatementImpl) MapRows() ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	iter := s.Iter()
+	for {
+		m := make(map[string]interface{})
+		if !iter.MapScan(m) {
+			break
+		}
+		rows = append(rows, m)
+	}
+	return rows, iter.Close()
+}
+
 // Exec builds the query statement and executes it returning nil or the gocql
 // error. On DELETE and UPDATE statements, the behavior of Exec differs from
 // gocql if IfExists() is used, in this case, ecql will perform a ScanCAS and
 // return ErrNotFound if the query was not applied.
-func (s *StatementImpl) Exec() error {
-	if query, err := s.query(); err != nil {
+func (s *StatementImpl) Exec() (err error) {
+	if s.unchanged() {
+		return nil
+	}
+	if req, err := s.request(); err != nil {
 		return err
 	} else {
+		defer s.invalidateCache()
+		defer func() {
+			if err == nil && s.tracked != nil {
+				s.tracked.snapshot(s.dest, s.Table)
+			}
+		}()
+
+		rows := s.session.getDriver().Iter(req)
+
 		// Perform a ScanCAS and reeturn an error if the update/delete are not successful.
 		if s.IfExistsValue && (s.Command == UpdateCmd || s.Command == DeleteCmd) {
-			if applied, err := query.ScanCAS(); err != nil {
+			if applied, err := mapScanCAS(rows, make(map[string]interface{})); err != nil {
 				return err
 			} else if applied == false {
 				return ErrNotFound
 			}
-			return nil
+			return s.afterWrite(contextOf(req.Context))
 		}
 
-		return query.Exec()
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		return s.afterWrite(contextOf(req.Context))
+	}
+}
+
+// ExecInfo builds the query statement, executes it and returns the execution
+// information reported by gocql. On conditional statements (IfExists or
+// IfNotExists) the Applied flag and the previous values of the row are set,
+// but unlike Exec, ErrNotFound is not returned if the statement is not applied.
+// Only the first page of the result is fetched, so the Rows reported on
+// SELECT statements are the ones of the first page; use Iter to read all
+// of them.
+func (s *StatementImpl) ExecInfo() (QueryInfo, error) {
+	if s.unchanged() {
+		return QueryInfo{}, nil
+	}
+	req, err := s.request()
+	if err != nil {
+		return QueryInfo{}, err
+	}
+	defer s.invalidateCache()
+
+	var applied bool
+	var previous map[string]interface{}
+	rows := s.session.getDriver().Iter(req)
+	if s.IfExistsValue || s.IfNotExistsValue {
+		previous = make(map[string]interface{})
+		applied, err = mapScanCAS(rows, previous)
+	} else {
+		err = rows.Close()
+		applied = (err == nil)
+	}
+
+	if err == nil && applied {
+		err = s.afterWrite(contextOf(req.Context))
+	}
+	if err == nil && applied && s.tracked != nil {
+		s.tracked.snapshot(s.dest, s.Table)
+	}
+	info := rows.Info()
+	info.Applied = applied
+	if !applied {
+		info.Previous = previous
+	}
+	return info, err
+}
+
+// ExecAsync executes the statement in a new goroutine and returns a Future
+// to wait for its result. The number of statements running at the same time
+// is limited by the session, see WithMaxConcurrency.
+func (s *StatementImpl) ExecAsync() *Future {
+	// The statement is cloned before starting the goroutine, so it can be
+	// modified after ExecAsync returns.
+	c := s.Clone().(*StatementImpl)
+	return s.session.async(func(sess *SessionImpl) error {
+		c.session = sess
+		return c.Exec()
+	})
+}
+
+// unchanged returns true on updates of tracked structs without changes.
+func (s *StatementImpl) unchanged() bool {
+	return s.tracked != nil && s.Command == UpdateCmd && len(s.ColumnNames) == 0 && len(s.Assignments) == 0
+}
+
+// invalidateCache removes from the session cache the row modified by the
+// statement if the statement was built from a struct, or all the rows of the
+// table on other writes.
+func (s *StatementImpl) invalidateCache() {
+	if s.session.cache == nil || s.Table.Name == "" {
+		return
+	}
+	switch s.Command {
+	case InsertCmd, UpdateCmd, DeleteCmd:
+		if s.mapping != nil && len(s.Table.KeyColumns) > 0 {
+			s.session.cache.Delete(s.session.cacheKey(s.Table.Name, s.Table.keyValues(s.mapping)))
+		} else {
+			s.session.versions.invalidate(s.Table.Name)
+		}
+	case TruncateCmd, DropTableCmd:
+		s.session.versions.invalidate(s.Table.Name)
 	}
 }
 
@@ -107,42 +302,220 @@ func (s *StatementImpl) Iter() Iter {
 	}
 }
 
-func (s *StatementImpl) query() (*gocql.Query, error) {
+// beforeWrite calls the callback of the struct of a write before it is
+// executed, and binds again the values modified by the callback.
+func (s *StatementImpl) beforeWrite(ctx context.Context) error {
+	if s.bound == nil {
+		return nil
+	}
+	p, err := beforeWrite(ctx, s.Command, s.bound)
+	if err != nil || p == nil {
+		return err
+	}
+	s.bound = p
+	if s.Command != DeleteCmd {
+		s.values, s.mapping, _ = s.session.getRegistry().BindTable(p)
+	}
+	return nil
+}
+
+// afterWrite calls the callback of the struct of a write after it is
+// executed.
+func (s *StatementImpl) afterWrite(ctx context.Context) error {
+	if s.bound == nil {
+		return nil
+	}
+	return afterWrite(ctx, s.Command, s.bound)
+}
+
+// request returns the Request used to execute the statement.
+func (s *StatementImpl) request() (*Request, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if err := s.beforeWrite(contextOf(s.context())); err != nil {
+		return nil, err
+	}
+	if s.bound != nil && (s.Command == InsertCmd || s.Command == UpdateCmd) {
+		if err := s.session.validate(s.Table.Name, s.bound); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	s.prepareWrite()
 	stmt, args := s.BuildQuery()
-	return s.session.Query(stmt, args...), nil
+	req := s.session.request(s.Command, s.Table.Name, stmt, args)
+	if s.ConsistencyValue != nil {
+		req.Consistency = s.ConsistencyValue
+	}
+	req.RoutingKey = s.RoutingKeyValue
+	req.PartitionKey = s.partitionValues()
+	req.DC = s.DCValue
+	if s.readConsistency != nil && (s.Command == SelectCmd || s.Command == CountCmd) {
+		req.DC, req.Consistency = s.readDC, s.readConsistency
+	}
+	req.Idempotent = s.isIdempotent()
+	req.Retry = s.retry
+	if s.priority != nil {
+		req.Priority = *s.priority
+	}
+	req.Cluster = s.cluster
+	req.Context = s.context()
+	req.Row = s.row()
+	if s.timeout > 0 {
+		req.Timeout = s.timeout
+	}
+	if s.payload != nil {
+		req.Payload = mergePayload(req.Payload, s.payload)
+	}
+	return req, nil
+}
+
+// check scopes the statement to the tenant of the session and validates it
+// before building the query.
+func (s *StatementImpl) check() error {
+	if err := s.scopeTenant(); err != nil {
+		return err
+	}
+	if err := s.checkIdents(); err != nil {
+		return err
+	}
+	return s.Table.checkOrder(s.Orders)
+}
+
+// isIdempotent returns true if the statement can be safely retried.
+func (s *StatementImpl) isIdempotent() bool {
+	if s.idempotent {
+		return true
+	}
+	switch s.Command {
+	case SelectCmd, CountCmd:
+		return true
+	case InsertCmd, UpdateCmd, DeleteCmd:
+		if s.rawCQL != "" || s.IfExistsValue || s.IfNotExistsValue {
+			return false
+		}
+		for _, v := range s.Assignments {
+			switch v.(type) {
+			case increaseType, decreaseType:
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// partitionValues returns the values of the partition key of the statement
+// if they are known.
+func (s *StatementImpl) partitionValues() []interface{} {
+	if len(s.Table.PartitionColumns) == 0 {
+		return nil
+	}
+	if s.mapping != nil && (s.Command == InsertCmd || s.Command == UpdateCmd) {
+		return s.Table.partitionValues(s.mapping)
+	}
+	if s.Conditions == nil {
+		return nil
+	}
+
+	eqs := s.Conditions.equalities()
+	values := make([]interface{}, len(s.Table.PartitionColumns))
+	for i, col := range s.Table.PartitionColumns {
+		v, ok := eqs[col]
+		if !ok {
+			return nil
+		}
+		values[i] = deref(v)
+	}
+	return values
+}
+
+// row returns the values of the columns written by the statement, on deletes
+// only the values of the key columns.
+func (s *StatementImpl) row() map[string]interface{} {
+	if s.rawCQL != "" {
+		return nil
+	}
+	row := make(map[string]interface{})
+	if s.Conditions != nil && (s.Command == UpdateCmd || s.Command == DeleteCmd) {
+		for col, v := range s.Conditions.equalities() {
+			row[col] = v
+		}
+	}
+	switch s.Command {
+	case InsertCmd, UpdateCmd:
+		if s.mapping != nil {
+			cols := s.ColumnNames
+			if len(cols) == 0 {
+				cols = s.Table.columnNames()
+			}
+			for _, col := range append(cols[:len(cols):len(cols)], s.Table.KeyColumns...) {
+				if v, ok := s.mapping[col]; ok {
+					row[col] = v
+				}
+			}
+		}
+		for col, v := range s.Assignments {
+			switch v.(type) {
+			case increaseType, decreaseType:
+			default:
+				row[col] = v
+			}
+		}
+	case DeleteCmd:
+		for _, col := range s.Table.KeyColumns {
+			if v, ok := s.mapping[col]; ok {
+				row[col] = v
+			}
+		}
+	default:
+		return nil
+	}
+	return row
 }
 
 // BuildQuery returns the statement query and arguments that will be executed.
 func (s *StatementImpl) BuildQuery() (string, []interface{}) {
+	if s.rawCQL != "" {
+		return s.rawCQL, s.values
+	}
+
 	var cql []string
 
 	// Query with specific column names
 	withColumnNames := len(s.ColumnNames) > 0
 
+	table := s.Table.quotedName()
+	columnNames := s.Table.quoteAll(s.ColumnNames)
 	switch s.Command {
 	case SelectCmd:
 		if withColumnNames {
-			cql = append(cql, fmt.Sprintf("SELECT %s FROM %s", strings.Join(s.ColumnNames, ", "), s.Table.Name))
+			cql = append(cql, fmt.Sprintf("SELECT %s FROM %s", strings.Join(columnNames, ", "), table))
 		} else {
-			cql = append(cql, fmt.Sprintf("SELECT %s FROM %s", s.Table.getCols(), s.Table.Name))
+			cql = append(cql, fmt.Sprintf("SELECT %s FROM %s", s.Table.selectCols(), table))
 		}
 	case InsertCmd:
 		if withColumnNames {
-			cql = append(cql, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", s.Table.Name, strings.Join(s.ColumnNames, ", "), qms(len(s.ColumnNames))))
+			cql = append(cql, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columnNames, ", "), qms(len(s.ColumnNames))))
 		} else {
-			cql = append(cql, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", s.Table.Name, s.Table.getCols(), s.Table.getQms()))
+			cql = append(cql, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, s.Table.getCols(), s.Table.getQms()))
 		}
 	case DeleteCmd:
 		if withColumnNames {
-			cql = append(cql, fmt.Sprintf("DELETE %s FROM %s", strings.Join(s.ColumnNames, ", "), s.Table.Name))
+			cql = append(cql, fmt.Sprintf("DELETE %s FROM %s", strings.Join(columnNames, ", "), table))
 		} else {
-			cql = append(cql, fmt.Sprintf("DELETE FROM %s", s.Table.Name))
+			cql = append(cql, fmt.Sprintf("DELETE FROM %s", table))
 		}
 		if s.TimestampValue > 0 {
 			cql = append(cql, fmt.Sprintf("USING TIMESTAMP %d", s.TimestampValue))
 		}
 	case UpdateCmd:
-		cql = append(cql, fmt.Sprintf("UPDATE %s", s.Table.Name))
+		cql = append(cql, fmt.Sprintf("UPDATE %s", table))
 		if s.TTLValue > 0 && s.TimestampValue > 0 {
 			cql = append(cql, fmt.Sprintf("USING TTL %d AND TIMESTAMP %d", s.TTLValue, s.TimestampValue))
 		} else if s.TTLValue > 0 {
@@ -151,7 +524,11 @@ func (s *StatementImpl) BuildQuery() (string, []interface{}) {
 			cql = append(cql, fmt.Sprintf("USING TIMESTAMP %d", s.TimestampValue))
 		}
 	case CountCmd:
-		cql = append(cql, fmt.Sprintf("SELECT COUNT(1) FROM %s", s.Table.Name))
+		cql = append(cql, fmt.Sprintf("SELECT COUNT(1) FROM %s", table))
+	case TruncateCmd:
+		cql = append(cql, fmt.Sprintf("TRUNCATE %s", table))
+	case DropTableCmd:
+		cql = append(cql, fmt.Sprintf("DROP TABLE IF EXISTS %s", table))
 	default:
 		// This should not happen
 		panic(ErrInvalidCommand)
@@ -165,20 +542,28 @@ func (s *StatementImpl) BuildQuery() (string, []interface{}) {
 		assignments := make([]string, len(s.Assignments)+len(s.ColumnNames))
 
 		for _, col := range s.ColumnNames {
-			assignments[i] = fmt.Sprintf("%s = ?", col)
+			assignments[i] = fmt.Sprintf("%s = ?", s.Table.quote(col))
 			args = append(args, s.mapping[col])
 			i++
 		}
-		for col, v := range s.Assignments {
+		// Sorted to always build the same statement
+		cols := make([]string, 0, len(s.Assignments))
+		for col := range s.Assignments {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+		for _, col := range cols {
+			v := s.Assignments[col]
+			name := s.Table.quote(col)
 			switch vv := v.(type) {
 			case increaseType:
-				assignments[i] = fmt.Sprintf("%s = %s + ?", col, col)
+				assignments[i] = fmt.Sprintf("%s = %s + ?", name, name)
 				args = append(args, int64(vv))
 			case decreaseType:
-				assignments[i] = fmt.Sprintf("%s = %s - ?", col, col)
+				assignments[i] = fmt.Sprintf("%s = %s - ?", name, name)
 				args = append(args, int64(vv))
 			default:
-				assignments[i] = fmt.Sprintf("%s = ?", col)
+				assignments[i] = fmt.Sprintf("%s = ?", name)
 				args = append(args, v)
 			}
 
@@ -197,11 +582,14 @@ func (s *StatementImpl) BuildQuery() (string, []interface{}) {
 
 	// On SELECT: ORDER BY ... LIMIT n
 	if s.Command == SelectCmd {
-		if len(s.Orders) > 0 {
+		if s.AnnColumn != "" {
+			cql = append(cql, fmt.Sprintf("ORDER BY %s ANN OF ?", s.Table.quote(s.AnnColumn)))
+			args = append(args, s.AnnVector)
+		} else if len(s.Orders) > 0 {
 			cql = append(cql, "ORDER BY")
 			orders := make([]string, len(s.Orders))
 			for i, o := range s.Orders {
-				orders[i] = fmt.Sprintf("%s %s", o.Column, o.OrderType)
+				orders[i] = fmt.Sprintf("%s %s", s.Table.quote(o.Column), o.OrderType)
 			}
 			cql = append(cql, strings.Join(orders, ", "))
 		}
@@ -267,15 +655,26 @@ func (s *StatementImpl) From(table string) Statement {
 	return s
 }
 
+// IntoTable overrides the name of the table of the statement, it must be
+// called after Map or Bind:
+//
+//	sess.Insert(&e).IntoTable("events_2024_06").Exec()
+func (s *StatementImpl) IntoTable(name string) Statement {
+	s.Table.Name = name
+	return s
+}
+
 func (s *StatementImpl) FromType(i interface{}) Statement {
-	table := GetTable(i)
-	return s.From(table.Name)
+	table := s.session.getRegistry().GetTable(i)
+	s.From(table.Name)
+	s.Table.TenantColumn = table.TenantColumn
+	return s
 }
 
 // Columns define a list of columns to get on SELECT statements, to set on
 // UPDATE or INSERT statemets or to remove on DELETE statements.
 func (s *StatementImpl) Columns(columns ...string) Statement {
-	s.ColumnNames = columns
+	s.ColumnNames = append([]string(nil), columns...)
 	return s
 }
 
@@ -292,21 +691,71 @@ func (s *StatementImpl) Set(column string, value interface{}) Statement {
 func (s *StatementImpl) Where(cond ...Condition) Statement {
 	and := And(cond[0], cond[1:]...)
 	s.Conditions = &and
+	if and.err != nil && s.err == nil {
+		s.err = and.err
+	}
+	return s
+}
+
+// AndWhere adds the conditions to the ones already set in the statement. It
+// allows to extend the conditions of a cloned statement:
+//
+//	base := sess.Select(&e).Where(Eq("tenant", tenant))
+//	stmt := base.Clone().AndWhere(Ge("time", from))
+func (s *StatementImpl) AndWhere(cond ...Condition) Statement {
+	if s.Conditions == nil {
+		return s.Where(cond...)
+	}
+	and := And(*s.Conditions, cond...)
+	s.Conditions = &and
+	if and.err != nil && s.err == nil {
+		s.err = and.err
+	}
+	return s
+}
+
+// OrderByAnn sorts the rows of a SELECT statement by the similarity of the
+// vector column with the given vector, it requires a storage attached index
+// on the column. It is usually used with a LIMIT:
+//
+//	sess.Select(&doc).OrderByAnn("embedding", v).Limit(10).Iter()
+func (s *StatementImpl) OrderByAnn(column string, vector []float32) Statement {
+	s.AnnColumn = column
+	s.AnnVector = Vector(vector)
 	return s
 }
 
 func (s *StatementImpl) OrderBy(order ...OrderBy) Statement {
-	s.Orders = order
+	s.Orders = append([]OrderBy(nil), order...)
 	return s
 }
 
 func (s *StatementImpl) Bind(i interface{}) Statement {
-	s.values, s.mapping, s.Table = BindTable(i)
+	i, err := s.session.scope(i)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	if s.Command == InsertCmd {
+		i = s.session.generateIDs(i)
+	}
+	i = s.session.setBucket(i)
+	s.values, s.mapping, s.Table = s.session.getRegistry().BindTable(i)
+	s.bound = i
+	if s.Command == InsertCmd && s.TTLValue == 0 {
+		ttl, err := s.Table.ttlFrom(structOf(i), s.session.now())
+		if err != nil {
+			s.err = err
+			return s
+		}
+		s.TTLValue = ttl
+	}
 	return s
 }
 
 func (s *StatementImpl) Map(i interface{}) Statement {
-	s.mapping, s.Table = MapTable(i)
+	s.mapping, s.Table = s.session.getRegistry().MapTable(i)
+	s.dest = structOf(i)
 	return s
 }
 
@@ -325,6 +774,122 @@ func (s *StatementImpl) Timestamp(microseconds int64) Statement {
 	return s
 }
 
+// RoutingKey sets the routing key of the statement using the given values of
+// the partition key columns. gocql computes the routing key of statements
+// with the partition key in bound values, but the routing key must be
+// explicitly set on statements using Raw conditions, like token ranges, for
+// the statements to be routed directly to a replica.
+func (s *StatementImpl) RoutingKey(values ...interface{}) Statement {
+	if key, err := routingKey(values); err != nil {
+		s.err = err
+	} else {
+		s.RoutingKeyValue = key
+	}
+	return s
+}
+
+// InDC restricts the hosts used to execute the statement to the ones in the
+// given datacenter. It requires a session created with NewSession, and a host
+// selection policy that returns hosts in that datacenter.
+func (s *StatementImpl) InDC(dc string) Statement {
+	s.DCValue = dc
+	return s
+}
+
+// ReadFrom executes the statement in the given datacenter with the
+// consistency c, for example to send an expensive read to an analytics
+// datacenter with LOCAL_ONE while the session keeps LOCAL_QUORUM. The rows
+// can be stale, as the writes of the session might not be replicated to that
+// datacenter yet. It only applies to reads, writes ignore it.
+func (s *StatementImpl) ReadFrom(dc string, c gocql.Consistency) Statement {
+	s.readDC, s.readConsistency = dc, &c
+	return s
+}
+
+// Retry sets the retry policy of the statement, overriding the one of the
+// session. Use NoRetry to disable the retries.
+func (s *StatementImpl) Retry(p *RetryPolicy) Statement {
+	s.retry = p
+	return s
+}
+
+// Idempotent marks the statement as safe to retry. It is only required on
+// statements that are not considered idempotent by default, see RetryPolicy.
+func (s *StatementImpl) Idempotent() Statement {
+	s.idempotent = true
+	return s
+}
+
+// Priority sets the priority of the statement, overriding the default of the
+// session. It is used by the Scheduler middleware.
+func (s *StatementImpl) Priority(p Priority) Statement {
+	s.priority = &p
+	return s
+}
+
+// On sets the name of the session, registered in a Router, where the
+// statement is executed.
+func (s *StatementImpl) On(cluster string) Statement {
+	s.cluster = cluster
+	return s
+}
+
+// WithContext sets the context used to execute the statement, it overrides
+// the one of the session.
+func (s *StatementImpl) WithContext(ctx context.Context) Statement {
+	s.ctx = ctx
+	return s
+}
+
+// context returns the context of the statement or the session.
+func (s *StatementImpl) context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return s.session.ctx
+}
+
+// Clone returns a copy of the statement that can be modified and executed
+// independently of s, so a base statement can be shared by multiple
+// goroutines as long as each of them uses its own clone. The statements
+// created from a struct keep the references to its fields, the clones scan
+// the results into the same struct.
+func (s *StatementImpl) Clone() Statement {
+	c := *s
+	c.ColumnNames = append([]string(nil), s.ColumnNames...)
+	c.Orders = append([]OrderBy(nil), s.Orders...)
+	c.AnnVector = append(Vector(nil), s.AnnVector...)
+	c.RoutingKeyValue = append([]byte(nil), s.RoutingKeyValue...)
+	c.values = append([]interface{}(nil), s.values...)
+	if s.Conditions != nil {
+		cond := Condition{
+			CQLFragment: s.Conditions.CQLFragment,
+			Values:      append([]interface{}(nil), s.Conditions.Values...),
+		}
+		c.Conditions = &cond
+	}
+	if s.Assignments != nil {
+		c.Assignments = make(map[string]interface{}, len(s.Assignments))
+		for k, v := range s.Assignments {
+			c.Assignments[k] = v
+		}
+	}
+	if s.mapping != nil {
+		c.mapping = make(map[string]interface{}, len(s.mapping))
+		for k, v := range s.mapping {
+			c.mapping[k] = v
+		}
+	}
+	return &c
+}
+
+// Consistency sets the consistency level of the statement, overriding the
+// default of the session.
+func (s *StatementImpl) Consistency(c gocql.Consistency) Statement {
+	s.ConsistencyValue = &c
+	return s
+}
+
 func (s *StatementImpl) AllowFiltering() Statement {
 	s.AllowFilteringValue = true
 	return s
@@ -0,0 +1,54 @@
+package ecql
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementClone(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	base := NewStatement(sess).Do(SelectCmd).From("events").Where(Eq("tenant", "a"))
+	s1 := base.Clone().AndWhere(Ge("time", 1)).OrderBy(Desc("time")).Limit(10)
+	s2 := base.Clone().AndWhere(Eq("kind", "click"))
+
+	cql, args := base.BuildQuery()
+	assert.Equal(t, "SELECT * FROM events WHERE tenant = ?", cql)
+	assert.Equal(t, []interface{}{"a"}, args)
+	cql, args = s1.BuildQuery()
+	assert.Equal(t, "SELECT * FROM events WHERE tenant = ? AND time >= ? ORDER BY time DESC LIMIT 10", cql)
+	assert.Equal(t, []interface{}{"a", 1}, args)
+	cql, args = s2.BuildQuery()
+	assert.Equal(t, "SELECT * FROM events WHERE tenant = ? AND kind = ?", cql)
+	assert.Equal(t, []interface{}{"a", "click"}, args)
+
+	// Updates do not share the assignments
+	ts := testStruct{F1: "foo"}
+	update := sess.Update(&ts).Set("f22", 1)
+	clone := update.Clone().Set("f3", nil)
+	cql, _ = update.BuildQuery()
+	assert.Equal(t, "UPDATE mytable SET f22 = ? WHERE f1 = ?", cql)
+	cql, _ = clone.BuildQuery()
+	assert.Contains(t, cql, "f3 = ?")
+
+	// Builder methods do not keep the arguments
+	cols := []string{"a", "b"}
+	stmt := NewStatement(sess).Do(SelectCmd).From("events").Columns(cols...)
+	cols[0] = "c"
+	cql, _ = stmt.BuildQuery()
+	assert.Equal(t, "SELECT a, b FROM events", cql)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, base.Clone().AndWhere(Eq("id", i)).Exec())
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, d.requests, 10)
+}
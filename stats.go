@@ -0,0 +1,294 @@
+package ecql
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultStatsSamples is the default number of latencies kept per table to
+// compute the percentiles.
+const DefaultStatsSamples = 1024
+
+// Stats collects the number of statements, errors and latencies per table of
+// the sessions that use its middleware, and the hit rate of the caches
+// wrapped with its Cache method. The statistics can be exposed as JSON with
+// Handler or with expvar using Publish:
+//
+//	stats := ecql.NewStats()
+//	sess, err := ecql.NewSession(cfg,
+//		ecql.WithMiddleware(stats.Middleware()),
+//		ecql.WithCache(stats.Cache(ecql.NewLRUCache(10000))))
+//	...
+//	http.Handle("/debug/ecql", stats.Handler())
+//
+// The latency of a statement is the time until its rows are closed, and the
+// percentiles are computed with the last DefaultStatsSamples latencies of
+// each table. The batches are counted on the table of their first statement.
+//
+// Clock is the source of the current time, it defaults to SystemClock. It
+// must be set before the statistics are collected, Reset starts a new
+// period with it.
+type Stats struct {
+	Clock Clock
+
+	mu       sync.Mutex
+	start    time.Time
+	inFlight int
+	tables   map[string]*tableCounters
+	hits     int64
+	misses   int64
+	caches   int
+}
+
+// StatsSnapshot contains the statistics collected since Since. InFlight is
+// the number of statements being executed, Tables the statistics of each
+// table sorted by name, and Cache the statistics of the caches if any.
+type StatsSnapshot struct {
+	Since    time.Time    `json:"since"`
+	InFlight int          `json:"in_flight"`
+	Tables   []TableStats `json:"tables"`
+	Cache    *CacheStats  `json:"cache,omitempty"`
+}
+
+// TableStats contains the statistics of a table. ErrorRate is the fraction
+// of the statements that failed.
+type TableStats struct {
+	Table     string       `json:"table"`
+	Queries   int64        `json:"queries"`
+	Errors    int64        `json:"errors"`
+	ErrorRate float64      `json:"error_rate"`
+	Latency   LatencyStats `json:"latency"`
+}
+
+// LatencyStats contains the percentiles of the latencies of a table.
+type LatencyStats struct {
+	P50 Duration `json:"p50"`
+	P95 Duration `json:"p95"`
+	P99 Duration `json:"p99"`
+	Max Duration `json:"max"`
+}
+
+// CacheStats contains the number of hits and misses of the caches.
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+type tableCounters struct {
+	queries   int64
+	errors    int64
+	latencies []time.Duration
+	next      int
+}
+
+// NewStats creates a new Stats.
+func NewStats() *Stats {
+	return &Stats{
+		start:  SystemClock.Now(),
+		tables: make(map[string]*tableCounters),
+	}
+}
+
+// Middleware returns the middleware that collects the statistics of the
+// statements.
+func (st *Stats) Middleware() Middleware {
+	return func(next Driver) Driver {
+		return &statsDriver{Driver: next, stats: st}
+	}
+}
+
+// Cache returns a Cache that counts the hits and misses of c.
+func (st *Stats) Cache(c Cache) Cache {
+	st.mu.Lock()
+	st.caches++
+	st.mu.Unlock()
+	return &statsCache{Cache: c, stats: st}
+}
+
+// Snapshot returns the current statistics.
+func (st *Stats) Snapshot() StatsSnapshot {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	snap := StatsSnapshot{
+		Since:    st.start,
+		InFlight: st.inFlight,
+		Tables:   make([]TableStats, 0, len(st.tables)),
+	}
+	for name, tc := range st.tables {
+		ts := TableStats{Table: name, Queries: tc.queries, Errors: tc.errors}
+		if tc.queries > 0 {
+			ts.ErrorRate = float64(tc.errors) / float64(tc.queries)
+		}
+		ts.Latency = percentiles(tc.latencies)
+		snap.Tables = append(snap.Tables, ts)
+	}
+	sort.Slice(snap.Tables, func(i, j int) bool {
+		return snap.Tables[i].Table < snap.Tables[j].Table
+	})
+	if st.caches > 0 {
+		snap.Cache = &CacheStats{Hits: st.hits, Misses: st.misses}
+		if total := st.hits + st.misses; total > 0 {
+			snap.Cache.HitRate = float64(st.hits) / float64(total)
+		}
+	}
+	return snap
+}
+
+// Reset removes the statistics collected, the statements in flight are
+// still counted.
+func (st *Stats) Reset() {
+	st.mu.Lock()
+	st.start = nowOf(st.Clock)
+	st.tables = make(map[string]*tableCounters)
+	st.hits, st.misses = 0, 0
+	st.mu.Unlock()
+}
+
+// Handler returns an http.Handler that responds with the snapshot of the
+// statistics in JSON.
+func (st *Stats) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(st.Snapshot())
+	})
+}
+
+// Publish exports the statistics with expvar using the given name. Like
+// expvar.Publish, it panics if the name is already used.
+func (st *Stats) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return st.Snapshot()
+	}))
+}
+
+// begin records the start of a statement.
+func (st *Stats) begin() time.Time {
+	st.mu.Lock()
+	st.inFlight++
+	st.mu.Unlock()
+	return nowOf(st.Clock)
+}
+
+// done records the result of a statement on the table.
+func (st *Stats) done(table string, start time.Time, err error) {
+	latency := nowOf(st.Clock).Sub(start)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.inFlight--
+
+	tc, ok := st.tables[table]
+	if !ok {
+		tc = &tableCounters{}
+		st.tables[table] = tc
+	}
+	tc.queries++
+	if err != nil && err != ErrNotFound {
+		tc.errors++
+	}
+	if len(tc.latencies) < DefaultStatsSamples {
+		tc.latencies = append(tc.latencies, latency)
+	} else {
+		tc.latencies[tc.next] = latency
+		tc.next = (tc.next + 1) % DefaultStatsSamples
+	}
+}
+
+func (st *Stats) cacheResult(hit bool) {
+	st.mu.Lock()
+	if hit {
+		st.hits++
+	} else {
+		st.misses++
+	}
+	st.mu.Unlock()
+}
+
+// percentiles returns the percentiles of the given latencies.
+func percentiles(latencies []time.Duration) LatencyStats {
+	if len(latencies) == 0 {
+		return LatencyStats{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	at := func(p float64) Duration {
+		return Duration(sorted[int(p*float64(len(sorted)-1))])
+	}
+	return LatencyStats{
+		P50: at(0.50),
+		P95: at(0.95),
+		P99: at(0.99),
+		Max: Duration(sorted[len(sorted)-1]),
+	}
+}
+
+// statsDriver is the Driver used by the Stats middleware.
+type statsDriver struct {
+	Driver
+	stats *Stats
+}
+
+func (d *statsDriver) Iter(req *Request) Rows {
+	start := d.stats.begin()
+	return &statsRows{Rows: d.Driver.Iter(req), stats: d.stats, table: req.Table, start: start}
+}
+
+func (d *statsDriver) ExecBatch(b *BatchRequest) error {
+	start := d.stats.begin()
+	err := d.Driver.ExecBatch(b)
+	d.stats.done(batchTable(b), start, err)
+	return err
+}
+
+func (d *statsDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	start := d.stats.begin()
+	applied, err := d.Driver.ExecBatchCAS(b, dest)
+	d.stats.done(batchTable(b), start, err)
+	return applied, err
+}
+
+// batchTable returns the table of the first statement of the batch.
+func batchTable(b *BatchRequest) string {
+	if len(b.Entries) == 0 {
+		return ""
+	}
+	return b.Entries[0].Table
+}
+
+// statsRows records the statement when the rows are closed.
+type statsRows struct {
+	Rows
+	stats  *Stats
+	table  string
+	start  time.Time
+	closed bool
+}
+
+func (r *statsRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.stats.done(r.table, r.start, err)
+	}
+	return err
+}
+
+// statsCache is the Cache returned by Stats.Cache.
+type statsCache struct {
+	Cache
+	stats *Stats
+}
+
+func (c *statsCache) Get(key string) (interface{}, bool) {
+	v, ok := c.Cache.Get(key)
+	c.stats.cacheResult(ok)
+	return v, ok
+}
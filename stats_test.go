@@ -0,0 +1,114 @@
+package ecql
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	DeleteRegistry()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	stats := NewStats()
+	stats.Clock = ClockFunc(func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	})
+	stats.Reset()
+	sess, d := newTestSession(WithMiddleware(stats.Middleware()), WithCache(stats.Cache(NewLRUCache(10))))
+	start := stats.Snapshot().Since
+
+	// Miss and hit, the hits are counted as queries
+	d.result([]string{"f1", "f22"}, []interface{}{"a", 1})
+	var ts testStruct
+	assert.NoError(t, sess.Get(&ts, "a"))
+	assert.NoError(t, sess.Get(&ts, "a"))
+	assert.Len(t, d.requests, 1)
+
+	assert.NoError(t, sess.Batch().Add(sess.Insert(&testStruct{F1: "b"})).Apply())
+
+	d.err = errors.New("unavailable")
+	assert.Error(t, sess.Set(testStruct{F1: "c"}))
+	assert.Error(t, sess.Select(&pageStruct{}).Exec())
+
+	d.err = nil
+	rows := stats.Middleware()(d).Iter(&Request{Command: SelectCmd, Table: "pages"})
+	assert.Equal(t, 1, stats.Snapshot().InFlight)
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, rows.Close())
+
+	assert.Equal(t, StatsSnapshot{
+		Since:    start,
+		InFlight: 0,
+		Tables: []TableStats{
+			{Table: "mytable", Queries: 4, Errors: 1, ErrorRate: 0.25, Latency: LatencyStats{
+				P50: Duration(time.Millisecond), P95: Duration(time.Millisecond), P99: Duration(time.Millisecond), Max: Duration(time.Millisecond),
+			}},
+			{Table: "pages", Queries: 2, Errors: 1, ErrorRate: 0.5, Latency: LatencyStats{
+				P50: Duration(time.Millisecond), P95: Duration(time.Millisecond), P99: Duration(time.Millisecond), Max: Duration(time.Millisecond),
+			}},
+		},
+		Cache: &CacheStats{Hits: 1, Misses: 1, HitRate: 0.5},
+	}, stats.Snapshot())
+
+	stats.Reset()
+	snap := stats.Snapshot()
+	assert.True(t, snap.Since.After(start))
+	assert.Empty(t, snap.Tables)
+	assert.Equal(t, &CacheStats{}, snap.Cache)
+
+	assert.Nil(t, NewStats().Snapshot().Cache)
+}
+
+func TestStatsPercentiles(t *testing.T) {
+	assert.Equal(t, LatencyStats{}, percentiles(nil))
+
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, LatencyStats{
+		P50: Duration(50 * time.Millisecond),
+		P95: Duration(95 * time.Millisecond),
+		P99: Duration(99 * time.Millisecond),
+		Max: Duration(100 * time.Millisecond),
+	}, percentiles(latencies))
+
+	// Only the last samples are kept
+	stats := NewStats()
+	for i := 0; i < DefaultStatsSamples+10; i++ {
+		stats.done("t", stats.begin(), nil)
+	}
+	assert.Len(t, stats.tables["t"].latencies, DefaultStatsSamples)
+	assert.Equal(t, 10, stats.tables["t"].next)
+	assert.Equal(t, int64(DefaultStatsSamples+10), stats.Snapshot().Tables[0].Queries)
+}
+
+func TestStatsHandler(t *testing.T) {
+	stats := NewStats()
+	stats.done("mytable", stats.begin().Add(-1500*time.Microsecond), errors.New("failed"))
+
+	rec := httptest.NewRecorder()
+	stats.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/ecql", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, float64(0), body["in_flight"])
+	assert.NotContains(t, body, "cache")
+	table := body["tables"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "mytable", table["table"])
+	assert.Equal(t, float64(1), table["queries"])
+	assert.Equal(t, float64(1), table["error_rate"])
+	assert.Contains(t, table["latency"].(map[string]interface{})["p50"], "ms")
+
+	stats.Publish("ecql_test_stats")
+	assert.Contains(t, expvar.Get("ecql_test_stats").String(), `"table":"mytable"`)
+}
@@ -0,0 +1,92 @@
+package ecql
+
+import (
+	"net"
+
+	"github.com/gocql/gocql"
+)
+
+// SystemLocal is a row of the system.local table, with the information of
+// the node the statement is sent to. The system types do not need to be
+// registered, they can be used with the typed API of any session. They only
+// map the columns available since Cassandra 3.0:
+//
+//	var local ecql.SystemLocal
+//	err := sess.Get(&local, "local")
+type SystemLocal struct {
+	Key                   string     `cql:"key" cqltable:"system.local" cqlkey:"key"`
+	Bootstrapped          string     `cql:"bootstrapped"`
+	BroadcastAddress      net.IP     `cql:"broadcast_address"`
+	ClusterName           string     `cql:"cluster_name"`
+	CQLVersion            string     `cql:"cql_version"`
+	DataCenter            string     `cql:"data_center"`
+	HostID                gocql.UUID `cql:"host_id"`
+	ListenAddress         net.IP     `cql:"listen_address"`
+	NativeProtocolVersion string     `cql:"native_protocol_version"`
+	Partitioner           string     `cql:"partitioner"`
+	Rack                  string     `cql:"rack"`
+	ReleaseVersion        string     `cql:"release_version"`
+	RPCAddress            net.IP     `cql:"rpc_address"`
+	SchemaVersion         gocql.UUID `cql:"schema_version"`
+	Tokens                []string   `cql:"tokens"`
+}
+
+// SystemPeer is a row of the system.peers table, with the information of the
+// other nodes of the cluster known by a node:
+//
+//	var peer ecql.SystemPeer
+//	iter := sess.Select(&peer).Iter()
+//	for iter.TypeScan(&peer) {
+//		fmt.Println(peer.Peer, peer.DataCenter, peer.Rack)
+//	}
+//	err := iter.Close()
+type SystemPeer struct {
+	Peer           net.IP     `cql:"peer" cqltable:"system.peers" cqlkey:"peer"`
+	DataCenter     string     `cql:"data_center"`
+	HostID         gocql.UUID `cql:"host_id"`
+	PreferredIP    net.IP     `cql:"preferred_ip"`
+	Rack           string     `cql:"rack"`
+	ReleaseVersion string     `cql:"release_version"`
+	RPCAddress     net.IP     `cql:"rpc_address"`
+	SchemaVersion  gocql.UUID `cql:"schema_version"`
+	Tokens         []string   `cql:"tokens"`
+}
+
+// SystemSchemaTable is a row of the system_schema.tables table, with the
+// options of a table:
+//
+//	var table ecql.SystemSchemaTable
+//	err := sess.Get(&table, "app", "users")
+type SystemSchemaTable struct {
+	KeyspaceName      string            `cql:"keyspace_name" cqltable:"system_schema.tables" cqlkey:"keyspace_name,table_name"`
+	TableName         string            `cql:"table_name"`
+	ID                gocql.UUID        `cql:"id"`
+	Comment           string            `cql:"comment"`
+	Caching           map[string]string `cql:"caching"`
+	Compaction        map[string]string `cql:"compaction"`
+	Compression       map[string]string `cql:"compression"`
+	DefaultTimeToLive int               `cql:"default_time_to_live"`
+	GCGraceSeconds    int               `cql:"gc_grace_seconds"`
+	Flags             []string          `cql:"flags"`
+}
+
+// SystemSchemaColumn is a row of the system_schema.columns table, with the
+// definition of a column. Kind is partition_key, clustering, static or
+// regular, and Position is the position of the key columns in the primary
+// key, -1 on the other columns:
+//
+//	var col ecql.SystemSchemaColumn
+//	iter := sess.Select(&col).Where(ecql.Eq("keyspace_name", "app"), ecql.Eq("table_name", "users")).Iter()
+//	for iter.TypeScan(&col) {
+//		fmt.Println(col.ColumnName, col.Type)
+//	}
+//	err := iter.Close()
+type SystemSchemaColumn struct {
+	KeyspaceName    string `cql:"keyspace_name" cqltable:"system_schema.columns" cqlkey:"keyspace_name,table_name,column_name"`
+	TableName       string `cql:"table_name"`
+	ColumnName      string `cql:"column_name"`
+	ClusteringOrder string `cql:"clustering_order"`
+	Kind            string `cql:"kind"`
+	Position        int    `cql:"position"`
+	Type            string `cql:"type"`
+}
@@ -0,0 +1,85 @@
+package ecql
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemTables(t *testing.T) {
+	DeleteRegistry()
+
+	tests := []struct {
+		i           interface{}
+		name        string
+		partition   []string
+		clustering  []string
+		firstColumn string
+		numColumns  int
+	}{
+		{SystemLocal{}, "system.local", []string{"key"}, nil, "key", 15},
+		{SystemPeer{}, "system.peers", []string{"peer"}, nil, "peer", 9},
+		{SystemSchemaTable{}, "system_schema.tables", []string{"keyspace_name"}, []string{"table_name"}, "keyspace_name", 10},
+		{SystemSchemaColumn{}, "system_schema.columns", []string{"keyspace_name"}, []string{"table_name", "column_name"}, "keyspace_name", 7},
+	}
+	for _, tt := range tests {
+		table := GetTable(tt.i)
+		assert.Equal(t, tt.name, table.Name)
+		assert.Equal(t, tt.partition, table.PartitionColumns)
+		assert.Equal(t, tt.clustering, table.ClusteringColumns)
+		assert.Equal(t, tt.firstColumn, table.Columns[0].Name)
+		assert.Len(t, table.Columns, tt.numColumns)
+	}
+}
+
+func TestSystemTablesQueries(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession(WithKeyspace("app"))
+
+	hostID := MustUUID("7d4a4a3c-3c8f-4c2a-9a8e-4a1f3d1f9a11")
+	d.result([]string{"key", "cluster_name", "data_center", "host_id", "rpc_address", "release_version", "tokens"},
+		[]interface{}{"local", "Test Cluster", "dc1", hostID, net.ParseIP("10.0.0.1"), "4.1.3", []string{"-9223372036854775808"}})
+	var local SystemLocal
+	assert.NoError(t, sess.Get(&local, "local"))
+	assert.Equal(t, "SELECT key,bootstrapped,broadcast_address,cluster_name,cql_version,data_center,host_id,listen_address,native_protocol_version,partitioner,rack,release_version,rpc_address,schema_version,tokens FROM system.local WHERE key = ?", d.last().Statement)
+	assert.Equal(t, SystemLocal{
+		Key:            "local",
+		ClusterName:    "Test Cluster",
+		DataCenter:     "dc1",
+		HostID:         hostID,
+		RPCAddress:     net.ParseIP("10.0.0.1"),
+		ReleaseVersion: "4.1.3",
+		Tokens:         []string{"-9223372036854775808"},
+	}, local)
+
+	d.result([]string{"peer", "data_center", "rack"},
+		[]interface{}{net.ParseIP("10.0.0.2"), "dc1", "rack1"},
+		[]interface{}{net.ParseIP("10.0.0.3"), "dc2", "rack1"})
+	var peer SystemPeer
+	var dcs []string
+	iter := sess.Select(&peer).Iter()
+	for iter.TypeScan(&peer) {
+		dcs = append(dcs, peer.DataCenter)
+	}
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, "SELECT peer,data_center,host_id,preferred_ip,rack,release_version,rpc_address,schema_version,tokens FROM system.peers", d.last().Statement)
+	assert.Equal(t, []string{"dc1", "dc2"}, dcs)
+
+	d.result([]string{"keyspace_name", "table_name", "column_name", "kind", "position", "type"},
+		[]interface{}{"app", "users", "id", "partition_key", 0, "uuid"})
+	var col SystemSchemaColumn
+	iter = sess.Select(&col).Where(Eq("keyspace_name", "app"), Eq("table_name", "users")).OrderBy(Desc("table_name")).Iter()
+	assert.True(t, iter.TypeScan(&col))
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, "SELECT keyspace_name,table_name,column_name,clustering_order,kind,position,type FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ? ORDER BY table_name DESC", d.last().Statement)
+	assert.Equal(t, SystemSchemaColumn{KeyspaceName: "app", TableName: "users", ColumnName: "id", Kind: "partition_key", Type: "uuid"}, col)
+
+	d.result([]string{"keyspace_name", "table_name", "default_time_to_live", "compaction"},
+		[]interface{}{"app", "users", 3600, map[string]string{"class": "SizeTieredCompactionStrategy"}})
+	var table SystemSchemaTable
+	assert.NoError(t, sess.Get(&table, "app", "users"))
+	assert.Equal(t, "SELECT keyspace_name,table_name,id,comment,caching,compaction,compression,default_time_to_live,gc_grace_seconds,flags FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?", d.last().Statement)
+	assert.Equal(t, 3600, table.DefaultTimeToLive)
+	assert.Equal(t, "SizeTieredCompactionStrategy", table.Compaction["class"])
+}
@@ -1,6 +1,7 @@
 package ecql
 
 import (
+	"reflect"
 	"strings"
 
 	"fmt"
@@ -17,34 +18,76 @@ const (
 )
 
 // Table contains the information of a table in cassandra.
+//
+// KeyColumns contains all the columns in the primary key, PartitionColumns
+// the ones in the partition key, and ClusteringColumns the rest of them.
+// Remaining is the position of the field tagged with `cql:",remaining"`.
+// Sharding is set if the type was registered with RegisterSharded.
+// ClusteringOrder is set on tables returned by DescribeTable, and on the
+// registered types if the order of a clustering column is in the cqlkey tag.
+// TTLColumns are the fields tagged with `cql:",ttl=col"`, they are only
+// used on reads. Tracked is the position of the embedded Tracked field.
+// Bucket is set if a column has the bucket option. TTLFrom is the column set
+// with the option `cql:",ttlfrom=col"`, see TTLFrom. CaseSensitive is set
+// with the option quoted of the table tag, `cqltable:"Orders,quoted"`, the
+// names of the table and columns with uppercase letters are quoted to keep
+// their case. The reserved words are always quoted.
 type Table struct {
-	Name       string
-	KeyColumns []string
-	Columns    []Column
+	Name              string
+	KeyColumns        []string
+	PartitionColumns  []string
+	ClusteringColumns []string
+	ClusteringOrder   []OrderBy
+	Columns           []Column
+	TTLColumns        []Column
+	Remaining         []int
+	Tracked           []int
+	Sharding          *Sharding
+	TenantColumn      string
+	Bucket            *TimeBucket
+	TTLFrom           string
+	CaseSensitive     bool
+	accessors         []*fieldAccessor
 }
 
 // Column contains the information of a column in a table required
 // to create a map for it.
 // Every element of position represents its order in a hierarchy of nested structs
+// Type is the CQL type of the column if known, and Enum the valid values if
+// the field is an enumeration. OmitEmpty columns are unset on writes if the
+// value is the zero value. Encrypted columns are encrypted with the
+// KeyProvider of the registry.
 type Column struct {
-	Name     string
-	Position []int
+	Name       string
+	Position   []int
+	Type       string
+	Enum       []string
+	OmitEmpty  bool
+	Encrypted  bool
+	Serializer string
+	Compressed bool
+	Auto       bool
+	keys       *keyring
 }
 
 func (t *Table) BuildQuery(qt queryType) (string, error) {
+	if err := checkTable(t.Name); err != nil {
+		return "", err
+	}
+
 	var cql string
 	switch qt {
 	case selectQuery:
-		cql = fmt.Sprintf("SELECT %s FROM %s WHERE %s", t.getCols(), t.Name, appendCols(t.KeyColumns))
+		cql = fmt.Sprintf("SELECT %s FROM %s WHERE %s", t.selectCols(), t.quotedName(), appendCols(t.quoteAll(t.KeyColumns)))
 	case insertQuery:
-		cql = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.Name, t.getCols(), t.getQms())
+		cql = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.quotedName(), t.getCols(), t.getQms())
 	case deleteQuery:
-		cql = fmt.Sprintf("DELETE FROM %s WHERE %s", t.Name, appendCols(t.KeyColumns))
+		cql = fmt.Sprintf("DELETE FROM %s WHERE %s", t.quotedName(), appendCols(t.quoteAll(t.KeyColumns)))
 	case updateQuery:
 		// cql = "UPDATE %s WHERE %s = ?"
 		return "", ErrInvalidQueryType
 	case countQuery:
-		cql = fmt.Sprintf("SELECT COUNT(1) FROM %s WHERE %s", t.Name, appendCols(t.KeyColumns))
+		cql = fmt.Sprintf("SELECT COUNT(1) FROM %s WHERE %s", t.quotedName(), appendCols(t.quoteAll(t.KeyColumns)))
 	default:
 		return "", ErrInvalidQueryType
 	}
@@ -52,12 +95,212 @@ func (t *Table) BuildQuery(qt queryType) (string, error) {
 	return cql, nil
 }
 
+// setKey sets the key columns of the table using the syntax of the cqlkey
+// tag. Like in CQL, the first column is the partition key and the rest are
+// clustering columns, see splitPartitionKey for composite partition keys. The
+// clustering columns can be followed by their order, "id,time desc,seq", the
+// default order is ascending.
+func (t *Table) setKey(key string) {
+	partition, key := splitPartitionKey(key)
+	clustering := splitKey(key)
+	if len(partition) == 0 && len(clustering) > 0 {
+		partition, clustering = clustering[:1], clustering[1:]
+	}
+	if len(clustering) == 0 {
+		clustering = nil
+	}
+
+	var order []OrderBy
+	explicit := false
+	for i, col := range clustering {
+		fields := strings.Fields(col)
+		clustering[i] = fields[0]
+		if len(fields) > 1 && strings.EqualFold(fields[1], string(DescOrder)) {
+			order = append(order, Desc(fields[0]))
+		} else {
+			order = append(order, Asc(fields[0]))
+		}
+		explicit = explicit || len(fields) > 1
+	}
+	for i, col := range partition {
+		partition[i] = strings.Fields(col)[0]
+	}
+
+	t.PartitionColumns = partition
+	t.ClusteringColumns = clustering
+	t.KeyColumns = append(append([]string{}, partition...), clustering...)
+	if explicit {
+		t.ClusteringOrder = order
+	}
+}
+
+// splitKey returns the comma separated columns in key without the empty
+// ones.
+func splitKey(key string) []string {
+	var cols []string
+	for _, col := range strings.Split(key, ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// partitionKey returns a string representation of the partition key values in
+// mapping, it is used to group statements by partition.
+func (t *Table) partitionKey(mapping map[string]interface{}) string {
+	parts := make([]string, len(t.PartitionColumns)+1)
+	parts[0] = t.Name
+	for i, col := range t.PartitionColumns {
+		parts[i+1] = fmt.Sprintf("%v", deref(mapping[col]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// partitionValues returns the values of the partition key columns in mapping.
+func (t *Table) partitionValues(mapping map[string]interface{}) []interface{} {
+	values := make([]interface{}, len(t.PartitionColumns))
+	for i, col := range t.PartitionColumns {
+		values[i] = deref(mapping[col])
+	}
+	return values
+}
+
+// partitionOf returns the values of the partition key columns in the values
+// of the key columns, or nil if there are not enough values.
+func (t *Table) partitionOf(keys []interface{}) []interface{} {
+	n := len(t.PartitionColumns)
+	if n == 0 || len(keys) < n {
+		return nil
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		values[i] = deref(keys[i])
+	}
+	return values
+}
+
 func (t *Table) getCols() string {
+	return strings.Join(t.quoteAll(t.columnNames()), ",")
+}
+
+// columnNames returns the names of the columns.
+func (t *Table) columnNames() []string {
 	names := make([]string, len(t.Columns))
 	for i := range t.Columns {
 		names[i] = t.Columns[i].Name
 	}
-	return strings.Join(names, ",")
+	return names
+}
+
+// selectCols returns the columns to use in SELECT statements, all the columns
+// are selected if the table has a remaining field or no columns, in this case
+// the TTL columns are not selected.
+func (t *Table) selectCols() string {
+	if len(t.Columns) == 0 || t.Remaining != nil {
+		return "*"
+	}
+	cols := t.getCols()
+	for _, c := range t.TTLColumns {
+		if col := strings.TrimSuffix(strings.TrimPrefix(c.Name, "ttl("), ")"); col != c.Name {
+			cols += ",ttl(" + t.quote(col) + ")"
+		} else {
+			cols += "," + c.Name
+		}
+	}
+	return cols
+}
+
+// remainingOf returns the map in the remaining field of the struct v,
+// initializing it if necessary and possible.
+func (t *Table) remainingOf(v reflect.Value) map[string]interface{} {
+	if t.Remaining == nil {
+		return nil
+	}
+	field := v.FieldByIndex(t.Remaining)
+	if field.IsNil() && field.CanSet() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	m, _ := field.Interface().(map[string]interface{})
+	return m
+}
+
+// scanned updates the struct v after a MapScan with the mapping m, setting the
+// remaining field and the snapshot of the tracked values.
+func (t *Table) scanned(v reflect.Value, m map[string]interface{}) {
+	t.setRemaining(t.remainingOf(v), m)
+	if tr := t.trackedOf(v); tr != nil && v.CanAddr() {
+		tr.snapshot(v, *t)
+	}
+}
+
+// setRemaining copies into dest the values in m, after a MapScan, of the
+// columns that are not mapped to a field.
+func (t *Table) setRemaining(dest, m map[string]interface{}) {
+	if dest == nil {
+		return
+	}
+	for name, v := range m {
+		if !t.hasColumn(name) {
+			dest[name] = v
+		}
+	}
+}
+
+// isKey returns true if name is a column of the primary key.
+func (t *Table) isKey(name string) bool {
+	for _, k := range t.KeyColumns {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasColumn returns true if name is a column mapped to a field.
+func (t *Table) hasColumn(name string) bool {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return true
+		}
+	}
+	for i := range t.TTLColumns {
+		if t.TTLColumns[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// column returns the column with the given name.
+func (t *Table) column(name string) (Column, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// checkKey returns a *KeyError if a column of the primary key has a zero
+// value in the struct v.
+func (t *Table) checkKey(v reflect.Value) error {
+	return t.checkColumns(v, t.KeyColumns)
+}
+
+// checkColumns returns a *KeyError if one of the columns has a zero value in
+// the struct v.
+func (t *Table) checkColumns(v reflect.Value, names []string) error {
+	for _, name := range names {
+		col, ok := t.column(name)
+		if !ok || col.Position == nil {
+			continue
+		}
+		if field, _ := t.field(name, v); field.IsZero() {
+			return &KeyError{Table: t.Name, Column: name}
+		}
+	}
+	return nil
 }
 
 func (t *Table) getQms() string {
@@ -0,0 +1,99 @@
+package ecql
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTemplateValues is returned by the queries of a StatementTemplate if the
+// number of values does not match the placeholders of the template.
+var ErrTemplateValues = errors.New("ecql: wrong number of template values")
+
+// StatementTemplate is the shape of a statement built once and executed many
+// times with different values. The CQL of the template never changes, so the
+// driver reuses the same prepared statement, and executing it does not
+// rebuild the query:
+//
+//	tpl, err := sess.Select(Event{}).Columns("id", "text").Where(Eq("stream", "?"), Gt("time", "?")).Limit(10).Template()
+//	...
+//	var events []Event
+//	err = tpl.Bind(stream, t).SelectType(&events)
+//
+// The values of Bind are in the order of the placeholders in CQL, the values
+// used to build the template are ignored. The consistency, retry policy,
+// timeout, and other options of the statement are kept in the queries.
+type StatementTemplate struct {
+	Command Command
+	Table   string
+	CQL     string
+	n       int
+	stmt    StatementImpl
+}
+
+// Template validates the statement and returns a template with its CQL. The
+// values of Bind replace all the values of the statement, so the templates
+// of tables with a tenant column, or without a registered type, return
+// ErrCrossTenant on tenant sessions.
+func (s *StatementImpl) Template() (*StatementTemplate, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.session.tenant != nil {
+		if col, err := s.tenantColumn(); err != nil || col != "" {
+			return nil, ErrCrossTenant
+		}
+	}
+
+	c := s.Clone().(*StatementImpl)
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	cql, values := c.BuildQuery()
+
+	stmt := StatementImpl{
+		session:          c.session,
+		Command:          c.Command,
+		Table:            c.Table,
+		ConsistencyValue: c.ConsistencyValue,
+		DCValue:          c.DCValue,
+		retry:            c.retry,
+		idempotent:       c.isIdempotent(),
+		priority:         c.priority,
+		cluster:          c.cluster,
+		readDC:           c.readDC,
+		readConsistency:  c.readConsistency,
+		ctx:              c.ctx,
+		timeout:          c.timeout,
+		payload:          c.payload,
+		rawCQL:           cql,
+	}
+	return &StatementTemplate{
+		Command: c.Command,
+		Table:   c.Table.Name,
+		CQL:     cql,
+		n:       len(values),
+		stmt:    stmt,
+	}, nil
+}
+
+// NumValues returns the number of values required by Bind.
+func (t *StatementTemplate) NumValues() int {
+	return t.n
+}
+
+// Bind returns a query that executes the template with the given values.
+// The query returns an error matching ErrTemplateValues if the number of
+// values is not NumValues.
+func (t *StatementTemplate) Bind(values ...interface{}) RawQuery {
+	stmt := t.stmt
+	stmt.values = values
+	if len(values) != t.n {
+		stmt.err = fmt.Errorf("%w: %d values, the template requires %d", ErrTemplateValues, len(values), t.n)
+	}
+	return &RawQueryImpl{statement: &stmt}
+}
+
+// String returns the CQL of the template.
+func (t *StatementTemplate) String() string {
+	return t.CQL
+}
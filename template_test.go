@@ -0,0 +1,88 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementTemplate(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	tpl, err := sess.Select(testStruct{}).Columns("f1", "f22").Where(Eq("f1", "?"), Gt("f22", 0)).Limit(10).Consistency(gocql.One).Template()
+	assert.NoError(t, err)
+	assert.Equal(t, SelectCmd, tpl.Command)
+	assert.Equal(t, "mytable", tpl.Table)
+	assert.Equal(t, "SELECT f1, f22 FROM mytable WHERE f1 = ? AND f22 > ? LIMIT 10", tpl.CQL)
+	assert.Equal(t, 2, tpl.NumValues())
+
+	for _, key := range []string{"a", "b"} {
+		d.result([]string{"f1", "f22"}, []interface{}{key, 3})
+		var ts []testStruct
+		assert.NoError(t, tpl.Bind(key, 1).SelectType(&ts))
+		assert.Equal(t, []testStruct{{F1: key, F2: 3}}, ts)
+		assert.Equal(t, tpl.CQL, d.last().Statement)
+		assert.Equal(t, []interface{}{key, 1}, d.last().Values)
+		assert.Equal(t, gocql.One, *d.last().Consistency)
+		assert.True(t, d.last().Idempotent)
+	}
+
+	n := len(d.requests)
+	err = tpl.Bind("a").Exec()
+	assert.True(t, errors.Is(err, ErrTemplateValues))
+	assert.EqualError(t, err, "ecql: wrong number of template values: 1 values, the template requires 2")
+	assert.Len(t, d.requests, n)
+
+	// Invalid statements
+	_, err = sess.Select(testStruct{}).Where(Eq("f1;", "?")).Template()
+	assert.True(t, errors.Is(err, ErrInvalidIdentifier))
+}
+
+func TestStatementTemplateUpdate(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	stmt := sess.Update(testStruct{}).Set("f4", 0).Set("f22", 0).Set("f3", Inc(0)).Where(Eq("f1", "?"))
+	tpl, err := stmt.Template()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE mytable SET f22 = ?, f3 = f3 + ?, f4 = ? WHERE f1 = ?", tpl.CQL)
+
+	assert.NoError(t, tpl.Bind(1, int64(2), 3, "a").Exec())
+	assert.Equal(t, tpl.CQL, d.last().Statement)
+	assert.False(t, d.last().Idempotent)
+
+	// Immutable statements
+	tpl, err = Immutable(sess.Select(testStruct{}).Where(Eq("f1", "?"))).Template()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ?", tpl.String())
+}
+
+func TestStatementTemplateTenant(t *testing.T) {
+	DeleteRegistry()
+	base, d := newTestSession()
+	sess := base.WithTenant(context.Background(), "acme")
+
+	// The values of Bind cannot replace the tenant
+	for _, stmt := range []Statement{
+		sess.Select(tenantNote{}).Where(Eq("id", "?")),
+		sess.Update(tenantNote{}).Set("text", "?").Where(Eq("id", "?")),
+		sess.Insert(tenantNote{ID: "1"}),
+		sess.Delete(tenantNote{ID: "1"}),
+		NewStatement(sess.(*SessionImpl)).Do(SelectCmd).From("notes").Where(Eq("id", "?")),
+		NewStatement(sess.(*SessionImpl)).Do(SelectCmd).From("unknown").Where(Eq("id", "?")),
+	} {
+		_, err := stmt.Template()
+		assert.True(t, errors.Is(err, ErrCrossTenant))
+	}
+	assert.Empty(t, d.requests)
+
+	// Tables without a tenant column
+	tpl, err := sess.Select(testStruct{}).Where(Eq("f1", "?")).Template()
+	assert.NoError(t, err)
+	assert.NoError(t, tpl.Bind("a").Exec())
+	assert.Equal(t, []interface{}{"a"}, d.last().Values)
+}
@@ -0,0 +1,199 @@
+package ecql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// ErrTenantMismatch is returned when a tenant session reads or writes a
+// value of a different tenant.
+var ErrTenantMismatch = errors.New("ecql: value belongs to a different tenant")
+
+// ErrCrossTenant is returned when a tenant session executes a statement that
+// affects all the tenants, like a TRUNCATE, or a statement on a table without
+// a registered type.
+var ErrCrossTenant = errors.New("ecql: statement not allowed on a tenant session")
+
+type tenantKey struct{}
+
+// TenantOf returns the tenant of the context of a session created with
+// WithTenant.
+func TenantOf(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	id := ctx.Value(tenantKey{})
+	return id, id != nil
+}
+
+// WithTenant returns a Session scoped to the given tenant. The tenant column
+// of the tenant-scoped types, tagged with the tenant option, is set on the
+// writes and added to the conditions of the statements:
+//
+//	type Note struct {
+//		Tenant string `cql:"tenant,tenant" cqltable:"notes" cqlkey:"tenant,id"`
+//		ID     string `cql:"id"`
+//		Text   string `cql:"text"`
+//	}
+//
+//	sess := sess.WithTenant(ctx, "acme")
+//	err := sess.Set(&Note{ID: "1", Text: "foo"}) // sets Tenant to "acme"
+//	err = sess.Get(&note, "1")                   // the tenant key is added
+//
+// The values of other tenants return ErrTenantMismatch. The tenant column
+// should be part of the partition key. The statements built with From are
+// scoped using the type registered with the table name, and they return
+// ErrCrossTenant if there is none. Raw statements are not scoped.
+func (s *SessionImpl) WithTenant(ctx context.Context, id interface{}) Session {
+	sess := *s
+	sess.ctx = context.WithValue(contextOf(ctx), tenantKey{}, id)
+	sess.tenant = id
+	return &sess
+}
+
+// sameTenant returns true if v is the value of the tenant.
+func (s *SessionImpl) sameTenant(v interface{}) bool {
+	v = deref(v)
+	if reflect.DeepEqual(v, s.tenant) {
+		return true
+	}
+	rv, rt := reflect.ValueOf(v), reflect.ValueOf(s.tenant)
+	return rv.IsValid() && rt.IsValid() && rt.Type().ConvertibleTo(rv.Type()) &&
+		reflect.DeepEqual(v, rt.Convert(rv.Type()).Interface())
+}
+
+// scope sets the tenant in the tenant column of the struct i if it is
+// empty. It returns a pointer to a copy of i if it is not a pointer.
+func (s *SessionImpl) scope(i interface{}) (interface{}, error) {
+	if s.tenant == nil {
+		return i, nil
+	}
+	table := s.getRegistry().GetTable(i)
+	col, ok := table.tenantColumn()
+	if !ok {
+		return i, nil
+	}
+
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		i, v = p.Interface(), p
+	}
+	field := v.Elem().FieldByIndex(col.Position)
+	if !field.IsZero() {
+		if !s.sameTenant(field.Interface()) {
+			return nil, ErrTenantMismatch
+		}
+		return i, nil
+	}
+	tenant := reflect.ValueOf(s.tenant)
+	if !tenant.Type().ConvertibleTo(field.Type()) {
+		return nil, ErrTenantMismatch
+	}
+	field.Set(tenant.Convert(field.Type()))
+	return i, nil
+}
+
+// scopeKeys adds the tenant to the values of the key columns of the table if
+// it is missing, or checks it if it is present.
+func (s *SessionImpl) scopeKeys(table Table, keys []interface{}) ([]interface{}, error) {
+	if s.tenant == nil || table.TenantColumn == "" {
+		return keys, nil
+	}
+	for i, name := range table.KeyColumns {
+		if name != table.TenantColumn {
+			continue
+		}
+		switch len(keys) {
+		case len(table.KeyColumns):
+			if !s.sameTenant(keys[i]) {
+				return nil, ErrTenantMismatch
+			}
+		case len(table.KeyColumns) - 1:
+			scoped := make([]interface{}, 0, len(table.KeyColumns))
+			scoped = append(scoped, keys[:i]...)
+			scoped = append(scoped, s.tenant)
+			keys = append(scoped, keys[i:]...)
+		}
+	}
+	return keys, nil
+}
+
+// tableNamed returns the table of the type registered with the given name.
+func (r *Registry) tableNamed(name string) (Table, bool) {
+	types := make(map[reflect.Type]Table)
+	r.collect(types)
+	for _, t := range types {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Table{}, false
+}
+
+// tenantColumn returns the tenant column of the table.
+func (t *Table) tenantColumn() (Column, bool) {
+	if t.TenantColumn != "" {
+		for _, c := range t.Columns {
+			if c.Name == t.TenantColumn {
+				return c, true
+			}
+		}
+	}
+	return Column{}, false
+}
+
+// tenantColumn returns the tenant column of the table of the statement. The
+// statements built with From only have the name of the table, they use the
+// type registered with that name and return ErrCrossTenant if there is none.
+func (s *StatementImpl) tenantColumn() (string, error) {
+	if s.Table.TenantColumn != "" || len(s.Table.Columns) > 0 {
+		return s.Table.TenantColumn, nil
+	}
+	table, ok := s.session.getRegistry().tableNamed(s.Table.Name)
+	if !ok {
+		return "", ErrCrossTenant
+	}
+	return table.TenantColumn, nil
+}
+
+// scopeTenant adds the tenant to the conditions or values of the statement.
+func (s *StatementImpl) scopeTenant() error {
+	sess := s.session
+	if sess.tenant == nil || s.rawCQL != "" {
+		return nil
+	}
+	col, err := s.tenantColumn()
+	if err != nil || col == "" {
+		return err
+	}
+	switch s.Command {
+	case SelectCmd, CountCmd, UpdateCmd, DeleteCmd:
+		if s.Conditions != nil {
+			if v, ok := s.Conditions.equalities()[col]; ok {
+				if !sess.sameTenant(v) {
+					return ErrTenantMismatch
+				}
+				return nil
+			}
+		}
+		s.AndWhere(Eq(s.Table.quote(col), sess.tenant))
+	case InsertCmd:
+		if s.mapping != nil {
+			// Bind has already set the tenant
+			return nil
+		}
+		if v, ok := s.Assignments[col]; ok {
+			if !sess.sameTenant(v) {
+				return ErrTenantMismatch
+			}
+			return nil
+		}
+		s.Set(col, sess.tenant)
+	case TruncateCmd, DropTableCmd:
+		return ErrCrossTenant
+	}
+	return nil
+}
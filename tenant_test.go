@@ -0,0 +1,116 @@
+package ecql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantNote struct {
+	Tenant string `cql:"tenant,tenant" cqltable:"notes" cqlkey:"tenant,id"`
+	ID     string `cql:"id"`
+	Text   string `cql:"text"`
+}
+
+// derefValues returns the values of a request without pointers.
+func derefValues(req *Request) []interface{} {
+	values := make([]interface{}, len(req.Values))
+	for i, v := range req.Values {
+		values[i] = deref(v)
+	}
+	return values
+}
+
+func TestTenantOf(t *testing.T) {
+	_, ok := TenantOf(nil)
+	assert.False(t, ok)
+	_, ok = TenantOf(context.Background())
+	assert.False(t, ok)
+	sess, _ := newTestSession()
+	id, ok := TenantOf(sess.WithTenant(context.Background(), "acme").(*SessionImpl).ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", id)
+}
+
+func TestTenantWrites(t *testing.T) {
+	DeleteRegistry()
+	assert.Equal(t, "tenant", GetTable(tenantNote{}).TenantColumn)
+	base, d := newTestSession()
+	sess := base.WithTenant(context.Background(), "acme")
+
+	assert.NoError(t, sess.Set(tenantNote{ID: "1", Text: "foo"}))
+	assert.Equal(t, []interface{}{"acme", "1", "foo"}, d.last().Values)
+	tenant, _ := TenantOf(d.last().Context)
+	assert.Equal(t, "acme", tenant)
+
+	note := &tenantNote{ID: "2"}
+	assert.NoError(t, sess.Insert(note).Exec())
+	assert.Equal(t, "acme", note.Tenant)
+	assert.Equal(t, []interface{}{"acme", "2", ""}, d.last().Values)
+
+	assert.NoError(t, sess.Update(tenantNote{ID: "1", Text: "bar"}).Columns("text").Exec())
+	assert.Equal(t, "UPDATE notes SET text = ? WHERE tenant = ? AND id = ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"bar", "acme", "1"}, derefValues(d.last()))
+
+	assert.NoError(t, sess.Del(tenantNote{ID: "1"}))
+	assert.Equal(t, []interface{}{"acme", "1"}, derefValues(d.last()))
+
+	assert.NoError(t, sess.Delete(tenantNote{ID: "1"}).Exec())
+	assert.Equal(t, []interface{}{"acme", "1"}, derefValues(d.last()))
+
+	// Other tenants
+	n := len(d.requests)
+	other := tenantNote{Tenant: "other", ID: "1"}
+	assert.Equal(t, ErrTenantMismatch, sess.Set(other))
+	assert.Equal(t, ErrTenantMismatch, sess.Del(other))
+	assert.Equal(t, ErrTenantMismatch, sess.Insert(other).Exec())
+	assert.Equal(t, ErrTenantMismatch, sess.Update(other).Exec())
+	assert.Equal(t, ErrTenantMismatch, sess.Delete(other).Exec())
+	assert.Equal(t, ErrCrossTenant, sess.Truncate(tenantNote{}))
+	assert.Len(t, d.requests, n)
+
+	// Without tenant
+	assert.NoError(t, base.Set(tenantNote{ID: "1"}))
+	assert.Equal(t, []interface{}{"", "1", ""}, d.last().Values)
+}
+
+func TestTenantReads(t *testing.T) {
+	DeleteRegistry()
+	base, d := newTestSession()
+	sess := base.WithTenant(context.Background(), "acme")
+	d.result([]string{"tenant", "id", "text"}, []interface{}{"acme", "1", "foo"})
+
+	var note tenantNote
+	assert.NoError(t, sess.Get(&note, "1"))
+	assert.Equal(t, []interface{}{"acme", "1"}, d.last().Values)
+	assert.NoError(t, sess.Get(&note, "acme", "1"))
+	assert.Equal(t, []interface{}{"acme", "1"}, d.last().Values)
+	assert.Equal(t, ErrTenantMismatch, sess.Get(&note, "other", "1"))
+
+	assert.NoError(t, sess.Select(&note).TypeScan())
+	assert.Equal(t, "SELECT tenant,id,text FROM notes WHERE tenant = ?", d.last().Statement)
+	assert.NoError(t, sess.Select(&note).Where(Eq("id", "1")).TypeScan())
+	assert.Equal(t, "SELECT tenant,id,text FROM notes WHERE id = ? AND tenant = ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"1", "acme"}, d.last().Values)
+	assert.Equal(t, ErrTenantMismatch, sess.Select(&note).Where(Eq("tenant", "other")).TypeScan())
+
+	d.result([]string{"count"}, []interface{}{1})
+	var count int
+	assert.NoError(t, sess.Count(tenantNote{}).Scan(&count))
+	assert.Equal(t, "SELECT COUNT(1) FROM notes WHERE tenant = ?", d.last().Statement)
+
+	ok, err := sess.Exists(&tenantNote{ID: "1"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{"acme", "1"}, derefValues(d.last()))
+	// Statements built with From
+	tenantSess := sess.(*SessionImpl)
+	assert.NoError(t, NewStatement(tenantSess).Do(SelectCmd).From("notes").Where(Eq("id", "1")).Exec())
+	assert.Equal(t, "SELECT * FROM notes WHERE id = ? AND tenant = ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"1", "acme"}, d.last().Values)
+	Register(testStruct{})
+	assert.NoError(t, NewStatement(tenantSess).Do(SelectCmd).From("mytable").Exec())
+	assert.Equal(t, "SELECT * FROM mytable", d.last().Statement)
+	assert.Equal(t, ErrCrossTenant, NewStatement(tenantSess).Do(SelectCmd).From("unknown").Exec())
+}
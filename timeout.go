@@ -0,0 +1,76 @@
+package ecql
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout sets the default timeout of the statements and batches of the
+// session, Statement.Timeout can be used to override it on a specific
+// statement. The timeout is set as the deadline of the context of the
+// requests, so it limits the whole execution, including the retries and the
+// fetch of all the pages of the result. The Timeout of the gocql cluster
+// configuration still limits each request sent to a host.
+func WithTimeout(d time.Duration) Option {
+	return func(s *SessionImpl) {
+		s.timeout = d
+	}
+}
+
+// Timeout sets the timeout of the statement, overriding the default of the
+// session, so long scans can have a larger limit than point reads:
+//
+//	iter := sess.Select(Event{}).Where(Eq("stream", id)).Timeout(time.Minute).Iter()
+func (s *StatementImpl) Timeout(d time.Duration) Statement {
+	s.timeout = d
+	return s
+}
+
+// timeoutDriver sets the deadline of the context of the requests with a
+// timeout. The context is canceled when the rows are closed.
+type timeoutDriver struct {
+	Driver
+}
+
+func (d timeoutDriver) Iter(req *Request) Rows {
+	if req.Timeout <= 0 {
+		return d.Driver.Iter(req)
+	}
+	ctx, cancel := context.WithTimeout(contextOf(req.Context), req.Timeout)
+	r := *req
+	r.Context = ctx
+	return &timeoutRows{Rows: d.Driver.Iter(&r), cancel: cancel}
+}
+
+func (d timeoutDriver) ExecBatch(b *BatchRequest) error {
+	if b.Timeout <= 0 {
+		return d.Driver.ExecBatch(b)
+	}
+	ctx, cancel := context.WithTimeout(contextOf(b.Context), b.Timeout)
+	defer cancel()
+	batch := *b
+	batch.Context = ctx
+	return d.Driver.ExecBatch(&batch)
+}
+
+func (d timeoutDriver) ExecBatchCAS(b *BatchRequest, dest map[string]interface{}) (bool, error) {
+	if b.Timeout <= 0 {
+		return d.Driver.ExecBatchCAS(b, dest)
+	}
+	ctx, cancel := context.WithTimeout(contextOf(b.Context), b.Timeout)
+	defer cancel()
+	batch := *b
+	batch.Context = ctx
+	return d.Driver.ExecBatchCAS(&batch, dest)
+}
+
+// timeoutRows cancels the context of the request when the rows are closed.
+type timeoutRows struct {
+	Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
@@ -0,0 +1,36 @@
+package ecql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	sess, d := newTestSession(WithTimeout(time.Second))
+
+	assert.NoError(t, sess.Set(testStruct{F1: "a"}))
+	req := d.last()
+	assert.Equal(t, time.Second, req.Timeout)
+	deadline, ok := req.Context.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	assert.Equal(t, context.Canceled, req.Context.Err())
+
+	assert.NoError(t, sess.Select(testStruct{}).Timeout(time.Minute).Exec())
+	req = d.last()
+	assert.Equal(t, time.Minute, req.Timeout)
+	deadline, _ = req.Context.Deadline()
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 100*time.Millisecond)
+
+	assert.NoError(t, sess.Batch().Add(sess.Insert(testStruct{F1: "a"})).Apply())
+	assert.Equal(t, time.Second, d.batches[len(d.batches)-1].Timeout)
+	_, ok = d.batches[len(d.batches)-1].Context.Deadline()
+	assert.True(t, ok)
+
+	sess, d = newTestSession()
+	assert.NoError(t, sess.Set(testStruct{F1: "a"}))
+	assert.Nil(t, d.last().Context)
+}
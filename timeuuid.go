@@ -0,0 +1,47 @@
+package ecql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// MinTimeUUID creates the condition 'col >= ?' on a timeuuid column that
+// matches the UUIDs generated at or after t. The bound is the smallest UUID
+// with the time t, like the CQL function minTimeuuid.
+func MinTimeUUID(col string, t time.Time) Condition {
+	return Condition{
+		CQLFragment: fmt.Sprintf("%s >= ?", ident(col)),
+		Values:      []interface{}{gocql.MinTimeUUID(t)},
+		err:         checkIdent(col),
+	}
+}
+
+// MaxTimeUUID creates the condition 'col <= ?' on a timeuuid column that
+// matches the UUIDs generated at or before t. The bound is the largest UUID
+// with the time t, like the CQL function maxTimeuuid.
+func MaxTimeUUID(col string, t time.Time) Condition {
+	return Condition{
+		CQLFragment: fmt.Sprintf("%s <= ?", ident(col)),
+		Values:      []interface{}{gocql.MaxTimeUUID(t)},
+		err:         checkIdent(col),
+	}
+}
+
+// BetweenTime creates the condition on a timeuuid column that matches the
+// UUIDs generated in the half-open interval [from, to), so consecutive
+// intervals do not overlap:
+//
+//	sess.Select(&e).Where(Eq("sensor", id), BetweenTime("id", from, from.Add(time.Hour)))
+//
+// Comparing timeuuid columns with UUIDs generated with gocql.UUIDFromTime is
+// a common mistake, they have a random clock sequence and node and do not
+// match all the UUIDs with the same time.
+func BetweenTime(col string, from, to time.Time) Condition {
+	return Condition{
+		CQLFragment: fmt.Sprintf("%s >= ? AND %s < ?", ident(col), ident(col)),
+		Values:      []interface{}{gocql.MinTimeUUID(from), gocql.MinTimeUUID(to)},
+		err:         checkIdent(col),
+	}
+}
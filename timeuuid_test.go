@@ -0,0 +1,41 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeUUIDConditions(t *testing.T) {
+	from := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	assert.Equal(t, Condition{CQLFragment: "id >= ?", Values: []interface{}{gocql.MinTimeUUID(from)}}, MinTimeUUID("id", from))
+	assert.Equal(t, Condition{CQLFragment: "id <= ?", Values: []interface{}{gocql.MaxTimeUUID(to)}}, MaxTimeUUID("id", to))
+
+	cond := BetweenTime("id", from, to)
+	assert.Equal(t, "id >= ? AND id < ?", cond.CQLFragment)
+	assert.Equal(t, []interface{}{gocql.MinTimeUUID(from), gocql.MinTimeUUID(to)}, cond.Values)
+	assert.Equal(t, from, cond.Values[0].(gocql.UUID).Time())
+	assert.Equal(t, to, cond.Values[1].(gocql.UUID).Time())
+
+	sess, d := newTestSession()
+	var ts testStruct
+	sess.Select(&ts).Where(Eq("f1", "a"), BetweenTime("f22", from, to)).Exec()
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable WHERE f1 = ? AND f22 >= ? AND f22 < ?", d.last().Statement)
+
+	// Invalid identifiers
+	n := len(d.requests)
+	for _, cond := range []Condition{
+		MinTimeUUID("id; DROP TABLE mytable", from),
+		MaxTimeUUID("id) OR (1", to),
+		BetweenTime("id--", from, to),
+	} {
+		err := sess.Select(&ts).Where(Eq("f1", "a"), cond).Exec()
+		assert.True(t, errors.Is(err, ErrInvalidIdentifier))
+	}
+	assert.Len(t, d.requests, n)
+}
@@ -0,0 +1,258 @@
+package ecql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// TLSConfig is the TLS configuration of a session using the certificates in
+// files. CertFile and KeyFile are the client certificate used on mutual TLS.
+//
+// If ReloadInterval is set, the files are checked at most once per interval
+// when a connection is opened, and the certificates are reloaded if the
+// files have changed. This allows long-lived services to rotate the
+// certificates without restarting. The CA bundle can only be reloaded if
+// ServerName is set, the name is used to verify the hosts.
+type TLSConfig struct {
+	Enabled            bool     `json:"enabled" yaml:"enabled" env:"ECQL_TLS"`
+	CAFile             string   `json:"ca_file" yaml:"ca_file" env:"ECQL_TLS_CA_FILE"`
+	CertFile           string   `json:"cert_file" yaml:"cert_file" env:"ECQL_TLS_CERT_FILE"`
+	KeyFile            string   `json:"key_file" yaml:"key_file" env:"ECQL_TLS_KEY_FILE"`
+	ServerName         string   `json:"server_name" yaml:"server_name" env:"ECQL_TLS_SERVER_NAME"`
+	InsecureSkipVerify bool     `json:"insecure_skip_verify" yaml:"insecure_skip_verify" env:"ECQL_TLS_INSECURE_SKIP_VERIFY"`
+	ReloadInterval     Duration `json:"reload_interval" yaml:"reload_interval" env:"ECQL_TLS_RELOAD_INTERVAL"`
+}
+
+// Load returns the tls.Config with the certificates in the files. If
+// ReloadInterval is set, the certificates are loaded by the callbacks of the
+// tls.Config, and the CA bundle is verified by VerifyConnection.
+func (c TLSConfig) Load() (*tls.Config, error) {
+	roots, cert, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.ReloadInterval <= 0 {
+		config.RootCAs = roots
+		if cert != nil {
+			config.Certificates = []tls.Certificate{*cert}
+		}
+		return config, nil
+	}
+
+	r := &certReloader{
+		files:    c,
+		interval: time.Duration(c.ReloadInterval),
+		checked:  time.Now(),
+		stamps:   c.stamps(),
+		roots:    roots,
+		cert:     cert,
+	}
+	if cert != nil {
+		config.GetClientCertificate = r.clientCertificate
+	}
+	if roots != nil && !c.InsecureSkipVerify {
+		if c.ServerName == "" {
+			return nil, fmt.Errorf("%w: a server name is required to reload %s", ErrInvalidConfig, c.CAFile)
+		}
+		// The roots cannot be replaced in a tls.Config, the verification
+		// is done in VerifyConnection with the current ones.
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = r.verifyConnection
+	}
+	return config, nil
+}
+
+// load reads the CA bundle and the client certificate.
+func (c TLSConfig) load() (*x509.CertPool, *tls.Certificate, error) {
+	var roots *x509.CertPool
+	if c.CAFile != "" {
+		b, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(b) {
+			return nil, nil, fmt.Errorf("%w: no certificates in %s", ErrInvalidConfig, c.CAFile)
+		}
+	}
+
+	var cert *tls.Certificate
+	if c.CertFile != "" || c.KeyFile != "" {
+		pair, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+		cert = &pair
+	}
+	return roots, cert, nil
+}
+
+// fileStamp identifies a version of a file by its modification time and
+// size.
+type fileStamp struct {
+	modTime int64
+	size    int64
+}
+
+// stamps returns the stamps of the CA, certificate and key files. A file
+// replaced by one with an older modification time is also detected.
+func (c TLSConfig) stamps() [3]fileStamp {
+	var s [3]fileStamp
+	for i, name := range []string{c.CAFile, c.CertFile, c.KeyFile} {
+		if name == "" {
+			continue
+		}
+		if fi, err := os.Stat(name); err == nil {
+			s[i] = fileStamp{modTime: fi.ModTime().UnixNano(), size: fi.Size()}
+		}
+	}
+	return s
+}
+
+// certReloader keeps the certificates of a TLSConfig up to date.
+type certReloader struct {
+	files    TLSConfig
+	interval time.Duration
+	mu       sync.Mutex
+	checked  time.Time
+	stamps   [3]fileStamp
+	roots    *x509.CertPool
+	cert     *tls.Certificate
+}
+
+// current returns the certificates, reloading them if the files have
+// changed. The previous certificates are kept if the files cannot be loaded,
+// for example, while they are being replaced.
+func (r *certReloader) current() (*x509.CertPool, *tls.Certificate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := time.Now(); now.Sub(r.checked) >= r.interval {
+		r.checked = now
+		if stamps := r.files.stamps(); stamps != r.stamps {
+			if roots, cert, err := r.files.load(); err == nil {
+				r.roots, r.cert, r.stamps = roots, cert, stamps
+			}
+		}
+	}
+	return r.roots, r.cert
+}
+
+func (r *certReloader) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	_, cert := r.current()
+	return cert, nil
+}
+
+func (r *certReloader) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("ecql: no certificates from %s", r.files.ServerName)
+	}
+	roots, _ := r.current()
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       r.files.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// sslOptions returns the gocql options that use the given configuration.
+func sslOptions(config *tls.Config) *gocql.SslOptions {
+	return &gocql.SslOptions{
+		Config:                 config,
+		EnableHostVerification: !config.InsecureSkipVerify,
+	}
+}
+
+// WithTLSFiles configures the session to use TLS with the certificates in
+// the files of the given configuration, its Enabled field is ignored. If
+// the files cannot be loaded NewSession returns the error. It replaces the
+// configuration set with WithTLS.
+//
+// It only has effect on sessions created with NewSession.
+func WithTLSFiles(c TLSConfig) Option {
+	return func(s *SessionImpl) {
+		if s.cluster != nil {
+			s.tlsFiles = &c
+		}
+	}
+}
+
+// WithTLSCAFile configures the session to use TLS and verify the hosts with
+// the CA bundle in the given file.
+//
+// It only has effect on sessions created with NewSession.
+func WithTLSCAFile(path string) Option {
+	return withTLSFiles(func(c *TLSConfig) {
+		c.CAFile = path
+	})
+}
+
+// WithTLSClientCert configures the session to use mutual TLS with the client
+// certificate and key in the given files.
+//
+// It only has effect on sessions created with NewSession.
+func WithTLSClientCert(certFile, keyFile string) Option {
+	return withTLSFiles(func(c *TLSConfig) {
+		c.CertFile = certFile
+		c.KeyFile = keyFile
+	})
+}
+
+// WithTLSServerName configures the session to use TLS and verify the hosts
+// with the given name instead of their addresses.
+//
+// It only has effect on sessions created with NewSession.
+func WithTLSServerName(name string) Option {
+	return withTLSFiles(func(c *TLSConfig) {
+		c.ServerName = name
+	})
+}
+
+// WithTLSInsecureSkipVerify configures the session to use TLS without
+// verifying the certificates of the hosts. It should only be used on tests.
+//
+// It only has effect on sessions created with NewSession.
+func WithTLSInsecureSkipVerify() Option {
+	return withTLSFiles(func(c *TLSConfig) {
+		c.InsecureSkipVerify = true
+	})
+}
+
+// WithTLSReload configures the session to reload the TLS certificates if
+// their files change, checking them at most once per interval, see
+// TLSConfig.
+//
+// It only has effect on sessions created with NewSession.
+func WithTLSReload(interval time.Duration) Option {
+	return withTLSFiles(func(c *TLSConfig) {
+		c.ReloadInterval = Duration(interval)
+	})
+}
+
+func withTLSFiles(fn func(c *TLSConfig)) Option {
+	return func(s *SessionImpl) {
+		if s.cluster != nil {
+			if s.tlsFiles == nil {
+				s.tlsFiles = &TLSConfig{Enabled: true}
+			}
+			fn(s.tlsFiles)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package ecql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseCertificate(t *testing.T, cert string) *x509.Certificate {
+	block, _ := pem.Decode([]byte(cert))
+	c, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	return c
+}
+
+// rotate writes the content in the file with the modification time moved by
+// d, older files are also reloaded.
+func rotate(t *testing.T, path, content string, d time.Duration) {
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	mtime := time.Now().Add(d)
+	assert.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestTLSConfigLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecql")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cert, key := testCertificate(t)
+	c := TLSConfig{
+		CAFile:     writeConfig(t, dir, "ca.pem", cert),
+		CertFile:   writeConfig(t, dir, "cert.pem", cert),
+		KeyFile:    writeConfig(t, dir, "key.pem", key),
+		ServerName: "ecql",
+	}
+	config, err := c.Load()
+	assert.NoError(t, err)
+	assert.NotNil(t, config.RootCAs)
+	assert.Len(t, config.Certificates, 1)
+	assert.Equal(t, "ecql", config.ServerName)
+	assert.False(t, config.InsecureSkipVerify)
+	assert.Nil(t, config.GetClientCertificate)
+	assert.Nil(t, config.VerifyConnection)
+
+	bad := c
+	bad.CAFile = c.KeyFile
+	_, err = bad.Load()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+
+	bad = c
+	bad.KeyFile = ""
+	_, err = bad.Load()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+
+	bad = c
+	bad.CAFile = filepath.Join(dir, "missing.pem")
+	_, err = bad.Load()
+	assert.Error(t, err)
+
+	bad = c
+	bad.ServerName = ""
+	bad.ReloadInterval = Duration(time.Minute)
+	_, err = bad.Load()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func TestTLSConfigReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecql")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cert1, key1 := testCertificate(t)
+	cert2, key2 := testCertificate(t)
+	c := TLSConfig{
+		CAFile:         writeConfig(t, dir, "ca.pem", cert1),
+		CertFile:       writeConfig(t, dir, "cert.pem", cert1),
+		KeyFile:        writeConfig(t, dir, "key.pem", key1),
+		ServerName:     "ecql",
+		ReloadInterval: Duration(time.Nanosecond),
+	}
+	config, err := c.Load()
+	assert.NoError(t, err)
+	assert.True(t, config.InsecureSkipVerify)
+	assert.Empty(t, config.Certificates)
+	assert.False(t, sslOptions(config).EnableHostVerification)
+
+	peer1 := tls.ConnectionState{PeerCertificates: []*x509.Certificate{parseCertificate(t, cert1)}}
+	peer2 := tls.ConnectionState{PeerCertificates: []*x509.Certificate{parseCertificate(t, cert2)}}
+
+	cert, err := config.GetClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, peer1.PeerCertificates[0].Raw, cert.Certificate[0])
+	assert.NoError(t, config.VerifyConnection(peer1))
+	assert.Error(t, config.VerifyConnection(peer2))
+	assert.Error(t, config.VerifyConnection(tls.ConnectionState{}))
+
+	// Keep the previous certificates while the files are not consistent.
+	rotate(t, c.CertFile, cert2, time.Minute)
+	cert, err = config.GetClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, peer1.PeerCertificates[0].Raw, cert.Certificate[0])
+
+	// A file replaced by an older one is also detected.
+	rotate(t, c.KeyFile, key2, -time.Hour)
+	rotate(t, c.CAFile, cert2, -time.Hour)
+	cert, err = config.GetClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, peer2.PeerCertificates[0].Raw, cert.Certificate[0])
+	assert.Error(t, config.VerifyConnection(peer1))
+	assert.NoError(t, config.VerifyConnection(peer2))
+
+	// Files are not checked again before the interval.
+	c.ReloadInterval = Duration(time.Hour)
+	config, err = c.Load()
+	assert.NoError(t, err)
+	rotate(t, c.CAFile, cert1, 3*time.Minute)
+	assert.NoError(t, config.VerifyConnection(peer2))
+	assert.Error(t, config.VerifyConnection(peer1))
+}
+
+func TestTLSOptions(t *testing.T) {
+	s := &SessionImpl{cluster: &gocql.ClusterConfig{}}
+	for _, opt := range []Option{
+		WithTLSCAFile("ca.pem"),
+		WithTLSClientCert("cert.pem", "key.pem"),
+		WithTLSServerName("cassandra"),
+		WithTLSInsecureSkipVerify(),
+		WithTLSReload(time.Minute),
+	} {
+		opt(s)
+	}
+	assert.Equal(t, &TLSConfig{
+		Enabled:            true,
+		CAFile:             "ca.pem",
+		CertFile:           "cert.pem",
+		KeyFile:            "key.pem",
+		ServerName:         "cassandra",
+		InsecureSkipVerify: true,
+		ReloadInterval:     Duration(time.Minute),
+	}, s.tlsFiles)
+
+	WithTLSFiles(TLSConfig{CAFile: "other.pem"})(s)
+	assert.Equal(t, &TLSConfig{CAFile: "other.pem"}, s.tlsFiles)
+
+	s = &SessionImpl{}
+	WithTLSCAFile("ca.pem")(s)
+	assert.Nil(t, s.tlsFiles)
+
+	_, err := NewSession(*gocql.NewCluster("127.0.0.1"), WithTLSCAFile("missing.pem"))
+	assert.Error(t, err)
+}
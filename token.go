@@ -0,0 +1,119 @@
+package ecql
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// Token returns the Murmur3 token of the partition key of i, the same value
+// returned by the CQL function token() on the partition key columns. It can
+// be used to route or group statements by token range, or to build
+// deterministic fixtures, without a round trip to the database.
+func Token(i interface{}) (int64, error) {
+	key, err := RoutingKey(i)
+	if err != nil {
+		return 0, err
+	}
+	return murmur3H1(key), nil
+}
+
+// TokenRange is a range of the Murmur3 token ring, Start and End are
+// included.
+type TokenRange struct {
+	Start int64
+	End   int64
+}
+
+// TokenRanges splits the token ring in n consecutive ranges of the same size.
+// They can be used to scan a table in parallel:
+//
+//	for _, r := range ecql.TokenRanges(16) {
+//		go scan(sess.Select(Event{}).Where(r.Condition(ecql.TableOf(sess, Event{}))).Iter())
+//	}
+func TokenRanges(n int) []TokenRange {
+	if n < 1 {
+		n = 1
+	}
+	// The step is 2^64/n computed without overflowing
+	step := math.MaxUint64 / uint64(n)
+	if math.MaxUint64%uint64(n) == uint64(n)-1 {
+		step++
+	}
+	ranges := make([]TokenRange, n)
+	start := uint64(0)
+	for i := range ranges {
+		end := start + step - 1
+		if i == n-1 {
+			end = math.MaxUint64
+		}
+		// Shift the unsigned range to the signed token ring
+		ranges[i] = TokenRange{
+			Start: int64(start ^ 1<<63),
+			End:   int64(end ^ 1<<63),
+		}
+		start = end + 1
+	}
+	return ranges
+}
+
+// Condition returns the condition that selects the partitions of the table
+// in the range.
+func (r TokenRange) Condition(table Table) Condition {
+	key := strings.Join(table.quoteAll(table.PartitionColumns), ", ")
+	return Raw(fmt.Sprintf("token(%s) >= ? AND token(%s) <= ?", key, key), r.Start, r.End)
+}
+
+// ScanTokenRanges scans the table of i splitting the token ring in the given
+// number of ranges, and scanning parallelism ranges at the same time. The
+// function fn is called with each range and the iterator of its rows, the
+// iterator is closed after it. It stops at the first error and returns it.
+func ScanTokenRanges(s Session, i interface{}, splits, parallelism int, fn func(split int, r TokenRange, iter Iter) error) error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	ranges := TokenRanges(splits)
+	table := registryOf(s).GetTable(i)
+	jobs := make(chan int)
+
+	var mu sync.Mutex
+	var firstErr error
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(parallelism)
+	for n := 0; n < parallelism; n++ {
+		go func() {
+			defer wg.Done()
+			for split := range jobs {
+				iter := s.Select(i).Where(ranges[split].Condition(table)).Iter()
+				err := fn(split, ranges[split], iter)
+				if cerr := iter.Close(); err == nil {
+					err = cerr
+				}
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for split := range ranges {
+		if failed() {
+			break
+		}
+		jobs <- split
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
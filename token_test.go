@@ -0,0 +1,85 @@
+package ecql
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMurmur3H1(t *testing.T) {
+	// Values generated by the java datastax implementation, the strings of
+	// increasing length test all the tail lengths
+	series := []uint64{
+		0x0000000000000000, 0x2ac9debed546a380, 0x649e4eaa7fc1708e, 0xce68f60d7c353bdb,
+		0x0f95757ce7f38254, 0x0f04e459497f3fc1, 0x88c0a92586be0a27, 0x13eb9fb82606f7a6,
+		0x8236039b7387354d, 0x4c1e87519fe738ba, 0x3f9652ac3effeb24, 0x3f33760ded9006c6,
+		0xaed70a6631854cb1, 0x8a299a8f8e0e2da7, 0x624b675c779249a6, 0xa4b203bb1d90b9a3,
+		0xa3293ad698ecb99a, 0xbc740023dbd50048, 0x3fe5ab9837d25cdd, 0x2d0338c1ca87d132,
+	}
+	sample := ""
+	for i, exp := range series {
+		assert.Equal(t, int64(exp), murmur3H1([]byte(sample)), sample)
+		sample += strconv.Itoa(i % 10)
+	}
+
+	u64 := func(v uint64) int64 { return int64(v) }
+	assert.Equal(t, u64(0xcbd8a7b341bd9b02), murmur3H1([]byte("hello")))
+	assert.Equal(t, u64(0xcd99481f9ee902c9), murmur3H1([]byte("The quick brown fox jumps over the lazy dog.")))
+}
+
+func TestToken(t *testing.T) {
+	DeleteRegistry()
+
+	type single struct {
+		ID string `cql:"id" cqltable:"single"`
+	}
+	token, err := Token(single{ID: "hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-3758069500696749310), token)
+
+	type composite struct {
+		ID   gocql.UUID `cql:"id" cqltable:"composite" cqlkey:"(id,n),time"`
+		N    int        `cql:"n"`
+		Time int64      `cql:"time"`
+	}
+	id, _ := gocql.ParseUUID("4327529f-b645-dd00-b883-ec39ae448bb8")
+	c := composite{ID: id, N: 420459, Time: 1}
+	key, err := RoutingKey(&c)
+	assert.NoError(t, err)
+	assert.Equal(t, "00104327529fb645dd00b883ec39ae448bb800000400066a6b00", hex.EncodeToString(key))
+	token, err = Token(&c)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-9223371632693506265), token)
+
+	type unsupported struct {
+		ID []string `cql:"id" cqltable:"unsupported"`
+	}
+	_, err = Token(unsupported{})
+	assert.Error(t, err)
+}
+
+func TestTokenRanges(t *testing.T) {
+	DeleteRegistry()
+	assert.Equal(t, []TokenRange{{math.MinInt64, math.MaxInt64}}, TokenRanges(0))
+	assert.Equal(t, []TokenRange{
+		{math.MinInt64, -1},
+		{0, math.MaxInt64},
+	}, TokenRanges(2))
+
+	ranges := TokenRanges(7)
+	assert.Len(t, ranges, 7)
+	assert.Equal(t, int64(math.MinInt64), ranges[0].Start)
+	assert.Equal(t, int64(math.MaxInt64), ranges[6].End)
+	for i := 1; i < len(ranges); i++ {
+		assert.Equal(t, ranges[i-1].End+1, ranges[i].Start)
+	}
+
+	assert.Equal(t, Condition{
+		CQLFragment: "token(f1) >= ? AND token(f1) <= ?",
+		Values:      []interface{}{int64(0), int64(math.MaxInt64)},
+	}, TokenRanges(2)[1].Condition(GetTable(testStruct{})))
+}
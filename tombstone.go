@@ -0,0 +1,119 @@
+package ecql
+
+import (
+	"reflect"
+
+	"github.com/gocql/gocql"
+)
+
+// WithUnsetEmpty enables the tombstone avoidance mode. On INSERT and UPDATE
+// statements built from structs, the columns not in the primary key with
+// nil or zero values are not set, instead of writing a null cell. It can be
+// enabled per statement using Statement.UnsetEmpty.
+func WithUnsetEmpty() Option {
+	return func(s *SessionImpl) {
+		s.unsetEmpty = true
+	}
+}
+
+// WithNullCellsHook sets a function that is called when an INSERT or UPDATE
+// statement built from a struct would write at least threshold null cells,
+// creating the same number of tombstones. It can be used to log the writes
+// that should use omitempty fields or the tombstone avoidance mode.
+func WithNullCellsHook(threshold int, fn func(table string, columns []string)) Option {
+	return func(s *SessionImpl) {
+		s.nullCells = nullCellsHook{threshold: threshold, fn: fn}
+	}
+}
+
+type nullCellsHook struct {
+	threshold int
+	fn        func(table string, columns []string)
+}
+
+// prepareWrite sets UnsetValue in the empty values not in the primary key if
+// unset is true, and calls the null cells hook if necessary. The values are
+// modified in place.
+func (s *SessionImpl) prepareWrite(table Table, names []string, values []interface{}, unset bool) {
+	var nulls []string
+	for i, name := range names {
+		if table.isKey(name) || values[i] == gocql.UnsetValue {
+			continue
+		}
+		if unset && isEmpty(values[i]) {
+			values[i] = gocql.UnsetValue
+		} else if isNull(values[i]) {
+			nulls = append(nulls, name)
+		}
+	}
+	if s.nullCells.fn != nil && len(nulls) > 0 && len(nulls) >= s.nullCells.threshold {
+		s.nullCells.fn(table.Name, nulls)
+	}
+}
+
+// prepareWrite applies the tombstone avoidance and null cells hook to the
+// values of INSERT and UPDATE statements built from structs.
+func (s *StatementImpl) prepareWrite() {
+	if s.mapping == nil || (s.Command != InsertCmd && s.Command != UpdateCmd) {
+		return
+	}
+
+	unset := s.unsetEmpty || s.session.unsetEmpty
+	if !unset && s.session.nullCells.fn == nil {
+		return
+	}
+
+	// INSERT with all the columns
+	if s.Command == InsertCmd && len(s.ColumnNames) == 0 {
+		s.session.prepareWrite(s.Table, s.Table.columnNames(), s.values, unset)
+		return
+	}
+
+	values := make([]interface{}, len(s.ColumnNames))
+	for i, name := range s.ColumnNames {
+		values[i] = s.mapping[name]
+	}
+	s.session.prepareWrite(s.Table, s.ColumnNames, values, unset)
+	for i, name := range s.ColumnNames {
+		s.mapping[name] = values[i]
+	}
+}
+
+// UnsetEmpty enables the tombstone avoidance mode in the statement, see
+// WithUnsetEmpty.
+func (s *StatementImpl) UnsetEmpty() Statement {
+	s.unsetEmpty = true
+	return s
+}
+
+// isNull returns true if v is written as a null cell.
+func isNull(v interface{}) bool {
+	switch vv := v.(type) {
+	case nil:
+		return true
+	case nullRef:
+		return !vv.v.Field(1).Bool()
+	case enumRef:
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// isEmpty returns true if v is null or the zero value.
+func isEmpty(v interface{}) bool {
+	switch vv := v.(type) {
+	case nil:
+		return true
+	case nullRef:
+		return !vv.v.Field(1).Bool()
+	case enumRef:
+		return vv.v.IsZero()
+	}
+	return reflect.ValueOf(v).IsZero()
+}
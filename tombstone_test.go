@@ -0,0 +1,54 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type tombstoneStruct struct {
+	ID    string   `cql:"id" cqltable:"tombstones"`
+	Name  string   `cql:"name"`
+	Email *string  `cql:"email"`
+	Tags  []string `cql:"tags"`
+	Count int      `cql:"count"`
+}
+
+func TestUnsetEmpty(t *testing.T) {
+	DeleteRegistry()
+	unset := gocql.UnsetValue
+
+	sess, d := newTestSession(WithUnsetEmpty())
+	assert.NoError(t, sess.Set(tombstoneStruct{Name: "foo"}))
+	assert.Equal(t, []interface{}{"", "foo", unset, unset, unset}, d.last().Values)
+
+	assert.NoError(t, sess.Insert(tombstoneStruct{ID: "a", Count: 1}).Exec())
+	assert.Equal(t, []interface{}{"a", unset, unset, unset, 1}, d.last().Values)
+
+	// Per statement
+	sess, d = newTestSession()
+	assert.NoError(t, sess.Insert(tombstoneStruct{ID: "a"}).Exec())
+	assert.Equal(t, []interface{}{"a", "", (*string)(nil), []string(nil), 0}, d.last().Values)
+	assert.NoError(t, sess.Insert(tombstoneStruct{ID: "a"}).UnsetEmpty().Exec())
+	assert.Equal(t, []interface{}{"a", unset, unset, unset, unset}, d.last().Values)
+	assert.NoError(t, sess.Update(tombstoneStruct{ID: "a", Count: 2}).Columns("name", "count").UnsetEmpty().Exec())
+	assert.Equal(t, []interface{}{unset, 2, "a"}, d.last().Values)
+}
+
+func TestNullCellsHook(t *testing.T) {
+	DeleteRegistry()
+	var table string
+	var columns []string
+	sess, _ := newTestSession(WithNullCellsHook(2, func(t string, c []string) {
+		table, columns = t, c
+	}))
+
+	assert.NoError(t, sess.Set(tombstoneStruct{ID: "a", Tags: []string{"x"}}))
+	assert.Equal(t, "", table)
+	assert.Nil(t, columns)
+
+	assert.NoError(t, sess.Insert(tombstoneStruct{ID: "a"}).Exec())
+	assert.Equal(t, "tombstones", table)
+	assert.Equal(t, []string{"email", "tags"}, columns)
+}
@@ -0,0 +1,101 @@
+package ecql
+
+import "reflect"
+
+var trackedType = reflect.TypeOf(Tracked{})
+
+// Tracked enables the change tracking of a struct. When Tracked is embedded
+// in a type, the values of a struct loaded from the database are remembered,
+// and Session.Update only sets the columns that have changed:
+//
+//	type User struct {
+//		ecql.Tracked
+//		ID    string `cql:"id" cqltable:"users"`
+//		Name  string `cql:"name"`
+//		Email string `cql:"email"`
+//	}
+//
+//	sess.Get(&u, "id")
+//	u.Name = "New Name"
+//	sess.Update(&u).Exec() // UPDATE users SET name = ? WHERE id = ?
+//
+// The struct must be passed as a pointer on reads. If no column has changed,
+// the execution of the update is skipped.
+type Tracked struct {
+	original map[string]interface{}
+}
+
+// Loaded returns true if the struct has been loaded from the database.
+func (t *Tracked) Loaded() bool {
+	return t.original != nil
+}
+
+// trackedOf returns the Tracked field of the struct v if the type embeds it.
+func (t *Table) trackedOf(v reflect.Value) *Tracked {
+	if t.Tracked == nil {
+		return nil
+	}
+	field := newFieldAccessor(v.Type(), t.Tracked).field(v)
+	if !field.CanAddr() {
+		tr := field.Interface().(Tracked)
+		return &tr
+	}
+	return field.Addr().Interface().(*Tracked)
+}
+
+// snapshot remembers the values of the columns of the struct v.
+func (tr *Tracked) snapshot(v reflect.Value, table Table) {
+	if tr.original == nil {
+		tr.original = make(map[string]interface{}, len(table.Columns))
+	}
+	for i, col := range table.Columns {
+		if col.Position != nil {
+			tr.original[col.Name] = copyValue(table.columnField(i, v))
+		}
+	}
+}
+
+// changed returns the columns not in the primary key that have changed since
+// the last snapshot.
+func (tr *Tracked) changed(v reflect.Value, table Table) []string {
+	keys := make(map[string]bool, len(table.KeyColumns))
+	for _, k := range table.KeyColumns {
+		keys[k] = true
+	}
+
+	columns := []string{}
+	for i, col := range table.Columns {
+		if keys[col.Name] || col.Position == nil {
+			continue
+		}
+		if !reflect.DeepEqual(tr.original[col.Name], table.columnField(i, v).Interface()) {
+			columns = append(columns, col.Name)
+		}
+	}
+	return columns
+}
+
+// copyValue returns a copy of the value of v, slices and maps are copied so
+// changes in their elements can be detected.
+func copyValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c.Interface()
+	case reflect.Map:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, v.MapIndex(k))
+		}
+		return c.Interface()
+	default:
+		return v.Interface()
+	}
+}
@@ -0,0 +1,99 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type trackedStruct struct {
+	Tracked
+	ID    string   `cql:"id" cqltable:"tracked"`
+	Name  string   `cql:"name"`
+	Email string   `cql:"email"`
+	Tags  []string `cql:"tags"`
+}
+
+func TestTracked(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	table := GetTable(trackedStruct{})
+	assert.Equal(t, []int{0}, table.Tracked)
+	assert.Equal(t, "id,name,email,tags", table.getCols())
+
+	// Not loaded, Columns must be used
+	u := trackedStruct{ID: "a", Name: "foo"}
+	assert.False(t, u.Loaded())
+	assert.NoError(t, sess.Update(&u).Columns("name").Exec())
+	assert.Equal(t, "UPDATE tracked SET name = ? WHERE id = ?", d.last().Statement)
+
+	d.result([]string{"id", "name", "email", "tags"}, []interface{}{"a", "foo", "foo@example.com", []string{"x"}})
+	assert.NoError(t, sess.Get(&u, "a"))
+	assert.True(t, u.Loaded())
+
+	// No changes
+	n := len(d.requests)
+	assert.NoError(t, sess.Update(&u).Exec())
+	assert.Len(t, d.requests, n)
+
+	u.Name = "bar"
+	u.Tags[0] = "y"
+	assert.NoError(t, sess.Update(&u).Exec())
+	assert.Equal(t, "UPDATE tracked SET name = ?, tags = ? WHERE id = ?", d.last().Statement)
+	assert.Equal(t, []interface{}{"bar", []string{"y"}}, d.last().Values[:2])
+
+	// The snapshot is updated after the update
+	u.Email = "bar@example.com"
+	assert.NoError(t, sess.Update(u).Exec())
+	assert.Equal(t, "UPDATE tracked SET email = ? WHERE id = ?", d.last().Statement)
+
+	// And after ExecInfo
+	u.Name = "zar"
+	_, err := sess.Update(&u).ExecInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE tracked SET name = ? WHERE id = ?", d.last().Statement)
+	n = len(d.requests)
+	assert.NoError(t, sess.Update(&u).Exec())
+	assert.Len(t, d.requests, n)
+
+	// Loaded with a statement
+	var u2 trackedStruct
+	assert.NoError(t, sess.Select(&u2).Where(Eq("id", "a")).TypeScan())
+	assert.True(t, u2.Loaded())
+	u2.Name = "zar"
+	stmt, _ := sess.Update(&u2).BuildQuery()
+	assert.Equal(t, "UPDATE tracked SET name = ? WHERE id = ?", stmt)
+}
+
+type TrackedProfile struct {
+	Bio string `cql:"bio"`
+}
+
+type trackedPtrStruct struct {
+	Tracked
+	ID              string `cql:"id" cqltable:"tracked_ptr" cqlkey:"id"`
+	Name            string `cql:"name"`
+	*TrackedProfile `cql:"-"`
+}
+
+func TestTrackedNilEmbedded(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+	sess.getRegistry().Register(&trackedPtrStruct{TrackedProfile: &TrackedProfile{}})
+
+	d.result([]string{"id", "name", "bio"}, []interface{}{"a", "foo", "hello"})
+	var u trackedPtrStruct
+	assert.NoError(t, sess.Get(&u, "a"))
+	assert.True(t, u.Loaded())
+	assert.Equal(t, "hello", u.Bio)
+
+	// A nil embedded pointer is read as the zero value
+	u.TrackedProfile = nil
+	u.Name = "bar"
+	assert.NotPanics(t, func() {
+		assert.NoError(t, sess.Update(u).Exec())
+	})
+	assert.Equal(t, "UPDATE tracked_ptr SET name = ?, bio = ? WHERE id = ?", d.last().Statement)
+	assert.Nil(t, u.TrackedProfile)
+}
@@ -0,0 +1,48 @@
+package ecql
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// TTL is the remaining time to live of a column, it can be used in fields
+// tagged with `cql:",ttl=col"` to read the TTL of the column col:
+//
+//	type Session struct {
+//		ID      string `cql:"id" cqltable:"sessions"`
+//		Data    string `cql:"data"`
+//		Expires TTL    `cql:",ttl=data"`
+//	}
+//
+// Fields of type int can also be used to get just the seconds.
+type TTL struct {
+	Seconds int
+	ReadAt  time.Time
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler, it sets the seconds and the
+// current time. The unmarshalers do not have access to the session, so
+// ReadAt is set with SystemClock instead of the clock of the session.
+func (t *TTL) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	t.Seconds, t.ReadAt = 0, SystemClock.Now()
+	if data == nil {
+		return nil
+	}
+	return gocql.Unmarshal(info, data, &t.Seconds)
+}
+
+// ExpiresAt returns the time when the column expires or the zero time if the
+// column does not expire.
+func (t TTL) ExpiresAt() time.Time {
+	if t.Seconds <= 0 {
+		return time.Time{}
+	}
+	return t.ReadAt.Add(time.Duration(t.Seconds) * time.Second)
+}
+
+// Expired returns true if the column has expired at the given time.
+func (t TTL) Expired(now time.Time) bool {
+	expiresAt := t.ExpiresAt()
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
@@ -0,0 +1,57 @@
+package ecql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+type ttlStruct struct {
+	ID       string `cql:"id" cqltable:"sessions"`
+	Data     string `cql:"data"`
+	Token    string `cql:"token"`
+	Expires  TTL    `cql:",ttl=data"`
+	TokenTTL int    `cql:",ttl=token"`
+}
+
+func TestTTLColumns(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	table := GetTable(ttlStruct{})
+	assert.Len(t, table.Columns, 3)
+	assert.Equal(t, []Column{{Name: "ttl(data)", Position: []int{3}}, {Name: "ttl(token)", Position: []int{4}}}, table.TTLColumns)
+
+	var s ttlStruct
+	d.result([]string{"id", "data", "token", "ttl(token)"}, []interface{}{"a", "foo", "bar", 60})
+	assert.NoError(t, sess.Get(&s, "a"))
+	assert.Equal(t, `SELECT id,data,"token",ttl(data),ttl("token") FROM sessions WHERE id = ?`, d.last().Statement)
+	assert.Equal(t, ttlStruct{ID: "a", Data: "foo", Token: "bar", TokenTTL: 60}, s)
+
+	assert.NoError(t, sess.Set(&s))
+	assert.Equal(t, `INSERT INTO sessions (id,data,"token") VALUES (?,?,?)`, d.last().Statement)
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	SystemClock = ClockFunc(func() time.Time { return now })
+	defer func() { SystemClock = ClockFunc(time.Now) }()
+
+	info := gocql.NewNativeType(4, gocql.TypeInt, "")
+	data, err := gocql.Marshal(info, 60)
+	assert.NoError(t, err)
+
+	var ttl TTL
+	assert.NoError(t, ttl.UnmarshalCQL(info, data))
+	assert.Equal(t, TTL{Seconds: 60, ReadAt: now}, ttl)
+	assert.Equal(t, now.Add(time.Minute), ttl.ExpiresAt())
+	assert.False(t, ttl.Expired(now))
+	assert.True(t, ttl.Expired(now.Add(time.Minute)))
+
+	assert.NoError(t, ttl.UnmarshalCQL(info, nil))
+	assert.Equal(t, TTL{ReadAt: now}, ttl)
+	assert.True(t, ttl.ExpiresAt().IsZero())
+	assert.False(t, ttl.Expired(now.Add(time.Hour)))
+}
@@ -0,0 +1,50 @@
+package ecql
+
+import (
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ErrExpired is returned by the inserts of types with the ttlfrom option if
+// the expiration time has already passed.
+var ErrExpired = errors.New("ecql: expiration time in the past")
+
+// TTLFrom returns the time to live in seconds of a row that expires at the
+// given time, rounded up, or 0 if the row does not expire. The inserts of
+// the types with the option `cql:",ttlfrom=col"` use the value of the
+// column col, a time or a timeuuid, to set the TTL of the rows:
+//
+//	type Token struct {
+//		ID        string    `cql:"id" cqltable:"tokens" cqlkey:"id"`
+//		ExpiresAt time.Time `cql:"expires_at"`
+//		_         struct{}  `cql:",ttlfrom=expires_at"`
+//	}
+//
+// If the column is empty the rows do not expire, and if the time is in the
+// past the insert fails with ErrExpired. A TTL set with the TTL method of the
+// statement takes precedence.
+func TTLFrom(expires, now time.Time) (int, error) {
+	if expires.IsZero() {
+		return 0, nil
+	}
+	d := expires.Sub(now)
+	if d <= 0 {
+		return 0, ErrExpired
+	}
+	return int((d + time.Second - 1) / time.Second), nil
+}
+
+// ttlFrom returns the time to live of the struct v, using the ttlfrom column
+// of the table.
+func (t *Table) ttlFrom(v reflect.Value, now time.Time) (int, error) {
+	if t.TTLFrom == "" {
+		return 0, nil
+	}
+	col, ok := t.column(t.TTLFrom)
+	if !ok || col.Position == nil {
+		return 0, nil
+	}
+	expires, _ := timeOf(v.FieldByIndex(col.Position))
+	return TTLFrom(expires, now)
+}
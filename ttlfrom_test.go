@@ -0,0 +1,59 @@
+package ecql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type expiringStruct struct {
+	ID        string    `cql:"id" cqltable:"tokens" cqlkey:"id"`
+	ExpiresAt time.Time `cql:"expires_at"`
+	_         struct{}  `cql:",ttlfrom=expires_at"`
+}
+
+func TestTTLFrom(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		expires time.Time
+		ttl     int
+		err     error
+	}{
+		{time.Time{}, 0, nil},
+		{now.Add(time.Hour), 3600, nil},
+		{now.Add(1500 * time.Millisecond), 2, nil},
+		{now, 0, ErrExpired},
+		{now.Add(-time.Second), 0, ErrExpired},
+	}
+	for _, tc := range tests {
+		ttl, err := TTLFrom(tc.expires, now)
+		assert.Equal(t, tc.ttl, ttl)
+		assert.Equal(t, tc.err, err)
+	}
+}
+
+func TestInsertTTLFrom(t *testing.T) {
+	DeleteRegistry()
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	sess, d := newTestSession(WithClock(ClockFunc(func() time.Time { return now })))
+
+	table := GetTable(expiringStruct{})
+	assert.Equal(t, "expires_at", table.TTLFrom)
+	assert.Equal(t, []string{"id", "expires_at"}, table.columnNames())
+
+	assert.NoError(t, sess.Set(expiringStruct{ID: "a", ExpiresAt: now.Add(time.Hour)}))
+	assert.Equal(t, "INSERT INTO tokens (id,expires_at) VALUES (?,?) USING TTL 3600", d.last().Statement)
+	assert.NoError(t, sess.Insert(expiringStruct{ID: "a", ExpiresAt: now.Add(time.Minute)}).Exec())
+	assert.Equal(t, "INSERT INTO tokens (id,expires_at) VALUES (?,?) USING TTL 60", d.last().Statement)
+	assert.NoError(t, sess.Insert(expiringStruct{ID: "a", ExpiresAt: now.Add(time.Minute)}).TTL(10).Exec())
+	assert.Equal(t, "INSERT INTO tokens (id,expires_at) VALUES (?,?) USING TTL 10", d.last().Statement)
+
+	assert.NoError(t, sess.Set(expiringStruct{ID: "a"}))
+	assert.Equal(t, "INSERT INTO tokens (id,expires_at) VALUES (?,?)", d.last().Statement)
+
+	n := len(d.requests)
+	assert.Equal(t, ErrExpired, sess.Set(expiringStruct{ID: "a", ExpiresAt: now}))
+	assert.Equal(t, ErrExpired, sess.Insert(expiringStruct{ID: "a", ExpiresAt: now}).Exec())
+	assert.Len(t, d.requests, n)
+}
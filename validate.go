@@ -0,0 +1,106 @@
+package ecql
+
+import (
+	"reflect"
+	"strings"
+)
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// Validator is implemented by the types that validate their values before
+// they are written. Validate is called before the INSERT and UPDATE
+// statements built from the struct are executed, if it returns an error the
+// statement is not executed and the error is returned.
+//
+//	func (u *User) Validate() error {
+//		var errs ecql.ValidationError
+//		if u.Email == "" {
+//			errs.Add("email", "is required")
+//		}
+//		return errs.Err()
+//	}
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is the error of a column with an invalid value.
+type FieldError struct {
+	Column  string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Column + " " + e.Message
+}
+
+// ValidationError is the error returned when a struct is not valid, it
+// contains the errors of each invalid column. The Table is set by the
+// session.
+type ValidationError struct {
+	Table  string
+	Fields []FieldError
+}
+
+// Add adds the error of a column.
+func (e *ValidationError) Add(column, message string) {
+	e.Fields = append(e.Fields, FieldError{Column: column, Message: message})
+}
+
+// Err returns e if it contains any error, or nil.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	msg := "invalid values: " + strings.Join(msgs, ", ")
+	if e.Table != "" {
+		msg = "invalid values in " + e.Table + ": " + strings.Join(msgs, ", ")
+	}
+	return msg
+}
+
+// WithValidation sets a function called with the structs written by the
+// session after their Validate method, if they implement Validator. It can be
+// used to integrate a validation package based on struct tags:
+//
+//	validate := validator.New()
+//	sess, err := ecql.NewSession(cfg, ecql.WithValidation(validate.Struct))
+func WithValidation(fn func(i interface{}) error) Option {
+	return func(s *SessionImpl) {
+		s.validation = fn
+	}
+}
+
+// validate validates the struct i written in the table.
+func (s *SessionImpl) validate(table string, i interface{}) error {
+	err := validateStruct(i)
+	if err == nil && s.validation != nil {
+		err = s.validation(i)
+	}
+	if verr, ok := err.(*ValidationError); ok && verr.Table == "" {
+		verr.Table = table
+	}
+	return err
+}
+
+// validateStruct calls the Validate method of i, it is also called on the
+// types that implement Validator with a pointer receiver.
+func validateStruct(i interface{}) error {
+	if v, ok := i.(Validator); ok {
+		return v.Validate()
+	}
+	v := reflect.ValueOf(i)
+	if v.Kind() == reflect.Struct && reflect.PtrTo(v.Type()).Implements(validatorType) {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface().(Validator).Validate()
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package ecql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validStruct struct {
+	ID    string `cql:"id" cqltable:"valid" cqlkey:"id"`
+	Email string `cql:"email"`
+	Age   int    `cql:"age"`
+}
+
+func (v *validStruct) Validate() error {
+	var errs ValidationError
+	if v.Email == "" {
+		errs.Add("email", "is required")
+	}
+	if v.Age < 0 {
+		errs.Add("age", "must be positive")
+	}
+	return errs.Err()
+}
+
+func TestValidate(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	// Set and Insert
+	err := sess.Set(validStruct{ID: "a", Age: -1})
+	assert.Equal(t, &ValidationError{Table: "valid", Fields: []FieldError{
+		{Column: "email", Message: "is required"},
+		{Column: "age", Message: "must be positive"},
+	}}, err)
+	assert.EqualError(t, err, "invalid values in valid: email is required, age must be positive")
+	assert.Error(t, sess.Insert(&validStruct{ID: "a"}).Exec())
+	assert.Empty(t, d.requests)
+
+	assert.NoError(t, sess.Set(validStruct{ID: "a", Email: "a@example.com"}))
+	assert.NoError(t, sess.Insert(&validStruct{ID: "a", Email: "a@example.com"}).Exec())
+	assert.Len(t, d.requests, 2)
+
+	// Update and batches
+	assert.Error(t, sess.Update(validStruct{ID: "a"}).Exec())
+	err = sess.Batch().Add(sess.Insert(validStruct{ID: "a"})).Apply()
+	_, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Len(t, d.requests, 2)
+	assert.Empty(t, d.batches)
+
+	// Reads are not validated
+	assert.NoError(t, sess.Select(&validStruct{}).Exec())
+	assert.NoError(t, sess.Delete(validStruct{ID: "a"}).Exec())
+}
+
+func TestWithValidation(t *testing.T) {
+	DeleteRegistry()
+	errInvalid := errors.New("invalid struct")
+	var validated []interface{}
+	sess, d := newTestSession(WithValidation(func(i interface{}) error {
+		validated = append(validated, i)
+		if ts, ok := i.(testStruct); ok && ts.F2 < 0 {
+			return errInvalid
+		}
+		return nil
+	}))
+
+	assert.NoError(t, sess.Set(testStruct{F1: "a"}))
+	assert.Equal(t, errInvalid, sess.Set(testStruct{F1: "a", F2: -1}))
+	assert.Equal(t, errInvalid, sess.Insert(testStruct{F1: "a", F2: -1}).Exec())
+	assert.Len(t, validated, 3)
+	assert.Len(t, d.requests, 1)
+
+	// The hook is called after Validate
+	validated = nil
+	assert.Error(t, sess.Set(validStruct{ID: "a"}))
+	assert.Empty(t, validated)
+	assert.NoError(t, sess.Set(validStruct{ID: "a", Email: "a@example.com"}))
+	assert.Len(t, validated, 1)
+}
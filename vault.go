@@ -0,0 +1,193 @@
+package ecql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultCredentials is a CredentialsProvider that reads the credentials from
+// a secret of HashiCorp Vault using its HTTP API. The secret can be a static
+// secret of the KV engine, version 1 or 2, or a dynamic secret of the
+// database engine like "database/creds/app".
+//
+// The credentials of the dynamic secrets are kept until RenewBefore their
+// expiration, a third of the lease duration by default. Then the lease is
+// renewed if it is renewable, otherwise new credentials are read and the
+// previous lease is revoked. It can be combined with CachedCredentials to
+// keep the credentials during an outage of Vault:
+//
+//	vault := ecql.NewVaultCredentialsFromEnv("database/creds/app")
+//	sess, err := ecql.NewSession(cfg, ecql.WithCredentialsProvider(ecql.NewCachedCredentials(vault, time.Hour)))
+//
+// UsernameKey and PasswordKey are the keys of the secret with the username
+// and the password, they default to "username" and "password". Clock
+// defaults to SystemClock.
+type VaultCredentials struct {
+	Address     string
+	Token       string
+	Namespace   string
+	Path        string
+	UsernameKey string
+	PasswordKey string
+	Client      *http.Client
+	RenewBefore time.Duration
+	Clock       Clock
+
+	mu    sync.Mutex
+	lease *vaultLease
+}
+
+type vaultLease struct {
+	ID        string
+	renewable bool
+	renew     time.Time
+	creds     Credentials
+}
+
+type vaultSecret struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// NewVaultCredentialsFromEnv creates a VaultCredentials for the secret in the
+// given path using the standard Vault environment variables: VAULT_ADDR,
+// VAULT_TOKEN and VAULT_NAMESPACE.
+func NewVaultCredentialsFromEnv(path string) *VaultCredentials {
+	return &VaultCredentials{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Path:      path,
+	}
+}
+
+// Credentials implements the CredentialsProvider interface.
+func (v *VaultCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := nowOf(v.Clock)
+	if l := v.lease; l != nil {
+		if now.Before(l.renew) {
+			return l.creds, nil
+		}
+		if l.renewable {
+			secret, err := v.do(ctx, http.MethodPut, "sys/leases/renew", map[string]string{"lease_id": l.ID})
+			if err == nil && secret.LeaseDuration > 0 {
+				l.creds.Expires, l.renew = v.expires(now, secret.LeaseDuration)
+				return l.creds, nil
+			}
+		}
+	}
+
+	secret, err := v.do(ctx, http.MethodGet, v.Path, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	usernameKey, passwordKey := v.UsernameKey, v.PasswordKey
+	if usernameKey == "" {
+		usernameKey = "username"
+	}
+	if passwordKey == "" {
+		passwordKey = "password"
+	}
+	username, _ := data[usernameKey].(string)
+	password, _ := data[passwordKey].(string)
+	if username == "" || password == "" {
+		return Credentials{}, fmt.Errorf("ecql: vault secret %s does not have %s and %s", v.Path, usernameKey, passwordKey)
+	}
+
+	creds := Credentials{Username: username, Password: password}
+	old := v.lease
+	v.lease = nil
+	if secret.LeaseDuration > 0 && secret.LeaseID != "" {
+		l := &vaultLease{ID: secret.LeaseID, renewable: secret.Renewable}
+		creds.Expires, l.renew = v.expires(now, secret.LeaseDuration)
+		l.creds = creds
+		v.lease = l
+	}
+	// The previous credentials are not used anymore, errors are ignored as
+	// the lease is going to expire anyway.
+	if old != nil {
+		v.do(ctx, http.MethodPut, "sys/leases/revoke", map[string]string{"lease_id": old.ID})
+	}
+	return creds, nil
+}
+
+// expires returns the expiration of a lease and when it has to be renewed.
+func (v *VaultCredentials) expires(now time.Time, seconds int) (time.Time, time.Time) {
+	d := time.Duration(seconds) * time.Second
+	before := v.RenewBefore
+	if before <= 0 || before > d {
+		before = d / 3
+	}
+	return now.Add(d), now.Add(d - before)
+}
+
+// do sends a request to the Vault API and decodes the response.
+func (v *VaultCredentials) do(ctx context.Context, method, path string, body interface{}) (*vaultSecret, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	url := strings.TrimRight(v.Address, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		if len(e.Errors) > 0 {
+			return nil, fmt.Errorf("ecql: error reading vault %s: %s: %s", path, resp.Status, strings.Join(e.Errors, ", "))
+		}
+		return nil, fmt.Errorf("ecql: error reading vault %s: %s", path, resp.Status)
+	}
+
+	var secret vaultSecret
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
@@ -0,0 +1,138 @@
+package ecql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaultCredentials(t *testing.T) {
+	var reads, renewals int
+	var revoked []string
+	renewable := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "s.token", r.Header.Get("X-Vault-Token"))
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/database/creds/app":
+			reads++
+			assert.Equal(t, http.MethodGet, r.Method)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       fmt.Sprintf("database/creds/app/%d", reads),
+				"lease_duration": 3600,
+				"renewable":      renewable,
+				"data":           map[string]string{"username": "v-app", "password": fmt.Sprintf("p%d", reads)},
+			})
+		case "/v1/sys/leases/renew":
+			renewals++
+			assert.Equal(t, http.MethodPut, r.Method)
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{"lease_id":"database/creds/app/1"}`, string(b))
+			if renewals > 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["lease expired"]}`))
+				return
+			}
+			w.Write([]byte(`{"lease_id":"database/creds/app/1","lease_duration":1800,"renewable":true}`))
+		case "/v1/sys/leases/revoke":
+			assert.Equal(t, http.MethodPut, r.Method)
+			var body map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			revoked = append(revoked, body["lease_id"])
+			w.WriteHeader(http.StatusNoContent)
+		case "/v1/secret/data/cassandra":
+			w.Write([]byte(`{"data":{"data":{"user":"app","pass":"secret"},"metadata":{"version":2}}}`))
+		case "/v1/secret/cassandra":
+			w.Write([]byte(`{"lease_duration":2764800,"data":{"username":"app"}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	v := &VaultCredentials{Address: srv.URL + "/", Token: "s.token", Namespace: "team", Path: "database/creds/app"}
+	v.Clock = ClockFunc(func() time.Time { return now })
+	ctx := context.Background()
+
+	creds, err := v.Credentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "v-app", Password: "p1", Expires: now.Add(time.Hour)}, creds)
+
+	// Cached until a third of the lease remains
+	now = now.Add(39 * time.Minute)
+	creds, err = v.Credentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "p1", creds.Password)
+	assert.Equal(t, 1, reads)
+	assert.Equal(t, 0, renewals)
+
+	// Renewed lease
+	now = now.Add(time.Minute)
+	creds, err = v.Credentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "v-app", Password: "p1", Expires: now.Add(30 * time.Minute)}, creds)
+	assert.Equal(t, 1, renewals)
+
+	// New credentials after a failed renewal, the previous lease is revoked
+	renewable = false
+	now = now.Add(20 * time.Minute)
+	creds, err = v.Credentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "p2", creds.Password)
+	assert.Equal(t, []string{"database/creds/app/1"}, revoked)
+	creds, err = v.Credentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "p2", creds.Password)
+
+	// Leases that are not renewable are replaced
+	v.RenewBefore = time.Minute
+	now = now.Add(40 * time.Minute)
+	creds, err = v.Credentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "p3", creds.Password)
+	assert.Equal(t, 3, reads)
+	assert.Equal(t, 2, renewals)
+	assert.Equal(t, []string{"database/creds/app/1", "database/creds/app/2"}, revoked)
+
+	now = now.Add(58 * time.Minute)
+	creds, err = v.Credentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "p3", creds.Password)
+
+	// KV version 2
+	v = &VaultCredentials{Address: srv.URL, Token: "s.token", Namespace: "team", Path: "secret/data/cassandra", UsernameKey: "user", PasswordKey: "pass"}
+	creds, err = v.Credentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "app", Password: "secret"}, creds)
+
+	v = &VaultCredentials{Address: srv.URL, Token: "s.token", Namespace: "team", Path: "secret/cassandra"}
+	_, err = v.Credentials(ctx)
+	assert.EqualError(t, err, "ecql: vault secret secret/cassandra does not have username and password")
+
+	v = &VaultCredentials{Address: srv.URL, Token: "s.token", Namespace: "team", Path: "secret/other"}
+	_, err = v.Credentials(ctx)
+	assert.EqualError(t, err, "ecql: error reading vault secret/other: 403 Forbidden: permission denied")
+}
+
+func TestNewVaultCredentialsFromEnv(t *testing.T) {
+	os.Setenv("VAULT_ADDR", "https://vault:8200")
+	os.Setenv("VAULT_TOKEN", "s.token")
+	defer os.Unsetenv("VAULT_ADDR")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	v := NewVaultCredentialsFromEnv("database/creds/app")
+	assert.Equal(t, "https://vault:8200", v.Address)
+	assert.Equal(t, "s.token", v.Token)
+	assert.Empty(t, v.Namespace)
+	assert.Equal(t, "database/creds/app", v.Path)
+}
@@ -0,0 +1,48 @@
+package ecql
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	vectorType    = reflect.TypeOf(Vector{})
+	vectorPtrType = reflect.TypeOf(&Vector{})
+)
+
+// Vector is a vector<float, n> value used for vector search. Fields of type
+// []float32 tagged with `cql:"name,vector=n"` are converted automatically.
+type Vector []float32
+
+// MarshalCQL implements gocql.Marshaler, vectors of floats are serialized as
+// the concatenation of its big-endian elements.
+func (v Vector) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.BigEndian.PutUint32(data[4*i:], math.Float32bits(f))
+	}
+	return data, nil
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler.
+func (v *Vector) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if data == nil {
+		*v = nil
+		return nil
+	}
+	if len(data)%4 != 0 {
+		return ErrInvalidVector
+	}
+	vec := make(Vector, len(data)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.BigEndian.Uint32(data[4*i:]))
+	}
+	*v = vec
+	return nil
+}
@@ -0,0 +1,51 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type vectorStruct struct {
+	ID        string    `cql:"id" cqltable:"docs"`
+	Embedding []float32 `cql:"embedding,vector=3"`
+	Other     Vector    `cql:"other,vector=2"`
+}
+
+func TestVector(t *testing.T) {
+	v := Vector{1.5, -2, 0}
+	data, err := v.MarshalCQL(nil)
+	assert.NoError(t, err)
+	assert.Len(t, data, 12)
+
+	var got Vector
+	assert.NoError(t, got.UnmarshalCQL(nil, data))
+	assert.Equal(t, v, got)
+	assert.Equal(t, ErrInvalidVector, got.UnmarshalCQL(nil, data[:5]))
+	assert.NoError(t, got.UnmarshalCQL(nil, nil))
+	assert.Nil(t, got)
+}
+
+func TestVectorColumns(t *testing.T) {
+	DeleteRegistry()
+	sess, d := newTestSession()
+
+	table := GetTable(vectorStruct{})
+	assert.Equal(t, "vector<float, 3>", table.Columns[1].Type)
+	assert.Equal(t, "vector<float, 2>", table.Columns[2].Type)
+
+	doc := vectorStruct{ID: "a", Embedding: []float32{1, 2, 3}, Other: Vector{4, 5}}
+	m := Map(&doc)
+	assert.IsType(t, &Vector{}, m["embedding"])
+	assert.IsType(t, &Vector{}, m["other"])
+	values := Bind(doc)
+	assert.Equal(t, Vector{1, 2, 3}, values[1])
+
+	stmt, args := sess.Select(&doc).OrderByAnn("embedding", []float32{1, 0, 0}).Limit(2).BuildQuery()
+	assert.Equal(t, "SELECT id,embedding,other FROM docs ORDER BY embedding ANN OF ? LIMIT 2", stmt)
+	assert.Equal(t, []interface{}{Vector{1, 0, 0}}, args)
+
+	sess.keyspace = "ks"
+	assert.NoError(t, sess.AutoMigrate(doc))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS docs (id text, embedding vector<float, 3>, other vector<float, 2>, PRIMARY KEY (id))", d.last().Statement)
+}
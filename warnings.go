@@ -0,0 +1,58 @@
+package ecql
+
+import (
+	"log"
+	"strings"
+)
+
+// WarningFunc is called with the warnings returned by the server on the
+// execution of a request.
+type WarningFunc func(req *Request, warnings []string)
+
+// LogWarnings is a WarningFunc that logs the warnings with the standard
+// logger.
+func LogWarnings(req *Request, warnings []string) {
+	log.Printf("ecql: warnings on %s: %s", req.Statement, strings.Join(warnings, "; "))
+}
+
+// WarningsMiddleware returns a middleware that calls fn with the warnings
+// returned by the server, so they are not silently dropped:
+//
+//	sess, err := ecql.NewSession(cfg, ecql.WithMiddleware(ecql.WarningsMiddleware(ecql.LogWarnings)))
+//
+// The warnings are reported when the rows are closed. The warnings of the
+// batches are not available in gocql.
+func WarningsMiddleware(fn WarningFunc) Middleware {
+	return func(next Driver) Driver {
+		return &warningsDriver{Driver: next, fn: fn}
+	}
+}
+
+// warningsDriver is the Driver used by WarningsMiddleware.
+type warningsDriver struct {
+	Driver
+	fn WarningFunc
+}
+
+func (d *warningsDriver) Iter(req *Request) Rows {
+	return &warningsRows{Rows: d.Driver.Iter(req), req: req, fn: d.fn}
+}
+
+// warningsRows reports the warnings when the rows are closed.
+type warningsRows struct {
+	Rows
+	req    *Request
+	fn     WarningFunc
+	closed bool
+}
+
+func (r *warningsRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		if warnings := r.Rows.Info().Warnings; len(warnings) > 0 {
+			r.fn(r.req, warnings)
+		}
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package ecql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarnings(t *testing.T) {
+	var reported []string
+	var stmt string
+	fn := func(req *Request, warnings []string) {
+		stmt = req.Statement
+		reported = append(reported, warnings...)
+	}
+	sess, d := newTestSession(WithMiddleware(WarningsMiddleware(fn)))
+
+	assert.NoError(t, sess.Set(testStruct{F1: "a"}))
+	assert.Nil(t, reported)
+
+	d.warnings = []string{"Aggregation query used without partition key"}
+	d.result([]string{"count"}, []interface{}{int64(1)})
+	info, err := sess.Select(testStruct{}).ExecInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, d.warnings, info.Warnings)
+	assert.Equal(t, d.warnings, reported)
+	assert.Equal(t, "SELECT f1,f22,f3,f4 FROM mytable", stmt)
+
+	reported = nil
+	_, err = sess.Select(testStruct{}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, d.warnings, reported)
+}